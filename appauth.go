@@ -0,0 +1,138 @@
+package main
+
+import (
+	"context"
+	"crypto"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"golang.org/x/oauth2"
+)
+
+const (
+	githubAPIURL string = "https://api.github.com"
+
+	// appJWTLifetime is how long the JWT authenticating as the app is valid.
+	// GitHub accepts at most 10 minutes.
+	appJWTLifetime time.Duration = 9 * time.Minute
+	// appTokenRefreshMargin is how long before it expires an installation
+	// token is replaced, so that a poll never uses a token about to expire.
+	appTokenRefreshMargin time.Duration = 5 * time.Minute
+	appTokenTimeout       time.Duration = 30 * time.Second
+)
+
+// parseAppPrivateKey parses the PEM-encoded private key of a GitHub App,
+// which GitHub issues in PKCS #1 form.
+func parseAppPrivateKey(raw string) (*rsa.PrivateKey, error) {
+	block, _ := pem.Decode([]byte(strings.TrimSpace(raw)))
+	if block == nil {
+		return nil, fmt.Errorf("app private key is not PEM-encoded")
+	}
+	if key, err := x509.ParsePKCS1PrivateKey(block.Bytes); err == nil {
+		return key, nil
+	}
+	key, err := x509.ParsePKCS8PrivateKey(block.Bytes)
+	if err != nil {
+		return nil, fmt.Errorf("error parsing app private key: %w", err)
+	}
+	rsaKey, ok := key.(*rsa.PrivateKey)
+	if !ok {
+		return nil, fmt.Errorf("app private key is not an RSA key")
+	}
+	return rsaKey, nil
+}
+
+// appJWT creates the JWT that authenticates as the app itself. It is issued
+// a minute in the past to allow for clock drift.
+func appJWT(appID string, key *rsa.PrivateKey, now time.Time) (string, error) {
+	header := base64.RawURLEncoding.EncodeToString([]byte(`{"alg":"RS256","typ":"JWT"}`))
+	claims, err := json.Marshal(map[string]interface{}{
+		"iat": now.Add(-time.Minute).Unix(),
+		"exp": now.Add(appJWTLifetime).Unix(),
+		"iss": appID,
+	})
+	if err != nil {
+		return "", err
+	}
+	unsigned := header + "." + base64.RawURLEncoding.EncodeToString(claims)
+	digest := sha256.Sum256([]byte(unsigned))
+	signature, err := rsa.SignPKCS1v15(rand.Reader, key, crypto.SHA256, digest[:])
+	if err != nil {
+		return "", err
+	}
+	return unsigned + "." + base64.RawURLEncoding.EncodeToString(signature), nil
+}
+
+// appTokenSource issues installation tokens of a GitHub App. Wrapped in an
+// oauth2.ReuseTokenSource, a new token is requested shortly before the
+// current one expires, so that waits longer than the one-hour lifetime of
+// installation tokens keep working.
+type appTokenSource struct {
+	ctx            context.Context
+	appID          string
+	key            *rsa.PrivateKey
+	installationID int64
+	repoFullName   string
+}
+
+func (s *appTokenSource) Token() (*oauth2.Token, error) {
+	ctx, cancel := context.WithTimeout(s.ctx, appTokenTimeout)
+	defer cancel()
+
+	jwt, err := appJWT(s.appID, s.key, time.Now())
+	if err != nil {
+		return nil, fmt.Errorf("error signing app JWT: %w", err)
+	}
+	if s.installationID == 0 {
+		var installation struct {
+			ID int64 `json:"id"`
+		}
+		if err := appRequest(ctx, http.MethodGet, fmt.Sprintf("%s/repos/%s/installation", githubAPIURL, s.repoFullName), jwt, &installation); err != nil {
+			return nil, fmt.Errorf("error finding the app installation of %s: %w", s.repoFullName, err)
+		}
+		s.installationID = installation.ID
+	}
+
+	var token struct {
+		Token     string    `json:"token"`
+		ExpiresAt time.Time `json:"expires_at"`
+	}
+	if err := appRequest(ctx, http.MethodPost, fmt.Sprintf("%s/app/installations/%d/access_tokens", githubAPIURL, s.installationID), jwt, &token); err != nil {
+		return nil, fmt.Errorf("error creating an installation token: %w", err)
+	}
+	maskSecrets(token.Token)
+	debugf("Created an installation token expiring at %s", token.ExpiresAt.Format(time.RFC3339))
+	return &oauth2.Token{
+		AccessToken: token.Token,
+		TokenType:   "token",
+		Expiry:      token.ExpiresAt.Add(-appTokenRefreshMargin),
+	}, nil
+}
+
+func appRequest(ctx context.Context, method, url, jwt string, result interface{}) error {
+	req, err := http.NewRequestWithContext(ctx, method, url, nil)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Accept", "application/vnd.github.v3+json")
+	req.Header.Set("Authorization", "Bearer "+jwt)
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK && resp.StatusCode != http.StatusCreated {
+		return fmt.Errorf("unexpected status %s", resp.Status)
+	}
+	return json.NewDecoder(resp.Body).Decode(result)
+}
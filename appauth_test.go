@@ -0,0 +1,60 @@
+package main
+
+import (
+	"crypto"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestAppJWT(t *testing.T) {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatalf("error generating key: %v", err)
+	}
+	keyPEM := string(pem.EncodeToMemory(&pem.Block{Type: "RSA PRIVATE KEY", Bytes: x509.MarshalPKCS1PrivateKey(key)}))
+	parsed, err := parseAppPrivateKey(keyPEM)
+	if err != nil {
+		t.Fatalf("error parsing key: %v", err)
+	}
+
+	now := time.Date(2022, 3, 1, 0, 0, 0, 0, time.UTC)
+	jwt, err := appJWT("1234", parsed, now)
+	if err != nil {
+		t.Fatalf("error creating JWT: %v", err)
+	}
+	parts := strings.Split(jwt, ".")
+	if len(parts) != 3 {
+		t.Fatalf("expected 3 parts but got %d", len(parts))
+	}
+
+	signature, _ := base64.RawURLEncoding.DecodeString(parts[2])
+	digest := sha256.Sum256([]byte(parts[0] + "." + parts[1]))
+	if err := rsa.VerifyPKCS1v15(&key.PublicKey, crypto.SHA256, digest[:], signature); err != nil {
+		t.Fatalf("invalid signature: %v", err)
+	}
+
+	payload, _ := base64.RawURLEncoding.DecodeString(parts[1])
+	var claims struct {
+		IssuedAt  int64  `json:"iat"`
+		ExpiresAt int64  `json:"exp"`
+		Issuer    string `json:"iss"`
+	}
+	if err := json.Unmarshal(payload, &claims); err != nil {
+		t.Fatalf("error decoding claims: %v", err)
+	}
+	if claims.Issuer != "1234" || claims.IssuedAt != now.Add(-time.Minute).Unix() || claims.ExpiresAt != now.Add(appJWTLifetime).Unix() {
+		t.Fatalf("unexpected claims %+v", claims)
+	}
+
+	if _, err := parseAppPrivateKey("not a key"); err == nil {
+		t.Fatal("expected an error for an invalid key")
+	}
+}
@@ -3,14 +3,16 @@ package main
 import (
 	"context"
 	"fmt"
-	"regexp"
+	"strconv"
 	"strings"
+	"time"
 
 	"github.com/google/go-github/v43/github"
+	"github.com/trstringer/manual-approval/pkg/approval"
 )
 
 type approvalEnvironment struct {
-	client                  *github.Client
+	client                  *githubClient
 	repoFullName            string
 	repo                    string
 	repoOwner               string
@@ -20,9 +22,100 @@ type approvalEnvironment struct {
 	approvalIssue           *github.Issue
 	approvalIssueNumber     int
 	mutlipleDeploymentNames []string
+	sha                     string
+	gateName                string
+	reviewMode              string
+	pullRequestNumber       int
+	pullRequestComment      bool
+	requestComment          *github.IssueComment
+	discussionCategory      string
+	discussionID            string
+	checkRunName            string
+	checkRunID              int64
+	commitStatusContext     string
+	createDeployment        bool
+	deploymentEnvironment   string
+	createIssue             bool
+	dispatchSecret          string
+	dispatchSHA             string
+	emergencySenders        []string
+	approveLabel            string
+	denyLabel               string
+	closeDecisions          bool
+	requestedAt             time.Time
+	runAttempt              int
+	runAttemptStartedAt     time.Time
+	botApprovers            []string
+	writeAccess             bool
+	writeAccessLogins       map[string]bool
+	requirements            []approvalRequirement
+	vetoUsers               []string
+	mentionOnly             bool
+	issueTitleTemplate      string
+	issueTitlePrefix        string
+	environment             string
+	branch                  string
+	bodyFileName            string
+	bodyFileContent         string
+	actor                   string
+	workflowName            string
+	jobName                 string
+	triggerPullRequest      int
+	changedFiles            []*github.CommitFile
+	dispatchInputs          map[string]interface{}
+	milestone               int
+	projectItem             *projectItem
+	projectDoneStatus       string
+	issueTemplate           *issueTemplate
+	sharedIssue             bool
+	sharedIssueFollower     bool
+	gateState               gateState
+	gateStateCommentID      int64
+	leaveIssueOpen          bool
+	lastPoll                *issuePoll
+	maxWait                 time.Duration
+	maxPolls                int
+	webhookDeliveries       chan struct{}
+	companionSignal         bool
+	issueETag               string
+	metrics                 gateMetrics
+	pushgatewayURL          string
+	pushgatewayJob          string
+	tracer                  *gateTracer
+	statsdAddress           string
+	statsdPrefix            string
+	statsdFormat            string
+	auditFile               string
+	decisionCheckRun        bool
+	workflowPath            string
+	attestationFile         string
+	attestationPublish      bool
+	fulcioURL               string
+	rekorURL                string
+	receiptSecret           string
+	approvalConfirmations   string
+	mentionPendingApprovers bool
+	selectApprovers         int
+	selectStrategy          string
+	rotationPrimary         string
+	approverPool            []string
+	selectFallback          time.Duration
+	sla                     time.Duration
+	slaReported             bool
+	onDenial                string
+	deleteIssue             bool
+	minimizeComments        string
+	minimized               map[int64]bool
+	statusLabels            bool
+	statusLabel             string
+	deploymentAliases       approval.Aliases
+	deploymentDescriptions  map[string]string
+	deploymentApprovers     approval.DeploymentApprovers
+	partialApproval         bool
+	reviewSHA               string
 }
 
-func newApprovalEnvironment(client *github.Client, repoFullName, repoOwner string, runID int, approvers []string, minimumApprovals int, mutlipleDeploymentNames []string) (*approvalEnvironment, error) {
+func newApprovalEnvironment(client *githubClient, repoFullName, repoOwner string, runID int, approvers []string, minimumApprovals int, mutlipleDeploymentNames []string) (*approvalEnvironment, error) {
 	repoOwnerAndName := strings.Split(repoFullName, "/")
 	if len(repoOwnerAndName) != 2 {
 		return nil, fmt.Errorf("repo owner and name in unexpected format: %s", repoFullName)
@@ -38,6 +131,7 @@ func newApprovalEnvironment(client *github.Client, repoFullName, repoOwner strin
 		approvers:               approvers,
 		minimumApprovals:        minimumApprovals,
 		mutlipleDeploymentNames: mutlipleDeploymentNames,
+		createIssue:             true,
 	}, nil
 }
 
@@ -45,8 +139,59 @@ func (a approvalEnvironment) runURL() string {
 	return fmt.Sprintf("https://github.com/%s/actions/runs/%d", a.repoFullName, a.runID)
 }
 
+// templateValues are the values of the placeholders that issue-title and
+// issue templates may reference, keyed by placeholder name.
+func (a *approvalEnvironment) templateValues() map[string]string {
+	shaShort := a.sha
+	if len(shaShort) > 7 {
+		shaShort = shaShort[:7]
+	}
+	return map[string]string{
+		"run-id":      strconv.Itoa(a.runID),
+		"run-url":     a.runURL(),
+		"gate-name":   a.gateName,
+		"environment": a.environment,
+		"branch":      a.branch,
+		"sha":         a.sha,
+		"sha-short":   shaShort,
+		"actor":       a.actor,
+		"workflow":    a.workflowName,
+		"approvers":   strings.Join(a.approvers, ", "),
+	}
+}
+
+// replacePlaceholders replaces every {name} placeholder in text with its
+// value from templateValues.
+func (a *approvalEnvironment) replacePlaceholders(text string) string {
+	var oldnew []string
+	for name, value := range a.templateValues() {
+		oldnew = append(oldnew, "{"+name+"}", value)
+	}
+	return strings.NewReplacer(oldnew...).Replace(text)
+}
+
+// issueTitle renders the title of the approval issue. The issue-title input
+// may reference {run-id}, {gate-name}, {environment}, {branch} and
+// {sha-short}, among the other placeholders of templateValues.
+func (a *approvalEnvironment) issueTitle() string {
+	title := a.replacePlaceholders(a.issueTitleTemplate)
+	if a.issueTitleTemplate == "" {
+		title = fmt.Sprintf("Manual approval required for workflow run %d", a.runID)
+		if a.runAttempt > 1 {
+			title = fmt.Sprintf("%s (attempt %d)", title, a.runAttempt)
+		}
+		if a.gateName != "" {
+			title = fmt.Sprintf("%s: %s", title, a.gateName)
+		}
+	}
+	if a.issueTitlePrefix != "" {
+		title = fmt.Sprintf("%s %s", a.issueTitlePrefix, title)
+	}
+	return title
+}
+
 func (a *approvalEnvironment) createApprovalIssue(ctx context.Context) error {
-	issueTitle := fmt.Sprintf("Manual approval required for workflow run %d", a.runID)
+	issueTitle := a.issueTitle()
 	issueMultipleDeployment := []string{"-"}
 	if len(a.mutlipleDeploymentNames) > 0 {
 		issueMultipleDeployment = a.mutlipleDeploymentNames
@@ -58,14 +203,99 @@ Required approvers: %s
 
 Multiple deployment: %s
 
-Respond %s to continue workflow or %s to cancel.`,
+Respond %s to continue workflow or %s to cancel.
+
+Respond %s to withdraw an earlier approval.
+
+Respond %s to put the workflow on hold, even if it has enough approvals, and %s to lift your hold.`,
 		a.runURL(),
 		a.approvers,
 		issueMultipleDeployment,
-		formatAcceptedWords(approvedWords, a.mutlipleDeploymentNames),
-		formatAcceptedWords(deniedWords, []string{}),
+		formatAcceptedWords(gateWords.Approved, a.mutlipleDeploymentNames),
+		formatAcceptedWords(gateWords.Denied, []string{}),
+		formatAcceptedWords(gateWords.Revoked, []string{}),
+		formatAcceptedWords(gateWords.Hold, []string{}),
+		formatAcceptedWords(gateWords.Unhold, []string{}),
 	)
+	if a.approveLabel != "" || a.denyLabel != "" {
+		issueBody = fmt.Sprintf("%s\n\nApplying the %s label to this issue approves it and the %s label denies it.", issueBody, formatLabel(a.approveLabel), formatLabel(a.denyLabel))
+	}
+	if a.closeDecisions {
+		issueBody = fmt.Sprintf("%s\n\nClosing this issue as completed approves it and closing it as not planned denies it.", issueBody)
+	}
+	switch a.reviewMode {
+	case reviewModeInclude:
+		issueBody = fmt.Sprintf("%s\n\nApproving or requesting changes on pull request #%d also counts.", issueBody, a.pullRequestNumber)
+	case reviewModeOnly:
+		issueBody = fmt.Sprintf(`Workflow is pending manual review.
+URL: %s
+
+Required approvers: %s
+
+Approve pull request #%d to continue workflow or request changes to cancel.`,
+			a.runURL(),
+			a.approvers,
+			a.pullRequestNumber,
+		)
+	}
+	if workflowContext := a.workflowContext(); workflowContext != "" {
+		issueBody = fmt.Sprintf("%s\n\n%s", issueBody, workflowContext)
+	}
+	if table := deploymentsTable(a.mutlipleDeploymentNames, a.deploymentDescriptions, a.deploymentApprovers); table != "" {
+		issueBody = fmt.Sprintf("%s\n\n%s", issueBody, table)
+	}
+	if table := inputsTable(a.dispatchInputs); table != "" {
+		issueBody = fmt.Sprintf("%s\n\n%s", issueBody, table)
+	}
+	issueTitle, issueBody, templateLabels := a.applyIssueTemplate(issueTitle, issueBody)
+	var sections []string
+	if summary := changedFilesSummary(a.changedFiles); summary != "" {
+		sections = append(sections, summary)
+	}
+	if a.bodyFileContent != "" {
+		sections = append(sections, bodyFileSections(a.bodyFileName, a.bodyFileContent, a.runURL())...)
+	}
+	issueBody, followUpComments := layoutIssueBody(issueBody, sections, a.issueMarker().String())
 	var err error
+	if !a.createIssue {
+		fmt.Printf("Not creating an approval issue, waiting for approval of workflow run %s\n", a.runURL())
+		return nil
+	}
+	if a.discussionCategory != "" {
+		if err := a.createApprovalDiscussion(ctx, issueTitle, issueBody); err != nil {
+			return err
+		}
+		return a.postFollowUpComments(ctx, followUpComments)
+	}
+	if a.pullRequestComment {
+		commentBody := fmt.Sprintf("**%s**\n\n%s\n\n%s", issueTitle, mentionApprovers(a.approvers), issueBody)
+		fmt.Printf(
+			"Commenting on pull request #%d in repo %s/%s with the following content:\n%s\n",
+			a.pullRequestNumber,
+			a.repoOwner,
+			a.repo,
+			commentBody,
+		)
+		a.requestComment, _, err = a.client.Issues.CreateComment(ctx, a.repoOwner, a.repo, a.pullRequestNumber, &github.IssueComment{
+			Body: &commentBody,
+		})
+		a.approvalIssueNumber = a.pullRequestNumber
+		a.requestedAt = a.requestComment.GetCreatedAt()
+		if err != nil {
+			return err
+		}
+		return a.postFollowUpComments(ctx, followUpComments)
+	}
+	assignees, unassigned := splitAssignees(a.approvers)
+	if a.mentionOnly {
+		assignees, unassigned = nil, a.approvers
+	}
+	switch {
+	case a.mentionOnly && len(unassigned) > 0:
+		issueBody = fmt.Sprintf("%s\n\nRequesting approval from %s.", issueBody, mentionApprovers(unassigned))
+	case len(unassigned) > 0:
+		issueBody = fmt.Sprintf("%s\n\nAlso requesting approval from %s.", issueBody, mentionApprovers(unassigned))
+	}
 	fmt.Printf(
 		"Creating issue in repo %s/%s with the following content:\nTitle: %s\nApprovers: %s\nBody:\n%s\n",
 		a.repoOwner,
@@ -74,139 +304,353 @@ Respond %s to continue workflow or %s to cancel.`,
 		a.approvers,
 		issueBody,
 	)
-	a.approvalIssue, _, err = a.client.Issues.Create(ctx, a.repoOwner, a.repo, &github.IssueRequest{
+	issueRequest := &github.IssueRequest{
 		Title:     &issueTitle,
 		Body:      &issueBody,
-		Assignees: &a.approvers,
+		Assignees: &assignees,
+	}
+	if a.milestone != 0 {
+		issueRequest.Milestone = &a.milestone
+	}
+	if len(templateLabels) > 0 {
+		issueRequest.Labels = &templateLabels
+	}
+	if a.sharedIssue {
+		shared, err := a.sharedApprovalIssue(ctx)
+		if err != nil {
+			return fmt.Errorf("error looking for the shared approval issue: %w", err)
+		}
+		if shared != nil {
+			a.joinApprovalIssue(shared)
+			return nil
+		}
+	}
+	issue, created, err := approval.CreateIssue(ctx, a.client.Issues, a.repoOwner, a.repo, issueRequest)
+	if err != nil {
+		return err
+	}
+	a.approvalIssue = created
+	a.approvalIssueNumber = issue.Number
+	a.requestedAt = issue.NotBefore
+	if a.sharedIssue {
+		joined, err := a.yieldSharedIssue(ctx)
+		if err != nil {
+			return fmt.Errorf("error checking for the shared approval issue: %w", err)
+		}
+		if joined {
+			return nil
+		}
+	}
+	return a.postFollowUpComments(ctx, followUpComments)
+}
+
+// postFollowUpComments posts the content that didn't fit in the approval
+// issue body.
+func (a *approvalEnvironment) postFollowUpComments(ctx context.Context, comments []string) error {
+	for _, comment := range comments {
+		if err := a.postStatusComment(ctx, comment); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// decisionsNotBefore is the time before which comments are not considered,
+// so that decisions made before the approval was requested or before the
+// current run attempt started don't count.
+func (a *approvalEnvironment) decisionsNotBefore() time.Time {
+	if a.runAttemptStartedAt.After(a.requestedAt) {
+		return a.runAttemptStartedAt
+	}
+	return a.requestedAt
+}
+
+// filterCommentsBefore drops comments created before notBefore. Comments
+// without a creation time are kept.
+func filterCommentsBefore(comments []*github.IssueComment, notBefore time.Time) []*github.IssueComment {
+	return approval.FilterCommentsBefore(comments, notBefore)
+}
+
+// resolveApproval records the final status of the gate on the check run and
+// commit status, if configured, then closes the approval issue with comment.
+// Failing to update the check run or commit status is logged but does not
+// stop the issue from being closed.
+func (a *approvalEnvironment) resolveApproval(ctx context.Context, status approvalStatus, comment string) error {
+	a.reportMetrics(ctx, status)
+	if notes := decisionNotes(a.metrics.comments, a.metrics.approvers); len(notes) > 0 {
+		comment = fmt.Sprintf("%s\n\n%s", comment, formatDecisionNotes(notes))
+		reportDecisionNotes(notes)
+	}
+	conclusion := "cancelled"
+	switch status {
+	case approvalStatusApproved:
+		conclusion = "success"
+	case approvalStatusDenied:
+		conclusion = "failure"
+		if a.onDenial == onDenialSkip {
+			conclusion = "neutral"
+		}
+	case approvalStatusTimedOut:
+		conclusion = "timed_out"
+	}
+	if err := a.completeApprovalCheckRun(ctx, conclusion, comment); err != nil {
+		fmt.Printf("error completing check run: %v\n", err)
+	}
+	if a.decisionCheckRun {
+		if err := a.recordDecisionCheckRun(ctx, status, conclusion); err != nil {
+			fmt.Printf("error recording decision check run: %v\n", err)
+		}
+	}
+	if err := a.setCommitStatus(ctx, status); err != nil {
+		fmt.Printf("error setting commit status: %v\n", err)
+	}
+	if err := a.setProjectStatus(ctx, a.projectDoneStatus); err != nil {
+		fmt.Printf("error updating project status: %v\n", err)
+	}
+	if err := a.setStatusLabel(ctx, status); err != nil {
+		fmt.Printf("error setting status label: %v\n", err)
+	}
+	state := a.gateState
+	state.Outcome = status
+	if err := a.writeGateState(ctx, state); err != nil {
+		fmt.Printf("error saving gate state: %v\n", err)
+	}
+	return a.closeApprovalIssue(ctx, comment)
+}
+
+// closeApprovalIssue leaves a final comment on the approval issue and closes
+// it. When the request was posted as a pull request comment only the comment
+// is left, as the pull request itself must stay open.
+func (a *approvalEnvironment) closeApprovalIssue(ctx context.Context, comment string) error {
+	if !a.createIssue || a.sharedIssueFollower || a.leaveIssueOpen {
+		fmt.Println(comment)
+		return nil
+	}
+	if a.discussionCategory != "" {
+		return a.closeApprovalDiscussion(ctx, comment)
+	}
+	if a.pullRequestComment {
+		// The pull request itself must stay open.
+		if err := a.issue().Comment(ctx, comment); err != nil {
+			return fmt.Errorf("error commenting on issue: %w", err)
+		}
+		return nil
+	}
+	if err := a.issue().Close(ctx, comment); err != nil {
+		return err
+	}
+	if a.deleteIssue {
+		return a.deleteApprovalIssue(ctx)
+	}
+	return nil
+}
+
+// postStatusComment comments on the approval issue to keep approvers informed
+// while the gate is still open.
+func (a *approvalEnvironment) postStatusComment(ctx context.Context, comment string) error {
+	fmt.Println(comment)
+	if !a.createIssue || a.sharedIssueFollower {
+		return nil
+	}
+	if a.discussionCategory != "" {
+		return a.graphQL(ctx, addDiscussionCommentMutation, map[string]interface{}{
+			"discussionId": a.discussionID,
+			"body":         comment,
+		}, nil)
+	}
+	_, _, err := a.client.Issues.CreateComment(ctx, a.repoOwner, a.repo, a.approvalIssueNumber, &github.IssueComment{
+		Body: &comment,
 	})
-	a.approvalIssueNumber = a.approvalIssue.GetNumber()
 	return err
 }
 
-func approvalFromComments(comments []*github.IssueComment, approvers []string, minimumApprovals int, multipleDeploymentNames []string) (approvalStatus approvalStatus, deploymentNames []string, error error) {
-	remainingApprovers := make([]string, len(approvers))
-	copy(remainingApprovers, approvers)
+// issue returns the lifecycle of the approval issue.
+func (a *approvalEnvironment) issue() *approval.Issue {
+	issue := approval.OpenIssue(a.client.Issues, a.repoOwner, a.repo, a.approvalIssueNumber)
+	issue.NotBefore = a.decisionsNotBefore()
+	issue.AllowedBots = a.botApprovers
+	return issue
+}
 
-	if minimumApprovals == 0 {
-		minimumApprovals = len(approvers)
+func (a *approvalEnvironment) listIssueComments(ctx context.Context) ([]*github.IssueComment, error) {
+	comments, err := a.issue().Comments(ctx)
+	if err != nil {
+		return nil, err
 	}
+	return a.commentsAfterRequest(comments), nil
+}
 
+// commentsAfterRequest drops the comments made before the approval request.
+// A pull request thread can contain comments from before the approval
+// request was made, none of which should count as a decision.
+func (a *approvalEnvironment) commentsAfterRequest(comments []*github.IssueComment) []*github.IssueComment {
+	if a.requestComment == nil {
+		return comments
+	}
+	var newer []*github.IssueComment
 	for _, comment := range comments {
-		commentUser := comment.User.GetLogin()
-		approverIdx := approversIndex(remainingApprovers, commentUser)
-		if approverIdx < 0 {
-			continue
-		}
-
-		commentBody := comment.GetBody()
-
-		var bodyDeploymentNames []string
-		if strings.Contains(commentBody, "[") && len(multipleDeploymentNames) != 0 {
-			commentBodySplit := strings.Split(commentBody, "[")
-			commentBody = commentBodySplit[0]
-
-			deploymentNamesRaw := "["
-			deploymentNamesRaw += commentBodySplit[1]
-
-			re := regexp.MustCompile(`\[(.*)\]`)
-			matches := re.FindStringSubmatch(deploymentNamesRaw)
-			if len(matches) != 2 {
-				return approvalStatusPending, []string{},fmt.Errorf("errors.comment body is not valid")
-			}
-
-			validDeploymentNamesMap := make(map[string]bool) 
-			for _, v := range multipleDeploymentNames {
-				validDeploymentNamesMap[v] = true
-			}
-			deploymentNames := strings.Split(matches[1], ",")
-			for _, v := range deploymentNames {
-				if !validDeploymentNamesMap[v] {
-					return approvalStatusPending, []string{},fmt.Errorf("errors.deployment name is invalid")
-				}
-				bodyDeploymentNames = append(bodyDeploymentNames, v)
-			}
-		}
-
-		isApprovalComment, err := isApproved(commentBody)
-		if err != nil {
-			return approvalStatusPending, []string{},  err
-		}
-		if isApprovalComment {
-			if len(remainingApprovers) == len(approvers)-minimumApprovals+1 {
-				return approvalStatusApproved, bodyDeploymentNames, nil
-			}
-			remainingApprovers[approverIdx] = remainingApprovers[len(remainingApprovers)-1]
-			remainingApprovers = remainingApprovers[:len(remainingApprovers)-1]
-			continue
+		if comment.GetID() > a.requestComment.GetID() {
+			newer = append(newer, comment)
 		}
+	}
+	return newer
+}
+
+// splitAssignees returns the approvers that fit within GitHub's assignee
+// limit and the remaining approvers, who are mentioned instead.
+func splitAssignees(approvers []string) (assignees []string, unassigned []string) {
+	if len(approvers) <= maxIssueAssignees {
+		return approvers, nil
+	}
+	return approvers[:maxIssueAssignees], approvers[maxIssueAssignees:]
+}
+
+func mentionApprovers(approvers []string) string {
+	var mentions []string
+	for _, approver := range approvers {
+		mentions = append(mentions, "@"+approver)
+	}
+	return strings.Join(mentions, " ")
+}
 
-		isDenialComment, err := isDenied(commentBody)
+// approvalComments returns every comment that should be considered for the
+// approval decision, in the order the comments were made.
+func (a *approvalEnvironment) approvalComments(ctx context.Context) ([]*github.IssueComment, error) {
+	var comments []*github.IssueComment
+	polled := false
+	switch {
+	case !a.createIssue:
+		// There is no approval issue to read comments from.
+	case a.discussionCategory != "":
+		discussionComments, err := a.listDiscussionComments(ctx)
+		if err != nil {
+			return nil, err
+		}
+		comments = append(comments, discussionComments...)
+	case a.reviewMode != reviewModeOnly:
+		// The comments, label and close events come from a single query.
+		poll, err := a.pollIssue(ctx)
 		if err != nil {
-			return approvalStatusPending, []string{}, err
+			return nil, err
 		}
-		if isDenialComment {
-			return approvalStatusDenied, []string{}, nil
+		comments = append(comments, poll.comments...)
+		if (a.approveLabel != "" || a.denyLabel != "") && a.approvalIssue != nil {
+			comments = append(comments, labelDecisionComments(poll.labelEvents, a.approveLabel, a.denyLabel)...)
 		}
+		if a.closeDecisions && a.approvalIssue != nil {
+			comments = append(comments, closeDecisionComments(poll.closeEvents)...)
+		}
+		sortCommentsByCreation(comments)
+		polled = true
 	}
 
-	return approvalStatusPending, []string{}, nil
-}
+	if (a.approveLabel != "" || a.denyLabel != "") && a.approvalIssue != nil && !polled {
+		labelComments, err := a.listLabelComments(ctx)
+		if err != nil {
+			return nil, err
+		}
+		comments = append(comments, labelComments...)
+		sortCommentsByCreation(comments)
+	}
 
-func approversIndex(approvers []string, name string) int {
-	for idx, approver := range approvers {
-		if approver == name {
-			return idx
+	if a.closeDecisions && a.approvalIssue != nil && !polled {
+		closeComments, err := a.listCloseComments(ctx)
+		if err != nil {
+			return nil, err
 		}
+		comments = append(comments, closeComments...)
+		sortCommentsByCreation(comments)
 	}
-	return -1
-}
 
-func isApproved(commentBody string) (bool, error) {
-	for _, approvedWord := range approvedWords {
-		matched, err := regexp.MatchString(fmt.Sprintf("(?i)^%s[.!]*\n*$", approvedWord), commentBody)
+	if a.dispatchSecret != "" {
+		dispatchComments, err := a.listDispatchComments(ctx)
 		if err != nil {
-			return false, err
+			return nil, err
 		}
-		if matched {
-			return true, nil
+		comments = append(comments, dispatchComments...)
+		sortCommentsByCreation(comments)
+	}
+
+	if a.reviewMode == reviewModeInclude || a.reviewMode == reviewModeOnly {
+		reviews, err := a.listReviewComments(ctx)
+		if err != nil {
+			return nil, err
 		}
+		comments = append(comments, reviews...)
+		sortCommentsByCreation(comments)
 	}
 
-	return false, nil
-}
+	// Reviews are held to the same cutoff as comments, so a review given
+	// before the gate opened doesn't count.
+	comments = filterCommentsBefore(comments, a.decisionsNotBefore())
 
-func isDenied(commentBody string) (bool, error) {
-	for _, deniedWord := range deniedWords {
-		matched, err := regexp.MatchString(fmt.Sprintf("(?i)^%s[.!]?$", deniedWord), commentBody)
+	if a.checkRunID != 0 {
+		checkRunComments, err := a.listCheckRunComments(ctx)
 		if err != nil {
-			return false, err
-		}
-		if matched {
-			return true, nil
+			return nil, err
 		}
+		comments = append(comments, checkRunComments...)
+		sortCommentsByCreation(comments)
 	}
 
-	return false, nil
+	comments = filterBotComments(comments, a.botApprovers)
+	comments = approval.ExpandAliasComments(comments, a.mutlipleDeploymentNames, a.deploymentAliases)
+	return gateWords.RestrictApprovalComments(comments, a.mutlipleDeploymentNames, a.deploymentApprovers), nil
 }
 
-func formatAcceptedWords(words []string, multipleDeploymentNames []string) string {
-	var quotedWords []string
+// filterBotComments drops comments made by bot accounts, such as GitHub Apps
+// posting status messages, unless the bot is explicitly allowed to approve.
+func filterBotComments(comments []*github.IssueComment, allowedBots []string) []*github.IssueComment {
+	return approval.FilterBotComments(comments, allowedBots)
+}
 
-	var deploymentNames string
-	if len(multipleDeploymentNames) > 1 {
-		deploymentNames += "["
-		for i, v := range multipleDeploymentNames {
-			deploymentNames += v
-			if i != len(multipleDeploymentNames)-1 {
-				deploymentNames += ","
-			}
+// approvalRequirement is an additional condition that the approvers who
+// approved must satisfy, on top of the minimum number of approvals.
+type approvalRequirement = approval.Requirement
+
+func approvalFromComments(comments []*github.IssueComment, approvers []string, minimumApprovals int, multipleDeploymentNames []string, requirements ...approvalRequirement) (approvalStatus approvalStatus, deploymentNames []string, error error) {
+	return gateWords.Evaluate(comments, approvers, minimumApprovals, multipleDeploymentNames, requirements...)
+}
+
+// approversIndex returns the index of name in approvers. GitHub logins are
+// case-insensitive, so they are compared accordingly.
+func approversIndex(approvers []string, name string) int {
+	return approval.ApproversIndex(approvers, name)
+}
+
+// parseApprovers splits the comma-delimited approvers input, trimming
+// whitespace and a leading @ from each entry and dropping empty entries.
+func parseApprovers(raw string) []string {
+	var approvers []string
+	for _, approver := range splitInputList(raw) {
+		approver = strings.TrimSpace(strings.TrimPrefix(approver, "@"))
+		if approver != "" {
+			approvers = append(approvers, approver)
 		}
-		deploymentNames += "]"
 	}
+	return approvers
+}
 
-	for _, word := range words {
-		quotedWords = append(quotedWords, fmt.Sprintf("\"%s%s\"", word, deploymentNames))
-	}
+func isApproved(commentBody string) (bool, error) {
+	return gateWords.IsApproved(commentBody)
+}
+
+func isDenied(commentBody string) (bool, error) {
+	return gateWords.IsDenied(commentBody)
+}
+
+func isRevoked(commentBody string) (bool, error) {
+	return gateWords.IsRevoked(commentBody)
+}
+
+func isHold(commentBody string) (bool, error) {
+	return gateWords.IsHold(commentBody)
+}
+
+func isUnhold(commentBody string) (bool, error) {
+	return gateWords.IsUnhold(commentBody)
+}
 
-	return strings.Join(quotedWords, ", ")
+func formatAcceptedWords(words []string, multipleDeploymentNames []string) string {
+	return approval.FormatAcceptedWords(words, multipleDeploymentNames)
 }
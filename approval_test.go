@@ -1,7 +1,10 @@
 package main
 
 import (
+	"fmt"
+	"reflect"
 	"testing"
+	"time"
 
 	"github.com/google/go-github/v43/github"
 )
@@ -13,6 +16,9 @@ func TestApprovalFromComments(t *testing.T) {
 	bodyApproved := "Approved"
 	bodyDenied := "Denied"
 	bodyPending := "not approval or denial"
+	bodyRevoked := "/unapprove"
+	bodyHold := "/hold"
+	bodyUnhold := "/unhold"
 
 	testCases := []struct {
 		name             string
@@ -158,6 +164,143 @@ func TestApprovalFromComments(t *testing.T) {
 			expectedStatus:   approvalStatusPending,
 			minimumApprovals: 2,
 		},
+		{
+			name: "multi_approver_revoked_before_minimum",
+			comments: []*github.IssueComment{
+				{
+					User: &github.User{Login: &login1},
+					Body: &bodyApproved,
+				},
+				{
+					User: &github.User{Login: &login1},
+					Body: &bodyRevoked,
+				},
+				{
+					User: &github.User{Login: &login2},
+					Body: &bodyApproved,
+				},
+			},
+			approvers:        []string{login1, login2, login3},
+			expectedStatus:   approvalStatusPending,
+			minimumApprovals: 2,
+		},
+		{
+			name: "multi_approver_revoked_and_approved_again",
+			comments: []*github.IssueComment{
+				{
+					User: &github.User{Login: &login1},
+					Body: &bodyApproved,
+				},
+				{
+					User: &github.User{Login: &login1},
+					Body: &bodyRevoked,
+				},
+				{
+					User: &github.User{Login: &login1},
+					Body: &bodyApproved,
+				},
+				{
+					User: &github.User{Login: &login2},
+					Body: &bodyApproved,
+				},
+			},
+			approvers:        []string{login1, login2, login3},
+			expectedStatus:   approvalStatusApproved,
+			minimumApprovals: 2,
+		},
+		{
+			name: "single_approver_held_after_approval",
+			comments: []*github.IssueComment{
+				{
+					User: &github.User{Login: &login2},
+					Body: &bodyHold,
+				},
+				{
+					User: &github.User{Login: &login1},
+					Body: &bodyApproved,
+				},
+			},
+			approvers:        []string{login1, login2},
+			expectedStatus:   approvalStatusHeld,
+			minimumApprovals: 1,
+		},
+		{
+			name: "multi_approver_hold_lifted",
+			comments: []*github.IssueComment{
+				{
+					User: &github.User{Login: &login2},
+					Body: &bodyHold,
+				},
+				{
+					User: &github.User{Login: &login1},
+					Body: &bodyApproved,
+				},
+				{
+					User: &github.User{Login: &login2},
+					Body: &bodyUnhold,
+				},
+			},
+			approvers:        []string{login1, login2},
+			expectedStatus:   approvalStatusApproved,
+			minimumApprovals: 1,
+		},
+		{
+			name: "multi_approver_hold_lifted_by_other_approver",
+			comments: []*github.IssueComment{
+				{
+					User: &github.User{Login: &login2},
+					Body: &bodyHold,
+				},
+				{
+					User: &github.User{Login: &login1},
+					Body: &bodyApproved,
+				},
+				{
+					User: &github.User{Login: &login1},
+					Body: &bodyUnhold,
+				},
+			},
+			approvers:        []string{login1, login2},
+			expectedStatus:   approvalStatusHeld,
+			minimumApprovals: 1,
+		},
+		{
+			name: "multi_approver_denied_while_held",
+			comments: []*github.IssueComment{
+				{
+					User: &github.User{Login: &login1},
+					Body: &bodyHold,
+				},
+				{
+					User: &github.User{Login: &login2},
+					Body: &bodyDenied,
+				},
+			},
+			approvers:      []string{login1, login2},
+			expectedStatus: approvalStatusDenied,
+		},
+		{
+			name: "single_approver_case_insensitive_login",
+			comments: []*github.IssueComment{
+				{
+					User: &github.User{Login: github.String("Login1")},
+					Body: &bodyApproved,
+				},
+			},
+			approvers:      []string{"login1"},
+			expectedStatus: approvalStatusApproved,
+		},
+		{
+			name: "single_approver_revoke_without_approval",
+			comments: []*github.IssueComment{
+				{
+					User: &github.User{Login: &login1},
+					Body: &bodyRevoked,
+				},
+			},
+			approvers:      []string{login1},
+			expectedStatus: approvalStatusPending,
+		},
 	}
 
 	for _, testCase := range testCases {
@@ -330,3 +473,190 @@ func TestDeniedCommentBody(t *testing.T) {
 		})
 	}
 }
+
+func TestRevokedCommentBody(t *testing.T) {
+	testCases := []struct {
+		name        string
+		commentBody string
+		isSuccess   bool
+	}{
+		{
+			name:        "revoke_lowercase_no_punctuation",
+			commentBody: "revoke",
+			isSuccess:   true,
+		},
+		{
+			name:        "unapprove_slash_command",
+			commentBody: "/unapprove",
+			isSuccess:   true,
+		},
+		{
+			name:        "revoke_slash_command_newline",
+			commentBody: "/revoke\n",
+			isSuccess:   true,
+		},
+		{
+			name:        "unapprove_titlecase_period",
+			commentBody: "Unapprove.",
+			isSuccess:   true,
+		},
+		{
+			name:        "sentence_with_keyword",
+			commentBody: "should i revoke this",
+			isSuccess:   false,
+		},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			actual, err := isRevoked(testCase.commentBody)
+			if err != nil {
+				t.Fatalf("error getting revocation: %v", err)
+			}
+			if actual != testCase.isSuccess {
+				t.Fatalf("expected %v but got %v", testCase.isSuccess, actual)
+			}
+		})
+	}
+}
+
+func TestFilterCommentsBefore(t *testing.T) {
+	requestedAt := time.Date(2022, 3, 1, 10, 0, 0, 0, time.UTC)
+	before := requestedAt.Add(-time.Minute)
+	after := requestedAt.Add(time.Minute)
+	comments := []*github.IssueComment{
+		{ID: github.Int64(1), CreatedAt: &before},
+		{ID: github.Int64(2), CreatedAt: &requestedAt},
+		{ID: github.Int64(3), CreatedAt: &after},
+		{ID: github.Int64(4)},
+	}
+
+	filtered := filterCommentsBefore(comments, requestedAt)
+	if len(filtered) != 3 {
+		t.Fatalf("expected 3 comments but got %d", len(filtered))
+	}
+	for _, comment := range filtered {
+		if comment.GetID() == 1 {
+			t.Fatalf("expected comment created before the request to be filtered")
+		}
+	}
+
+	if unfiltered := filterCommentsBefore(comments, time.Time{}); len(unfiltered) != len(comments) {
+		t.Fatalf("expected no comments to be filtered without a time but got %d", len(unfiltered))
+	}
+}
+
+func TestFilterBotComments(t *testing.T) {
+	comments := []*github.IssueComment{
+		{User: &github.User{Login: github.String("login1"), Type: github.String("User")}},
+		{User: &github.User{Login: github.String("status-app[bot]"), Type: github.String("Bot")}},
+		{User: &github.User{Login: github.String("policy-bot[bot]"), Type: github.String("Bot")}},
+		{User: &github.User{Login: github.String("other[bot]")}},
+	}
+
+	filtered := filterBotComments(comments, []string{"policy-bot[bot]"})
+	if len(filtered) != 2 {
+		t.Fatalf("expected 2 comments but got %d", len(filtered))
+	}
+	if filtered[0].User.GetLogin() != "login1" || filtered[1].User.GetLogin() != "policy-bot[bot]" {
+		t.Fatalf("expected comments from login1 and policy-bot[bot] but got %s and %s", filtered[0].User.GetLogin(), filtered[1].User.GetLogin())
+	}
+}
+
+func TestParseApprovers(t *testing.T) {
+	testCases := []struct {
+		name     string
+		raw      string
+		expected []string
+	}{
+		{
+			name:     "plain_list",
+			raw:      "login1,login2",
+			expected: []string{"login1", "login2"},
+		},
+		{
+			name:     "whitespace_and_mentions",
+			raw:      " @login1 , login2,@ login3 ",
+			expected: []string{"login1", "login2", "login3"},
+		},
+		{
+			name:     "empty_entries",
+			raw:      "login1,,login2,",
+			expected: []string{"login1", "login2"},
+		},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			actual := parseApprovers(testCase.raw)
+			if !reflect.DeepEqual(actual, testCase.expected) {
+				t.Fatalf("expected %v but got %v", testCase.expected, actual)
+			}
+		})
+	}
+}
+
+func TestSplitAssignees(t *testing.T) {
+	var approvers []string
+	for i := 1; i <= 12; i++ {
+		approvers = append(approvers, fmt.Sprintf("login%d", i))
+	}
+
+	assignees, unassigned := splitAssignees(approvers[:3])
+	if !reflect.DeepEqual(assignees, approvers[:3]) || len(unassigned) != 0 {
+		t.Fatalf("expected all approvers to be assigned but got %v and %v", assignees, unassigned)
+	}
+
+	assignees, unassigned = splitAssignees(approvers)
+	if !reflect.DeepEqual(assignees, approvers[:10]) {
+		t.Fatalf("expected %v to be assigned but got %v", approvers[:10], assignees)
+	}
+	if !reflect.DeepEqual(unassigned, []string{"login11", "login12"}) {
+		t.Fatalf("expected login11 and login12 to be unassigned but got %v", unassigned)
+	}
+}
+
+func TestIssueTitle(t *testing.T) {
+	testCases := []struct {
+		name     string
+		apprv    approvalEnvironment
+		expected string
+	}{
+		{
+			name:     "default",
+			apprv:    approvalEnvironment{runID: 123},
+			expected: "Manual approval required for workflow run 123",
+		},
+		{
+			name:     "gate_name",
+			apprv:    approvalEnvironment{runID: 123, gateName: "post-deploy"},
+			expected: "Manual approval required for workflow run 123: post-deploy",
+		},
+		{
+			name:     "prefix",
+			apprv:    approvalEnvironment{runID: 123, issueTitlePrefix: "[approval]"},
+			expected: "[approval] Manual approval required for workflow run 123",
+		},
+		{
+			name: "template",
+			apprv: approvalEnvironment{
+				runID:              123,
+				gateName:           "pre-deploy",
+				environment:        "production",
+				branch:             "main",
+				sha:                "0123456789abcdef",
+				issueTitleTemplate: "[{environment}] {gate-name}: deploy {sha-short} from {branch} (run {run-id})",
+			},
+			expected: "[production] pre-deploy: deploy 0123456 from main (run 123)",
+		},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			actual := testCase.apprv.issueTitle()
+			if actual != testCase.expected {
+				t.Fatalf("expected %q but got %q", testCase.expected, actual)
+			}
+		})
+	}
+}
@@ -0,0 +1,64 @@
+package main
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/google/go-github/v43/github"
+	"github.com/trstringer/manual-approval/pkg/approval"
+)
+
+// findCachedApproval looks for a closed approval issue created for the same
+// commit SHA and gate name whose comments satisfy the current approval
+// requirements. It returns nil if no such issue exists.
+func (a *approvalEnvironment) findCachedApproval(ctx context.Context) (*github.Issue, []string, error) {
+	issues, err := a.findApprovalIssues(ctx, "closed", approvalCacheSearchPages, func(marker issueMarker) bool {
+		return marker.SHA == a.sha && marker.Gate == a.gateName
+	})
+	if err != nil {
+		return nil, nil, err
+	}
+
+	for _, issue := range issues {
+		approved, deploymentNames, err := a.closedIssueApproval(ctx, issue)
+		if err != nil {
+			fmt.Printf("ignoring cached approval issue %d: %v\n", issue.GetNumber(), err)
+			continue
+		}
+		if approved == approvalStatusApproved {
+			return issue, deploymentNames, nil
+		}
+	}
+	return nil, nil, nil
+}
+
+// closedIssueApproval reads the decision on a closed approval issue,
+// returning approved and the deployment names approved only if the gate
+// closed the issue as approved and its comments still satisfy the current
+// approval requirements. The comments are filtered as those of an open gate
+// are, leaving out those of bots that aren't approvers and those made
+// before the issue was created.
+func (a *approvalEnvironment) closedIssueApproval(ctx context.Context, issue *github.Issue) (approvalStatus, []string, error) {
+	closed := approval.OpenIssue(a.client.Issues, a.repoOwner, a.repo, issue.GetNumber())
+	closed.NotBefore = issue.GetCreatedAt()
+	closed.AllowedBots = a.botApprovers
+	comments, err := closed.Comments(ctx)
+	if err != nil {
+		return approvalStatusPending, nil, err
+	}
+	if _, state := findGateState(comments, issue.GetUser().GetLogin()); state.Outcome != approvalStatusApproved {
+		return approvalStatusPending, nil, nil
+	}
+
+	comments = closed.Decisions(comments)
+	comments = approval.ExpandAliasComments(comments, a.mutlipleDeploymentNames, a.deploymentAliases)
+	comments = gateWords.RestrictApprovalComments(comments, a.mutlipleDeploymentNames, a.deploymentApprovers)
+	approved, deploymentNames, err := approvalFromComments(comments, a.approvers, a.minimumApprovals, a.mutlipleDeploymentNames, a.requirements...)
+	if err != nil {
+		return approvalStatusPending, nil, err
+	}
+	if len(a.mutlipleDeploymentNames) > 0 && len(deploymentNames) == 0 {
+		return approvalStatusPending, nil, nil
+	}
+	return approved, deploymentNames, nil
+}
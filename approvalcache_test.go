@@ -0,0 +1,79 @@
+package main
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+)
+
+func TestFindCachedApproval(t *testing.T) {
+	testCases := []struct {
+		name     string
+		comment  func(fake *fakeGitHub, number int)
+		resolved approvalStatus
+		cached   bool
+	}{
+		{
+			name:     "approved_after_the_first_page",
+			comment:  func(fake *fakeGitHub, number int) { fake.comment(number, "user1", "approve") },
+			resolved: approvalStatusApproved,
+			cached:   true,
+		},
+		{
+			name:     "bot_approval",
+			comment:  func(fake *fakeGitHub, number int) { fake.comment(number, "deploy-bot[bot]", "approve") },
+			resolved: approvalStatusApproved,
+		},
+		{
+			name:     "approved_after_timing_out",
+			resolved: approvalStatusTimedOut,
+		},
+		{
+			name:     "approved_after_cancelling",
+			resolved: approvalStatusCancelled,
+		},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			ctx := context.Background()
+			t.Setenv(envVarOutput, filepath.Join(t.TempDir(), "output"))
+			fake := newFakeGitHub()
+			gate := func() *approvalEnvironment {
+				apprv, err := newApprovalEnvironment(fake.client(), "owner/repo", "owner", 1, []string{"user1", "deploy-bot[bot]"}, 1, nil)
+				if err != nil {
+					t.Fatalf("unexpected error: %v", err)
+				}
+				apprv.sha = "abc123"
+				return apprv
+			}
+
+			earlier := gate()
+			if err := earlier.createApprovalIssue(ctx); err != nil {
+				t.Fatalf("error creating approval issue: %v", err)
+			}
+			for i := 0; i < 40; i++ {
+				fake.comment(earlier.approvalIssueNumber, "user2", "Looking into it")
+			}
+			if testCase.comment != nil {
+				testCase.comment(fake, earlier.approvalIssueNumber)
+			}
+			if err := earlier.resolveApproval(ctx, testCase.resolved, "Closing issue."); err != nil {
+				t.Fatalf("error closing issue: %v", err)
+			}
+			if testCase.resolved != approvalStatusApproved {
+				// An approval posted once the issue was closed doesn't
+				// approve it.
+				fake.comment(earlier.approvalIssueNumber, "user1", "approve")
+			}
+
+			issue, _, err := gate().findCachedApproval(ctx)
+			if err != nil {
+				t.Fatalf("error looking for a cached approval: %v", err)
+			}
+			if (issue != nil) != testCase.cached {
+				t.Fatalf("expected cached %t but got issue %v", testCase.cached, issue.GetNumber())
+			}
+		})
+	}
+}
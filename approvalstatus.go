@@ -1,9 +1,25 @@
 package main
 
-type approvalStatus string
+import "github.com/trstringer/manual-approval/pkg/approval"
 
+type approvalStatus = approval.Status
+
+const (
+	approvalStatusPending  = approval.StatusPending
+	approvalStatusApproved = approval.StatusApproved
+	approvalStatusDenied   = approval.StatusDenied
+	approvalStatusHeld     = approval.StatusHeld
+	// approvalStatusCancelled is never the result of evaluating comments; it
+	// is used when the workflow is cancelled while waiting.
+	approvalStatusCancelled = approval.StatusCancelled
+	// approvalStatusTimedOut is used when max-wait or max-polls is exceeded
+	// without a decision.
+	approvalStatusTimedOut = approval.StatusTimedOut
+)
+
+// Decisions recorded by channels other than comments, such as check run
+// buttons or dispatch events.
 const (
-	approvalStatusPending  approvalStatus = "Pending"
-	approvalStatusApproved approvalStatus = "Approved"
-	approvalStatusDenied   approvalStatus = "Denied"
+	decisionApprove string = "approve"
+	decisionDeny    string = "deny"
 )
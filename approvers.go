@@ -0,0 +1,184 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/google/go-github/v43/github"
+)
+
+// parseApproversFile reads one approver login or org/team slug per line.
+// Blank lines and lines starting with # are ignored.
+func parseApproversFile(content string) []string {
+	var approvers []string
+	for _, line := range strings.Split(content, "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		approvers = append(approvers, parseApprovers(line)...)
+	}
+	return approvers
+}
+
+// readApproversFile fetches the approvers file from the default branch of the
+// repository. Reading it from the default branch rather than from the commit
+// being built means changes to the list only apply once they are merged.
+func readApproversFile(ctx context.Context, client *github.Client, repoOwner, repo, path string) ([]string, error) {
+	file, _, _, err := client.Repositories.GetContents(ctx, repoOwner, repo, path, nil)
+	if err != nil {
+		return nil, err
+	}
+	if file == nil {
+		return nil, fmt.Errorf("%s is not a file", path)
+	}
+	content, err := file.GetContent()
+	if err != nil {
+		return nil, err
+	}
+	return parseApproversFile(content), nil
+}
+
+// approversURLTimeout bounds how long opening the gate waits for the
+// approvers endpoint.
+const approversURLTimeout = 30 * time.Second
+
+// parseApproversJSON accepts either a JSON array of logins or an object with
+// an "approvers" array.
+func parseApproversJSON(data []byte) ([]string, error) {
+	var list []string
+	if err := json.Unmarshal(data, &list); err != nil {
+		var object struct {
+			Approvers []string `json:"approvers"`
+		}
+		if err := json.Unmarshal(data, &object); err != nil {
+			return nil, fmt.Errorf("expected a JSON array of approvers or an object with an approvers array: %w", err)
+		}
+		list = object.Approvers
+	}
+	return parseApprovers(strings.Join(list, ",")), nil
+}
+
+// fetchApproversURL requests the list of approvers from an external endpoint.
+// authHeader, when set, is sent as the Authorization header.
+func fetchApproversURL(ctx context.Context, url, authHeader string) ([]string, error) {
+	ctx, cancel := context.WithTimeout(ctx, approversURLTimeout)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Accept", "application/json")
+	if authHeader != "" {
+		req.Header.Set("Authorization", authHeader)
+	}
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("unexpected status %s", resp.Status)
+	}
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+	return parseApproversJSON(data)
+}
+
+// teamMembersCache holds the expanded members of each org/team for the life
+// of the run, keyed by the lowercase org/team slug.
+var teamMembersCache = map[string][]string{}
+
+// expandTeams replaces every org/team entry with the members of that team and
+// of its child teams, removing duplicate logins.
+func expandTeams(ctx context.Context, client *github.Client, entries []string) ([]string, error) {
+	var approvers []string
+	for _, entry := range entries {
+		orgAndTeam := strings.Split(entry, "/")
+		if len(orgAndTeam) != 2 {
+			approvers = appendUniqueApprover(approvers, entry)
+			continue
+		}
+
+		members, err := teamMembers(ctx, client, orgAndTeam[0], orgAndTeam[1], map[string]bool{})
+		if err != nil {
+			return nil, err
+		}
+		for _, member := range members {
+			approvers = appendUniqueApprover(approvers, member)
+		}
+	}
+	return approvers, nil
+}
+
+// teamMembers lists the members of a team and, recursively, of its child
+// teams. visited guards against expanding a team twice within one entry.
+func teamMembers(ctx context.Context, client *github.Client, org, slug string, visited map[string]bool) ([]string, error) {
+	key := strings.ToLower(org + "/" + slug)
+	if members, ok := teamMembersCache[key]; ok {
+		return members, nil
+	}
+	if visited[key] {
+		return nil, nil
+	}
+	visited[key] = true
+
+	var members []string
+	opts := &github.TeamListTeamMembersOptions{
+		ListOptions: github.ListOptions{PerPage: 100},
+	}
+	for {
+		users, resp, err := client.Teams.ListTeamMembersBySlug(ctx, org, slug, opts)
+		if err != nil {
+			return nil, fmt.Errorf("error listing members of team %s/%s: %w", org, slug, err)
+		}
+		for _, user := range users {
+			members = appendUniqueApprover(members, user.GetLogin())
+		}
+		if resp.NextPage == 0 {
+			break
+		}
+		opts.Page = resp.NextPage
+	}
+
+	listOpts := &github.ListOptions{PerPage: 100}
+	for {
+		children, resp, err := client.Teams.ListChildTeamsByParentSlug(ctx, org, slug, listOpts)
+		if err != nil {
+			return nil, fmt.Errorf("error listing child teams of %s/%s: %w", org, slug, err)
+		}
+		for _, child := range children {
+			childMembers, err := teamMembers(ctx, client, org, child.GetSlug(), visited)
+			if err != nil {
+				return nil, err
+			}
+			for _, member := range childMembers {
+				members = appendUniqueApprover(members, member)
+			}
+		}
+		if resp.NextPage == 0 {
+			break
+		}
+		listOpts.Page = resp.NextPage
+	}
+
+	teamMembersCache[key] = members
+	return members, nil
+}
+
+func appendUniqueApprover(approvers []string, approver string) []string {
+	if approversIndex(approvers, approver) >= 0 {
+		return approvers
+	}
+	return append(approvers, approver)
+}
@@ -0,0 +1,184 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"reflect"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/google/go-github/v43/github"
+)
+
+func TestParseApproversFile(t *testing.T) {
+	content := `# Production approvers
+login1
+  @login2
+
+my-org/deploy-approvers
+# login3
+`
+
+	expected := []string{"login1", "login2", "my-org/deploy-approvers"}
+	actual := parseApproversFile(content)
+	if !reflect.DeepEqual(actual, expected) {
+		t.Fatalf("expected %v but got %v", expected, actual)
+	}
+}
+
+func TestParseApproversJSON(t *testing.T) {
+	testCases := []struct {
+		name      string
+		data      string
+		expected  []string
+		expectErr bool
+	}{
+		{
+			name:     "array",
+			data:     `["login1", "@login2", "my-org/sre"]`,
+			expected: []string{"login1", "login2", "my-org/sre"},
+		},
+		{
+			name:     "object",
+			data:     `{"approvers": ["login1", "login2"]}`,
+			expected: []string{"login1", "login2"},
+		},
+		{
+			name:      "invalid",
+			data:      `"login1"`,
+			expectErr: true,
+		},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			actual, err := parseApproversJSON([]byte(testCase.data))
+			if testCase.expectErr {
+				if err == nil {
+					t.Fatalf("expected an error but got %v", actual)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if !reflect.DeepEqual(actual, testCase.expected) {
+				t.Fatalf("expected %v but got %v", testCase.expected, actual)
+			}
+		})
+	}
+}
+
+// teamsClient returns a client backed by a server that lists the members and
+// child teams of the teams of my-org, pages of per_page at a time.
+func teamsClient(t *testing.T, members, children map[string][]string) *github.Client {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		parts := strings.Split(strings.Trim(r.URL.Path, "/"), "/")
+		if len(parts) != 5 || parts[0] != "orgs" || parts[1] != "my-org" || parts[2] != "teams" {
+			http.NotFound(w, r)
+			return
+		}
+		var logins []string
+		switch parts[4] {
+		case "members":
+			logins = members[parts[3]]
+		case "teams":
+			logins = children[parts[3]]
+		default:
+			http.NotFound(w, r)
+			return
+		}
+
+		perPage, _ := strconv.Atoi(r.URL.Query().Get("per_page"))
+		page, _ := strconv.Atoi(r.URL.Query().Get("page"))
+		if page == 0 {
+			page = 1
+		}
+		start := (page - 1) * perPage
+		end := start + perPage
+		if start > len(logins) {
+			start = len(logins)
+		}
+		if end >= len(logins) {
+			end = len(logins)
+		} else {
+			w.Header().Set("Link", fmt.Sprintf(`<%s%s?per_page=%d&page=%d>; rel="next"`, "http://"+r.Host, r.URL.Path, perPage, page+1))
+		}
+
+		var body []map[string]string
+		for _, login := range logins[start:end] {
+			if parts[4] == "members" {
+				body = append(body, map[string]string{"login": login})
+			} else {
+				body = append(body, map[string]string{"slug": login})
+			}
+		}
+		if err := json.NewEncoder(w).Encode(body); err != nil {
+			t.Errorf("unexpected error: %v", err)
+		}
+	}))
+	t.Cleanup(server.Close)
+
+	client := github.NewClient(nil)
+	client.BaseURL, _ = url.Parse(server.URL + "/")
+	return client
+}
+
+func TestTeamMembers(t *testing.T) {
+	var manyMembers []string
+	for i := 1; i <= 150; i++ {
+		manyMembers = append(manyMembers, fmt.Sprintf("member%d", i))
+	}
+
+	testCases := []struct {
+		name     string
+		members  map[string][]string
+		children map[string][]string
+		expected []string
+	}{
+		{
+			name:     "members",
+			members:  map[string][]string{"deployers": {"login1", "login2"}},
+			expected: []string{"login1", "login2"},
+		},
+		{
+			name:     "child_team",
+			members:  map[string][]string{"deployers": {"login1", "login2"}, "sre": {"login2", "login3"}},
+			children: map[string][]string{"deployers": {"sre"}},
+			expected: []string{"login1", "login2", "login3"},
+		},
+		{
+			name:     "team_cycle",
+			members:  map[string][]string{"deployers": {"login1"}, "sre": {"login2"}},
+			children: map[string][]string{"deployers": {"sre"}, "sre": {"deployers"}},
+			expected: []string{"login1", "login2"},
+		},
+		{
+			name:     "more_members_than_a_page",
+			members:  map[string][]string{"deployers": manyMembers},
+			expected: manyMembers,
+		},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			cache := teamMembersCache
+			teamMembersCache = map[string][]string{}
+			defer func() { teamMembersCache = cache }()
+
+			client := teamsClient(t, testCase.members, testCase.children)
+			actual, err := teamMembers(context.Background(), client, "my-org", "deployers", map[string]bool{})
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if !reflect.DeepEqual(actual, testCase.expected) {
+				t.Fatalf("expected %v but got %v", testCase.expected, actual)
+			}
+		})
+	}
+}
@@ -0,0 +1,414 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"encoding/pem"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"time"
+)
+
+const (
+	// attestationPredicateType identifies the approval predicate of the
+	// in-toto statement.
+	attestationPredicateType string = "https://github.com/trstringer/manual-approval/approval/v1"
+	// attestationPayloadType is the DSSE payload type of in-toto statements.
+	attestationPayloadType string = "application/vnd.in-toto+json"
+	// attestationBundleMediaType is the media type of the Sigstore bundle
+	// written to attestation-file.
+	attestationBundleMediaType string = "application/vnd.dev.sigstore.bundle+json;version=0.2"
+
+	defaultFulcioURL string = "https://fulcio.sigstore.dev"
+	defaultRekorURL  string = "https://rekor.sigstore.dev"
+
+	// attestationTimeout bounds each request made to sign and publish the
+	// attestation.
+	attestationTimeout time.Duration = 30 * time.Second
+)
+
+// attestationSubject is the commit the approval was given for.
+type attestationSubject struct {
+	Name   string            `json:"name"`
+	Digest map[string]string `json:"digest"`
+}
+
+// attestationDecision is a decision made by an approver.
+type attestationDecision struct {
+	Approver string     `json:"approver"`
+	Decision string     `json:"decision"`
+	At       *time.Time `json:"at,omitempty"`
+}
+
+// approvalPredicate is who approved what and when.
+type approvalPredicate struct {
+	Repository       string                `json:"repository"`
+	Gate             string                `json:"gate,omitempty"`
+	Environment      string                `json:"environment,omitempty"`
+	Decision         string                `json:"decision"`
+	ApprovedBy       []string              `json:"approvedBy"`
+	DeniedBy         []string              `json:"deniedBy"`
+	MinimumApprovals int                   `json:"minimumApprovals"`
+	Decisions        []attestationDecision `json:"decisions"`
+	RequestedAt      *time.Time            `json:"requestedAt,omitempty"`
+	ResolvedAt       time.Time             `json:"resolvedAt"`
+	IssueURL         string                `json:"issueUrl,omitempty"`
+	RunURL           string                `json:"runUrl"`
+	RunID            int                   `json:"runId"`
+	RunAttempt       int                   `json:"runAttempt"`
+}
+
+// attestationStatement is an in-toto statement whose subject is the head
+// commit and whose predicate is the decision.
+type attestationStatement struct {
+	Type          string               `json:"_type"`
+	Subject       []attestationSubject `json:"subject"`
+	PredicateType string               `json:"predicateType"`
+	Predicate     approvalPredicate    `json:"predicate"`
+}
+
+// attestationStatement builds the statement of the resolved gate from its
+// last poll.
+func (a *approvalEnvironment) attestationStatement() attestationStatement {
+	record := a.auditRecord()
+	predicate := approvalPredicate{
+		Repository:       record.Repository,
+		Gate:             record.Gate,
+		Environment:      record.Environment,
+		Decision:         record.Decision,
+		ApprovedBy:       record.ApprovedBy,
+		DeniedBy:         record.DeniedBy,
+		MinimumApprovals: a.minimumApprovals,
+		Decisions:        []attestationDecision{},
+		RequestedAt:      record.RequestedAt,
+		ResolvedAt:       record.ResolvedAt,
+		IssueURL:         record.IssueURL,
+		RunURL:           a.runURL(),
+		RunID:            record.RunID,
+		RunAttempt:       record.RunAttempt,
+	}
+	for _, comment := range record.Comments {
+		switch comment.Classification {
+		case "approval", "denial", "revocation":
+			predicate.Decisions = append(predicate.Decisions, attestationDecision{
+				Approver: comment.Author,
+				Decision: comment.Classification,
+				At:       comment.CreatedAt,
+			})
+		}
+	}
+	return attestationStatement{
+		Type: "https://in-toto.io/Statement/v1",
+		Subject: []attestationSubject{{
+			Name:   record.Repository,
+			Digest: map[string]string{"gitCommit": record.SHA},
+		}},
+		PredicateType: attestationPredicateType,
+		Predicate:     predicate,
+	}
+}
+
+// dssePAE is the DSSE pre-authentication encoding of the payload, which is
+// what gets signed.
+func dssePAE(payloadType string, payload []byte) []byte {
+	return []byte(fmt.Sprintf("DSSEv1 %d %s %d %s", len(payloadType), payloadType, len(payload), payload))
+}
+
+type dsseSignature struct {
+	Sig string `json:"sig"`
+}
+
+type dsseEnvelope struct {
+	PayloadType string          `json:"payloadType"`
+	Payload     string          `json:"payload"`
+	Signatures  []dsseSignature `json:"signatures"`
+}
+
+// signStatement signs the statement with the key as a DSSE envelope.
+func signStatement(statement attestationStatement, key *ecdsa.PrivateKey) (dsseEnvelope, error) {
+	payload, err := json.Marshal(statement)
+	if err != nil {
+		return dsseEnvelope{}, err
+	}
+	digest := sha256.Sum256(dssePAE(attestationPayloadType, payload))
+	signature, err := ecdsa.SignASN1(rand.Reader, key, digest[:])
+	if err != nil {
+		return dsseEnvelope{}, err
+	}
+	return dsseEnvelope{
+		PayloadType: attestationPayloadType,
+		Payload:     base64.StdEncoding.EncodeToString(payload),
+		Signatures:  []dsseSignature{{Sig: base64.StdEncoding.EncodeToString(signature)}},
+	}, nil
+}
+
+// attestationRequest posts a JSON body and decodes the JSON response.
+func attestationRequest(ctx context.Context, method, endpoint, bearer string, body, result interface{}) error {
+	ctx, cancel := context.WithTimeout(ctx, attestationTimeout)
+	defer cancel()
+	var reader io.Reader
+	if body != nil {
+		content, err := json.Marshal(body)
+		if err != nil {
+			return err
+		}
+		reader = bytes.NewReader(content)
+	}
+	req, err := http.NewRequestWithContext(ctx, method, endpoint, reader)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Accept", "application/json")
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	if bearer != "" {
+		req.Header.Set("Authorization", "Bearer "+bearer)
+	}
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	content, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return err
+	}
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return fmt.Errorf("unexpected status %s from %s: %s", resp.Status, endpoint, strings.TrimSpace(string(content)))
+	}
+	return json.Unmarshal(content, result)
+}
+
+// requestOIDCToken requests the runner's OIDC identity token for Sigstore,
+// which needs the id-token: write permission.
+func requestOIDCToken(ctx context.Context, requestURL, requestToken string) (string, error) {
+	if requestURL == "" || requestToken == "" {
+		return "", fmt.Errorf("no OIDC token available, the job needs the id-token: write permission")
+	}
+	tokenURL, err := url.Parse(requestURL)
+	if err != nil {
+		return "", err
+	}
+	query := tokenURL.Query()
+	query.Set("audience", "sigstore")
+	tokenURL.RawQuery = query.Encode()
+
+	var response struct {
+		Value string `json:"value"`
+	}
+	if err := attestationRequest(ctx, http.MethodGet, tokenURL.String(), requestToken, nil, &response); err != nil {
+		return "", err
+	}
+	return response.Value, nil
+}
+
+// oidcSubject is the subject claim of the identity token, which Fulcio
+// requires to be signed as proof of possession of the key.
+func oidcSubject(token string) (string, error) {
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 {
+		return "", fmt.Errorf("malformed OIDC token")
+	}
+	claims, err := base64.RawURLEncoding.DecodeString(parts[1])
+	if err != nil {
+		return "", err
+	}
+	var decoded struct {
+		Subject string `json:"sub"`
+	}
+	if err := json.Unmarshal(claims, &decoded); err != nil {
+		return "", err
+	}
+	if decoded.Subject == "" {
+		return "", fmt.Errorf("OIDC token has no subject")
+	}
+	return decoded.Subject, nil
+}
+
+// requestSigningCertificate requests a short-lived certificate for the key
+// from Fulcio, bound to the runner's identity. It returns the PEM
+// certificate chain, leaf first.
+func requestSigningCertificate(ctx context.Context, fulcioURL, token string, key *ecdsa.PrivateKey) ([]string, error) {
+	subject, err := oidcSubject(token)
+	if err != nil {
+		return nil, err
+	}
+	digest := sha256.Sum256([]byte(subject))
+	proof, err := ecdsa.SignASN1(rand.Reader, key, digest[:])
+	if err != nil {
+		return nil, err
+	}
+	publicKey, err := x509.MarshalPKIXPublicKey(&key.PublicKey)
+	if err != nil {
+		return nil, err
+	}
+
+	request := map[string]interface{}{
+		"credentials": map[string]string{"oidcIdentityToken": token},
+		"publicKeyRequest": map[string]interface{}{
+			"publicKey": map[string]string{
+				"algorithm": "ECDSA",
+				"content":   string(pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: publicKey})),
+			},
+			"proofOfPossession": base64.StdEncoding.EncodeToString(proof),
+		},
+	}
+	type chain struct {
+		Chain struct {
+			Certificates []string `json:"certificates"`
+		} `json:"chain"`
+	}
+	var response struct {
+		Embedded *chain `json:"signedCertificateEmbeddedSct"`
+		Detached *chain `json:"signedCertificateDetachedSct"`
+	}
+	if err := attestationRequest(ctx, http.MethodPost, strings.TrimSuffix(fulcioURL, "/")+"/api/v2/signingCert", "", request, &response); err != nil {
+		return nil, err
+	}
+	for _, result := range []*chain{response.Embedded, response.Detached} {
+		if result != nil && len(result.Chain.Certificates) > 0 {
+			return result.Chain.Certificates, nil
+		}
+	}
+	return nil, fmt.Errorf("no certificate in the response from Fulcio")
+}
+
+// rekorEntry is the transparency log entry of the attestation.
+type rekorEntry struct {
+	Body           string `json:"body"`
+	IntegratedTime int64  `json:"integratedTime"`
+	LogID          string `json:"logID"`
+	LogIndex       int64  `json:"logIndex"`
+	Verification   struct {
+		SignedEntryTimestamp string `json:"signedEntryTimestamp"`
+	} `json:"verification"`
+}
+
+// publishToRekor adds the signed envelope to the Rekor transparency log.
+func publishToRekor(ctx context.Context, rekorURL string, envelope dsseEnvelope, certificate string) (rekorEntry, error) {
+	content, err := json.Marshal(envelope)
+	if err != nil {
+		return rekorEntry{}, err
+	}
+	request := map[string]interface{}{
+		"apiVersion": "0.0.1",
+		"kind":       "dsse",
+		"spec": map[string]interface{}{
+			"proposedContent": map[string]interface{}{
+				"envelope":  string(content),
+				"verifiers": []string{base64.StdEncoding.EncodeToString([]byte(certificate))},
+			},
+		},
+	}
+	var response map[string]rekorEntry
+	if err := attestationRequest(ctx, http.MethodPost, strings.TrimSuffix(rekorURL, "/")+"/api/v1/log/entries", "", request, &response); err != nil {
+		return rekorEntry{}, err
+	}
+	for _, entry := range response {
+		return entry, nil
+	}
+	return rekorEntry{}, fmt.Errorf("no entry in the response from Rekor")
+}
+
+// attestationBundle is a Sigstore bundle of the signed attestation, which
+// can be verified with cosign verify-blob-attestation or
+// gh attestation verify.
+type attestationBundle struct {
+	MediaType            string                 `json:"mediaType"`
+	VerificationMaterial map[string]interface{} `json:"verificationMaterial"`
+	DSSEEnvelope         dsseEnvelope           `json:"dsseEnvelope"`
+}
+
+func newAttestationBundle(envelope dsseEnvelope, certificates []string, entry *rekorEntry) attestationBundle {
+	var chain []map[string]string
+	for _, certificate := range certificates {
+		block, _ := pem.Decode([]byte(certificate))
+		if block == nil {
+			continue
+		}
+		chain = append(chain, map[string]string{"rawBytes": base64.StdEncoding.EncodeToString(block.Bytes)})
+	}
+	material := map[string]interface{}{
+		"x509CertificateChain": map[string]interface{}{"certificates": chain},
+		"tlogEntries":          []interface{}{},
+	}
+	if entry != nil {
+		logID, _ := hexToBase64(entry.LogID)
+		material["tlogEntries"] = []interface{}{map[string]interface{}{
+			"logIndex":          fmt.Sprint(entry.LogIndex),
+			"logId":             map[string]string{"keyId": logID},
+			"kindVersion":       map[string]string{"kind": "dsse", "version": "0.0.1"},
+			"integratedTime":    fmt.Sprint(entry.IntegratedTime),
+			"inclusionPromise":  map[string]string{"signedEntryTimestamp": entry.Verification.SignedEntryTimestamp},
+			"canonicalizedBody": entry.Body,
+		}}
+	}
+	return attestationBundle{
+		MediaType:            attestationBundleMediaType,
+		VerificationMaterial: material,
+		DSSEEnvelope:         envelope,
+	}
+}
+
+func hexToBase64(value string) (string, error) {
+	decoded, err := hex.DecodeString(value)
+	if err != nil {
+		return "", err
+	}
+	return base64.StdEncoding.EncodeToString(decoded), nil
+}
+
+// attest signs an attestation of the decision keylessly with the runner's
+// OIDC identity, publishes it to Rekor if enabled, and writes the bundle to
+// attestation-file. It returns the log index of the Rekor entry, or -1 if it
+// wasn't published.
+func (a *approvalEnvironment) attest(ctx context.Context) (int64, error) {
+	token, err := requestOIDCToken(ctx, os.Getenv(envVarOIDCRequestURL), os.Getenv(envVarOIDCRequestToken))
+	if err != nil {
+		return -1, fmt.Errorf("error requesting OIDC token: %w", err)
+	}
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		return -1, err
+	}
+	certificates, err := requestSigningCertificate(ctx, a.fulcioURL, token, key)
+	if err != nil {
+		return -1, fmt.Errorf("error requesting signing certificate: %w", err)
+	}
+	envelope, err := signStatement(a.attestationStatement(), key)
+	if err != nil {
+		return -1, fmt.Errorf("error signing attestation: %w", err)
+	}
+
+	logIndex := int64(-1)
+	var entry *rekorEntry
+	if a.attestationPublish {
+		published, err := publishToRekor(ctx, a.rekorURL, envelope, certificates[0])
+		if err != nil {
+			return -1, fmt.Errorf("error publishing attestation to Rekor: %w", err)
+		}
+		entry, logIndex = &published, published.LogIndex
+	}
+
+	content, err := json.MarshalIndent(newAttestationBundle(envelope, certificates, entry), "", "  ")
+	if err != nil {
+		return -1, err
+	}
+	if err := os.WriteFile(a.attestationFile, append(content, '\n'), 0o644); err != nil {
+		return -1, err
+	}
+	return logIndex, nil
+}
@@ -0,0 +1,184 @@
+package main
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/google/go-github/v43/github"
+)
+
+func TestDSSEPAE(t *testing.T) {
+	actual := string(dssePAE("http://example.com/HelloWorld", []byte("hello world")))
+	expected := "DSSEv1 29 http://example.com/HelloWorld 11 hello world"
+	if actual != expected {
+		t.Fatalf("expected %q but got %q", expected, actual)
+	}
+}
+
+func TestOIDCSubject(t *testing.T) {
+	claims := base64.RawURLEncoding.EncodeToString([]byte(`{"sub":"repo:owner/repo:ref:refs/heads/main"}`))
+	testCases := []struct {
+		name        string
+		token       string
+		expected    string
+		expectError bool
+	}{
+		{
+			name:     "valid",
+			token:    "header." + claims + ".signature",
+			expected: "repo:owner/repo:ref:refs/heads/main",
+		},
+		{
+			name:        "malformed",
+			token:       "not-a-token",
+			expectError: true,
+		},
+		{
+			name:        "no_subject",
+			token:       "header." + base64.RawURLEncoding.EncodeToString([]byte(`{}`)) + ".signature",
+			expectError: true,
+		},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			actual, err := oidcSubject(testCase.token)
+			if testCase.expectError {
+				if err == nil {
+					t.Fatalf("expected error but got subject %q", actual)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if actual != testCase.expected {
+				t.Fatalf("expected %q but got %q", testCase.expected, actual)
+			}
+		})
+	}
+}
+
+func TestAttest(t *testing.T) {
+	claims := base64.RawURLEncoding.EncodeToString([]byte(`{"sub":"repo:owner/repo"}`))
+	var signedPayload []byte
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/token":
+			if r.URL.Query().Get("audience") != "sigstore" || r.Header.Get("Authorization") != "Bearer request-token" {
+				w.WriteHeader(http.StatusUnauthorized)
+				return
+			}
+			_, _ = w.Write([]byte(`{"value":"header.` + claims + `.signature"}`))
+		case "/api/v2/signingCert":
+			_, _ = w.Write([]byte(`{"signedCertificateEmbeddedSct":{"chain":{"certificates":["-----BEGIN CERTIFICATE-----\nAAAA\n-----END CERTIFICATE-----\n"]}}}`))
+		case "/api/v1/log/entries":
+			var request struct {
+				Spec struct {
+					ProposedContent struct {
+						Envelope string `json:"envelope"`
+					} `json:"proposedContent"`
+				} `json:"spec"`
+			}
+			if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+				w.WriteHeader(http.StatusBadRequest)
+				return
+			}
+			signedPayload = []byte(request.Spec.ProposedContent.Envelope)
+			w.WriteHeader(http.StatusCreated)
+			_, _ = w.Write([]byte(`{"uuid":{"body":"Ym9keQ==","integratedTime":1646128800,"logID":"c0ffee","logIndex":42,"verification":{"signedEntryTimestamp":"c2V0"}}}`))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+
+	os.Setenv(envVarOIDCRequestURL, server.URL+"/token?api-version=2.0")
+	os.Setenv(envVarOIDCRequestToken, "request-token")
+	defer os.Unsetenv(envVarOIDCRequestURL)
+	defer os.Unsetenv(envVarOIDCRequestToken)
+
+	createdAt := time.Date(2022, 3, 1, 10, 0, 0, 0, time.UTC)
+	a := approvalEnvironment{
+		repoFullName:       "owner/repo",
+		sha:                "0123456789abcdef",
+		gateName:           "production",
+		minimumApprovals:   1,
+		approvers:          []string{"login1"},
+		attestationFile:    filepath.Join(t.TempDir(), "attestation.json"),
+		attestationPublish: true,
+		fulcioURL:          server.URL,
+		rekorURL:           server.URL,
+		metrics: gateMetrics{
+			status:     approvalStatusApproved,
+			approvedBy: []string{"login1"},
+			comments: []*github.IssueComment{
+				{User: &github.User{Login: github.String("login1")}, Body: github.String("approve"), CreatedAt: &createdAt},
+				{User: &github.User{Login: github.String("login2")}, Body: github.String("approve"), CreatedAt: &createdAt},
+			},
+		},
+	}
+	logIndex, err := a.attest(context.Background())
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if logIndex != 42 {
+		t.Fatalf("expected log index 42 but got %d", logIndex)
+	}
+
+	content, err := os.ReadFile(a.attestationFile)
+	if err != nil {
+		t.Fatalf("error reading attestation: %v", err)
+	}
+	var bundle attestationBundle
+	if err := json.Unmarshal(content, &bundle); err != nil {
+		t.Fatalf("error parsing attestation: %v", err)
+	}
+	if bundle.MediaType != attestationBundleMediaType {
+		t.Fatalf("expected media type %q but got %q", attestationBundleMediaType, bundle.MediaType)
+	}
+	var published dsseEnvelope
+	if err := json.Unmarshal(signedPayload, &published); err != nil || published.Payload != bundle.DSSEEnvelope.Payload {
+		t.Fatalf("expected the published envelope to match the bundle")
+	}
+
+	payload, _ := base64.StdEncoding.DecodeString(bundle.DSSEEnvelope.Payload)
+	var statement attestationStatement
+	if err := json.Unmarshal(payload, &statement); err != nil {
+		t.Fatalf("error parsing statement: %v", err)
+	}
+	if statement.Subject[0].Digest["gitCommit"] != a.sha {
+		t.Fatalf("expected subject %s but got %v", a.sha, statement.Subject[0].Digest)
+	}
+	if statement.Predicate.Decision != "approved" || len(statement.Predicate.Decisions) != 1 || statement.Predicate.Decisions[0].Approver != "login1" {
+		t.Fatalf("expected a single approval by login1 but got %+v", statement.Predicate)
+	}
+}
+
+func TestSignStatement(t *testing.T) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("error generating key: %v", err)
+	}
+	envelope, err := signStatement(attestationStatement{Type: "https://in-toto.io/Statement/v1"}, key)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	payload, _ := base64.StdEncoding.DecodeString(envelope.Payload)
+	signature, _ := base64.StdEncoding.DecodeString(envelope.Signatures[0].Sig)
+	digest := sha256.Sum256(dssePAE(envelope.PayloadType, payload))
+	if !ecdsa.VerifyASN1(&key.PublicKey, digest[:], signature) {
+		t.Fatalf("expected signature to verify")
+	}
+}
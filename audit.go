@@ -0,0 +1,137 @@
+package main
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// auditComment is a comment considered by the gate and how it was read.
+type auditComment struct {
+	ID             int64      `json:"id,omitempty"`
+	Author         string     `json:"author"`
+	CreatedAt      *time.Time `json:"created_at,omitempty"`
+	UpdatedAt      *time.Time `json:"updated_at,omitempty"`
+	Body           string     `json:"body"`
+	Classification string     `json:"classification"`
+}
+
+// auditPolicy are the inputs that decided who could approve and how many
+// approvals were needed.
+type auditPolicy struct {
+	Approvers               []string `json:"approvers"`
+	MinimumApprovals        int      `json:"minimum_approvals"`
+	MultipleDeploymentNames []string `json:"multiple_deployment_names,omitempty"`
+	VetoUsers               []string `json:"veto_users,omitempty"`
+	BotApprovers            []string `json:"bot_approvers,omitempty"`
+	WriteAccessApprovers    bool     `json:"write_access_approvers"`
+	MaxWait                 string   `json:"max_wait,omitempty"`
+	MaxPolls                int      `json:"max_polls,omitempty"`
+}
+
+// auditRecord is the transcript of a gate written to audit-file.
+type auditRecord struct {
+	Version     string         `json:"version"`
+	Repository  string         `json:"repository"`
+	RunID       int            `json:"run_id"`
+	RunAttempt  int            `json:"run_attempt"`
+	Gate        string         `json:"gate,omitempty"`
+	Environment string         `json:"environment,omitempty"`
+	SHA         string         `json:"sha,omitempty"`
+	IssueNumber int            `json:"issue_number,omitempty"`
+	IssueURL    string         `json:"issue_url,omitempty"`
+	RequestedAt *time.Time     `json:"requested_at,omitempty"`
+	ResolvedAt  time.Time      `json:"resolved_at"`
+	Decision    string         `json:"decision"`
+	ApprovedBy  []string       `json:"approved_by"`
+	DeniedBy    []string       `json:"denied_by"`
+	Polls       int            `json:"polls"`
+	Policy      auditPolicy    `json:"policy"`
+	Comments    []auditComment `json:"comments"`
+}
+
+// auditRecord builds the transcript of the gate from the comments of its
+// last poll.
+func (a *approvalEnvironment) auditRecord() auditRecord {
+	record := auditRecord{
+		Version:     version,
+		Repository:  a.repoFullName,
+		RunID:       a.runID,
+		RunAttempt:  a.runAttempt,
+		Gate:        a.gateName,
+		Environment: a.environment,
+		SHA:         a.sha,
+		IssueNumber: a.approvalIssueNumber,
+		IssueURL:    a.approvalIssue.GetHTMLURL(),
+		ResolvedAt:  a.metrics.resolvedAt.UTC(),
+		Decision:    strings.ToLower(string(a.metrics.status)),
+		ApprovedBy:  append([]string{}, a.metrics.approvedBy...),
+		DeniedBy:    append([]string{}, a.metrics.deniedBy...),
+		Polls:       a.metrics.polls,
+		Policy: auditPolicy{
+			Approvers:               a.metrics.approvers,
+			MinimumApprovals:        a.minimumApprovals,
+			MultipleDeploymentNames: a.mutlipleDeploymentNames,
+			VetoUsers:               a.vetoUsers,
+			BotApprovers:            a.botApprovers,
+			WriteAccessApprovers:    a.writeAccess,
+			MaxPolls:                a.maxPolls,
+		},
+		Comments: []auditComment{},
+	}
+	if record.Policy.Approvers == nil {
+		record.Policy.Approvers = a.approvers
+	}
+	if a.maxWait > 0 {
+		record.Policy.MaxWait = a.maxWait.String()
+	}
+	if requestedAt := a.metrics.requestedAt; !requestedAt.IsZero() {
+		requestedAt = requestedAt.UTC()
+		record.RequestedAt = &requestedAt
+	}
+	for _, comment := range a.metrics.comments {
+		record.Comments = append(record.Comments, auditComment{
+			ID:             comment.GetID(),
+			Author:         comment.User.GetLogin(),
+			CreatedAt:      comment.CreatedAt,
+			UpdatedAt:      comment.UpdatedAt,
+			Body:           comment.GetBody(),
+			Classification: describeComment(comment, record.Policy.Approvers, a.mutlipleDeploymentNames),
+		})
+	}
+	return record
+}
+
+// writeAuditFile writes the record to path and returns the SHA-256 digest of
+// what was written, so that the file can be checked for changes later.
+// Existing files are not overwritten, as each record should be kept as it
+// was written.
+func writeAuditFile(path string, record auditRecord) (string, error) {
+	content, err := json.MarshalIndent(record, "", "  ")
+	if err != nil {
+		return "", err
+	}
+	content = append(content, '\n')
+	if dir := filepath.Dir(path); dir != "" {
+		if err := os.MkdirAll(dir, 0o755); err != nil {
+			return "", err
+		}
+	}
+	file, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0o444)
+	if err != nil {
+		return "", err
+	}
+	if _, err := file.Write(content); err != nil {
+		file.Close()
+		return "", err
+	}
+	if err := file.Close(); err != nil {
+		return "", err
+	}
+	digest := sha256.Sum256(content)
+	return hex.EncodeToString(digest[:]), nil
+}
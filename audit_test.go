@@ -0,0 +1,84 @@
+package main
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/google/go-github/v43/github"
+)
+
+func TestAuditRecord(t *testing.T) {
+	createdAt := time.Date(2022, 1, 1, 0, 0, 0, 0, time.UTC)
+	comment := func(id int64, login, body string) *github.IssueComment {
+		return &github.IssueComment{ID: github.Int64(id), User: &github.User{Login: github.String(login)}, Body: github.String(body), CreatedAt: &createdAt}
+	}
+	apprv := &approvalEnvironment{
+		repoFullName:        "owner/repo",
+		runID:               1234,
+		runAttempt:          2,
+		gateName:            "prod",
+		approvalIssueNumber: 5,
+		minimumApprovals:    1,
+		metrics: gateMetrics{
+			status:      approvalStatusApproved,
+			requestedAt: createdAt,
+			resolvedAt:  createdAt.Add(time.Minute),
+			approvedBy:  []string{"user1"},
+			polls:       4,
+			approvers:   []string{"user1", "user2"},
+			comments: []*github.IssueComment{
+				comment(1, "user3", "approved"),
+				comment(2, "user2", "looks fine"),
+				comment(3, "user1", "approved"),
+			},
+		},
+	}
+
+	record := apprv.auditRecord()
+	if record.Decision != "approved" || record.RunAttempt != 2 || record.Polls != 4 || record.Policy.MinimumApprovals != 1 {
+		t.Fatalf("unexpected record %+v", record)
+	}
+	expected := []string{"ignored, user3 is not an approver", "ignored, no decision word matched", "approval"}
+	if len(record.Comments) != len(expected) {
+		t.Fatalf("expected %d comments but got %d", len(expected), len(record.Comments))
+	}
+	for i, classification := range expected {
+		if record.Comments[i].Classification != classification {
+			t.Fatalf("comment %d: expected %q but got %q", i, classification, record.Comments[i].Classification)
+		}
+	}
+}
+
+func TestWriteAuditFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "audit", "approval.json")
+	record := auditRecord{Repository: "owner/repo", Decision: "denied", DeniedBy: []string{"user1"}}
+
+	digest, err := writeAuditFile(path, record)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	content, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	sum := sha256.Sum256(content)
+	if digest != hex.EncodeToString(sum[:]) {
+		t.Fatalf("expected the digest of the file but got %s", digest)
+	}
+	var written auditRecord
+	if err := json.Unmarshal(content, &written); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if written.Decision != "denied" || written.DeniedBy[0] != "user1" {
+		t.Fatalf("unexpected record %+v", written)
+	}
+
+	if _, err := writeAuditFile(path, record); err == nil {
+		t.Fatal("expected an existing audit file not to be overwritten")
+	}
+}
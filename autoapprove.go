@@ -0,0 +1,58 @@
+package main
+
+import (
+	"context"
+
+	"github.com/google/go-github/v43/github"
+)
+
+// autoApprovalFromLabel checks whether the pull request carries the given
+// label and returns the login of the user who applied it. The label only
+// counts if its most recent applier has maintain or admin access; otherwise
+// an empty login is returned.
+func (a *approvalEnvironment) autoApprovalFromLabel(ctx context.Context, pullRequestNumber int, label string) (string, error) {
+	labels, _, err := a.client.Issues.ListLabelsByIssue(ctx, a.repoOwner, a.repo, pullRequestNumber, &github.ListOptions{PerPage: 100})
+	if err != nil {
+		return "", err
+	}
+	hasLabel := false
+	for _, l := range labels {
+		if l.GetName() == label {
+			hasLabel = true
+			break
+		}
+	}
+	if !hasLabel {
+		return "", nil
+	}
+
+	var labeler string
+	opts := &github.ListOptions{PerPage: 100}
+	for {
+		events, resp, err := a.client.Issues.ListIssueEvents(ctx, a.repoOwner, a.repo, pullRequestNumber, opts)
+		if err != nil {
+			return "", err
+		}
+		for _, event := range events {
+			if event.GetEvent() == "labeled" && event.GetLabel().GetName() == label {
+				labeler = event.GetActor().GetLogin()
+			}
+		}
+		if resp.NextPage == 0 {
+			break
+		}
+		opts.Page = resp.NextPage
+	}
+	if labeler == "" {
+		return "", nil
+	}
+
+	allowed, err := a.hasMaintainAccess(ctx, labeler)
+	if err != nil {
+		return "", err
+	}
+	if !allowed {
+		return "", nil
+	}
+	return labeler, nil
+}
@@ -0,0 +1,61 @@
+package main
+
+import (
+	"context"
+	"testing"
+
+	"github.com/google/go-github/v43/github"
+)
+
+func TestAutoApprovalFromLabel(t *testing.T) {
+	labeled := func(login, label string) *github.IssueEvent {
+		return &github.IssueEvent{
+			Event: github.String("labeled"),
+			Actor: &github.User{Login: github.String(login)},
+			Label: &github.Label{Name: github.String(label)},
+		}
+	}
+	permissions := map[string]collaboratorPermission{
+		"maintainer": {Permission: "write", RoleName: "maintain"},
+		"admin":      {Permission: "admin", RoleName: "admin"},
+		"developer":  {Permission: "write", RoleName: "write"},
+		"releaser":   {Permission: "admin", RoleName: "release-admin"},
+	}
+	testCases := []struct {
+		name     string
+		labels   []string
+		events   []*github.IssueEvent
+		expected string
+	}{
+		{name: "label_by_maintainer", labels: []string{"auto-approve"}, events: []*github.IssueEvent{labeled("maintainer", "auto-approve")}, expected: "maintainer"},
+		{name: "label_by_admin", labels: []string{"auto-approve"}, events: []*github.IssueEvent{labeled("admin", "auto-approve")}, expected: "admin"},
+		{name: "label_by_custom_admin_role", labels: []string{"auto-approve"}, events: []*github.IssueEvent{labeled("releaser", "auto-approve")}, expected: "releaser"},
+		{name: "label_by_developer", labels: []string{"auto-approve"}, events: []*github.IssueEvent{labeled("developer", "auto-approve")}, expected: ""},
+		{name: "relabeled_by_developer", labels: []string{"auto-approve"}, events: []*github.IssueEvent{labeled("maintainer", "auto-approve"), labeled("developer", "auto-approve")}, expected: ""},
+		{name: "other_label", labels: []string{"bug"}, events: []*github.IssueEvent{labeled("maintainer", "bug")}, expected: ""},
+		{name: "no_labels", expected: ""},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			fake := newFakeGitHub()
+			fake.permissions = permissions
+			fake.events[7] = testCase.events
+			for _, label := range testCase.labels {
+				fake.labels[7] = append(fake.labels[7], &github.Label{Name: github.String(label)})
+			}
+			apprv, err := newApprovalEnvironment(fake.client(), "owner/repo", "owner", 1, nil, 0, nil)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			labeler, err := apprv.autoApprovalFromLabel(context.Background(), 7, "auto-approve")
+			if err != nil {
+				t.Fatalf("error checking the label: %v", err)
+			}
+			if labeler != testCase.expected {
+				t.Fatalf("expected labeler %q but got %q", testCase.expected, labeler)
+			}
+		})
+	}
+}
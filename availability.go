@@ -0,0 +1,222 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/google/go-github/v43/github"
+	"gopkg.in/yaml.v3"
+)
+
+// availabilityDateLayout is the layout of the dates in availability files.
+const availabilityDateLayout = "2006-01-02"
+
+// dateRange is a span of days, from the start of start to the start of end.
+type dateRange struct {
+	start, end time.Time
+}
+
+func (r dateRange) contains(t time.Time) bool {
+	return !t.Before(r.start) && t.Before(r.end)
+}
+
+// availability lists when approvers are unavailable, by lowercase login.
+type availability map[string][]dateRange
+
+func (a availability) add(login string, r dateRange) {
+	key := strings.ToLower(strings.TrimPrefix(login, "@"))
+	a[key] = append(a[key], r)
+}
+
+// unavailable returns the approvers who are unavailable at now.
+func (a availability) unavailable(approvers []string, now time.Time) []string {
+	var unavailable []string
+	for _, approver := range approvers {
+		for _, r := range a[strings.ToLower(approver)] {
+			if r.contains(now) {
+				unavailable = append(unavailable, approver)
+				break
+			}
+		}
+	}
+	return unavailable
+}
+
+// parseDateRange reads a date such as 2026-12-24 or an inclusive range of
+// dates such as 2026-08-01..2026-08-21.
+func parseDateRange(value string) (dateRange, error) {
+	first, last := value, value
+	if i := strings.Index(value, ".."); i >= 0 {
+		first, last = value[:i], value[i+2:]
+	}
+	start, err := time.Parse(availabilityDateLayout, strings.TrimSpace(first))
+	if err != nil {
+		return dateRange{}, fmt.Errorf("invalid date range %q: %w", value, err)
+	}
+	end, err := time.Parse(availabilityDateLayout, strings.TrimSpace(last))
+	if err != nil {
+		return dateRange{}, fmt.Errorf("invalid date range %q: %w", value, err)
+	}
+	if end.Before(start) {
+		return dateRange{}, fmt.Errorf("invalid date range %q: it ends before it starts", value)
+	}
+	return dateRange{start: start, end: end.AddDate(0, 0, 1)}, nil
+}
+
+// parseAvailabilityFile reads a YAML mapping of logins to the dates and
+// ranges of dates on which they are unavailable:
+//
+//	alice:
+//	  - 2026-08-01..2026-08-21
+//	  - 2026-12-24
+func parseAvailabilityFile(content []byte) (availability, error) {
+	var entries map[string]stringList
+	if err := yaml.Unmarshal(content, &entries); err != nil {
+		return nil, err
+	}
+	result := availability{}
+	for login, values := range entries {
+		for _, value := range values {
+			r, err := parseDateRange(value)
+			if err != nil {
+				return nil, fmt.Errorf("%s: %w", login, err)
+			}
+			result.add(login, r)
+		}
+	}
+	return result, nil
+}
+
+func readAvailabilityFile(ctx context.Context, client *github.Client, repoOwner, repo, path string) (availability, error) {
+	file, _, _, err := client.Repositories.GetContents(ctx, repoOwner, repo, path, nil)
+	if err != nil {
+		return nil, err
+	}
+	if file == nil {
+		return nil, fmt.Errorf("%s is not a file", path)
+	}
+	content, err := file.GetContent()
+	if err != nil {
+		return nil, err
+	}
+	return parseAvailabilityFile([]byte(content))
+}
+
+// calendarMention finds the logins that calendar events are for.
+var calendarMention = regexp.MustCompile(`@([A-Za-z0-9][A-Za-z0-9-]*)`)
+
+// parseCalendarTime reads a DTSTART or DTEND value, either a date or a date
+// and time.
+func parseCalendarTime(value string) (time.Time, error) {
+	switch {
+	case len(value) == len("20060102"):
+		return time.Parse("20060102", value)
+	case strings.HasSuffix(value, "Z"):
+		return time.Parse("20060102T150405Z", value)
+	}
+	return time.Parse("20060102T150405", value)
+}
+
+// parseICalendar reads the events of an iCalendar feed as unavailability of
+// the logins mentioned in their summaries, such as "@alice vacation".
+// All-day events end at the start of their DTEND date, as iCalendar
+// defines, and events without DTEND last a day. Times without a zone are
+// read as UTC.
+func parseICalendar(data io.Reader) (availability, error) {
+	// Long lines are folded onto lines starting with a space or tab.
+	var lines []string
+	scanner := bufio.NewScanner(data)
+	for scanner.Scan() {
+		line := strings.TrimRight(scanner.Text(), "\r")
+		if len(lines) > 0 && (strings.HasPrefix(line, " ") || strings.HasPrefix(line, "\t")) {
+			lines[len(lines)-1] += line[1:]
+			continue
+		}
+		lines = append(lines, line)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+
+	result := availability{}
+	inEvent := false
+	var summary, start, end string
+	for _, line := range lines {
+		nameAndValue := strings.SplitN(line, ":", 2)
+		if len(nameAndValue) != 2 {
+			continue
+		}
+		// Parameters such as ;VALUE=DATE follow the property name.
+		name, value := strings.ToUpper(strings.SplitN(nameAndValue[0], ";", 2)[0]), strings.TrimSpace(nameAndValue[1])
+		switch {
+		case name == "BEGIN" && value == "VEVENT":
+			inEvent, summary, start, end = true, "", "", ""
+		case name == "END" && value == "VEVENT" && inEvent:
+			inEvent = false
+			startTime, err := parseCalendarTime(start)
+			if err != nil {
+				return nil, fmt.Errorf("event %q: invalid DTSTART: %w", summary, err)
+			}
+			r := dateRange{start: startTime, end: startTime.AddDate(0, 0, 1)}
+			if end != "" {
+				if r.end, err = parseCalendarTime(end); err != nil {
+					return nil, fmt.Errorf("event %q: invalid DTEND: %w", summary, err)
+				}
+			}
+			for _, match := range calendarMention.FindAllStringSubmatch(summary, -1) {
+				result.add(match[1], r)
+			}
+		case !inEvent:
+		case name == "SUMMARY":
+			summary = value
+		case name == "DTSTART":
+			start = value
+		case name == "DTEND":
+			end = value
+		}
+	}
+	return result, nil
+}
+
+// availabilityURLTimeout bounds how long opening the gate waits for the
+// availability calendar.
+const availabilityURLTimeout = 30 * time.Second
+
+// fetchAvailabilityCalendar requests an iCalendar feed of unavailability.
+func fetchAvailabilityCalendar(ctx context.Context, url string) (availability, error) {
+	ctx, cancel := context.WithTimeout(ctx, availabilityURLTimeout)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Accept", "text/calendar")
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("unexpected status %s", resp.Status)
+	}
+	return parseICalendar(resp.Body)
+}
+
+// removeApprovers returns the approvers that aren't in removed.
+func removeApprovers(approvers, removed []string) []string {
+	var remaining []string
+	for _, approver := range approvers {
+		if approversIndex(removed, approver) < 0 {
+			remaining = append(remaining, approver)
+		}
+	}
+	return remaining
+}
@@ -0,0 +1,99 @@
+package main
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestParseAvailabilityFile(t *testing.T) {
+	content := []byte(`
+alice:
+  - 2026-08-01..2026-08-21
+  - 2026-12-24
+"@Bob": 2026-08-10
+`)
+	unavailability, err := parseAvailabilityFile(content)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	approvers := []string{"alice", "bob", "carol"}
+	testCases := []struct {
+		now      string
+		expected string
+	}{
+		{now: "2026-07-31T23:59:59Z", expected: ""},
+		{now: "2026-08-01T00:00:00Z", expected: "alice"},
+		{now: "2026-08-10T12:00:00Z", expected: "alice,bob"},
+		{now: "2026-08-21T23:59:59Z", expected: "alice"},
+		{now: "2026-08-22T00:00:00Z", expected: ""},
+		{now: "2026-12-24T08:00:00Z", expected: "alice"},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.now, func(t *testing.T) {
+			now, _ := time.Parse(time.RFC3339, testCase.now)
+			if actual := strings.Join(unavailability.unavailable(approvers, now), ","); actual != testCase.expected {
+				t.Fatalf("expected %q but got %q", testCase.expected, actual)
+			}
+		})
+	}
+
+	for _, invalid := range []string{"alice: [2026-13-01]", "alice: [2026-08-21..2026-08-01]", "alice: [next week]"} {
+		if _, err := parseAvailabilityFile([]byte(invalid)); err == nil {
+			t.Fatalf("expected an error for %q", invalid)
+		}
+	}
+}
+
+func TestParseICalendar(t *testing.T) {
+	feed := strings.Join([]string{
+		"BEGIN:VCALENDAR",
+		"VERSION:2.0",
+		"BEGIN:VEVENT",
+		"SUMMARY:@alice vacation",
+		"DTSTART;VALUE=DATE:20260801",
+		"DTEND;VALUE=DATE:20260822",
+		"END:VEVENT",
+		"BEGIN:VEVENT",
+		"SUMMARY:Offsite for @bob and",
+		"  @carol",
+		"DTSTART:20260810T090000Z",
+		"DTEND:20260810T170000Z",
+		"END:VEVENT",
+		"BEGIN:VEVENT",
+		"SUMMARY:Team lunch",
+		"DTSTART;VALUE=DATE:20260810",
+		"END:VEVENT",
+		"END:VCALENDAR",
+	}, "\r\n")
+	unavailability, err := parseICalendar(strings.NewReader(feed))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	approvers := []string{"alice", "bob", "carol", "dave"}
+	testCases := []struct {
+		now      string
+		expected string
+	}{
+		{now: "2026-08-10T08:00:00Z", expected: "alice"},
+		{now: "2026-08-10T12:00:00Z", expected: "alice,bob,carol"},
+		{now: "2026-08-21T12:00:00Z", expected: "alice"},
+		{now: "2026-08-22T00:00:00Z", expected: ""},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.now, func(t *testing.T) {
+			now, _ := time.Parse(time.RFC3339, testCase.now)
+			if actual := strings.Join(unavailability.unavailable(approvers, now), ","); actual != testCase.expected {
+				t.Fatalf("expected %q but got %q", testCase.expected, actual)
+			}
+		})
+	}
+
+	if _, err := parseICalendar(strings.NewReader("BEGIN:VEVENT\nSUMMARY:@alice\nDTSTART:soon\nEND:VEVENT\n")); err == nil {
+		t.Fatal("expected an error for an invalid DTSTART")
+	}
+}
@@ -0,0 +1,60 @@
+package main
+
+import (
+	"path"
+	"strings"
+)
+
+// shouldBypass reports whether the gate should be skipped for the given actor
+// and branch. Every configured allowlist must match for the gate to be
+// bypassed, so bypassActors and bypassBranches can be combined to only skip
+// the gate for a given bot on a given branch. Branch entries may be glob
+// patterns such as "renovate/*".
+func shouldBypass(actor, branch string, bypassActors, bypassBranches []string) (bool, error) {
+	if len(bypassActors) == 0 && len(bypassBranches) == 0 {
+		return false, nil
+	}
+
+	if len(bypassActors) > 0 && !containsFold(bypassActors, actor) {
+		return false, nil
+	}
+
+	if len(bypassBranches) > 0 {
+		matched := false
+		for _, pattern := range bypassBranches {
+			ok, err := path.Match(pattern, branch)
+			if err != nil {
+				return false, err
+			}
+			if ok {
+				matched = true
+				break
+			}
+		}
+		if !matched {
+			return false, nil
+		}
+	}
+
+	return true, nil
+}
+
+// bypassBranch returns the branch that bypass-branches is matched against.
+// On pull request events that is the base branch the pull request targets,
+// as the author chooses the name of the head branch, even from a fork.
+// Otherwise it is the branch the run is for, such as the one pushed to.
+func bypassBranch(baseRef, refName string) string {
+	if baseRef != "" {
+		return baseRef
+	}
+	return refName
+}
+
+func containsFold(values []string, value string) bool {
+	for _, v := range values {
+		if strings.EqualFold(v, value) {
+			return true
+		}
+	}
+	return false
+}
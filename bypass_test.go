@@ -0,0 +1,104 @@
+package main
+
+import "testing"
+
+func TestShouldBypass(t *testing.T) {
+	testCases := []struct {
+		name           string
+		actor          string
+		branch         string
+		bypassActors   []string
+		bypassBranches []string
+		expected       bool
+	}{
+		{
+			name:     "no_bypass_configured",
+			actor:    "renovate[bot]",
+			branch:   "main",
+			expected: false,
+		},
+		{
+			name:         "actor_matches",
+			actor:        "renovate[bot]",
+			branch:       "main",
+			bypassActors: []string{"dependabot[bot]", "renovate[bot]"},
+			expected:     true,
+		},
+		{
+			name:         "actor_matches_case_insensitive",
+			actor:        "Renovate[bot]",
+			branch:       "main",
+			bypassActors: []string{"renovate[bot]"},
+			expected:     true,
+		},
+		{
+			name:         "actor_does_not_match",
+			actor:        "user1",
+			branch:       "main",
+			bypassActors: []string{"renovate[bot]"},
+			expected:     false,
+		},
+		{
+			name:           "branch_glob_matches",
+			actor:          "user1",
+			branch:         "sandbox/feature",
+			bypassBranches: []string{"sandbox/*"},
+			expected:       true,
+		},
+		{
+			name:           "branch_does_not_match",
+			actor:          "user1",
+			branch:         "main",
+			bypassBranches: []string{"sandbox/*"},
+			expected:       false,
+		},
+		{
+			name:           "actor_and_branch_match",
+			actor:          "renovate[bot]",
+			branch:         "sandbox",
+			bypassActors:   []string{"renovate[bot]"},
+			bypassBranches: []string{"sandbox"},
+			expected:       true,
+		},
+		{
+			name:           "actor_matches_branch_does_not",
+			actor:          "renovate[bot]",
+			branch:         "main",
+			bypassActors:   []string{"renovate[bot]"},
+			bypassBranches: []string{"sandbox"},
+			expected:       false,
+		},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			actual, err := shouldBypass(testCase.actor, testCase.branch, testCase.bypassActors, testCase.bypassBranches)
+			if err != nil {
+				t.Fatalf("error checking bypass: %v", err)
+			}
+			if actual != testCase.expected {
+				t.Fatalf("expected %v but got %v", testCase.expected, actual)
+			}
+		})
+	}
+}
+
+func TestBypassBranch(t *testing.T) {
+	testCases := []struct {
+		name     string
+		baseRef  string
+		refName  string
+		expected string
+	}{
+		{name: "push", refName: "sandbox/x", expected: "sandbox/x"},
+		{name: "pull_request", baseRef: "main", refName: "42/merge", expected: "main"},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			if actual := bypassBranch(testCase.baseRef, testCase.refName); actual != testCase.expected {
+				t.Fatalf("expected %q, got %q", testCase.expected, actual)
+			}
+		})
+	}
+}
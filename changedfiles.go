@@ -0,0 +1,55 @@
+package main
+
+import (
+	"context"
+
+	"github.com/google/go-github/v43/github"
+)
+
+// changedFiles lists the files touched by the change that triggered the run:
+// the files of the pull request, the range of a push, or otherwise the files
+// of the commit being built.
+func changedFiles(ctx context.Context, client *github.Client, repoOwner, repo string, event *workflowEvent, sha string) ([]*github.CommitFile, error) {
+	switch {
+	case event.PullRequest != nil:
+		var files []*github.CommitFile
+		opts := &github.ListOptions{PerPage: 100}
+		for {
+			pageFiles, resp, err := client.PullRequests.ListFiles(ctx, repoOwner, repo, event.PullRequest.Number, opts)
+			if err != nil {
+				return nil, err
+			}
+			files = append(files, pageFiles...)
+			if resp.NextPage == 0 {
+				break
+			}
+			opts.Page = resp.NextPage
+		}
+		return files, nil
+	case event.isPush():
+		comparison, _, err := client.Repositories.CompareCommits(ctx, repoOwner, repo, event.Before, event.After, nil)
+		if err != nil {
+			return nil, err
+		}
+		return comparison.Files, nil
+	default:
+		commit, _, err := client.Repositories.GetCommit(ctx, repoOwner, repo, sha, nil)
+		if err != nil {
+			return nil, err
+		}
+		return commit.Files, nil
+	}
+}
+
+// commitFilePaths returns the paths of the files. Renamed files are listed
+// under both names.
+func commitFilePaths(files []*github.CommitFile) []string {
+	var paths []string
+	for _, file := range files {
+		paths = append(paths, file.GetFilename())
+		if previous := file.GetPreviousFilename(); previous != "" {
+			paths = append(paths, previous)
+		}
+	}
+	return paths
+}
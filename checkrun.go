@@ -0,0 +1,162 @@
+package main
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/google/go-github/v43/github"
+)
+
+var checkRunDecisionRegex = regexp.MustCompile(`<!-- manual-approval-decision user=(\S+) action=(\S+) at=(\S+) sig=([0-9a-f]+) -->`)
+
+// checkRunDecisionSignature signs a button click recorded on a check run.
+// Anyone who can write checks can edit the output of the check run, so only
+// decisions signed with the dispatch secret by the companion workflow count.
+func checkRunDecisionSignature(secret string, checkRunID int64, user, action, at string) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(fmt.Sprintf("%d:%s:%s:%s", checkRunID, user, action, at)))
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+func (a *approvalEnvironment) createApprovalCheckRun(ctx context.Context) error {
+	title := "Waiting for manual approval"
+	summary := fmt.Sprintf("Approve or deny workflow run %s.", a.runURL())
+	if a.approvalIssue != nil {
+		summary = fmt.Sprintf("%s\n\nApproval issue: %s", summary, a.approvalIssue.GetHTMLURL())
+	}
+	checkRun, _, err := a.client.Checks.CreateCheckRun(ctx, a.repoOwner, a.repo, github.CreateCheckRunOptions{
+		Name:       a.checkRunName,
+		HeadSHA:    a.sha,
+		DetailsURL: github.String(a.runURL()),
+		Status:     github.String("in_progress"),
+		Output: &github.CheckRunOutput{
+			Title:   &title,
+			Summary: &summary,
+		},
+		Actions: []*github.CheckRunAction{
+			{Label: "Approve", Description: "Approve the workflow run", Identifier: decisionApprove},
+			{Label: "Deny", Description: "Deny the workflow run", Identifier: decisionDeny},
+		},
+	})
+	if err != nil {
+		return err
+	}
+	a.checkRunID = checkRun.GetID()
+	return nil
+}
+
+// listCheckRunComments returns the button clicks recorded on the approval
+// check run as issue comments.
+func (a *approvalEnvironment) listCheckRunComments(ctx context.Context) ([]*github.IssueComment, error) {
+	checkRun, _, err := a.client.Checks.GetCheckRun(ctx, a.repoOwner, a.repo, a.checkRunID)
+	if err != nil {
+		return nil, err
+	}
+	return checkRunDecisionComments(checkRun.GetOutput().GetText(), a.dispatchSecret, a.checkRunID), nil
+}
+
+// checkRunDecisionComments parses the decision markers that the companion
+// check_run workflow appends to the check run output. Markers whose
+// signature doesn't verify are ignored.
+func checkRunDecisionComments(text, secret string, checkRunID int64) []*github.IssueComment {
+	var comments []*github.IssueComment
+	for _, match := range checkRunDecisionRegex.FindAllStringSubmatch(text, -1) {
+		expected := checkRunDecisionSignature(secret, checkRunID, match[1], match[2], match[3])
+		if !hmac.Equal([]byte(match[4]), []byte(expected)) {
+			fmt.Printf("ignoring check run decision from %s with an invalid signature\n", match[1])
+			continue
+		}
+		var body string
+		switch match[2] {
+		case decisionApprove:
+			body = gateWords.Approved[0]
+		case decisionDeny:
+			body = gateWords.Denied[0]
+		default:
+			continue
+		}
+		comment := &github.IssueComment{
+			Body: github.String(body),
+			User: &github.User{Login: github.String(match[1])},
+		}
+		if createdAt, err := time.Parse(time.RFC3339, match[3]); err == nil {
+			comment.CreatedAt = &createdAt
+		}
+		comments = append(comments, comment)
+	}
+	return comments
+}
+
+// completeApprovalCheckRun marks the approval check run, if there is one, as
+// completed with the given conclusion.
+func (a *approvalEnvironment) completeApprovalCheckRun(ctx context.Context, conclusion, summary string) error {
+	if a.checkRunID == 0 {
+		return nil
+	}
+	checkRun, _, err := a.client.Checks.GetCheckRun(ctx, a.repoOwner, a.repo, a.checkRunID)
+	if err != nil {
+		return err
+	}
+	title := "Manual approval completed"
+	_, _, err = a.client.Checks.UpdateCheckRun(ctx, a.repoOwner, a.repo, a.checkRunID, github.UpdateCheckRunOptions{
+		Name:        a.checkRunName,
+		Status:      github.String("completed"),
+		Conclusion:  github.String(conclusion),
+		CompletedAt: &github.Timestamp{Time: time.Now()},
+		Output: &github.CheckRunOutput{
+			Title:   &title,
+			Summary: &summary,
+			Text:    github.String(checkRun.GetOutput().GetText()),
+		},
+		Actions: []*github.CheckRunAction{},
+	})
+	return err
+}
+
+// recordCheckRunAction handles a check_run requested_action event in the
+// companion workflow by appending the decision, signed with secret, to the
+// check run output, where the waiting gate picks it up on its next poll.
+// The approver is the sender of the event, which GitHub sets.
+func recordCheckRunAction(ctx context.Context, client *github.Client, repoOwner, repo, secret string, event *workflowEvent) error {
+	action := event.RequestedAction.Identifier
+	if action != decisionApprove && action != decisionDeny {
+		return fmt.Errorf("unknown check run action %s", action)
+	}
+
+	checkRun, _, err := client.Checks.GetCheckRun(ctx, repoOwner, repo, event.CheckRun.ID)
+	if err != nil {
+		return err
+	}
+	if checkRun.GetStatus() == "completed" {
+		fmt.Printf("Check run %d is already completed, ignoring %s from %s\n", checkRun.GetID(), action, event.Sender.Login)
+		return nil
+	}
+
+	at := time.Now().UTC().Format(time.RFC3339)
+	line := fmt.Sprintf(
+		"- @%s: %s <!-- manual-approval-decision user=%s action=%s at=%s sig=%s -->",
+		event.Sender.Login,
+		action,
+		event.Sender.Login,
+		action,
+		at,
+		checkRunDecisionSignature(secret, checkRun.GetID(), event.Sender.Login, action, at),
+	)
+	text := strings.TrimSpace(fmt.Sprintf("%s\n%s", checkRun.GetOutput().GetText(), line))
+	fmt.Printf("Recording %s from %s on check run %d\n", action, event.Sender.Login, checkRun.GetID())
+	_, _, err = client.Checks.UpdateCheckRun(ctx, repoOwner, repo, checkRun.GetID(), github.UpdateCheckRunOptions{
+		Name: checkRun.GetName(),
+		Output: &github.CheckRunOutput{
+			Title:   github.String(checkRun.GetOutput().GetTitle()),
+			Summary: github.String(checkRun.GetOutput().GetSummary()),
+			Text:    &text,
+		},
+	})
+	return err
+}
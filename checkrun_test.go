@@ -0,0 +1,48 @@
+package main
+
+import (
+	"fmt"
+	"testing"
+)
+
+func TestCheckRunDecisionComments(t *testing.T) {
+	const secret = "dispatch-secret"
+	const checkRunID = 42
+	signed := func(checkRunID int64, user, action, at string) string {
+		return fmt.Sprintf(
+			"- @%s: %s <!-- manual-approval-decision user=%s action=%s at=%s sig=%s -->",
+			user, action, user, action, at,
+			checkRunDecisionSignature(secret, checkRunID, user, action, at),
+		)
+	}
+	text := signed(checkRunID, "login1", "approve", "2022-03-01T10:00:00Z") + "\n" +
+		signed(checkRunID, "login2", "unknown", "2022-03-01T10:01:00Z") + "\n" +
+		"some unrelated text\n" +
+		signed(checkRunID, "login3", "deny", "2022-03-01T10:02:00Z") + "\n" +
+		// Forged by editing the check run output.
+		"- @login4: approve <!-- manual-approval-decision user=login4 action=approve at=2022-03-01T10:03:00Z -->\n" +
+		"- @login5: approve <!-- manual-approval-decision user=login5 action=approve at=2022-03-01T10:04:00Z sig=0123abcd -->\n" +
+		// Copied from another check run.
+		signed(7, "login6", "approve", "2022-03-01T10:05:00Z") + "\n" +
+		// Signed for another approver.
+		"- @login7: approve <!-- manual-approval-decision user=login7 action=approve at=2022-03-01T10:00:00Z sig=" +
+		checkRunDecisionSignature(secret, checkRunID, "login1", "approve", "2022-03-01T10:00:00Z") + " -->"
+
+	comments := checkRunDecisionComments(text, secret, checkRunID)
+	if len(comments) != 2 {
+		t.Fatalf("expected 2 comments but got %d", len(comments))
+	}
+	if comments[0].User.GetLogin() != "login1" || comments[0].GetBody() != gateWords.Approved[0] {
+		t.Fatalf("expected approval from login1 but got %q from %s", comments[0].GetBody(), comments[0].User.GetLogin())
+	}
+	if comments[1].User.GetLogin() != "login3" || comments[1].GetBody() != gateWords.Denied[0] {
+		t.Fatalf("expected denial from login3 but got %q from %s", comments[1].GetBody(), comments[1].User.GetLogin())
+	}
+	if comments[1].CreatedAt == nil || comments[1].CreatedAt.Minute() != 2 {
+		t.Fatalf("expected creation time to be parsed but got %v", comments[1].CreatedAt)
+	}
+
+	if comments := checkRunDecisionComments(text, "other-secret", checkRunID); len(comments) != 0 {
+		t.Fatalf("expected no comments with another secret but got %d", len(comments))
+	}
+}
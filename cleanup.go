@@ -0,0 +1,71 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/google/go-github/v43/github"
+)
+
+// staleApprovalReason returns why an open approval issue is no longer waited
+// on by its workflow run, or an empty string if the run may still be waiting.
+// run is nil when the run no longer exists.
+func staleApprovalReason(run *github.WorkflowRun, marker issueMarker) string {
+	if run == nil {
+		return fmt.Sprintf("workflow run %d no longer exists", marker.RunID)
+	}
+	if run.GetStatus() == "completed" {
+		return fmt.Sprintf("workflow run %d has completed with conclusion %s", marker.RunID, run.GetConclusion())
+	}
+	if marker.RunAttempt > 0 && run.GetRunAttempt() > marker.RunAttempt {
+		return fmt.Sprintf("workflow run %d was re-run as attempt %d", marker.RunID, run.GetRunAttempt())
+	}
+	return ""
+}
+
+// cleanupApprovalIssues closes the open approval issues whose workflow runs
+// are no longer waiting for them, such as runs that were cancelled or whose
+// runner crashed. It returns the number of issues closed.
+func (a *approvalEnvironment) cleanupApprovalIssues(ctx context.Context) (int, error) {
+	issues, err := a.findApprovalIssues(ctx, "open", cleanupSearchPages, func(marker issueMarker) bool {
+		return marker.RunID != 0 && marker.RunID != a.runID
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	closed := 0
+	for _, issue := range issues {
+		marker, _ := parseIssueMarker(issue.GetBody())
+		run, _, err := a.client.Actions.GetWorkflowRunByID(ctx, a.repoOwner, a.repo, int64(marker.RunID))
+		var errorResponse *github.ErrorResponse
+		if errors.As(err, &errorResponse) && errorResponse.Response.StatusCode == http.StatusNotFound {
+			run, err = nil, nil
+		}
+		if err != nil {
+			return closed, fmt.Errorf("error getting workflow run %d: %w", marker.RunID, err)
+		}
+
+		reason := staleApprovalReason(run, marker)
+		if reason == "" {
+			continue
+		}
+		fmt.Printf("Closing approval issue #%d: %s\n", issue.GetNumber(), reason)
+		comment := fmt.Sprintf("Closing this approval issue because %s, so it is no longer waiting for approval.", reason)
+		if _, _, err := a.client.Issues.CreateComment(ctx, a.repoOwner, a.repo, issue.GetNumber(), &github.IssueComment{
+			Body: &comment,
+		}); err != nil {
+			return closed, fmt.Errorf("error commenting on issue #%d: %w", issue.GetNumber(), err)
+		}
+		state := "closed"
+		if _, _, err := a.client.Issues.Edit(ctx, a.repoOwner, a.repo, issue.GetNumber(), &github.IssueRequest{
+			State: &state,
+		}); err != nil {
+			return closed, fmt.Errorf("error closing issue #%d: %w", issue.GetNumber(), err)
+		}
+		closed++
+	}
+	return closed, nil
+}
@@ -0,0 +1,58 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/google/go-github/v43/github"
+)
+
+func TestStaleApprovalReason(t *testing.T) {
+	marker := issueMarker{RunID: 1234, RunAttempt: 1}
+
+	testCases := []struct {
+		name   string
+		run    *github.WorkflowRun
+		marker issueMarker
+		stale  bool
+	}{
+		{
+			name:   "deleted_run",
+			run:    nil,
+			marker: marker,
+			stale:  true,
+		},
+		{
+			name:   "in_progress",
+			run:    &github.WorkflowRun{Status: github.String("in_progress"), RunAttempt: github.Int(1)},
+			marker: marker,
+			stale:  false,
+		},
+		{
+			name:   "cancelled",
+			run:    &github.WorkflowRun{Status: github.String("completed"), Conclusion: github.String("cancelled"), RunAttempt: github.Int(1)},
+			marker: marker,
+			stale:  true,
+		},
+		{
+			name:   "rerun",
+			run:    &github.WorkflowRun{Status: github.String("in_progress"), RunAttempt: github.Int(2)},
+			marker: marker,
+			stale:  true,
+		},
+		{
+			name:   "unknown_attempt",
+			run:    &github.WorkflowRun{Status: github.String("in_progress"), RunAttempt: github.Int(2)},
+			marker: issueMarker{RunID: 1234},
+			stale:  false,
+		},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			reason := staleApprovalReason(testCase.run, testCase.marker)
+			if actual := reason != ""; actual != testCase.stale {
+				t.Fatalf("expected stale %v but got %v (%q)", testCase.stale, actual, reason)
+			}
+		})
+	}
+}
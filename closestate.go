@@ -0,0 +1,108 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/google/go-github/v43/github"
+)
+
+// closeTimelineEvent is a closed event from the issue timeline. go-github
+// does not expose the state reason of the close yet, so the timeline is
+// decoded directly.
+type closeTimelineEvent struct {
+	Event       string     `json:"event"`
+	StateReason string     `json:"state_reason"`
+	CreatedAt   *time.Time `json:"created_at"`
+	Actor       struct {
+		Login string `json:"login"`
+	} `json:"actor"`
+}
+
+// closeDecisionComments converts the closes of the approval issue into
+// synthetic issue comments. Closing as completed counts as an approval and
+// closing as not planned as a denial by the user who closed the issue.
+func closeDecisionComments(events []closeTimelineEvent) []*github.IssueComment {
+	var comments []*github.IssueComment
+	for _, event := range events {
+		if event.Event != "closed" {
+			continue
+		}
+		var body string
+		switch event.StateReason {
+		case "completed":
+			body = gateWords.Approved[0]
+		case "not_planned":
+			body = gateWords.Denied[0]
+		default:
+			continue
+		}
+		comments = append(comments, &github.IssueComment{
+			Body:      github.String(body),
+			User:      &github.User{Login: github.String(event.Actor.Login)},
+			CreatedAt: event.CreatedAt,
+		})
+	}
+	return comments
+}
+
+func (a *approvalEnvironment) listCloseComments(ctx context.Context) ([]*github.IssueComment, error) {
+	var events []closeTimelineEvent
+	page := 1
+	for {
+		req, err := a.client.NewRequest("GET", fmt.Sprintf("repos/%s/%s/issues/%d/timeline?per_page=100&page=%d", a.repoOwner, a.repo, a.approvalIssueNumber, page), nil)
+		if err != nil {
+			return nil, err
+		}
+		var pageEvents []closeTimelineEvent
+		resp, err := a.client.Do(ctx, req, &pageEvents)
+		if err != nil {
+			return nil, err
+		}
+		events = append(events, pageEvents...)
+		if resp.NextPage == 0 {
+			break
+		}
+		page = resp.NextPage
+	}
+	return closeDecisionComments(events), nil
+}
+
+// reopenIfClosed reopens the approval issue if someone closed it while the gate
+// is still pending, for example a user who is not an approver or an approver
+// whose approval alone does not reach the minimum.
+func (a *approvalEnvironment) reopenIfClosed(ctx context.Context) error {
+	if a.sharedIssueFollower {
+		return nil
+	}
+	// The state read by the last poll is recent enough to act on.
+	state, body := "", ""
+	if a.lastPoll != nil {
+		state, body = a.lastPoll.state, a.lastPoll.body
+	} else {
+		issue, _, err := a.client.Issues.Get(ctx, a.repoOwner, a.repo, a.approvalIssueNumber)
+		if err != nil {
+			return err
+		}
+		state, body = issue.GetState(), issue.GetBody()
+	}
+	if state != "closed" {
+		return nil
+	}
+	if marker, ok := parseIssueMarker(body); ok && marker.SupersededBy != 0 {
+		return nil
+	}
+
+	comment := "Reopening this issue as the workflow is still pending approval."
+	fmt.Println(comment)
+	_, _, err := a.client.Issues.CreateComment(ctx, a.repoOwner, a.repo, a.approvalIssueNumber, &github.IssueComment{
+		Body: &comment,
+	})
+	if err != nil {
+		return err
+	}
+	newState := "open"
+	_, _, err = a.client.Issues.Edit(ctx, a.repoOwner, a.repo, a.approvalIssueNumber, &github.IssueRequest{State: &newState})
+	return err
+}
@@ -0,0 +1,29 @@
+package main
+
+import "testing"
+
+func TestCloseDecisionComments(t *testing.T) {
+	closed := func(login, stateReason string) closeTimelineEvent {
+		event := closeTimelineEvent{Event: "closed", StateReason: stateReason}
+		event.Actor.Login = login
+		return event
+	}
+	events := []closeTimelineEvent{
+		{Event: "commented"},
+		closed("login1", "completed"),
+		{Event: "reopened"},
+		closed("login2", ""),
+		closed("login3", "not_planned"),
+	}
+
+	comments := closeDecisionComments(events)
+	if len(comments) != 2 {
+		t.Fatalf("expected 2 comments but got %d", len(comments))
+	}
+	if comments[0].User.GetLogin() != "login1" || comments[0].GetBody() != gateWords.Approved[0] {
+		t.Fatalf("expected approval from login1 but got %q from %s", comments[0].GetBody(), comments[0].User.GetLogin())
+	}
+	if comments[1].User.GetLogin() != "login3" || comments[1].GetBody() != gateWords.Denied[0] {
+		t.Fatalf("expected denial from login3 but got %q from %s", comments[1].GetBody(), comments[1].User.GetLogin())
+	}
+}
@@ -0,0 +1,73 @@
+package main
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/google/go-github/v43/github"
+)
+
+// commentEditTracker remembers the comments seen on earlier polls so that
+// edited comments can be reported and, optionally, edits to comments that
+// already counted as an approval can be ignored.
+type commentEditTracker struct {
+	ignoreEditsAfterApproval bool
+	updatedAt                map[int64]time.Time
+	approvalBodies           map[int64]string
+}
+
+func newCommentEditTracker(ignoreEditsAfterApproval bool) *commentEditTracker {
+	return &commentEditTracker{
+		ignoreEditsAfterApproval: ignoreEditsAfterApproval,
+		updatedAt:                make(map[int64]time.Time),
+		approvalBodies:           make(map[int64]string),
+	}
+}
+
+// restore resumes tracking from the approval bodies counted by an earlier
+// attempt of the run.
+func (t *commentEditTracker) restore(approvalBodies map[int64]string) {
+	for id, body := range approvalBodies {
+		t.approvalBodies[id] = body
+	}
+}
+
+// apply logs comments that were edited since the last poll and, if edits
+// after approval are ignored, restores the body an approval comment had when
+// it was first counted. Comments without an ID, such as reviews converted to
+// comments, are left untouched.
+func (t *commentEditTracker) apply(comments []*github.IssueComment, multipleDeploymentNames []string) {
+	for _, comment := range comments {
+		id := comment.GetID()
+		if id == 0 {
+			continue
+		}
+
+		lastUpdatedAt, seen := t.updatedAt[id]
+		if seen && comment.GetUpdatedAt().After(lastUpdatedAt) {
+			fmt.Printf("Comment %d from %s was edited, re-evaluating it\n", id, comment.User.GetLogin())
+		}
+		t.updatedAt[id] = comment.GetUpdatedAt()
+
+		if !t.ignoreEditsAfterApproval {
+			continue
+		}
+		if body, ok := t.approvalBodies[id]; ok {
+			if body != comment.GetBody() {
+				fmt.Printf("Ignoring edit to comment %d from %s made after it was counted as an approval\n", id, comment.User.GetLogin())
+				comment.Body = github.String(body)
+			}
+			continue
+		}
+		if isApprovalComment(comment, multipleDeploymentNames) {
+			t.approvalBodies[id] = comment.GetBody()
+		}
+	}
+}
+
+// isApprovalComment reports whether the comment on its own approves the
+// gate for its author.
+func isApprovalComment(comment *github.IssueComment, multipleDeploymentNames []string) bool {
+	status, _, err := approvalFromComments([]*github.IssueComment{comment}, []string{comment.User.GetLogin()}, 1, multipleDeploymentNames)
+	return err == nil && status == approvalStatusApproved
+}
@@ -0,0 +1,81 @@
+package main
+
+import (
+	"testing"
+	"time"
+
+	"github.com/google/go-github/v43/github"
+)
+
+func TestCommentEditTracker(t *testing.T) {
+	login1 := "login1"
+	created := time.Date(2022, 3, 1, 0, 0, 0, 0, time.UTC)
+	edited := created.Add(time.Minute)
+
+	testCases := []struct {
+		name                     string
+		ignoreEditsAfterApproval bool
+		firstBody                string
+		editedBody               string
+		expectedStatus           approvalStatus
+	}{
+		{
+			name:           "typo_fixed",
+			firstBody:      "aproved",
+			editedBody:     "approved",
+			expectedStatus: approvalStatusApproved,
+		},
+		{
+			name:                     "typo_fixed_with_edits_ignored",
+			ignoreEditsAfterApproval: true,
+			firstBody:                "aproved",
+			editedBody:               "approved",
+			expectedStatus:           approvalStatusApproved,
+		},
+		{
+			name:           "approval_edited_to_denial",
+			firstBody:      "approved",
+			editedBody:     "denied",
+			expectedStatus: approvalStatusDenied,
+		},
+		{
+			name:                     "approval_edit_ignored",
+			ignoreEditsAfterApproval: true,
+			firstBody:                "approved",
+			editedBody:               "denied",
+			expectedStatus:           approvalStatusApproved,
+		},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			tracker := newCommentEditTracker(testCase.ignoreEditsAfterApproval)
+			tracker.apply([]*github.IssueComment{
+				{
+					ID:        github.Int64(1),
+					User:      &github.User{Login: &login1},
+					Body:      github.String(testCase.firstBody),
+					UpdatedAt: &created,
+				},
+			}, []string{})
+
+			comments := []*github.IssueComment{
+				{
+					ID:        github.Int64(1),
+					User:      &github.User{Login: &login1},
+					Body:      github.String(testCase.editedBody),
+					UpdatedAt: &edited,
+				},
+			}
+			tracker.apply(comments, []string{})
+
+			actual, _, err := approvalFromComments(comments, []string{login1}, 1, []string{})
+			if err != nil {
+				t.Fatalf("error getting approval from comments: %v", err)
+			}
+			if actual != testCase.expectedStatus {
+				t.Fatalf("actual %s, expected %s", actual, testCase.expectedStatus)
+			}
+		})
+	}
+}
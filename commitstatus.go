@@ -0,0 +1,48 @@
+package main
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/google/go-github/v43/github"
+)
+
+// setCommitStatus publishes the gate state as a commit status on the commit
+// being approved so that other tooling can see it without reading issues.
+// It does nothing unless a commit status context is configured.
+func (a *approvalEnvironment) setCommitStatus(ctx context.Context, status approvalStatus) error {
+	if a.commitStatusContext == "" {
+		return nil
+	}
+
+	var state, description string
+	switch status {
+	case approvalStatusPending:
+		state = "pending"
+		description = "Waiting for manual approval"
+	case approvalStatusApproved:
+		state = "success"
+		description = "Manual approval granted"
+	case approvalStatusDenied:
+		state = "failure"
+		description = "Manual approval denied"
+	case approvalStatusTimedOut:
+		state = "failure"
+		description = "Manual approval timed out"
+	default:
+		state = "error"
+		description = fmt.Sprintf("Manual approval %s", status)
+	}
+
+	targetURL := a.runURL()
+	if a.approvalIssue != nil {
+		targetURL = a.approvalIssue.GetHTMLURL()
+	}
+	_, _, err := a.client.Repositories.CreateStatus(ctx, a.repoOwner, a.repo, a.sha, &github.RepoStatus{
+		State:       &state,
+		Description: &description,
+		Context:     &a.commitStatusContext,
+		TargetURL:   &targetURL,
+	})
+	return err
+}
@@ -0,0 +1,137 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/google/go-github/v43/github"
+)
+
+// companionProgressMarker identifies the comment in which companion mode
+// records the progress of the gate.
+const companionProgressMarker = "<!-- manual-approval-progress -->"
+
+// companionProgressComment describes how far the comments got the gate.
+func companionProgressComment(status approvalStatus, pending []string, approvals, minimumApprovals int) string {
+	var progress string
+	switch status {
+	case approvalStatusApproved:
+		progress = "Approved, the waiting workflow continues shortly."
+	case approvalStatusDenied:
+		progress = "Denied, the waiting workflow fails shortly."
+	case approvalStatusHeld:
+		progress = "On hold until every hold is lifted with `/unhold`."
+	default:
+		progress = fmt.Sprintf("%d of %d required approvals.", approvals, minimumApprovals)
+		if len(pending) > 0 {
+			progress += fmt.Sprintf(" Waiting for %s.", mentionList(pending))
+		}
+	}
+	return fmt.Sprintf("%s\n**Approval progress:** %s", companionProgressMarker, progress)
+}
+
+// mentionList joins logins as mentions.
+func mentionList(logins []string) string {
+	mentions := make([]string, len(logins))
+	for i, login := range logins {
+		mentions[i] = "@" + login
+	}
+	return strings.Join(mentions, ", ")
+}
+
+// findProgressComment returns the progress comment left on the approval
+// issue by author, or nil if there is none yet.
+func findProgressComment(comments []*github.IssueComment, author string) *github.IssueComment {
+	for _, comment := range comments {
+		if strings.EqualFold(comment.User.GetLogin(), author) && strings.HasPrefix(comment.GetBody(), companionProgressMarker) {
+			return comment
+		}
+	}
+	return nil
+}
+
+// runCompanion evaluates the comments on the approval issue from a workflow
+// triggered by one of them. It records the progress in a comment on the
+// issue and, once the gate is decided, applies companionLabel so that a gate
+// waiting with companion-signal notices right away. Resolving the gate is
+// left to the waiting job.
+func (a *approvalEnvironment) runCompanion(ctx context.Context, approvers []string, minimumApprovals int) (approvalStatus, error) {
+	comments, err := a.approvalComments(ctx)
+	if err != nil {
+		return "", fmt.Errorf("error getting comments: %w", err)
+	}
+
+	status := approvalStatusPending
+	vetoedBy, err := vetoFromComments(comments, a.vetoUsers)
+	if err != nil {
+		return "", fmt.Errorf("error checking for a veto: %w", err)
+	}
+	eligibleApprovers := a.activeApprovers(approvers, time.Now())
+	if vetoedBy != "" {
+		status = approvalStatusDenied
+	} else {
+		if a.writeAccess {
+			eligibleApprovers, err = a.writeAccessApprovers(ctx, comments, approvers)
+			if err != nil {
+				return "", fmt.Errorf("error getting approvers with write access: %w", err)
+			}
+		}
+		status, _, err = approvalFromComments(comments, eligibleApprovers, minimumApprovals, a.mutlipleDeploymentNames, a.requirements...)
+		if err != nil {
+			return "", fmt.Errorf("error getting approval from comments: %w", err)
+		}
+	}
+
+	pending := pendingApprovers(comments, eligibleApprovers)
+	body := companionProgressComment(status, pending, len(eligibleApprovers)-len(pending), minimumApprovals)
+	if existing := findProgressComment(comments, a.approvalIssue.GetUser().GetLogin()); existing == nil {
+		if _, _, err := a.client.Issues.CreateComment(ctx, a.repoOwner, a.repo, a.approvalIssueNumber, &github.IssueComment{
+			Body: &body,
+		}); err != nil {
+			return "", fmt.Errorf("error commenting on issue: %w", err)
+		}
+	} else if existing.GetBody() != body {
+		if _, _, err := a.client.Issues.EditComment(ctx, a.repoOwner, a.repo, existing.GetID(), &github.IssueComment{
+			Body: &body,
+		}); err != nil {
+			return "", fmt.Errorf("error updating progress comment: %w", err)
+		}
+	}
+
+	if status == approvalStatusApproved || status == approvalStatusDenied {
+		if err := a.ensureLabel(ctx, companionLabel, "c5def5", "The manual approval was decided, the waiting workflow picks it up"); err != nil {
+			return "", err
+		}
+		if _, _, err := a.client.Issues.AddLabelsToIssue(ctx, a.repoOwner, a.repo, a.approvalIssueNumber, []string{companionLabel}); err != nil {
+			return "", fmt.Errorf("error labeling issue: %w", err)
+		}
+	}
+	return status, nil
+}
+
+// issueChanged reports whether the approval issue changed since it was last
+// checked, such as by a new comment or a label. It uses a conditional
+// request, which doesn't count against the rate limit when nothing changed.
+// The first check only records the current version of the issue.
+func (a *approvalEnvironment) issueChanged(ctx context.Context) (bool, error) {
+	req, err := a.client.NewRequest("GET", fmt.Sprintf("repos/%s/%s/issues/%d", a.repoOwner, a.repo, a.approvalIssueNumber), nil)
+	if err != nil {
+		return false, err
+	}
+	if a.issueETag != "" {
+		req.Header.Set("If-None-Match", a.issueETag)
+	}
+	resp, err := a.client.Do(ctx, req, nil)
+	if resp != nil && resp.StatusCode == http.StatusNotModified {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	first := a.issueETag == ""
+	a.issueETag = resp.Header.Get("ETag")
+	return !first, nil
+}
@@ -0,0 +1,79 @@
+package main
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/google/go-github/v43/github"
+)
+
+func TestCompanionProgressComment(t *testing.T) {
+	testCases := []struct {
+		name     string
+		status   approvalStatus
+		pending  []string
+		expected string
+	}{
+		{name: "pending", status: approvalStatusPending, pending: []string{"user2", "user3"}, expected: "1 of 2 required approvals. Waiting for @user2, @user3."},
+		{name: "approved", status: approvalStatusApproved, expected: "Approved, the waiting workflow continues shortly."},
+		{name: "denied", status: approvalStatusDenied, pending: []string{"user2"}, expected: "Denied, the waiting workflow fails shortly."},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			actual := companionProgressComment(testCase.status, testCase.pending, 1, 2)
+			if !strings.HasPrefix(actual, companionProgressMarker) || !strings.HasSuffix(actual, testCase.expected) {
+				t.Fatalf("expected progress %q but got %q", testCase.expected, actual)
+			}
+		})
+	}
+}
+
+func TestFindProgressComment(t *testing.T) {
+	comments := []*github.IssueComment{
+		{ID: github.Int64(1), Body: github.String(companionProgressMarker + "\nforged"), User: &github.User{Login: github.String("user1")}},
+		{ID: github.Int64(2), Body: github.String("approved"), User: &github.User{Login: github.String("github-actions[bot]")}},
+		{ID: github.Int64(3), Body: github.String(companionProgressMarker + "\nprogress"), User: &github.User{Login: github.String("github-actions[bot]")}},
+	}
+	if comment := findProgressComment(comments, "github-actions[bot]"); comment.GetID() != 3 {
+		t.Fatalf("expected comment 3 but got %d", comment.GetID())
+	}
+	if comment := findProgressComment(comments[:2], "github-actions[bot]"); comment != nil {
+		t.Fatalf("expected no progress comment but got %d", comment.GetID())
+	}
+}
+
+func TestIssueChanged(t *testing.T) {
+	etag := `"1"`
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("If-None-Match") == etag {
+			w.WriteHeader(http.StatusNotModified)
+			return
+		}
+		w.Header().Set("ETag", etag)
+		w.Write([]byte(`{"number": 1}`))
+	}))
+	defer server.Close()
+
+	client := github.NewClient(nil)
+	client.BaseURL, _ = url.Parse(server.URL + "/")
+	apprv := &approvalEnvironment{client: wrapGithubClient(client), repoOwner: "owner", repo: "repo", approvalIssueNumber: 1}
+
+	expected := []bool{false, false, true, false}
+	for i, want := range expected {
+		if i == 2 {
+			etag = `"2"`
+		}
+		changed, err := apprv.issueChanged(context.Background())
+		if err != nil {
+			t.Fatalf("check %d: unexpected error: %v", i, err)
+		}
+		if changed != want {
+			t.Fatalf("check %d: expected changed %t but got %t", i, want, changed)
+		}
+	}
+}
@@ -0,0 +1,152 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/google/go-github/v43/github"
+	"github.com/trstringer/manual-approval/pkg/approval"
+)
+
+const (
+	// confirmationsComment confirms each counted approval in a new comment.
+	confirmationsComment string = "comment"
+	// confirmationsEdit confirms the counted approvals in a single comment
+	// that is edited as they change.
+	confirmationsEdit string = "edit"
+	// confirmationsNone doesn't confirm approvals.
+	confirmationsNone string = "none"
+)
+
+// countedApproval is an approval comment that counts towards the gate.
+type countedApproval struct {
+	approver   string
+	commentID  int64
+	approvedAt time.Time
+}
+
+// countedApprovals returns the latest approval of each approver whose latest
+// decision is an approval, in the order they approved. Approvals whose
+// deployment names can't be read aren't counted.
+func countedApprovals(comments []*github.IssueComment, approvers []string, deploymentNames []string) []countedApproval {
+	var counted []countedApproval
+	remove := func(login string) {
+		for i, approval := range counted {
+			if strings.EqualFold(approval.approver, login) {
+				counted = append(counted[:i], counted[i+1:]...)
+				return
+			}
+		}
+	}
+	for _, comment := range comments {
+		login := comment.User.GetLogin()
+		if approversIndex(approvers, login) < 0 {
+			continue
+		}
+		parsed, err := approval.ParseComment(comment.GetBody(), deploymentNames)
+		var parseErr *approval.ParseError
+		if errors.As(err, &parseErr) {
+			continue
+		}
+		if approved, _ := isApproved(parsed.Decision); approved {
+			remove(login)
+			counted = append(counted, countedApproval{approver: login, commentID: comment.GetID(), approvedAt: comment.GetCreatedAt()})
+		} else if denied, _ := isDenied(parsed.Decision); denied {
+			remove(login)
+		} else if revoked, _ := isRevoked(parsed.Decision); revoked {
+			remove(login)
+		}
+	}
+	return counted
+}
+
+func confirmationLine(approval countedApproval, count, required int) string {
+	return fmt.Sprintf("Approval from @%s recorded \u2014 %d of %d required.", approval.approver, count, required)
+}
+
+// confirmationsSummary lists the counted approvals in the comment that
+// confirmationsEdit edits.
+func confirmationsSummary(counted []countedApproval, required int) string {
+	if len(counted) == 0 {
+		return fmt.Sprintf("No approvals recorded \u2014 0 of %d required.", required)
+	}
+	lines := make([]string, len(counted))
+	for i, approval := range counted {
+		lines[i] = "- " + confirmationLine(approval, i+1, required)
+	}
+	return strings.Join(lines, "\n")
+}
+
+// confirmApprovals acknowledges the approvals counted since the last poll,
+// so that approvers know their response was accepted. The confirmed
+// approvals are kept in the gate state.
+func (a *approvalEnvironment) confirmApprovals(ctx context.Context, comments []*github.IssueComment, approvers []string, required int) error {
+	if a.approvalConfirmations == confirmationsNone || a.approvalIssue == nil || a.sharedIssueFollower {
+		return nil
+	}
+	counted := countedApprovals(comments, approvers, a.mutlipleDeploymentNames)
+	confirmed := map[int64]bool{}
+	for _, id := range a.gateState.ConfirmedApprovals {
+		confirmed[id] = true
+	}
+	state := a.gateState
+
+	if a.approvalConfirmations == confirmationsEdit {
+		ids := make([]int64, len(counted))
+		for i, approval := range counted {
+			ids[i] = approval.commentID
+		}
+		if sameCommentIDs(ids, state.ConfirmedApprovals) {
+			return nil
+		}
+		body := confirmationsSummary(counted, required)
+		if state.ConfirmationCommentID == 0 {
+			comment, _, err := a.client.Issues.CreateComment(ctx, a.repoOwner, a.repo, a.approvalIssueNumber, &github.IssueComment{
+				Body: &body,
+			})
+			if err != nil {
+				return err
+			}
+			state.ConfirmationCommentID = comment.GetID()
+		} else if _, _, err := a.client.Issues.EditComment(ctx, a.repoOwner, a.repo, state.ConfirmationCommentID, &github.IssueComment{
+			Body: &body,
+		}); err != nil {
+			return err
+		}
+		state.ConfirmedApprovals = ids
+		return a.writeGateState(ctx, state)
+	}
+
+	changed := false
+	for i, approval := range counted {
+		// Reviews converted to comments have no ID to record, and GitHub
+		// already shows them on the pull request.
+		if approval.commentID == 0 || confirmed[approval.commentID] {
+			continue
+		}
+		if err := a.postStatusComment(ctx, confirmationLine(approval, i+1, required)); err != nil {
+			return err
+		}
+		state.ConfirmedApprovals = append(state.ConfirmedApprovals, approval.commentID)
+		changed = true
+	}
+	if !changed {
+		return nil
+	}
+	return a.writeGateState(ctx, state)
+}
+
+func sameCommentIDs(a, b []int64) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
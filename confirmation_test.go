@@ -0,0 +1,106 @@
+package main
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/google/go-github/v43/github"
+)
+
+func TestCountedApprovals(t *testing.T) {
+	comment := func(id int64, login, body string) *github.IssueComment {
+		return &github.IssueComment{ID: github.Int64(id), User: &github.User{Login: github.String(login)}, Body: github.String(body)}
+	}
+	comments := []*github.IssueComment{
+		comment(1, "alice", "approve"),
+		comment(2, "bob", "approve[prdo]"),
+		comment(3, "carol", "approve[prod]"),
+		comment(4, "carol", "revoke"),
+		comment(5, "dave", "lgtm [prod]"),
+		comment(6, "mallory", "approve"),
+		comment(7, "carol", "approve[prod]\nrechecked"),
+	}
+	expected := []countedApproval{
+		{approver: "alice", commentID: 1},
+		{approver: "dave", commentID: 5},
+		{approver: "carol", commentID: 7},
+	}
+	actual := countedApprovals(comments, []string{"alice", "bob", "carol", "dave"}, []string{"prod"})
+	if len(actual) != len(expected) {
+		t.Fatalf("expected %v but got %v", expected, actual)
+	}
+	for i := range actual {
+		if actual[i] != expected[i] {
+			t.Fatalf("expected %v but got %v", expected, actual)
+		}
+	}
+}
+
+func TestConfirmApprovals(t *testing.T) {
+	testCases := []struct {
+		mode     string
+		expected []string
+	}{
+		{
+			mode: confirmationsComment,
+			expected: []string{
+				"Approval from @user1 recorded \u2014 1 of 2 required.",
+				"Approval from @user2 recorded \u2014 2 of 2 required.",
+			},
+		},
+		{
+			mode: confirmationsEdit,
+			expected: []string{
+				"- Approval from @user1 recorded \u2014 1 of 2 required.\n- Approval from @user2 recorded \u2014 2 of 2 required.",
+			},
+		},
+		{
+			mode: confirmationsNone,
+		},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.mode, func(t *testing.T) {
+			ctx := context.Background()
+			fake := newFakeGitHub()
+			approvers := []string{"user1", "user2", "user3"}
+			apprv, err := newApprovalEnvironment(fake.client(), "owner/repo", "owner", 1, approvers, 2, nil)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			apprv.approvalConfirmations = testCase.mode
+			if err := apprv.createApprovalIssue(ctx); err != nil {
+				t.Fatalf("error creating approval issue: %v", err)
+			}
+
+			for _, approver := range []string{"user1", "user2"} {
+				fake.comment(apprv.approvalIssueNumber, approver, "approve")
+				// Polling twice must not confirm an approval again.
+				for i := 0; i < 2; i++ {
+					comments, err := apprv.approvalComments(ctx)
+					if err != nil {
+						t.Fatalf("error getting comments: %v", err)
+					}
+					if err := apprv.confirmApprovals(ctx, comments, approvers, 2); err != nil {
+						t.Fatalf("error confirming approvals: %v", err)
+					}
+				}
+			}
+
+			if len(testCase.expected) > 0 && len(apprv.gateState.ConfirmedApprovals) != 2 {
+				t.Fatalf("expected the confirmed approvals to be saved but got %v", apprv.gateState)
+			}
+
+			var actual []string
+			for _, comment := range fake.comments[apprv.approvalIssueNumber] {
+				if comment.User.GetType() == "Bot" && !strings.HasPrefix(comment.GetBody(), gateStatePrefix) {
+					actual = append(actual, comment.GetBody())
+				}
+			}
+			if strings.Join(actual, "|") != strings.Join(testCase.expected, "|") {
+				t.Fatalf("expected %q but got %q", testCase.expected, actual)
+			}
+		})
+	}
+}
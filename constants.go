@@ -1,20 +1,213 @@
 package main
 
-import "time"
+import (
+	"time"
+
+	"github.com/trstringer/manual-approval/pkg/approval"
+)
 
 const (
 	pollingInterval time.Duration = 10 * time.Second
+	// minPollingInterval is the polling interval right after a comment, and
+	// maxPollingInterval the one the interval backs off to while the approval
+	// issue is quiet.
+	minPollingInterval time.Duration = 5 * time.Second
+	maxPollingInterval time.Duration = 60 * time.Second
+	// commentResyncPolls is how many polls read only new comments before all
+	// comments are read again, in case an edit or deletion was missed.
+	commentResyncPolls int = 6
+	// fallbackPollingInterval is the polling interval while webhooks or the
+	// companion signal tell the gate when to poll, in case a signal is lost.
+	fallbackPollingInterval time.Duration = 5 * time.Minute
+	// pollingJitter is the fraction by which each polling interval is
+	// randomly lengthened or shortened.
+	pollingJitter float64 = 0.2
+	// interruptTimeout bounds closing the approval request after the run is
+	// cancelled, as the runner kills the action soon after signalling it.
+	interruptTimeout time.Duration = 5 * time.Second
 
-	envVarRepoFullName         string = "GITHUB_REPOSITORY"
-	envVarRunID                string = "GITHUB_RUN_ID"
-	envVarRepoOwner            string = "GITHUB_REPOSITORY_OWNER"
-	envVarToken                string = "INPUT_SECRET"
-	envVarApprovers            string = "INPUT_APPROVERS"
-	envVarMinimumApprovals     string = "INPUT_MINIMUM-APPROVALS"
-	envMultipleDeploymentNames string = "INPUT_MULTIPLE-DEPLOYMENT-NAMES"
-)
+	// rateLimitReserve is the quota kept for resolving the approval request
+	// when throttling polls to the rate limit.
+	rateLimitReserve int = 20
+	// rateLimitWarningFraction is the share of a rate limit left when a
+	// warning is emitted.
+	rateLimitWarningFraction float64 = 0.2
+
+	// maxIssueAssignees is the most assignees GitHub accepts on an issue.
+	maxIssueAssignees int = 10
+	// maxIssueBodyLength is the longest issue body GitHub accepts.
+	maxIssueBodyLength int = 65536
+	// issueBodyReserve is kept free when truncating content added to the
+	// issue body, for the parts of the body that follow it.
+	issueBodyReserve int = 2048
+	// collapseLineThreshold is the number of lines above which content
+	// embedded in the approval issue, such as the changed files, is
+	// collapsed.
+	collapseLineThreshold int = 20
+	// maxFollowUpComments limits how many comments are used for content that
+	// doesn't fit in the approval issue body.
+	maxFollowUpComments int = 10
 
-var (
-	approvedWords = []string{"approved", "approve", "lgtm", "yes"}
-	deniedWords   = []string{"denied", "deny", "no"}
+	envVarRepoFullName             string = "GITHUB_REPOSITORY"
+	envVarRunID                    string = "GITHUB_RUN_ID"
+	envVarRunAttempt               string = "GITHUB_RUN_ATTEMPT"
+	envVarRepoOwner                string = "GITHUB_REPOSITORY_OWNER"
+	envVarSHA                      string = "GITHUB_SHA"
+	envVarActor                    string = "GITHUB_ACTOR"
+	envVarRefName                  string = "GITHUB_REF_NAME"
+	envVarHeadRef                  string = "GITHUB_HEAD_REF"
+	envVarBaseRef                  string = "GITHUB_BASE_REF"
+	envVarEventPath                string = "GITHUB_EVENT_PATH"
+	envVarEventName                string = "GITHUB_EVENT_NAME"
+	envVarWorkflow                 string = "GITHUB_WORKFLOW"
+	envVarJob                      string = "GITHUB_JOB"
+	envVarWorkflowRef              string = "GITHUB_WORKFLOW_REF"
+	envVarOIDCRequestURL           string = "ACTIONS_ID_TOKEN_REQUEST_URL"
+	envVarStepSummary              string = "GITHUB_STEP_SUMMARY"
+	envVarOutput                   string = "GITHUB_OUTPUT"
+	envVarOIDCRequestToken         string = "ACTIONS_ID_TOKEN_REQUEST_TOKEN"
+	envVarGitHubActions            string = "GITHUB_ACTIONS"
+	envVarToken                    string = "INPUT_SECRET"
+	envVarApprovers                string = "INPUT_APPROVERS"
+	envVarApproversFile            string = "INPUT_APPROVERS-FILE"
+	envVarOrgConfig                string = "INPUT_ORG-CONFIG"
+	envVarEnvironment              string = "INPUT_ENVIRONMENT"
+	envVarMinimumApprovals         string = "INPUT_MINIMUM-APPROVALS"
+	envMultipleDeploymentNames     string = "INPUT_MULTIPLE-DEPLOYMENT-NAMES"
+	envVarGateName                 string = "INPUT_GATE-NAME"
+	envVarApprovalCache            string = "INPUT_APPROVAL-CACHE"
+	envVarBypassActors             string = "INPUT_BYPASS-ACTORS"
+	envVarBypassBranches           string = "INPUT_BYPASS-BRANCHES"
+	envVarAutoApproveLabel         string = "INPUT_AUTO-APPROVE-LABEL"
+	envVarReviewMode               string = "INPUT_REVIEW-MODE"
+	envVarPullRequestComment       string = "INPUT_PULL-REQUEST-COMMENT"
+	envVarDiscussionCategory       string = "INPUT_DISCUSSION-CATEGORY"
+	envVarCheckRunName             string = "INPUT_CHECK-RUN-NAME"
+	envVarCommitStatusContext      string = "INPUT_COMMIT-STATUS-CONTEXT"
+	envVarCreateDeployment         string = "INPUT_CREATE-DEPLOYMENT"
+	envVarDeploymentEnvironment    string = "INPUT_DEPLOYMENT-ENVIRONMENT"
+	envVarDeploymentStatus         string = "INPUT_DEPLOYMENT-STATUS"
+	envVarDeploymentIDs            string = "INPUT_DEPLOYMENT-IDS"
+	envVarDispatchSecret           string = "INPUT_DISPATCH-SECRET"
+	envVarCreateIssue              string = "INPUT_CREATE-ISSUE"
+	envVarEmergencyDispatchType    string = "INPUT_EMERGENCY-DISPATCH-TYPE"
+	envVarEmergencySenders         string = "INPUT_EMERGENCY-SENDERS"
+	envVarApproveLabel             string = "INPUT_APPROVE-LABEL"
+	envVarDenyLabel                string = "INPUT_DENY-LABEL"
+	envVarCloseDecisions           string = "INPUT_CLOSE-DECISIONS"
+	envVarIgnoreEditsAfterApproval string = "INPUT_IGNORE-EDITS-AFTER-APPROVAL"
+	envVarBotApprovers             string = "INPUT_BOT-APPROVERS"
+	envVarApproversURL             string = "INPUT_APPROVERS-URL"
+	envVarApproversURLAuthHeader   string = "INPUT_APPROVERS-URL-AUTH-HEADER"
+	envVarOnCallProvider           string = "INPUT_ONCALL-PROVIDER"
+	envVarOnCallAPIKey             string = "INPUT_ONCALL-API-KEY"
+	envVarOnCallScheduleID         string = "INPUT_ONCALL-SCHEDULE-ID"
+	envVarOnCallUserMap            string = "INPUT_ONCALL-USER-MAP"
+	envVarWriteAccessApprovers     string = "INPUT_WRITE-ACCESS-APPROVERS"
+	envVarPathApprovers            string = "INPUT_PATH-APPROVERS"
+	envVarDistinctTeams            string = "INPUT_DISTINCT-TEAMS"
+	envVarVetoUsers                string = "INPUT_VETO-USERS"
+	envVarMentionOnly              string = "INPUT_MENTION-ONLY"
+	envVarIssueTitle               string = "INPUT_ISSUE-TITLE"
+	envVarBodyFile                 string = "INPUT_BODY-FILE"
+	envVarMilestone                string = "INPUT_MILESTONE"
+	envVarProjectNumber            string = "INPUT_PROJECT-NUMBER"
+	envVarProjectOwner             string = "INPUT_PROJECT-OWNER"
+	envVarProjectStatus            string = "INPUT_PROJECT-STATUS"
+	envVarProjectDoneStatus        string = "INPUT_PROJECT-DONE-STATUS"
+	envVarIssueTemplate            string = "INPUT_ISSUE-TEMPLATE"
+	envVarIssueTitlePrefix         string = "INPUT_ISSUE-TITLE-PREFIX"
+	envVarMode                     string = "INPUT_MODE"
+	envVarSupersedeOlderIssues     string = "INPUT_SUPERSEDE-OLDER-ISSUES"
+	envVarSharedIssue              string = "INPUT_SHARED-ISSUE"
+	envVarRerunBehavior            string = "INPUT_RERUN-BEHAVIOR"
+	envVarIssueNumber              string = "INPUT_ISSUE-NUMBER"
+	envVarSimulateFixture          string = "INPUT_SIMULATE-FIXTURE"
+	envVarProxyURL                 string = "INPUT_PROXY-URL"
+	envVarCACertificates           string = "INPUT_CA-CERTIFICATES"
+	envVarAppID                    string = "INPUT_APP-ID"
+	envVarAppPrivateKey            string = "INPUT_APP-PRIVATE-KEY"
+	envVarAppInstallationID        string = "INPUT_APP-INSTALLATION-ID"
+	envVarMaxWait                  string = "INPUT_MAX-WAIT"
+	envVarMaxPolls                 string = "INPUT_MAX-POLLS"
+	envVarWebhookAddress           string = "INPUT_WEBHOOK-ADDRESS"
+	envVarWebhookSecret            string = "INPUT_WEBHOOK-SECRET"
+	envVarCompanionSignal          string = "INPUT_COMPANION-SIGNAL"
+	envVarPushgatewayURL           string = "INPUT_PUSHGATEWAY-URL"
+	envVarPushgatewayJob           string = "INPUT_PUSHGATEWAY-JOB"
+	envVarStatsdAddress            string = "INPUT_STATSD-ADDRESS"
+	envVarStatsdPrefix             string = "INPUT_STATSD-PREFIX"
+	envVarStatsdFormat             string = "INPUT_STATSD-FORMAT"
+	envVarAuditFile                string = "INPUT_AUDIT-FILE"
+	envVarDecisionCheckRun         string = "INPUT_DECISION-CHECK-RUN"
+	envVarAttestationFile          string = "INPUT_ATTESTATION-FILE"
+	envVarAttestationPublish       string = "INPUT_ATTESTATION-PUBLISH"
+	envVarFulcioURL                string = "INPUT_FULCIO-URL"
+	envVarRekorURL                 string = "INPUT_REKOR-URL"
+	envVarReceiptSecret            string = "INPUT_RECEIPT-SECRET"
+	envVarLocale                   string = "INPUT_LOCALE"
+	envVarApprovedWords            string = "INPUT_APPROVED-WORDS"
+	envVarDeniedWords              string = "INPUT_DENIED-WORDS"
+	envVarMatchMode                string = "INPUT_MATCH-MODE"
+	envVarApprovalConfirmations    string = "INPUT_APPROVAL-CONFIRMATIONS"
+	envVarMentionPending           string = "INPUT_MENTION-PENDING"
+	envVarSelectApprovers          string = "INPUT_SELECT-APPROVERS"
+	envVarSelectApproversFallback  string = "INPUT_SELECT-APPROVERS-FALLBACK"
+	envVarSelectStrategy           string = "INPUT_SELECT-STRATEGY"
+	envVarAvailabilityFile         string = "INPUT_AVAILABILITY-FILE"
+	envVarAvailabilityURL          string = "INPUT_AVAILABILITY-URL"
+	envVarAvailabilityEscalation   string = "INPUT_AVAILABILITY-ESCALATION"
+	envVarSLA                      string = "INPUT_SLA"
+	envVarExitZero                 string = "INPUT_EXIT-ZERO"
+	envVarExitCodeApproved         string = "INPUT_EXIT-CODE-APPROVED"
+	envVarExitCodeDenied           string = "INPUT_EXIT-CODE-DENIED"
+	envVarExitCodeTimedOut         string = "INPUT_EXIT-CODE-TIMED-OUT"
+	envVarExitCodeError            string = "INPUT_EXIT-CODE-ERROR"
+	envVarOnDenial                 string = "INPUT_ON-DENIAL"
+	envVarDeleteIssueOnCompletion  string = "INPUT_DELETE-ISSUE-ON-COMPLETION"
+	envVarMinimizeComments         string = "INPUT_MINIMIZE-COMMENTS"
+	envVarStatusLabels             string = "INPUT_STATUS-LABELS"
+	envVarDeploymentAliases        string = "INPUT_DEPLOYMENT-ALIASES"
+	envVarDeploymentApprovers      string = "INPUT_DEPLOYMENT-APPROVERS"
+	envVarPartialApproval          string = "INPUT_PARTIAL-APPROVAL"
+
+	// modeGate creates an approval request and waits for its decision.
+	modeGate string = "gate"
+	// modeCreate only creates the approval issue, for a later job to wait
+	// for in modeWait and close in modeResolve.
+	modeCreate string = "create"
+	// modeWait waits for the decision on an approval issue created in
+	// modeCreate, leaving it open.
+	modeWait string = "wait"
+	// modeResolve closes an approval issue created in modeCreate with the
+	// decision made on it.
+	modeResolve string = "resolve"
+	// modeCleanup closes approval issues whose workflow runs are no longer
+	// waiting for them.
+	modeCleanup string = "cleanup"
+	// modeSimulate prints how the comments of a fixture file would resolve
+	// the gate, without calling GitHub.
+	modeSimulate string = "simulate"
+	// modeCompanion records the progress of the gate on its approval issue
+	// from a workflow triggered by a comment on it, and signals the waiting
+	// gate once it is decided.
+	modeCompanion string = "companion"
+
+	// companionLabel is applied to the approval issue by modeCompanion once
+	// the gate is decided.
+	companionLabel string = "manual-approval-decided"
+	// slaBreachedLabel is applied to the approval issue once it was pending
+	// past its SLA.
+	slaBreachedLabel string = "manual-approval-sla-breached"
+
+	// approvalCacheSearchPages bounds how many pages of closed issues are
+	// scanned when looking for a previous approval of the same commit.
+	approvalCacheSearchPages int = 5
+	// cleanupSearchPages bounds how many pages of open issues are scanned
+	// for stale approval issues.
+	cleanupSearchPages int = 10
 )
+
+// gateWords are the words that decide the gate, configured from the
+// locale, custom words and match mode inputs.
+var gateWords = approval.DefaultWords()
@@ -0,0 +1,77 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/google/go-github/v43/github"
+	"github.com/trstringer/manual-approval/pkg/approval"
+)
+
+// debugf logs a workflow ::debug:: message, which the runner only shows when
+// step debug logging is enabled with the ACTIONS_STEP_DEBUG secret.
+func debugf(format string, args ...interface{}) {
+	message := fmt.Sprintf(format, args...)
+	// Workflow commands end at the first newline, so the message is escaped
+	// the way the runner expects.
+	message = strings.NewReplacer("%", "%25", "\r", "%0D", "\n", "%0A").Replace(message)
+	fmt.Printf("::debug::%s\n", message)
+}
+
+// describeComment explains how a comment is read on its own: whose it is
+// and which decision, if any, it expresses.
+func describeComment(comment *github.IssueComment, approvers []string, multipleDeploymentNames []string) string {
+	login := comment.User.GetLogin()
+	body := comment.GetBody()
+	if approversIndex(approvers, login) < 0 {
+		return fmt.Sprintf("ignored, %s is not an approver", login)
+	}
+	decision, err := commentDecision(body, multipleDeploymentNames)
+	if err != nil {
+		return fmt.Sprintf("unreadable: %v", err)
+	}
+	if decision == "" {
+		return "ignored, no decision word matched"
+	}
+	return decision
+}
+
+// commentDecision returns the decision a comment expresses, such as
+// "approval", or "" if no decision word matched.
+func commentDecision(body string, multipleDeploymentNames []string) (string, error) {
+	parsed, err := approval.ParseComment(body, multipleDeploymentNames)
+	if err != nil {
+		return "", err
+	}
+	body = parsed.Decision
+	checks := []struct {
+		decision string
+		matches  func(string) (bool, error)
+	}{
+		{"approval", isApproved},
+		{"denial", isDenied},
+		{"revocation", isRevoked},
+		{"hold", isHold},
+		{"lifted hold", isUnhold},
+	}
+	for _, check := range checks {
+		matched, err := check.matches(body)
+		if err != nil {
+			return "", err
+		}
+		if matched {
+			return check.decision, nil
+		}
+	}
+	return "", nil
+}
+
+// debugComments logs how each comment of a poll was read and which
+// approvers are still pending.
+func debugComments(comments []*github.IssueComment, approvers []string, multipleDeploymentNames []string) {
+	debugf("Fetched %d comments", len(comments))
+	for _, comment := range comments {
+		debugf("Comment %d from %s %q: %s", comment.GetID(), comment.User.GetLogin(), comment.GetBody(), describeComment(comment, approvers, multipleDeploymentNames))
+	}
+	debugf("Approvers still pending: %s", strings.Join(pendingApprovers(comments, approvers), ", "))
+}
@@ -0,0 +1,36 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/google/go-github/v43/github"
+)
+
+func TestDescribeComment(t *testing.T) {
+	approvers := []string{"login1"}
+
+	testCases := []struct {
+		name     string
+		login    string
+		body     string
+		expected string
+	}{
+		{name: "approval", login: "login1", body: "LGTM", expected: "approval"},
+		{name: "denial", login: "login1", body: "no", expected: "denial"},
+		{name: "hold", login: "login1", body: "/hold", expected: "hold"},
+		{name: "not_an_approver", login: "login2", body: "approved", expected: "ignored, login2 is not an approver"},
+		{name: "no_decision", login: "login1", body: "looks good to me", expected: "ignored, no decision word matched"},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			comment := &github.IssueComment{
+				User: &github.User{Login: github.String(testCase.login)},
+				Body: github.String(testCase.body),
+			}
+			if actual := describeComment(comment, approvers, nil); actual != testCase.expected {
+				t.Fatalf("expected %q but got %q", testCase.expected, actual)
+			}
+		})
+	}
+}
@@ -0,0 +1,120 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/google/go-github/v43/github"
+)
+
+// decisionCheckRunName is the name of the check run the decision is
+// recorded on, after the gate if it has a name.
+func decisionCheckRunName(gateName string) string {
+	if gateName == "" {
+		return "Manual approval"
+	}
+	return fmt.Sprintf("Manual approval: %s", gateName)
+}
+
+// workflowFilePath returns the path of the workflow file in the repository
+// from a workflow reference such as
+// owner/repo/.github/workflows/deploy.yml@refs/heads/main.
+func workflowFilePath(workflowRef, repoFullName string) string {
+	path := strings.TrimPrefix(workflowRef, repoFullName+"/")
+	if i := strings.LastIndex(path, "@"); i >= 0 {
+		path = path[:i]
+	}
+	if path == workflowRef || !strings.HasPrefix(path, ".github/workflows/") {
+		return ""
+	}
+	return path
+}
+
+// decisionCheckRunOutput describes the decision for the check run. An
+// annotation on the workflow file, if it is known, shows the decision in the
+// commit view.
+func (a *approvalEnvironment) decisionCheckRunOutput(status approvalStatus, workflowPath string) *github.CheckRunOutput {
+	title := fmt.Sprintf("Manual approval %s", strings.ToLower(string(status)))
+	if status == approvalStatusTimedOut {
+		title = "Manual approval timed out"
+	}
+
+	lines := []string{fmt.Sprintf("**Decision:** %s", strings.ToLower(string(status)))}
+	if len(a.metrics.approvedBy) > 0 {
+		lines = append(lines, fmt.Sprintf("**Approved by:** %s", mentionList(a.metrics.approvedBy)))
+	}
+	if len(a.metrics.deniedBy) > 0 {
+		lines = append(lines, fmt.Sprintf("**Denied by:** %s", mentionList(a.metrics.deniedBy)))
+	}
+	if a.approvalIssue != nil {
+		lines = append(lines, fmt.Sprintf("**Approval issue:** %s", a.approvalIssue.GetHTMLURL()))
+	}
+	lines = append(lines, fmt.Sprintf("**Workflow run:** %s", a.runURL()))
+	summary := strings.Join(lines, "\n\n")
+
+	output := &github.CheckRunOutput{Title: &title, Summary: &summary}
+	if workflowPath != "" {
+		level := "notice"
+		switch status {
+		case approvalStatusDenied:
+			level = "failure"
+		case approvalStatusTimedOut, approvalStatusCancelled:
+			level = "warning"
+		}
+		message := strings.Join(lines, "\n")
+		output.Annotations = []*github.CheckRunAnnotation{{
+			Path:            github.String(workflowPath),
+			StartLine:       github.Int(1),
+			EndLine:         github.Int(1),
+			AnnotationLevel: github.String(level),
+			Title:           &title,
+			Message:         github.String(strings.ReplaceAll(message, "**", "")),
+		}}
+	}
+	return output
+}
+
+// recordDecisionCheckRun records the decision as a completed check run on
+// the commit, updating the check run of an earlier attempt of the gate if
+// there is one.
+func (a *approvalEnvironment) recordDecisionCheckRun(ctx context.Context, status approvalStatus, conclusion string) error {
+	name := decisionCheckRunName(a.gateName)
+	output := a.decisionCheckRunOutput(status, a.workflowPath)
+	completedAt := &github.Timestamp{Time: time.Now()}
+
+	existing, _, err := a.client.Checks.ListCheckRunsForRef(ctx, a.repoOwner, a.repo, a.sha, &github.ListCheckRunsOptions{
+		CheckName: &name,
+	})
+	if err != nil {
+		return err
+	}
+	for _, checkRun := range existing.CheckRuns {
+		// The check run of the approval buttons can have the same name, and
+		// is completed separately.
+		if checkRun.GetID() == a.checkRunID {
+			continue
+		}
+		_, _, err := a.client.Checks.UpdateCheckRun(ctx, a.repoOwner, a.repo, checkRun.GetID(), github.UpdateCheckRunOptions{
+			Name:        name,
+			DetailsURL:  github.String(a.runURL()),
+			Status:      github.String("completed"),
+			Conclusion:  &conclusion,
+			CompletedAt: completedAt,
+			Output:      output,
+		})
+		return err
+	}
+
+	_, _, err = a.client.Checks.CreateCheckRun(ctx, a.repoOwner, a.repo, github.CreateCheckRunOptions{
+		Name:        name,
+		HeadSHA:     a.sha,
+		DetailsURL:  github.String(a.runURL()),
+		Status:      github.String("completed"),
+		Conclusion:  &conclusion,
+		CompletedAt: completedAt,
+		Output:      output,
+	})
+	return err
+}
@@ -0,0 +1,119 @@
+package main
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/google/go-github/v43/github"
+)
+
+func TestWorkflowFilePath(t *testing.T) {
+	testCases := []struct {
+		name        string
+		workflowRef string
+		expected    string
+	}{
+		{
+			name:        "branch_ref",
+			workflowRef: "owner/repo/.github/workflows/deploy.yml@refs/heads/main",
+			expected:    ".github/workflows/deploy.yml",
+		},
+		{
+			name:        "tag_ref",
+			workflowRef: "owner/repo/.github/workflows/release.yaml@refs/tags/v1.0.0",
+			expected:    ".github/workflows/release.yaml",
+		},
+		{
+			name:        "reusable_workflow_in_other_repo",
+			workflowRef: "other/shared/.github/workflows/deploy.yml@refs/heads/main",
+			expected:    "",
+		},
+		{
+			name:        "empty",
+			workflowRef: "",
+			expected:    "",
+		},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			actual := workflowFilePath(testCase.workflowRef, "owner/repo")
+			if actual != testCase.expected {
+				t.Fatalf("expected %q but got %q", testCase.expected, actual)
+			}
+		})
+	}
+}
+
+func TestDecisionCheckRunOutput(t *testing.T) {
+	testCases := []struct {
+		name          string
+		status        approvalStatus
+		workflowPath  string
+		expectedTitle string
+		expectedLevel string
+	}{
+		{
+			name:          "approved",
+			status:        approvalStatusApproved,
+			workflowPath:  ".github/workflows/deploy.yml",
+			expectedTitle: "Manual approval approved",
+			expectedLevel: "notice",
+		},
+		{
+			name:          "denied",
+			status:        approvalStatusDenied,
+			workflowPath:  ".github/workflows/deploy.yml",
+			expectedTitle: "Manual approval denied",
+			expectedLevel: "failure",
+		},
+		{
+			name:          "timed_out",
+			status:        approvalStatusTimedOut,
+			workflowPath:  ".github/workflows/deploy.yml",
+			expectedTitle: "Manual approval timed out",
+			expectedLevel: "warning",
+		},
+		{
+			name:          "no_workflow_path",
+			status:        approvalStatusApproved,
+			expectedTitle: "Manual approval approved",
+		},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			a := approvalEnvironment{
+				repoFullName:  "owner/repo",
+				runID:         1,
+				approvalIssue: &github.Issue{HTMLURL: github.String("https://github.com/owner/repo/issues/3")},
+				metrics: gateMetrics{
+					approvedBy: []string{"login1"},
+					deniedBy:   []string{"login2"},
+				},
+			}
+			output := a.decisionCheckRunOutput(testCase.status, testCase.workflowPath)
+			if output.GetTitle() != testCase.expectedTitle {
+				t.Fatalf("expected title %q but got %q", testCase.expectedTitle, output.GetTitle())
+			}
+			for _, expected := range []string{"@login1", "@login2", "https://github.com/owner/repo/issues/3"} {
+				if !strings.Contains(output.GetSummary(), expected) {
+					t.Fatalf("expected summary to contain %q but got %q", expected, output.GetSummary())
+				}
+			}
+			if testCase.expectedLevel == "" {
+				if len(output.Annotations) != 0 {
+					t.Fatalf("expected no annotations but got %d", len(output.Annotations))
+				}
+				return
+			}
+			if len(output.Annotations) != 1 {
+				t.Fatalf("expected 1 annotation but got %d", len(output.Annotations))
+			}
+			annotation := output.Annotations[0]
+			if annotation.GetPath() != testCase.workflowPath || annotation.GetAnnotationLevel() != testCase.expectedLevel {
+				t.Fatalf("expected %s annotation on %s but got %s on %s", testCase.expectedLevel, testCase.workflowPath, annotation.GetAnnotationLevel(), annotation.GetPath())
+			}
+		})
+	}
+}
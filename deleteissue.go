@@ -0,0 +1,44 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+)
+
+const deleteIssueMutation = `mutation($issueId: ID!) {
+  deleteIssue(input: {issueId: $issueId}) {
+    clientMutationId
+  }
+}`
+
+// deleteApprovalIssue deletes the approval issue once it is resolved, for
+// teams that treat approval requests as ephemeral prompts. Deleting issues
+// needs a token of a repository admin.
+func (a *approvalEnvironment) deleteApprovalIssue(ctx context.Context) error {
+	nodeID := a.approvalIssue.GetNodeID()
+	if nodeID == "" {
+		return fmt.Errorf("approval issue #%d has no node ID", a.approvalIssueNumber)
+	}
+	if err := a.graphQL(ctx, deleteIssueMutation, map[string]interface{}{"issueId": nodeID}, nil); err != nil {
+		return fmt.Errorf("error deleting issue #%d: %w", a.approvalIssueNumber, err)
+	}
+	fmt.Printf("Deleted approval issue #%d\n", a.approvalIssueNumber)
+	return nil
+}
+
+// deleteIssueConflict returns the first input that keeps the approval issue
+// as a record of the decision, which deleting it would break, or "" if none
+// is set.
+func (a *approvalEnvironment) deleteIssueConflict(approvalCacheRaw string) string {
+	if a.auditFile != "" {
+		return "audit-file"
+	}
+	if a.attestationFile != "" {
+		return "attestation-file"
+	}
+	if approvalCache, _ := strconv.ParseBool(approvalCacheRaw); approvalCache {
+		return "approval-cache"
+	}
+	return ""
+}
@@ -0,0 +1,72 @@
+package main
+
+import (
+	"context"
+	"testing"
+)
+
+func TestResolveApprovalDeletesIssue(t *testing.T) {
+	ctx := context.Background()
+	fake := newFakeGitHub()
+	apprv, err := newApprovalEnvironment(fake.client(), "owner/repo", "owner", 1, []string{"user1"}, 1, nil)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	apprv.deleteIssue = true
+	if err := apprv.createApprovalIssue(ctx); err != nil {
+		t.Fatalf("error creating approval issue: %v", err)
+	}
+	fake.comment(apprv.approvalIssueNumber, "user1", "approve")
+
+	if err := apprv.resolveApproval(ctx, approvalStatusApproved, "Closing issue."); err != nil {
+		t.Fatalf("error resolving approval: %v", err)
+	}
+	if issue, ok := fake.issues[apprv.approvalIssueNumber]; ok {
+		t.Fatalf("expected the issue to be deleted but it is %s", issue.GetState())
+	}
+	if len(fake.deleted) != 1 || fake.deleted[0] != apprv.approvalIssueNumber {
+		t.Fatalf("expected issue #%d to be deleted, got %v", apprv.approvalIssueNumber, fake.deleted)
+	}
+}
+
+func TestDeleteIssueConflict(t *testing.T) {
+	testCases := []struct {
+		name             string
+		apprv            approvalEnvironment
+		approvalCacheRaw string
+		expected         string
+	}{
+		{
+			name:     "no_conflict",
+			expected: "",
+		},
+		{
+			name:     "audit_file",
+			apprv:    approvalEnvironment{auditFile: "approval.json"},
+			expected: "audit-file",
+		},
+		{
+			name:     "attestation_file",
+			apprv:    approvalEnvironment{attestationFile: "attestation.json"},
+			expected: "attestation-file",
+		},
+		{
+			name:             "approval_cache",
+			approvalCacheRaw: "true",
+			expected:         "approval-cache",
+		},
+		{
+			name:             "approval_cache_disabled",
+			approvalCacheRaw: "false",
+			expected:         "",
+		},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			if actual := testCase.apprv.deleteIssueConflict(testCase.approvalCacheRaw); actual != testCase.expected {
+				t.Fatalf("expected %q, got %q", testCase.expected, actual)
+			}
+		})
+	}
+}
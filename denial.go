@@ -0,0 +1,57 @@
+package main
+
+import (
+	"fmt"
+
+	"github.com/google/go-github/v43/github"
+	"github.com/trstringer/manual-approval/pkg/approval"
+)
+
+// The ways the gate ends on a denial. onDenialFail fails the job, and
+// onDenialSkip exits successfully with the approved output set to false, so
+// that the steps guarded by it are skipped and the job stays green.
+const (
+	onDenialFail = "fail"
+	onDenialSkip = "skip"
+)
+
+// denialDetails describes who denied the gate with comment, its note and
+// where to find it, for the closing comment and the failure message.
+func denialDetails(comment *github.IssueComment) string {
+	if comment == nil {
+		return ""
+	}
+	details := fmt.Sprintf(" by @%s", comment.User.GetLogin())
+	if _, note := approval.SplitNote(comment.GetBody()); note != "" {
+		details += fmt.Sprintf(": %q", note)
+	}
+	if url := comment.GetHTMLURL(); url != "" {
+		details += fmt.Sprintf(" (%s)", url)
+	}
+	return details
+}
+
+// denialClosing ends the closing comment of a denied gate with what happens
+// to the workflow.
+func (a *approvalEnvironment) denialClosing() string {
+	if a.onDenial == onDenialSkip {
+		return "Closing issue and skipping the steps that need approval."
+	}
+	return "Closing issue and failing workflow."
+}
+
+// reportDenial sets the denier and denial-comment-url outputs and annotates
+// the run with who denied it, as an error unless denials are skipped.
+func (a *approvalEnvironment) reportDenial(comment *github.IssueComment) {
+	annotation := "error"
+	if a.onDenial == onDenialSkip {
+		annotation = "notice"
+	}
+	if comment == nil {
+		fmt.Printf("::%s::The approval was denied\n", annotation)
+		return
+	}
+	setOutput("denier", comment.User.GetLogin())
+	setOutput("denial-comment-url", comment.GetHTMLURL())
+	fmt.Printf("::%s::The approval was denied%s\n", annotation, denialDetails(comment))
+}
@@ -0,0 +1,65 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/google/go-github/v43/github"
+)
+
+func TestDenialDetails(t *testing.T) {
+	url := "https://github.com/owner/repo/issues/1#issuecomment-2"
+	testCases := []struct {
+		name     string
+		comment  *github.IssueComment
+		expected string
+	}{
+		{
+			name:     "no_comment",
+			comment:  nil,
+			expected: "",
+		},
+		{
+			name:     "denier_and_url",
+			comment:  &github.IssueComment{User: &github.User{Login: github.String("user1")}, Body: github.String("deny"), HTMLURL: &url},
+			expected: " by @user1 (" + url + ")",
+		},
+		{
+			name:     "with_note",
+			comment:  &github.IssueComment{User: &github.User{Login: github.String("user1")}, Body: github.String("deny\nThe migration is not ready."), HTMLURL: &url},
+			expected: ` by @user1: "The migration is not ready." (` + url + ")",
+		},
+		{
+			name:     "without_url",
+			comment:  &github.IssueComment{User: &github.User{Login: github.String("user1")}, Body: github.String("deny")},
+			expected: " by @user1",
+		},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			actual := denialDetails(testCase.comment)
+			if actual != testCase.expected {
+				t.Fatalf("expected %q, got %q", testCase.expected, actual)
+			}
+		})
+	}
+}
+
+func TestDenialClosing(t *testing.T) {
+	testCases := []struct {
+		onDenial string
+		expected string
+	}{
+		{onDenial: onDenialFail, expected: "Closing issue and failing workflow."},
+		{onDenial: onDenialSkip, expected: "Closing issue and skipping the steps that need approval."},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.onDenial, func(t *testing.T) {
+			apprv := &approvalEnvironment{onDenial: testCase.onDenial}
+			if actual := apprv.denialClosing(); actual != testCase.expected {
+				t.Fatalf("expected %q, got %q", testCase.expected, actual)
+			}
+		})
+	}
+}
@@ -0,0 +1,89 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strconv"
+
+	"github.com/google/go-github/v43/github"
+)
+
+// createDeployments creates a deployment of the approved commit for each
+// approved deployment name, or for the configured deployment environment if
+// the gate does not use multiple deployment names, and marks each of them as
+// in progress. It returns the IDs of the created deployments.
+func (a *approvalEnvironment) createDeployments(ctx context.Context, deploymentNames []string) ([]int64, error) {
+	environments := deploymentNames
+	if len(environments) == 0 {
+		environments = []string{a.deploymentEnvironment}
+	}
+
+	var deploymentIDs []int64
+	for _, environment := range environments {
+		environment := environment
+		description := fmt.Sprintf("Approved in workflow run %d", a.runID)
+		deployment, _, err := a.client.Repositories.CreateDeployment(ctx, a.repoOwner, a.repo, &github.DeploymentRequest{
+			Ref:         &a.sha,
+			Environment: &environment,
+			Description: &description,
+			AutoMerge:   github.Bool(false),
+			// The gate's own commit status is still pending at this point, so
+			// commit status checks must not block the deployment.
+			RequiredContexts: &[]string{},
+		})
+		if err != nil {
+			return deploymentIDs, fmt.Errorf("error creating deployment for %s: %w", environment, err)
+		}
+		fmt.Printf("Created deployment %d for environment %s\n", deployment.GetID(), environment)
+		deploymentIDs = append(deploymentIDs, deployment.GetID())
+	}
+
+	if err := updateDeploymentStatuses(ctx, a.client, a.repoOwner, a.repo, deploymentIDs, "in_progress", a.runURL()); err != nil {
+		return deploymentIDs, err
+	}
+	return deploymentIDs, nil
+}
+
+// updateDeploymentStatuses sets the state of each deployment. This is used
+// both when the gate creates deployments and by later steps reporting the
+// result of the deployment.
+func updateDeploymentStatuses(ctx context.Context, client *githubClient, repoOwner, repo string, deploymentIDs []int64, state, logURL string) error {
+	for _, deploymentID := range deploymentIDs {
+		_, _, err := client.Repositories.CreateDeploymentStatus(ctx, repoOwner, repo, deploymentID, &github.DeploymentStatusRequest{
+			State:  &state,
+			LogURL: &logURL,
+		})
+		if err != nil {
+			return fmt.Errorf("error setting status of deployment %d: %w", deploymentID, err)
+		}
+		fmt.Printf("Set status of deployment %d to %s\n", deploymentID, state)
+	}
+	return nil
+}
+
+func setDeploymentIDsOutput(deploymentIDs []int64) {
+	if len(deploymentIDs) == 0 {
+		return
+	}
+	jsonDeploymentIDs, _ := json.Marshal(deploymentIDs)
+	setOutput("deployment-ids", string(jsonDeploymentIDs))
+	setOutput("deployment-id", fmt.Sprint(deploymentIDs[0]))
+}
+
+// parseDeploymentIDs accepts either a JSON array of IDs, as written to the
+// deployment-ids output, or a comma-delimited list.
+func parseDeploymentIDs(raw string) ([]int64, error) {
+	var deploymentIDs []int64
+	if err := json.Unmarshal([]byte(raw), &deploymentIDs); err == nil {
+		return deploymentIDs, nil
+	}
+	for _, v := range splitInputList(raw) {
+		deploymentID, err := strconv.ParseInt(v, 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid deployment ID %s: %w", v, err)
+		}
+		deploymentIDs = append(deploymentIDs, deploymentID)
+	}
+	return deploymentIDs, nil
+}
@@ -0,0 +1,54 @@
+package main
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParseDeploymentIDs(t *testing.T) {
+	testCases := []struct {
+		name     string
+		raw      string
+		expected []int64
+		isError  bool
+	}{
+		{
+			name:     "json_array",
+			raw:      "[1,22,333]",
+			expected: []int64{1, 22, 333},
+		},
+		{
+			name:     "comma_delimited",
+			raw:      "1, 22,333",
+			expected: []int64{1, 22, 333},
+		},
+		{
+			name:     "single_id",
+			raw:      "42",
+			expected: []int64{42},
+		},
+		{
+			name:    "invalid_id",
+			raw:     "1,abc",
+			isError: true,
+		},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			actual, err := parseDeploymentIDs(testCase.raw)
+			if testCase.isError {
+				if err == nil {
+					t.Fatalf("expected error but got %v", actual)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("error parsing deployment IDs: %v", err)
+			}
+			if !reflect.DeepEqual(actual, testCase.expected) {
+				t.Fatalf("expected %v but got %v", testCase.expected, actual)
+			}
+		})
+	}
+}
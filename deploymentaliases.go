@@ -0,0 +1,108 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/trstringer/manual-approval/pkg/approval"
+)
+
+// parseDeploymentAliases reads the deployment-aliases input: one alias per
+// line or separated by semicolons, each naming the deployments it stands
+// for, such as "eu = eu-west-1,eu-central-1".
+func parseDeploymentAliases(raw string) (approval.Aliases, error) {
+	aliases := approval.Aliases{}
+	for _, entry := range strings.FieldsFunc(raw, func(r rune) bool { return r == '\n' || r == ';' }) {
+		if strings.TrimSpace(entry) == "" {
+			continue
+		}
+		separator := strings.IndexByte(entry, '=')
+		if separator < 0 {
+			return nil, fmt.Errorf("deployment alias %q is not of the form name = deployment,deployment", strings.TrimSpace(entry))
+		}
+		name, targets := strings.TrimSpace(entry[:separator]), entry[separator+1:]
+		if _, ok := aliases[name]; ok {
+			return nil, fmt.Errorf("deployment alias %q is defined more than once", name)
+		}
+		aliases[name] = splitInputList(targets)
+	}
+	return aliases, nil
+}
+
+// validateDeploymentAliases checks that each alias has a name of its own and
+// stands for deployment names, so that a typo in the aliases fails the run
+// instead of an approval.
+func validateDeploymentAliases(aliases approval.Aliases, deploymentNames []string) error {
+	if len(aliases) > 0 && len(deploymentNames) == 0 {
+		return fmt.Errorf("deployment aliases require multiple deployment names")
+	}
+	valid := make(map[string]bool, len(deploymentNames))
+	for _, name := range deploymentNames {
+		valid[name] = true
+	}
+	for name, targets := range aliases {
+		switch {
+		case name == "":
+			return fmt.Errorf("a deployment alias has an empty name")
+		case strings.ContainsAny(name, "[],"):
+			return fmt.Errorf("deployment alias %q can't contain brackets or commas", name)
+		case name == "all" || name == "*":
+			return fmt.Errorf("deployment alias %q already stands for every deployment name", name)
+		case valid[name]:
+			return fmt.Errorf("deployment alias %q is also a deployment name", name)
+		case len(targets) == 0:
+			return fmt.Errorf("deployment alias %q stands for no deployment names", name)
+		}
+		for _, target := range targets {
+			if !valid[target] {
+				return fmt.Errorf("deployment alias %q stands for %q, which is not a deployment name", name, target)
+			}
+		}
+	}
+	return nil
+}
+
+// parseDeploymentApprovers reads the deployment-approvers input: one
+// deployment per line or separated by semicolons, each followed by a colon
+// and the approvers who can approve it, such as "prod-db: my-org/dba-team".
+// The approvers can be logins or org/team slugs, which the caller expands.
+func parseDeploymentApprovers(raw string) (map[string][]string, error) {
+	deploymentApprovers := map[string][]string{}
+	for _, entry := range strings.FieldsFunc(raw, func(r rune) bool { return r == '\n' || r == ';' }) {
+		if strings.TrimSpace(entry) == "" {
+			continue
+		}
+		separator := strings.LastIndex(entry, ":")
+		if separator < 0 {
+			return nil, fmt.Errorf("deployment approvers %q are not of the form deployment: approver,approver", strings.TrimSpace(entry))
+		}
+		name, approvers := strings.TrimSpace(entry[:separator]), parseApprovers(entry[separator+1:])
+		if _, ok := deploymentApprovers[name]; ok {
+			return nil, fmt.Errorf("approvers of deployment %q are defined more than once", name)
+		}
+		if len(approvers) == 0 {
+			return nil, fmt.Errorf("deployment %q has no approvers", name)
+		}
+		deploymentApprovers[name] = approvers
+	}
+	return deploymentApprovers, nil
+}
+
+// validateDeploymentApprovers checks that approvers are only given for
+// deployment names, so that a typo can't leave a deployment open to every
+// approver.
+func validateDeploymentApprovers(deploymentApprovers map[string][]string, deploymentNames []string) error {
+	if len(deploymentApprovers) > 0 && len(deploymentNames) == 0 {
+		return fmt.Errorf("deployment approvers require multiple deployment names")
+	}
+	valid := make(map[string]bool, len(deploymentNames))
+	for _, name := range deploymentNames {
+		valid[name] = true
+	}
+	for name := range deploymentApprovers {
+		if !valid[name] {
+			return fmt.Errorf("deployment approvers are given for %q, which is not a deployment name", name)
+		}
+	}
+	return nil
+}
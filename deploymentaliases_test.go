@@ -0,0 +1,155 @@
+package main
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/trstringer/manual-approval/pkg/approval"
+)
+
+func TestParseDeploymentAliases(t *testing.T) {
+	testCases := []struct {
+		name      string
+		raw       string
+		expected  approval.Aliases
+		expectErr bool
+	}{
+		{
+			name:     "empty",
+			raw:      "",
+			expected: approval.Aliases{},
+		},
+		{
+			name:     "lines",
+			raw:      "eu = eu-west-1, eu-central-1\nus = us-east-1\n",
+			expected: approval.Aliases{"eu": {"eu-west-1", "eu-central-1"}, "us": {"us-east-1"}},
+		},
+		{
+			name:     "semicolons",
+			raw:      "eu=eu-west-1,eu-central-1; us=us-east-1",
+			expected: approval.Aliases{"eu": {"eu-west-1", "eu-central-1"}, "us": {"us-east-1"}},
+		},
+		{
+			name:      "missing_equals",
+			raw:       "eu eu-west-1",
+			expectErr: true,
+		},
+		{
+			name:      "defined_twice",
+			raw:       "eu = eu-west-1; eu = eu-central-1",
+			expectErr: true,
+		},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			actual, err := parseDeploymentAliases(testCase.raw)
+			if testCase.expectErr {
+				if err == nil {
+					t.Fatalf("expected an error, got %v", actual)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if !reflect.DeepEqual(actual, testCase.expected) {
+				t.Fatalf("expected %v, got %v", testCase.expected, actual)
+			}
+		})
+	}
+}
+
+func TestValidateDeploymentAliases(t *testing.T) {
+	deploymentNames := []string{"eu-west-1", "eu-central-1"}
+	testCases := []struct {
+		name            string
+		aliases         approval.Aliases
+		deploymentNames []string
+		expectErr       bool
+	}{
+		{name: "valid", aliases: approval.Aliases{"eu": {"eu-west-1", "eu-central-1"}}, deploymentNames: deploymentNames},
+		{name: "none", aliases: approval.Aliases{}},
+		{name: "without_deployment_names", aliases: approval.Aliases{"eu": {"eu-west-1"}}, expectErr: true},
+		{name: "unknown_target", aliases: approval.Aliases{"eu": {"eu-west-2"}}, deploymentNames: deploymentNames, expectErr: true},
+		{name: "no_targets", aliases: approval.Aliases{"eu": nil}, deploymentNames: deploymentNames, expectErr: true},
+		{name: "deployment_name", aliases: approval.Aliases{"eu-west-1": {"eu-central-1"}}, deploymentNames: deploymentNames, expectErr: true},
+		{name: "all", aliases: approval.Aliases{"all": {"eu-west-1"}}, deploymentNames: deploymentNames, expectErr: true},
+		{name: "brackets", aliases: approval.Aliases{"[eu]": {"eu-west-1"}}, deploymentNames: deploymentNames, expectErr: true},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			err := validateDeploymentAliases(testCase.aliases, testCase.deploymentNames)
+			if testCase.expectErr != (err != nil) {
+				t.Fatalf("expected error %t, got %v", testCase.expectErr, err)
+			}
+		})
+	}
+}
+
+func TestParseDeploymentApprovers(t *testing.T) {
+	testCases := []struct {
+		name      string
+		raw       string
+		expected  map[string][]string
+		expectErr bool
+	}{
+		{
+			name:     "semicolons",
+			raw:      "prod-db: my-org/dba-team; prod-web: @web1, web2",
+			expected: map[string][]string{"prod-db": {"my-org/dba-team"}, "prod-web": {"web1", "web2"}},
+		},
+		{
+			name:     "lines",
+			raw:      "k8s:prod: alice\ndev: bob\n",
+			expected: map[string][]string{"k8s:prod": {"alice"}, "dev": {"bob"}},
+		},
+		{
+			name:      "missing_colon",
+			raw:       "prod-db dba1",
+			expectErr: true,
+		},
+		{
+			name:      "no_approvers",
+			raw:       "prod-db:",
+			expectErr: true,
+		},
+		{
+			name:      "defined_twice",
+			raw:       "prod-db: dba1; prod-db: dba2",
+			expectErr: true,
+		},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			actual, err := parseDeploymentApprovers(testCase.raw)
+			if testCase.expectErr {
+				if err == nil {
+					t.Fatalf("expected an error, got %v", actual)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if !reflect.DeepEqual(actual, testCase.expected) {
+				t.Fatalf("expected %v, got %v", testCase.expected, actual)
+			}
+		})
+	}
+}
+
+func TestValidateDeploymentApprovers(t *testing.T) {
+	approvers := map[string][]string{"prod-db": {"dba1"}}
+	if err := validateDeploymentApprovers(approvers, []string{"prod-db", "prod-web"}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if err := validateDeploymentApprovers(approvers, []string{"prod-web"}); err == nil {
+		t.Fatal("expected an error for approvers of an unknown deployment")
+	}
+	if err := validateDeploymentApprovers(approvers, nil); err == nil {
+		t.Fatal("expected an error without deployment names")
+	}
+}
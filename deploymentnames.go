@@ -0,0 +1,90 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/trstringer/manual-approval/pkg/approval"
+)
+
+// parseDeploymentNames reads the multiple-deployment-names input. Entries
+// are separated by commas, and each can describe the deployment after a
+// colon, such as "k8s-prod-euc1-blue: Production, EU central". When the
+// input spans several lines, each line with a description is one entry, so
+// that descriptions can contain commas, and other lines are comma-delimited
+// names.
+func parseDeploymentNames(raw string) ([]string, map[string]string) {
+	if raw == "" {
+		return nil, nil
+	}
+	var entries []string
+	if strings.Contains(raw, "\n") {
+		for _, line := range strings.Split(raw, "\n") {
+			line = strings.TrimSuffix(strings.TrimSpace(line), ",")
+			switch {
+			case line == "":
+			case descriptionSeparator(line) >= 0:
+				entries = append(entries, line)
+			default:
+				entries = append(entries, strings.Split(line, ",")...)
+			}
+		}
+	} else {
+		entries = strings.Split(raw, ",")
+	}
+
+	names := make([]string, 0, len(entries))
+	descriptions := map[string]string{}
+	for _, entry := range entries {
+		name, description := entry, ""
+		if i := descriptionSeparator(entry); i >= 0 {
+			name, description = entry[:i], strings.TrimSpace(entry[i+1:])
+		}
+		name = strings.TrimSpace(name)
+		names = append(names, name)
+		if description != "" {
+			descriptions[name] = description
+		}
+	}
+	return names, descriptions
+}
+
+// descriptionSeparator returns the index of the colon that starts the
+// description of an entry, or -1 if it has none. The colon must be followed
+// by a space or end the entry, so that names such as "k8s:prod" are kept
+// whole.
+func descriptionSeparator(entry string) int {
+	entry = strings.TrimRight(entry, " \t")
+	if strings.HasSuffix(entry, ":") {
+		return len(entry) - 1
+	}
+	return strings.Index(entry, ": ")
+}
+
+// deploymentsTable renders the deployment names and their descriptions as a
+// markdown table, in the order they were given, so that approvers know what
+// each deployment is. When deployments have their own approvers, they are
+// listed in a column of their own, and "any approver" for the others. It is
+// empty if no deployment is described or has its own approvers.
+func deploymentsTable(names []string, descriptions map[string]string, approvers approval.DeploymentApprovers) string {
+	if len(descriptions) == 0 && len(approvers) == 0 {
+		return ""
+	}
+	escape := strings.NewReplacer("|", "\\|", "\r\n", "<br>", "\n", "<br>")
+	rows := []string{"| Deployment | Description |", "| --- | --- |"}
+	if len(approvers) > 0 {
+		rows = []string{"| Deployment | Description | Approvers |", "| --- | --- | --- |"}
+	}
+	for _, name := range names {
+		row := fmt.Sprintf("| `%s` | %s |", escape.Replace(name), escape.Replace(descriptions[name]))
+		if len(approvers) > 0 {
+			mentions := "any approver"
+			if logins, ok := approvers[name]; ok {
+				mentions = "@" + strings.Join(logins, ", @")
+			}
+			row += fmt.Sprintf(" %s |", mentions)
+		}
+		rows = append(rows, row)
+	}
+	return fmt.Sprintf("**Deployments:**\n\n%s", strings.Join(rows, "\n"))
+}
@@ -0,0 +1,82 @@
+package main
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/trstringer/manual-approval/pkg/approval"
+)
+
+func TestParseDeploymentNames(t *testing.T) {
+	testCases := []struct {
+		name                 string
+		raw                  string
+		expectedNames        []string
+		expectedDescriptions map[string]string
+	}{
+		{
+			name: "empty",
+			raw:  "",
+		},
+		{
+			name:                 "names",
+			raw:                  "prod, staging",
+			expectedNames:        []string{"prod", "staging"},
+			expectedDescriptions: map[string]string{},
+		},
+		{
+			name:                 "descriptions",
+			raw:                  "k8s-prod-euc1-blue: Production in EU central,staging",
+			expectedNames:        []string{"k8s-prod-euc1-blue", "staging"},
+			expectedDescriptions: map[string]string{"k8s-prod-euc1-blue": "Production in EU central"},
+		},
+		{
+			name:                 "colon_in_name",
+			raw:                  "k8s:prod,k8s:staging: Staging",
+			expectedNames:        []string{"k8s:prod", "k8s:staging"},
+			expectedDescriptions: map[string]string{"k8s:staging": "Staging"},
+		},
+		{
+			name:                 "lines",
+			raw:                  "k8s-prod-euc1-blue: Production, EU central\nk8s-prod-use1-blue: Production, US east\ndev, qa,\n",
+			expectedNames:        []string{"k8s-prod-euc1-blue", "k8s-prod-use1-blue", "dev", "qa"},
+			expectedDescriptions: map[string]string{"k8s-prod-euc1-blue": "Production, EU central", "k8s-prod-use1-blue": "Production, US east"},
+		},
+		{
+			name:                 "empty_name",
+			raw:                  "prod,,staging",
+			expectedNames:        []string{"prod", "", "staging"},
+			expectedDescriptions: map[string]string{},
+		},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			names, descriptions := parseDeploymentNames(testCase.raw)
+			if !reflect.DeepEqual(names, testCase.expectedNames) {
+				t.Fatalf("expected names %q, got %q", testCase.expectedNames, names)
+			}
+			if !reflect.DeepEqual(descriptions, testCase.expectedDescriptions) {
+				t.Fatalf("expected descriptions %q, got %q", testCase.expectedDescriptions, descriptions)
+			}
+		})
+	}
+}
+
+func TestDeploymentsTable(t *testing.T) {
+	names := []string{"k8s-prod-euc1-blue", "dev"}
+	descriptions := map[string]string{"k8s-prod-euc1-blue": "Production | EU central"}
+	expected := "**Deployments:**\n\n| Deployment | Description |\n| --- | --- |\n| `k8s-prod-euc1-blue` | Production \\| EU central |\n| `dev` |  |"
+	if actual := deploymentsTable(names, descriptions, nil); actual != expected {
+		t.Fatalf("expected %q, got %q", expected, actual)
+	}
+	if actual := deploymentsTable(names, nil, nil); actual != "" {
+		t.Fatalf("expected no table without descriptions, got %q", actual)
+	}
+
+	approvers := approval.DeploymentApprovers{"k8s-prod-euc1-blue": {"alice", "bob"}}
+	expected = "**Deployments:**\n\n| Deployment | Description | Approvers |\n| --- | --- | --- |\n| `k8s-prod-euc1-blue` | Production \\| EU central | @alice, @bob |\n| `dev` |  | any approver |"
+	if actual := deploymentsTable(names, descriptions, approvers); actual != expected {
+		t.Fatalf("expected %q, got %q", expected, actual)
+	}
+}
@@ -0,0 +1,192 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/google/go-github/v43/github"
+)
+
+const (
+	discussionCategoriesQuery = `query($owner: String!, $name: String!) {
+  repository(owner: $owner, name: $name) {
+    id
+    discussionCategories(first: 100) {
+      nodes { id name }
+    }
+  }
+}`
+
+	createDiscussionMutation = `mutation($repositoryId: ID!, $categoryId: ID!, $title: String!, $body: String!) {
+  createDiscussion(input: {repositoryId: $repositoryId, categoryId: $categoryId, title: $title, body: $body}) {
+    discussion { id number url createdAt }
+  }
+}`
+
+	discussionCommentsQuery = `query($owner: String!, $name: String!, $number: Int!, $after: String) {
+  repository(owner: $owner, name: $name) {
+    discussion(number: $number) {
+      comments(first: 100, after: $after) {
+        pageInfo { hasNextPage endCursor }
+        nodes {
+          databaseId
+          body
+          createdAt
+          url
+          author { __typename login }
+        }
+      }
+    }
+  }
+}`
+
+	addDiscussionCommentMutation = `mutation($discussionId: ID!, $body: String!) {
+  addDiscussionComment(input: {discussionId: $discussionId, body: $body}) {
+    comment { id }
+  }
+}`
+
+	closeDiscussionMutation = `mutation($discussionId: ID!) {
+  closeDiscussion(input: {discussionId: $discussionId, reason: RESOLVED}) {
+    discussion { id }
+  }
+}`
+)
+
+type discussionComment struct {
+	DatabaseID int64        `json:"databaseId"`
+	Body       string       `json:"body"`
+	CreatedAt  time.Time    `json:"createdAt"`
+	URL        string       `json:"url"`
+	Author     graphQLActor `json:"author"`
+}
+
+// createApprovalDiscussion opens the approval request as a discussion in the
+// configured category instead of an issue.
+func (a *approvalEnvironment) createApprovalDiscussion(ctx context.Context, title, body string) error {
+	var repository struct {
+		Repository struct {
+			ID                   string `json:"id"`
+			DiscussionCategories struct {
+				Nodes []struct {
+					ID   string `json:"id"`
+					Name string `json:"name"`
+				} `json:"nodes"`
+			} `json:"discussionCategories"`
+		} `json:"repository"`
+	}
+	err := a.graphQL(ctx, discussionCategoriesQuery, map[string]interface{}{
+		"owner": a.repoOwner,
+		"name":  a.repo,
+	}, &repository)
+	if err != nil {
+		return err
+	}
+
+	var categoryID string
+	for _, category := range repository.Repository.DiscussionCategories.Nodes {
+		if category.Name == a.discussionCategory {
+			categoryID = category.ID
+			break
+		}
+	}
+	if categoryID == "" {
+		return fmt.Errorf("discussion category %s not found in %s", a.discussionCategory, a.repoFullName)
+	}
+
+	fmt.Printf(
+		"Creating discussion in repo %s/%s category %s with the following content:\nTitle: %s\nBody:\n%s\n",
+		a.repoOwner,
+		a.repo,
+		a.discussionCategory,
+		title,
+		body,
+	)
+	var created struct {
+		CreateDiscussion struct {
+			Discussion struct {
+				ID        string    `json:"id"`
+				Number    int       `json:"number"`
+				URL       string    `json:"url"`
+				CreatedAt time.Time `json:"createdAt"`
+			} `json:"discussion"`
+		} `json:"createDiscussion"`
+	}
+	err = a.graphQL(ctx, createDiscussionMutation, map[string]interface{}{
+		"repositoryId": repository.Repository.ID,
+		"categoryId":   categoryID,
+		"title":        title,
+		"body":         body,
+	}, &created)
+	if err != nil {
+		return err
+	}
+
+	a.discussionID = created.CreateDiscussion.Discussion.ID
+	a.approvalIssueNumber = created.CreateDiscussion.Discussion.Number
+	a.requestedAt = created.CreateDiscussion.Discussion.CreatedAt
+	fmt.Printf("Created discussion %s\n", created.CreateDiscussion.Discussion.URL)
+	return nil
+}
+
+// listDiscussionComments returns the top-level discussion comments as issue
+// comments so they can be evaluated like any other approval comment.
+func (a *approvalEnvironment) listDiscussionComments(ctx context.Context) ([]*github.IssueComment, error) {
+	var comments []*github.IssueComment
+	var after interface{}
+	for {
+		var result struct {
+			Repository struct {
+				Discussion struct {
+					Comments struct {
+						PageInfo struct {
+							HasNextPage bool   `json:"hasNextPage"`
+							EndCursor   string `json:"endCursor"`
+						} `json:"pageInfo"`
+						Nodes []discussionComment `json:"nodes"`
+					} `json:"comments"`
+				} `json:"discussion"`
+			} `json:"repository"`
+		}
+		err := a.graphQL(ctx, discussionCommentsQuery, map[string]interface{}{
+			"owner":  a.repoOwner,
+			"name":   a.repo,
+			"number": a.approvalIssueNumber,
+			"after":  after,
+		}, &result)
+		if err != nil {
+			return nil, err
+		}
+
+		page := result.Repository.Discussion.Comments
+		for _, node := range page.Nodes {
+			createdAt := node.CreatedAt
+			comments = append(comments, &github.IssueComment{
+				ID:        github.Int64(node.DatabaseID),
+				Body:      github.String(node.Body),
+				User:      node.Author.user(),
+				CreatedAt: &createdAt,
+				HTMLURL:   github.String(node.URL),
+			})
+		}
+		if !page.PageInfo.HasNextPage {
+			break
+		}
+		after = page.PageInfo.EndCursor
+	}
+	return comments, nil
+}
+
+func (a *approvalEnvironment) closeApprovalDiscussion(ctx context.Context, comment string) error {
+	err := a.graphQL(ctx, addDiscussionCommentMutation, map[string]interface{}{
+		"discussionId": a.discussionID,
+		"body":         comment,
+	}, nil)
+	if err != nil {
+		return fmt.Errorf("error commenting on discussion: %w", err)
+	}
+	return a.graphQL(ctx, closeDiscussionMutation, map[string]interface{}{
+		"discussionId": a.discussionID,
+	}, nil)
+}
@@ -0,0 +1,50 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+
+	"github.com/google/go-github/v43/github"
+)
+
+func TestApprovalCommentsDiscussionBots(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `{"data": {"repository": {"discussion": {"comments": {
+			"pageInfo": {"hasNextPage": false, "endCursor": "3"},
+			"nodes": [
+				{"databaseId": 1, "body": "approve", "createdAt": "2022-03-01T10:01:00Z", "author": {"__typename": "Bot", "login": "status-app"}},
+				{"databaseId": 2, "body": "approve", "createdAt": "2022-03-01T10:02:00Z", "author": {"__typename": "Bot", "login": "deploy-bot"}},
+				{"databaseId": 3, "body": "approve", "createdAt": "2022-03-01T10:03:00Z", "author": {"__typename": "User", "login": "user1"}}
+			]
+		}}}}}`)
+	}))
+	defer server.Close()
+
+	client := github.NewClient(nil)
+	client.BaseURL, _ = url.Parse(server.URL + "/")
+	apprv := &approvalEnvironment{
+		client:              wrapGithubClient(client),
+		repoOwner:           "owner",
+		repo:                "repo",
+		createIssue:         true,
+		discussionCategory:  "Approvals",
+		approvalIssueNumber: 1,
+		botApprovers:        []string{"deploy-bot[bot]"},
+	}
+
+	comments, err := apprv.approvalComments(context.Background())
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	var logins []string
+	for _, comment := range comments {
+		logins = append(logins, comment.User.GetLogin())
+	}
+	if len(logins) != 2 || logins[0] != "deploy-bot[bot]" || logins[1] != "user1" {
+		t.Fatalf("expected the comments of deploy-bot[bot] and user1 but got %v", logins)
+	}
+}
@@ -0,0 +1,176 @@
+package main
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/google/go-github/v43/github"
+)
+
+const dispatchStatusContextPrefix string = "manual-approval/decision"
+
+// dispatchDecision is a decision delivered through a repository_dispatch or
+// workflow_dispatch event of a companion workflow.
+type dispatchDecision struct {
+	RunID     int
+	Gate      string
+	Decision  string
+	Approver  string
+	Signature string
+}
+
+// dispatchSignature is the hex encoded HMAC-SHA256 of the run ID, decision
+// and approver, which the sender of a dispatch event must compute with the
+// shared dispatch secret. Decisions for a named gate also sign the gate name,
+// so they can't be replayed against another gate of the same run.
+func dispatchSignature(secret string, runID int, gate, decision, approver string) string {
+	message := fmt.Sprintf("%d:%s:%s", runID, decision, approver)
+	if gate != "" {
+		message = fmt.Sprintf("%d:%s:%s:%s", runID, gate, decision, approver)
+	}
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(message))
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+func (d dispatchDecision) verify(secret string) bool {
+	expected := dispatchSignature(secret, d.RunID, d.Gate, d.Decision, d.Approver)
+	return hmac.Equal([]byte(expected), []byte(strings.ToLower(d.Signature)))
+}
+
+// parseDispatchDecision reads a decision from the client payload of a
+// repository_dispatch event or the inputs of a workflow_dispatch event.
+func parseDispatchDecision(payload map[string]interface{}) (*dispatchDecision, error) {
+	value := func(key string) string {
+		v, ok := payload[key]
+		if !ok || v == nil {
+			return ""
+		}
+		return strings.TrimSpace(fmt.Sprint(v))
+	}
+
+	runID, err := strconv.Atoi(value("run_id"))
+	if err != nil {
+		return nil, fmt.Errorf("invalid run_id: %w", err)
+	}
+	decision := dispatchDecision{
+		RunID:     runID,
+		Gate:      value("gate"),
+		Decision:  strings.ToLower(value("decision")),
+		Approver:  value("approver"),
+		Signature: value("signature"),
+	}
+	if decision.Decision != decisionApprove && decision.Decision != decisionDeny {
+		return nil, fmt.Errorf("invalid decision %q", decision.Decision)
+	}
+	if decision.Approver == "" {
+		return nil, fmt.Errorf("missing approver")
+	}
+	return &decision, nil
+}
+
+// dispatchStatusContextRunPrefix is the prefix of the status contexts of the
+// decisions for a run and gate, which is followed by the approver.
+func dispatchStatusContextRunPrefix(runID int, gate string) string {
+	if gate == "" {
+		return fmt.Sprintf("%s/%d/", dispatchStatusContextPrefix, runID)
+	}
+	return fmt.Sprintf("%s/%d/%s/", dispatchStatusContextPrefix, runID, gate)
+}
+
+func dispatchStatusContext(runID int, gate, approver string) string {
+	return dispatchStatusContextRunPrefix(runID, gate) + approver
+}
+
+// recordDispatchDecision handles a dispatch event in the companion workflow.
+// The decision is verified and then recorded as a commit status on the head
+// commit of the waiting run, where the gate picks it up on its next poll.
+func recordDispatchDecision(ctx context.Context, client *github.Client, repoOwner, repo, secret string, decision *dispatchDecision) error {
+	if !decision.verify(secret) {
+		return fmt.Errorf("invalid signature for %s from %s", decision.Decision, decision.Approver)
+	}
+
+	run, _, err := client.Actions.GetWorkflowRunByID(ctx, repoOwner, repo, int64(decision.RunID))
+	if err != nil {
+		return err
+	}
+
+	state := "success"
+	if decision.Decision == decisionDeny {
+		state = "failure"
+	}
+	statusContext := dispatchStatusContext(decision.RunID, decision.Gate, decision.Approver)
+	fmt.Printf("Recording %s from %s for workflow run %d\n", decision.Decision, decision.Approver, decision.RunID)
+	_, _, err = client.Repositories.CreateStatus(ctx, repoOwner, repo, run.GetHeadSHA(), &github.RepoStatus{
+		State:       &state,
+		Context:     &statusContext,
+		Description: &decision.Signature,
+		TargetURL:   run.HTMLURL,
+	})
+	return err
+}
+
+// listDispatchComments returns the verified dispatch decisions for this run
+// as issue comments. Statuses that do not carry a valid signature are
+// ignored, since anyone able to set commit statuses could have created them.
+func (a *approvalEnvironment) listDispatchComments(ctx context.Context) ([]*github.IssueComment, error) {
+	prefix := dispatchStatusContextRunPrefix(a.runID, a.gateName)
+	seen := make(map[string]bool)
+	var comments []*github.IssueComment
+	opts := &github.ListOptions{PerPage: 100}
+	for {
+		statuses, resp, err := a.client.Repositories.ListStatuses(ctx, a.repoOwner, a.repo, a.dispatchSHA, opts)
+		if err != nil {
+			return nil, err
+		}
+		for _, status := range statuses {
+			if !strings.HasPrefix(status.GetContext(), prefix) {
+				continue
+			}
+			// Statuses are listed newest first, so only the latest decision
+			// of each approver is kept.
+			approver := strings.TrimPrefix(status.GetContext(), prefix)
+			if strings.Contains(approver, "/") {
+				// A decision for a named gate of the same run.
+				continue
+			}
+			if seen[approver] {
+				continue
+			}
+			seen[approver] = true
+
+			decision := dispatchDecision{
+				RunID:     a.runID,
+				Gate:      a.gateName,
+				Decision:  decisionApprove,
+				Approver:  approver,
+				Signature: status.GetDescription(),
+			}
+			body := gateWords.Approved[0]
+			if status.GetState() == "failure" {
+				decision.Decision = decisionDeny
+				body = gateWords.Denied[0]
+			}
+			if !decision.verify(a.dispatchSecret) {
+				fmt.Printf("ignoring dispatch decision from %s with an invalid signature\n", approver)
+				continue
+			}
+			comments = append(comments, &github.IssueComment{
+				Body:      github.String(body),
+				User:      &github.User{Login: github.String(approver)},
+				CreatedAt: status.CreatedAt,
+			})
+		}
+		if resp.NextPage == 0 {
+			break
+		}
+		opts.Page = resp.NextPage
+	}
+	sortCommentsByCreation(comments)
+	return comments, nil
+}
@@ -0,0 +1,80 @@
+package main
+
+import "testing"
+
+func TestDispatchDecision(t *testing.T) {
+	secret := "secret"
+	signature := dispatchSignature(secret, 1234, "", decisionApprove, "login1")
+	gateSignature := dispatchSignature(secret, 1234, "pre-deploy", decisionApprove, "login1")
+
+	testCases := []struct {
+		name     string
+		payload  map[string]interface{}
+		isError  bool
+		verified bool
+	}{
+		{
+			name:     "valid_signature",
+			payload:  map[string]interface{}{"run_id": "1234", "decision": "approve", "approver": "login1", "signature": signature},
+			verified: true,
+		},
+		{
+			name:     "numeric_run_id",
+			payload:  map[string]interface{}{"run_id": float64(1234), "decision": "Approve", "approver": "login1", "signature": signature},
+			verified: true,
+		},
+		{
+			name:     "tampered_approver",
+			payload:  map[string]interface{}{"run_id": "1234", "decision": "approve", "approver": "login2", "signature": signature},
+			verified: false,
+		},
+		{
+			name:     "tampered_decision",
+			payload:  map[string]interface{}{"run_id": "1234", "decision": "deny", "approver": "login1", "signature": signature},
+			verified: false,
+		},
+		{
+			name:     "gate",
+			payload:  map[string]interface{}{"run_id": "1234", "gate": "pre-deploy", "decision": "approve", "approver": "login1", "signature": gateSignature},
+			verified: true,
+		},
+		{
+			name:     "other_gate",
+			payload:  map[string]interface{}{"run_id": "1234", "gate": "post-deploy", "decision": "approve", "approver": "login1", "signature": gateSignature},
+			verified: false,
+		},
+		{
+			name:     "gate_signature_without_gate",
+			payload:  map[string]interface{}{"run_id": "1234", "decision": "approve", "approver": "login1", "signature": gateSignature},
+			verified: false,
+		},
+		{
+			name:    "invalid_decision",
+			payload: map[string]interface{}{"run_id": "1234", "decision": "maybe", "approver": "login1", "signature": signature},
+			isError: true,
+		},
+		{
+			name:    "missing_run_id",
+			payload: map[string]interface{}{"decision": "approve", "approver": "login1", "signature": signature},
+			isError: true,
+		},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			decision, err := parseDispatchDecision(testCase.payload)
+			if testCase.isError {
+				if err == nil {
+					t.Fatalf("expected error but got %+v", decision)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("error parsing dispatch decision: %v", err)
+			}
+			if actual := decision.verify(secret); actual != testCase.verified {
+				t.Fatalf("expected verified %v but got %v", testCase.verified, actual)
+			}
+		})
+	}
+}
@@ -0,0 +1,69 @@
+package main
+
+import (
+	"context"
+	"strings"
+
+	"github.com/google/go-github/v43/github"
+)
+
+// approverTeam is a configured org/team entry and its expanded members.
+type approverTeam struct {
+	name    string
+	members []string
+}
+
+// approverTeams expands every org/team entry of approvers on its own, so that
+// approvals can be attributed to the team they came from.
+func approverTeams(ctx context.Context, client *github.Client, approvers []string) ([]approverTeam, error) {
+	var teams []approverTeam
+	for _, entry := range approvers {
+		orgAndTeam := strings.Split(entry, "/")
+		if len(orgAndTeam) != 2 {
+			continue
+		}
+		members, err := teamMembers(ctx, client, orgAndTeam[0], orgAndTeam[1], map[string]bool{})
+		if err != nil {
+			return nil, err
+		}
+		teams = append(teams, approverTeam{name: entry, members: members})
+	}
+	return teams, nil
+}
+
+// distinctTeamsRequirement requires the approvals to come from members of at
+// least n different teams. Every approver counts for a single team only, so
+// one person who is a member of two teams can't satisfy both.
+func distinctTeamsRequirement(teams []approverTeam, n int) approvalRequirement {
+	return func(approvedBy []string) bool {
+		// Match approvers to teams with augmenting paths. teamApprover holds,
+		// for each team, the index in approvedBy of the approver counted for it.
+		teamApprover := make([]int, len(teams))
+		for i := range teamApprover {
+			teamApprover[i] = -1
+		}
+
+		var assign func(approver int, visited []bool) bool
+		assign = func(approver int, visited []bool) bool {
+			for i, team := range teams {
+				if visited[i] || approversIndex(team.members, approvedBy[approver]) < 0 {
+					continue
+				}
+				visited[i] = true
+				if teamApprover[i] < 0 || assign(teamApprover[i], visited) {
+					teamApprover[i] = approver
+					return true
+				}
+			}
+			return false
+		}
+
+		matched := 0
+		for approver := range approvedBy {
+			if assign(approver, make([]bool, len(teams))) {
+				matched++
+			}
+		}
+		return matched >= n
+	}
+}
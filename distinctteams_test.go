@@ -0,0 +1,58 @@
+package main
+
+import "testing"
+
+func TestDistinctTeamsRequirement(t *testing.T) {
+	teams := []approverTeam{
+		{name: "my-org/payments", members: []string{"login1", "login2"}},
+		{name: "my-org/sre", members: []string{"login2", "login3"}},
+		{name: "my-org/security", members: []string{"login4"}},
+	}
+
+	testCases := []struct {
+		name       string
+		approvedBy []string
+		n          int
+		expected   bool
+	}{
+		{
+			name:       "same_team",
+			approvedBy: []string{"login1", "Login1"},
+			n:          2,
+			expected:   false,
+		},
+		{
+			name:       "member_of_two_teams_counts_once",
+			approvedBy: []string{"login2"},
+			n:          2,
+			expected:   false,
+		},
+		{
+			name:       "member_of_two_teams_reassigned",
+			approvedBy: []string{"login2", "login1"},
+			n:          2,
+			expected:   true,
+		},
+		{
+			name:       "three_teams",
+			approvedBy: []string{"login1", "login3", "login4"},
+			n:          3,
+			expected:   true,
+		},
+		{
+			name:       "approver_outside_teams",
+			approvedBy: []string{"login1", "login5"},
+			n:          2,
+			expected:   false,
+		},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			actual := distinctTeamsRequirement(teams, testCase.n)(testCase.approvedBy)
+			if actual != testCase.expected {
+				t.Fatalf("expected %v but got %v", testCase.expected, actual)
+			}
+		})
+	}
+}
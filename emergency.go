@@ -0,0 +1,127 @@
+package main
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/google/go-github/v43/github"
+)
+
+const emergencyStatusContextPrefix string = "manual-approval/emergency"
+
+// emergencyRelease is an automated approval sent by incident tooling as a
+// repository_dispatch event of the configured type.
+type emergencyRelease struct {
+	RunID  int
+	Sender string
+	Reason string
+}
+
+func emergencyStatusContext(runID int) string {
+	return fmt.Sprintf("%s/%d", emergencyStatusContextPrefix, runID)
+}
+
+// parseEmergencyRelease validates a repository_dispatch event as an emergency
+// release. The sender must be in the allowlist and the client payload must
+// name the workflow run to release.
+func parseEmergencyRelease(event *workflowEvent, allowedSenders []string) (*emergencyRelease, error) {
+	if !containsFold(allowedSenders, event.Sender.Login) {
+		return nil, fmt.Errorf("sender %s is not allowed to release the gate", event.Sender.Login)
+	}
+	runIDRaw, ok := event.ClientPayload["run_id"]
+	if !ok {
+		return nil, fmt.Errorf("client payload is missing run_id")
+	}
+	runID, err := strconv.Atoi(strings.TrimSpace(fmt.Sprint(runIDRaw)))
+	if err != nil {
+		return nil, fmt.Errorf("invalid run_id: %w", err)
+	}
+	release := emergencyRelease{
+		RunID:  runID,
+		Sender: event.Sender.Login,
+	}
+	if reason, ok := event.ClientPayload["reason"]; ok && reason != nil {
+		release.Reason = fmt.Sprint(reason)
+	}
+	return &release, nil
+}
+
+// emergencySignature is the hex encoded HMAC-SHA256 of the run ID, head
+// commit and sender of an emergency release, computed with the shared
+// dispatch secret. Anyone able to set commit statuses can record a release,
+// so only a signed one is trusted by the gate.
+func emergencySignature(secret string, runID int, sha, sender string) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(fmt.Sprintf("%d:%s:emergency:%s", runID, sha, sender)))
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// recordEmergencyRelease handles the emergency dispatch in the companion
+// workflow by recording it as a commit status on the head commit of the
+// waiting run. The status description is limited to 140 characters, so only
+// the sender and its signature are stored there; the reason goes into the
+// companion log.
+func recordEmergencyRelease(ctx context.Context, client *github.Client, repoOwner, repo, secret string, release *emergencyRelease) error {
+	run, _, err := client.Actions.GetWorkflowRunByID(ctx, repoOwner, repo, int64(release.RunID))
+	if err != nil {
+		return err
+	}
+	if run.GetStatus() == "completed" {
+		return fmt.Errorf("workflow run %d is already completed", release.RunID)
+	}
+
+	statusContext := emergencyStatusContext(release.RunID)
+	description := release.Sender + ":" + emergencySignature(secret, release.RunID, run.GetHeadSHA(), release.Sender)
+	fmt.Printf("Recording emergency release of workflow run %d by %s: %s\n", release.RunID, release.Sender, release.Reason)
+	_, _, err = client.Repositories.CreateStatus(ctx, repoOwner, repo, run.GetHeadSHA(), &github.RepoStatus{
+		State:       github.String("success"),
+		Context:     &statusContext,
+		Description: &description,
+		TargetURL:   run.HTMLURL,
+	})
+	return err
+}
+
+// findEmergencyRelease returns the sender of an emergency release recorded
+// for this run, or an empty string if there is none. Releases without a valid
+// signature are ignored, and the signed sender is checked against the gate's
+// own allowlist as well.
+func (a *approvalEnvironment) findEmergencyRelease(ctx context.Context) (string, error) {
+	statusContext := emergencyStatusContext(a.runID)
+	opts := &github.ListOptions{PerPage: 100}
+	for {
+		statuses, resp, err := a.client.Repositories.ListStatuses(ctx, a.repoOwner, a.repo, a.dispatchSHA, opts)
+		if err != nil {
+			return "", err
+		}
+		for _, status := range statuses {
+			if status.GetContext() != statusContext || status.GetState() != "success" {
+				continue
+			}
+			sender, signature := status.GetDescription(), ""
+			if i := strings.LastIndexByte(sender, ':'); i >= 0 {
+				sender, signature = sender[:i], sender[i+1:]
+			}
+			expected := emergencySignature(a.dispatchSecret, a.runID, a.dispatchSHA, sender)
+			if !hmac.Equal([]byte(expected), []byte(strings.ToLower(signature))) {
+				fmt.Printf("ignoring emergency release from %s with an invalid signature\n", sender)
+				continue
+			}
+			if !containsFold(a.emergencySenders, sender) {
+				fmt.Printf("ignoring emergency release from %s, who is not an allowed sender\n", sender)
+				continue
+			}
+			return sender, nil
+		}
+		if resp.NextPage == 0 {
+			break
+		}
+		opts.Page = resp.NextPage
+	}
+	return "", nil
+}
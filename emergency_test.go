@@ -0,0 +1,66 @@
+package main
+
+import (
+	"context"
+	"testing"
+
+	"github.com/google/go-github/v43/github"
+)
+
+func TestFindEmergencyRelease(t *testing.T) {
+	const secret, sha = "secret", "abc123"
+	statusContext := emergencyStatusContext(1)
+	testCases := []struct {
+		name        string
+		description string
+		expected    string
+	}{
+		{
+			name:        "signed",
+			description: "oncall-bot:" + emergencySignature(secret, 1, sha, "oncall-bot"),
+			expected:    "oncall-bot",
+		},
+		{
+			name:        "forged_description",
+			description: "oncall-bot",
+		},
+		{
+			name:        "signature_of_another_sender",
+			description: "oncall-bot:" + emergencySignature(secret, 1, sha, "mallory"),
+		},
+		{
+			name:        "signature_of_another_run",
+			description: "oncall-bot:" + emergencySignature(secret, 2, sha, "oncall-bot"),
+		},
+		{
+			name:        "signed_but_not_allowed",
+			description: "mallory:" + emergencySignature(secret, 1, sha, "mallory"),
+		},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			fake := newFakeGitHub()
+			apprv, err := newApprovalEnvironment(fake.client(), "owner/repo", "owner", 1, []string{"user1"}, 1, nil)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			apprv.dispatchSecret = secret
+			apprv.dispatchSHA = sha
+			apprv.emergencySenders = []string{"oncall-bot"}
+			fake.statuses[sha] = []*github.RepoStatus{{
+				State:       github.String("success"),
+				Context:     &statusContext,
+				Description: github.String(testCase.description),
+			}}
+
+			actual, err := apprv.findEmergencyRelease(context.Background())
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if actual != testCase.expected {
+				t.Fatalf("expected release by %q, got %q", testCase.expected, actual)
+			}
+		})
+	}
+}
@@ -0,0 +1,97 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"strings"
+
+	"github.com/google/go-github/v43/github"
+)
+
+// workflowEvent holds the parts of the triggering event payload
+// (GITHUB_EVENT_PATH) that the action cares about.
+type workflowEvent struct {
+	Action      string `json:"action"`
+	Before      string `json:"before"`
+	After       string `json:"after"`
+	PullRequest *struct {
+		Number int `json:"number"`
+		Head   struct {
+			SHA string `json:"sha"`
+		} `json:"head"`
+	} `json:"pull_request"`
+	Issue *struct {
+		Number int `json:"number"`
+	} `json:"issue"`
+	RequestedAction *struct {
+		Identifier string `json:"identifier"`
+	} `json:"requested_action"`
+	CheckRun *struct {
+		ID int64 `json:"id"`
+	} `json:"check_run"`
+	Sender struct {
+		Login string `json:"login"`
+	} `json:"sender"`
+	ClientPayload map[string]interface{} `json:"client_payload"`
+	Inputs        map[string]interface{} `json:"inputs"`
+}
+
+// dispatchPayload returns the payload of a repository_dispatch or
+// workflow_dispatch event if it carries an approval decision.
+func (e *workflowEvent) dispatchPayload() map[string]interface{} {
+	for _, payload := range []map[string]interface{}{e.ClientPayload, e.Inputs} {
+		if _, ok := payload["decision"]; ok {
+			return payload
+		}
+	}
+	return nil
+}
+
+// isPush reports whether the event is a push with a range of commits. The
+// before commit is all zeros for a newly created branch.
+func (e *workflowEvent) isPush() bool {
+	return e.Before != "" && e.After != "" && strings.Trim(e.Before, "0") != ""
+}
+
+func readWorkflowEvent(path string) (*workflowEvent, error) {
+	if path == "" {
+		return &workflowEvent{}, nil
+	}
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var event workflowEvent
+	if err := json.Unmarshal(content, &event); err != nil {
+		return nil, err
+	}
+	return &event, nil
+}
+
+// associatedPullRequest returns the number of the pull request the run was
+// triggered for. For other events it falls back to the first open pull
+// request containing the commit being built. It returns 0 if there is none.
+func (a *approvalEnvironment) associatedPullRequest(ctx context.Context) (int, error) {
+	event, err := readWorkflowEvent(os.Getenv(envVarEventPath))
+	if err != nil {
+		return 0, err
+	}
+	if event.PullRequest != nil {
+		return event.PullRequest.Number, nil
+	}
+	if a.sha == "" {
+		return 0, nil
+	}
+
+	pullRequests, _, err := a.client.PullRequests.ListPullRequestsWithCommit(ctx, a.repoOwner, a.repo, a.sha, &github.PullRequestListOptions{})
+	if err != nil {
+		return 0, err
+	}
+	for _, pullRequest := range pullRequests {
+		if pullRequest.GetState() == "open" {
+			return pullRequest.GetNumber(), nil
+		}
+	}
+	return 0, nil
+}
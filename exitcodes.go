@@ -0,0 +1,85 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+)
+
+// gateOutcome is how the gate ended. It is set as the status output and
+// decides the exit status of the action.
+type gateOutcome string
+
+const (
+	outcomeApproved  gateOutcome = "approved"
+	outcomeDenied    gateOutcome = "denied"
+	outcomeTimedOut  gateOutcome = "timed-out"
+	outcomeError     gateOutcome = "error"
+	outcomeCancelled gateOutcome = "cancelled"
+)
+
+// outcomeExitCodes are the exit statuses of the outcomes, as changed by the
+// exit code inputs.
+var outcomeExitCodes = defaultExitCodes()
+
+func defaultExitCodes() map[gateOutcome]int {
+	return map[gateOutcome]int{
+		outcomeApproved:  0,
+		outcomeDenied:    1,
+		outcomeTimedOut:  1,
+		outcomeError:     1,
+		outcomeCancelled: 1,
+	}
+}
+
+// parseExitCodes reads the exit statuses of the outcomes. Setting exit-zero
+// makes every outcome exit 0, so that callers branch on the status output
+// instead, and the exit code of an outcome can still be set on top of it.
+func parseExitCodes(exitZeroRaw string, codesRaw map[gateOutcome]string) (map[gateOutcome]int, error) {
+	codes := defaultExitCodes()
+	if exitZeroRaw != "" {
+		exitZero, err := strconv.ParseBool(exitZeroRaw)
+		if err != nil {
+			return nil, fmt.Errorf("error parsing exit zero: %w", err)
+		}
+		if exitZero {
+			for outcome := range codes {
+				codes[outcome] = 0
+			}
+		}
+	}
+	for outcome, raw := range codesRaw {
+		if raw == "" {
+			continue
+		}
+		code, err := strconv.Atoi(raw)
+		if err != nil || code < 0 || code > 255 {
+			return nil, fmt.Errorf("exit code %q for %s is not valid, expected a number from 0 to 255", raw, outcome)
+		}
+		codes[outcome] = code
+	}
+	return codes, nil
+}
+
+// setExitCodes applies the exit code inputs.
+func setExitCodes() error {
+	codes, err := parseExitCodes(os.Getenv(envVarExitZero), map[gateOutcome]string{
+		outcomeApproved: os.Getenv(envVarExitCodeApproved),
+		outcomeDenied:   os.Getenv(envVarExitCodeDenied),
+		outcomeTimedOut: os.Getenv(envVarExitCodeTimedOut),
+		outcomeError:    os.Getenv(envVarExitCodeError),
+	})
+	if err != nil {
+		return err
+	}
+	outcomeExitCodes = codes
+	return nil
+}
+
+// exitWith sets the status and approved outputs and exits with the status of
+// outcome.
+func exitWith(outcome gateOutcome) {
+	setOutput("status", string(outcome))
+	setOutput("approved", strconv.FormatBool(outcome == outcomeApproved))
+	exit(outcomeExitCodes[outcome])
+}
@@ -0,0 +1,75 @@
+package main
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParseExitCodes(t *testing.T) {
+	testCases := []struct {
+		name        string
+		exitZeroRaw string
+		codesRaw    map[gateOutcome]string
+		expected    map[gateOutcome]int
+		expectErr   bool
+	}{
+		{
+			name:     "defaults",
+			expected: map[gateOutcome]int{outcomeApproved: 0, outcomeDenied: 1, outcomeTimedOut: 1, outcomeError: 1, outcomeCancelled: 1},
+		},
+		{
+			name:     "denial_exits_zero",
+			codesRaw: map[gateOutcome]string{outcomeDenied: "0", outcomeTimedOut: ""},
+			expected: map[gateOutcome]int{outcomeApproved: 0, outcomeDenied: 0, outcomeTimedOut: 1, outcomeError: 1, outcomeCancelled: 1},
+		},
+		{
+			name:        "exit_zero",
+			exitZeroRaw: "true",
+			expected:    map[gateOutcome]int{outcomeApproved: 0, outcomeDenied: 0, outcomeTimedOut: 0, outcomeError: 0, outcomeCancelled: 0},
+		},
+		{
+			name:        "exit_zero_with_error_code",
+			exitZeroRaw: "true",
+			codesRaw:    map[gateOutcome]string{outcomeError: "2"},
+			expected:    map[gateOutcome]int{outcomeApproved: 0, outcomeDenied: 0, outcomeTimedOut: 0, outcomeError: 2, outcomeCancelled: 0},
+		},
+		{
+			name:        "exit_zero_false",
+			exitZeroRaw: "false",
+			expected:    map[gateOutcome]int{outcomeApproved: 0, outcomeDenied: 1, outcomeTimedOut: 1, outcomeError: 1, outcomeCancelled: 1},
+		},
+		{
+			name:        "invalid_exit_zero",
+			exitZeroRaw: "sometimes",
+			expectErr:   true,
+		},
+		{
+			name:      "code_not_a_number",
+			codesRaw:  map[gateOutcome]string{outcomeDenied: "no"},
+			expectErr: true,
+		},
+		{
+			name:      "code_out_of_range",
+			codesRaw:  map[gateOutcome]string{outcomeTimedOut: "256"},
+			expectErr: true,
+		},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			actual, err := parseExitCodes(testCase.exitZeroRaw, testCase.codesRaw)
+			if testCase.expectErr {
+				if err == nil {
+					t.Fatalf("expected an error, got %v", actual)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if !reflect.DeepEqual(actual, testCase.expected) {
+				t.Fatalf("expected %v, got %v", testCase.expected, actual)
+			}
+		})
+	}
+}
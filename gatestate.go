@@ -0,0 +1,144 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"strings"
+	"time"
+
+	"github.com/google/go-github/v43/github"
+)
+
+const gateStatePrefix string = "<!-- manual-approval-state "
+
+// gateState is the progress of an open gate that can't be recovered from the
+// comments alone. It is kept in a hidden comment on the approval issue, so
+// that a re-run of the waiting job resumes where the earlier attempt stopped
+// rather than starting over.
+type gateState struct {
+	RunAttempt int `json:"run_attempt"`
+	// ApprovalBodies are the bodies approval comments had when they were
+	// counted, by comment ID, so that edits made to them later can still be
+	// ignored after a restart.
+	ApprovalBodies map[int64]string `json:"approval_bodies,omitempty"`
+	// ConfirmedApprovals are the IDs of the approval comments that were
+	// confirmed, and ConfirmationCommentID the comment that confirms them
+	// when approval-confirmations is edit, so that approvals aren't
+	// confirmed twice.
+	ConfirmedApprovals    []int64 `json:"confirmed_approvals,omitempty"`
+	ConfirmationCommentID int64   `json:"confirmation_comment_id,omitempty"`
+	// MentionedPending is whether the approvers still pending after the
+	// first approval were mentioned.
+	MentionedPending bool `json:"mentioned_pending,omitempty"`
+	// PoolFallback is whether the pool of approvers was told that they can
+	// respond after the selected approvers didn't.
+	PoolFallback bool `json:"pool_fallback,omitempty"`
+	// Deadline is when the gate times out when max-wait is set, as computed
+	// by the attempt that started waiting, so that a re-run doesn't extend
+	// the wait.
+	Deadline *time.Time `json:"deadline,omitempty"`
+	// Outcome is the status the gate was resolved with, once the issue is
+	// closed, so that only the approval of an issue closed as approved is
+	// reused.
+	Outcome approvalStatus `json:"outcome,omitempty"`
+}
+
+// String renders the state as a hidden comment. The JSON encoder escapes
+// "<" and ">", so approval bodies can't end the comment early.
+func (s gateState) String() string {
+	data, _ := json.Marshal(s)
+	return gateStatePrefix + string(data) + " -->"
+}
+
+// parseGateState reads the state from a comment body. It returns false if
+// the comment doesn't hold a state.
+func parseGateState(body string) (gateState, bool) {
+	if !strings.HasPrefix(body, gateStatePrefix) || !strings.HasSuffix(body, " -->") {
+		return gateState{}, false
+	}
+	var state gateState
+	if err := json.Unmarshal([]byte(strings.TrimSuffix(strings.TrimPrefix(body, gateStatePrefix), " -->")), &state); err != nil {
+		return gateState{}, false
+	}
+	return state, true
+}
+
+// findGateState returns the latest state comment by author, who must be the
+// author of the approval issue so that nobody else can plant a state.
+func findGateState(comments []*github.IssueComment, author string) (*github.IssueComment, gateState) {
+	for i := len(comments) - 1; i >= 0; i-- {
+		if !strings.EqualFold(comments[i].User.GetLogin(), author) {
+			continue
+		}
+		if state, ok := parseGateState(comments[i].GetBody()); ok {
+			return comments[i], state
+		}
+	}
+	return nil, gateState{}
+}
+
+// loadGateState restores the state left on the approval issue by an earlier
+// attempt of the run.
+func (a *approvalEnvironment) loadGateState(ctx context.Context) error {
+	comments, err := a.listIssueComments(ctx)
+	if err != nil {
+		return err
+	}
+	comment, state := findGateState(comments, a.approvalIssue.GetUser().GetLogin())
+	if comment != nil {
+		a.gateStateCommentID = comment.GetID()
+		a.gateState = state
+	}
+	return nil
+}
+
+// saveGateState stores the approval bodies counted so far on the approval
+// issue if they changed since the state was last saved.
+func (a *approvalEnvironment) saveGateState(ctx context.Context, approvalBodies map[int64]string) error {
+	if sameApprovalBodies(a.gateState.ApprovalBodies, approvalBodies) {
+		return nil
+	}
+	state := a.gateState
+	state.ApprovalBodies = map[int64]string{}
+	for id, body := range approvalBodies {
+		state.ApprovalBodies[id] = body
+	}
+	return a.writeGateState(ctx, state)
+}
+
+// writeGateState stores state in the state comment on the approval issue,
+// creating it if there is none yet.
+func (a *approvalEnvironment) writeGateState(ctx context.Context, state gateState) error {
+	if a.approvalIssue == nil || a.sharedIssueFollower {
+		return nil
+	}
+	state.RunAttempt = a.runAttempt
+	body := state.String()
+	if a.gateStateCommentID == 0 {
+		comment, _, err := a.client.Issues.CreateComment(ctx, a.repoOwner, a.repo, a.approvalIssueNumber, &github.IssueComment{
+			Body: &body,
+		})
+		if err != nil {
+			return err
+		}
+		a.gateStateCommentID = comment.GetID()
+	} else if _, _, err := a.client.Issues.EditComment(ctx, a.repoOwner, a.repo, a.gateStateCommentID, &github.IssueComment{
+		Body: &body,
+	}); err != nil {
+		return err
+	}
+	a.gateState = state
+	return nil
+}
+
+func sameApprovalBodies(a, b map[int64]string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for id, body := range a {
+		if other, ok := b[id]; !ok || other != body {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,35 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/google/go-github/v43/github"
+)
+
+func TestGateState(t *testing.T) {
+	state := gateState{RunAttempt: 2, ApprovalBodies: map[int64]string{1: "approved -->", 2: "lgtm"}}
+
+	parsed, ok := parseGateState(state.String())
+	if !ok {
+		t.Fatalf("expected a state in %q", state.String())
+	}
+	if parsed.RunAttempt != state.RunAttempt || !sameApprovalBodies(parsed.ApprovalBodies, state.ApprovalBodies) {
+		t.Fatalf("expected %+v but got %+v", state, parsed)
+	}
+
+	if _, ok := parseGateState("approved"); ok {
+		t.Fatal("expected no state")
+	}
+
+	comments := []*github.IssueComment{
+		{ID: github.Int64(1), User: &github.User{Login: github.String("github-actions[bot]")}, Body: github.String(state.String())},
+		{ID: github.Int64(2), User: &github.User{Login: github.String("login1")}, Body: github.String(gateState{RunAttempt: 3}.String())},
+	}
+	comment, found := findGateState(comments, "github-actions[bot]")
+	if comment.GetID() != 1 || found.RunAttempt != 2 {
+		t.Fatalf("expected the state of the issue author but got comment %d with %+v", comment.GetID(), found)
+	}
+	if comment, _ := findGateState(comments, "login2"); comment != nil {
+		t.Fatalf("expected no state but got comment %d", comment.GetID())
+	}
+}
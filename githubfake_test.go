@@ -0,0 +1,524 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/google/go-github/v43/github"
+)
+
+// fakeGitHub keeps the issues, comments, labels, check runs, statuses and
+// deployments of a repository in memory, for tests of the gate lifecycle.
+// Issues and comments get creation times from a clock that advances by a
+// second on every write.
+type fakeGitHub struct {
+	mu          sync.Mutex
+	now         time.Time
+	nextID      int64
+	issues      map[int]*github.Issue
+	comments    map[int][]*github.IssueComment
+	labels      map[int][]*github.Label
+	checkRuns   map[int64]*github.CheckRun
+	statuses    map[string][]*github.RepoStatus
+	deployments []*github.Deployment
+	workflowRun *github.WorkflowRun
+	reviews     []*github.PullRequestReview
+	events      map[int][]*github.IssueEvent
+	permissions map[string]collaboratorPermission
+	deleted     []int
+	minimized   []string
+}
+
+func newFakeGitHub() *fakeGitHub {
+	return &fakeGitHub{
+		now:         time.Date(2022, 3, 1, 10, 0, 0, 0, time.UTC),
+		issues:      map[int]*github.Issue{},
+		comments:    map[int][]*github.IssueComment{},
+		labels:      map[int][]*github.Label{},
+		checkRuns:   map[int64]*github.CheckRun{},
+		statuses:    map[string][]*github.RepoStatus{},
+		events:      map[int][]*github.IssueEvent{},
+		permissions: map[string]collaboratorPermission{},
+	}
+}
+
+// client returns a client backed by the fake.
+func (f *fakeGitHub) client() *githubClient {
+	return &githubClient{
+		requestDoer:  fakeRequests{f},
+		Actions:      fakeActions{f},
+		Checks:       fakeChecks{f},
+		Issues:       fakeIssues{f},
+		PullRequests: fakePullRequests{f},
+		Repositories: fakeRepositories{f},
+	}
+}
+
+// tick advances the clock and returns the next ID.
+func (f *fakeGitHub) tick() (time.Time, int64) {
+	f.now = f.now.Add(time.Second)
+	f.nextID++
+	return f.now, f.nextID
+}
+
+// comment adds a comment by login to the issue.
+func (f *fakeGitHub) comment(number int, login, body string) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	createdAt, id := f.tick()
+	f.comments[number] = append(f.comments[number], &github.IssueComment{
+		ID:        github.Int64(id),
+		NodeID:    github.String(fmt.Sprintf("IC_%d", id)),
+		HTMLURL:   github.String(fmt.Sprintf("https://github.com/owner/repo/issues/%d#issuecomment-%d", number, id)),
+		Body:      github.String(body),
+		User:      &github.User{Login: github.String(login), Type: github.String("User")},
+		CreatedAt: &createdAt,
+		UpdatedAt: &createdAt,
+	})
+}
+
+func (f *fakeGitHub) notFound() *github.ErrorResponse {
+	return &github.ErrorResponse{Response: &http.Response{StatusCode: http.StatusNotFound}, Message: "Not Found"}
+}
+
+type fakeIssues struct{ *fakeGitHub }
+
+func (f fakeIssues) AddLabelsToIssue(ctx context.Context, owner string, repo string, number int, labels []string) ([]*github.Label, *github.Response, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	for _, name := range labels {
+		f.labels[number] = append(f.labels[number], &github.Label{Name: github.String(name)})
+	}
+	return f.labels[number], &github.Response{}, nil
+}
+
+func (f fakeIssues) Create(ctx context.Context, owner string, repo string, request *github.IssueRequest) (*github.Issue, *github.Response, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	createdAt, _ := f.tick()
+	number := len(f.issues) + len(f.deleted) + 1
+	issue := &github.Issue{
+		Number:    github.Int(number),
+		NodeID:    github.String(fmt.Sprintf("I_%d", number)),
+		Title:     request.Title,
+		Body:      request.Body,
+		State:     github.String("open"),
+		User:      &github.User{Login: github.String("github-actions[bot]"), Type: github.String("Bot")},
+		HTMLURL:   github.String(fmt.Sprintf("https://github.com/%s/%s/issues/%d", owner, repo, number)),
+		CreatedAt: &createdAt,
+	}
+	if request.Assignees != nil {
+		for _, login := range *request.Assignees {
+			issue.Assignees = append(issue.Assignees, &github.User{Login: github.String(login)})
+		}
+	}
+	if request.Labels != nil {
+		for _, name := range *request.Labels {
+			f.labels[number] = append(f.labels[number], &github.Label{Name: github.String(name)})
+		}
+	}
+	f.issues[number] = issue
+	return issue, &github.Response{}, nil
+}
+
+func (f fakeIssues) CreateComment(ctx context.Context, owner string, repo string, number int, comment *github.IssueComment) (*github.IssueComment, *github.Response, error) {
+	f.comment(number, "github-actions[bot]", comment.GetBody())
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	created := f.comments[number][len(f.comments[number])-1]
+	created.User.Type = github.String("Bot")
+	return created, &github.Response{}, nil
+}
+
+func (f fakeIssues) CreateLabel(ctx context.Context, owner string, repo string, label *github.Label) (*github.Label, *github.Response, error) {
+	return label, &github.Response{}, nil
+}
+
+func (f fakeIssues) Edit(ctx context.Context, owner string, repo string, number int, request *github.IssueRequest) (*github.Issue, *github.Response, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	issue, ok := f.issues[number]
+	if !ok {
+		return nil, nil, f.notFound()
+	}
+	if request.State != nil {
+		issue.State = request.State
+	}
+	if request.Body != nil {
+		issue.Body = request.Body
+	}
+	if request.Title != nil {
+		issue.Title = request.Title
+	}
+	if request.Assignees != nil {
+		issue.Assignees = nil
+		for _, login := range *request.Assignees {
+			issue.Assignees = append(issue.Assignees, &github.User{Login: github.String(login)})
+		}
+	}
+	return issue, &github.Response{}, nil
+}
+
+func (f fakeIssues) EditComment(ctx context.Context, owner string, repo string, commentID int64, comment *github.IssueComment) (*github.IssueComment, *github.Response, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	for _, comments := range f.comments {
+		for _, existing := range comments {
+			if existing.GetID() == commentID {
+				updatedAt, _ := f.tick()
+				existing.Body, existing.UpdatedAt = comment.Body, &updatedAt
+				return existing, &github.Response{}, nil
+			}
+		}
+	}
+	return nil, nil, f.notFound()
+}
+
+func (f fakeIssues) Get(ctx context.Context, owner string, repo string, number int) (*github.Issue, *github.Response, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	issue, ok := f.issues[number]
+	if !ok {
+		return nil, nil, f.notFound()
+	}
+	return issue, &github.Response{}, nil
+}
+
+func (f fakeIssues) GetLabel(ctx context.Context, owner string, repo string, name string) (*github.Label, *github.Response, error) {
+	return &github.Label{Name: github.String(name)}, &github.Response{}, nil
+}
+
+func (f fakeIssues) ListByRepo(ctx context.Context, owner string, repo string, opts *github.IssueListByRepoOptions) ([]*github.Issue, *github.Response, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	var issues []*github.Issue
+	for number := 1; number <= len(f.issues); number++ {
+		if issue := f.issues[number]; issue != nil && (opts.State == "all" || issue.GetState() == opts.State || (opts.State == "" && issue.GetState() == "open")) {
+			issues = append(issues, issue)
+		}
+	}
+	return issues, &github.Response{}, nil
+}
+
+// ListComments pages through the comments of the issue like GitHub does, 30
+// at a time unless the options ask for more.
+func (f fakeIssues) ListComments(ctx context.Context, owner string, repo string, number int, opts *github.IssueListCommentsOptions) ([]*github.IssueComment, *github.Response, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	comments := f.comments[number]
+	perPage, page := 30, 1
+	if opts != nil && opts.PerPage > 0 {
+		perPage = opts.PerPage
+	}
+	if opts != nil && opts.Page > 0 {
+		page = opts.Page
+	}
+	start, end := (page-1)*perPage, page*perPage
+	if start > len(comments) {
+		start = len(comments)
+	}
+	resp := &github.Response{}
+	if end < len(comments) {
+		resp.NextPage = page + 1
+	} else {
+		end = len(comments)
+	}
+	return append([]*github.IssueComment{}, comments[start:end]...), resp, nil
+}
+
+func (f fakeIssues) ListIssueEvents(ctx context.Context, owner, repo string, number int, opts *github.ListOptions) ([]*github.IssueEvent, *github.Response, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.events[number], &github.Response{}, nil
+}
+
+func (f fakeIssues) ListLabelsByIssue(ctx context.Context, owner string, repo string, number int, opts *github.ListOptions) ([]*github.Label, *github.Response, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.labels[number], &github.Response{}, nil
+}
+
+func (f fakeIssues) ListMilestones(ctx context.Context, owner string, repo string, opts *github.MilestoneListOptions) ([]*github.Milestone, *github.Response, error) {
+	return nil, &github.Response{}, nil
+}
+
+func (f fakeIssues) RemoveLabelForIssue(ctx context.Context, owner string, repo string, number int, label string) (*github.Response, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	for i, existing := range f.labels[number] {
+		if existing.GetName() == label {
+			f.labels[number] = append(f.labels[number][:i], f.labels[number][i+1:]...)
+			return &github.Response{}, nil
+		}
+	}
+	return nil, f.notFound()
+}
+
+type fakeChecks struct{ *fakeGitHub }
+
+func (f fakeChecks) CreateCheckRun(ctx context.Context, owner, repo string, opts github.CreateCheckRunOptions) (*github.CheckRun, *github.Response, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	_, id := f.tick()
+	checkRun := &github.CheckRun{
+		ID:         github.Int64(id),
+		Name:       github.String(opts.Name),
+		HeadSHA:    github.String(opts.HeadSHA),
+		Status:     opts.Status,
+		Conclusion: opts.Conclusion,
+		Output:     opts.Output,
+	}
+	f.checkRuns[id] = checkRun
+	return checkRun, &github.Response{}, nil
+}
+
+func (f fakeChecks) GetCheckRun(ctx context.Context, owner, repo string, checkRunID int64) (*github.CheckRun, *github.Response, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	checkRun, ok := f.checkRuns[checkRunID]
+	if !ok {
+		return nil, nil, f.notFound()
+	}
+	return checkRun, &github.Response{}, nil
+}
+
+func (f fakeChecks) ListCheckRunsForRef(ctx context.Context, owner, repo, ref string, opts *github.ListCheckRunsOptions) (*github.ListCheckRunsResults, *github.Response, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	results := &github.ListCheckRunsResults{}
+	for _, checkRun := range f.checkRuns {
+		if checkRun.GetHeadSHA() == ref && (opts == nil || opts.CheckName == nil || checkRun.GetName() == *opts.CheckName) {
+			results.CheckRuns = append(results.CheckRuns, checkRun)
+		}
+	}
+	results.Total = github.Int(len(results.CheckRuns))
+	return results, &github.Response{}, nil
+}
+
+func (f fakeChecks) UpdateCheckRun(ctx context.Context, owner, repo string, checkRunID int64, opts github.UpdateCheckRunOptions) (*github.CheckRun, *github.Response, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	checkRun, ok := f.checkRuns[checkRunID]
+	if !ok {
+		return nil, nil, f.notFound()
+	}
+	checkRun.Status, checkRun.Conclusion, checkRun.Output = opts.Status, opts.Conclusion, opts.Output
+	return checkRun, &github.Response{}, nil
+}
+
+type fakeActions struct{ *fakeGitHub }
+
+func (f fakeActions) GetWorkflowRunByID(ctx context.Context, owner, repo string, runID int64) (*github.WorkflowRun, *github.Response, error) {
+	if f.workflowRun == nil {
+		return nil, nil, f.notFound()
+	}
+	return f.workflowRun, &github.Response{}, nil
+}
+
+type fakePullRequests struct{ *fakeGitHub }
+
+func (f fakePullRequests) ListPullRequestsWithCommit(ctx context.Context, owner, repo, sha string, opts *github.PullRequestListOptions) ([]*github.PullRequest, *github.Response, error) {
+	return nil, &github.Response{}, nil
+}
+
+func (f fakePullRequests) ListReviews(ctx context.Context, owner, repo string, number int, opts *github.ListOptions) ([]*github.PullRequestReview, *github.Response, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.reviews, &github.Response{}, nil
+}
+
+// review adds a review of the commit sha by login to the pull request.
+func (f *fakeGitHub) review(login, state, sha string) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	submittedAt, id := f.tick()
+	f.reviews = append(f.reviews, &github.PullRequestReview{
+		ID:          github.Int64(id),
+		User:        &github.User{Login: github.String(login), Type: github.String("User")},
+		State:       github.String(state),
+		CommitID:    github.String(sha),
+		SubmittedAt: &submittedAt,
+	})
+}
+
+type fakeRepositories struct{ *fakeGitHub }
+
+func (f fakeRepositories) CreateDeployment(ctx context.Context, owner, repo string, request *github.DeploymentRequest) (*github.Deployment, *github.Response, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	_, id := f.tick()
+	deployment := &github.Deployment{ID: github.Int64(id), Ref: request.Ref, Environment: request.Environment}
+	f.deployments = append(f.deployments, deployment)
+	return deployment, &github.Response{}, nil
+}
+
+func (f fakeRepositories) CreateDeploymentStatus(ctx context.Context, owner, repo string, deployment int64, request *github.DeploymentStatusRequest) (*github.DeploymentStatus, *github.Response, error) {
+	return &github.DeploymentStatus{State: request.State}, &github.Response{}, nil
+}
+
+func (f fakeRepositories) CreateStatus(ctx context.Context, owner, repo, ref string, status *github.RepoStatus) (*github.RepoStatus, *github.Response, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.statuses[ref] = append(f.statuses[ref], status)
+	return status, &github.Response{}, nil
+}
+
+func (f fakeRepositories) Get(ctx context.Context, owner, repo string) (*github.Repository, *github.Response, error) {
+	return &github.Repository{Name: github.String(repo), FullName: github.String(owner + "/" + repo)}, &github.Response{}, nil
+}
+
+func (f fakeRepositories) GetContents(ctx context.Context, owner, repo, path string, opts *github.RepositoryContentGetOptions) (*github.RepositoryContent, []*github.RepositoryContent, *github.Response, error) {
+	return nil, nil, nil, f.notFound()
+}
+
+func (f fakeRepositories) ListStatuses(ctx context.Context, owner, repo, ref string, opts *github.ListOptions) ([]*github.RepoStatus, *github.Response, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.statuses[ref], &github.Response{}, nil
+}
+
+// fakeRequests answers the GraphQL query of issue polls from the fake's
+// comments, paging with the number of comments read as the cursor, deletes
+// issues, records the comments minimized and looks up the permissions of
+// collaborators. Other requests aren't supported.
+type fakeRequests struct{ *fakeGitHub }
+
+func (f fakeRequests) NewRequest(method, urlStr string, body interface{}) (*http.Request, error) {
+	content, err := json.Marshal(body)
+	if err != nil {
+		return nil, err
+	}
+	return http.NewRequest(method, "https://api.github.com/"+urlStr, bytes.NewReader(content))
+}
+
+func (f fakeRequests) Do(ctx context.Context, req *http.Request, v interface{}) (*github.Response, error) {
+	if strings.Contains(req.URL.Path, "/collaborators/") && strings.HasSuffix(req.URL.Path, "/permission") {
+		return f.collaboratorPermission(req, v)
+	}
+	var request graphQLRequest
+	content, err := io.ReadAll(req.Body)
+	if err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(content, &request); err != nil {
+		return nil, fmt.Errorf("unsupported request to %s", req.URL)
+	}
+	if strings.Contains(request.Query, "deleteIssue") {
+		return f.deleteIssue(request.Variables["issueId"])
+	}
+	if strings.Contains(request.Query, "minimizeComment") {
+		f.mu.Lock()
+		defer f.mu.Unlock()
+		subjectID, _ := request.Variables["subjectId"].(string)
+		f.minimized = append(f.minimized, subjectID)
+		return &github.Response{}, nil
+	}
+	if !strings.Contains(request.Query, "issueOrPullRequest") {
+		return nil, fmt.Errorf("unsupported request to %s", req.URL)
+	}
+	number, _ := request.Variables["number"].(float64)
+
+	f.mu.Lock()
+	var response issuePollResponse
+	issue := &response.Repository.IssueOrPullRequest
+	if existing, ok := f.issues[int(number)]; ok {
+		issue.State = strings.ToUpper(existing.GetState())
+		issue.Body = existing.GetBody()
+	}
+	// The cursor is the number of comments already read.
+	after := 0
+	if cursor, ok := request.Variables["commentsCursor"].(string); ok {
+		after, _ = strconv.Atoi(cursor)
+	}
+	comments := f.comments[int(number)]
+	issue.Comments.TotalCount = len(comments)
+	updated := append([]*github.IssueComment{}, comments...)
+	sort.SliceStable(updated, func(i, j int) bool { return updated[i].GetUpdatedAt().After(updated[j].GetUpdatedAt()) })
+	if len(updated) > 20 {
+		updated = updated[:20]
+	}
+	for _, comment := range updated {
+		issue.UpdatedComments.Nodes = append(issue.UpdatedComments.Nodes, struct {
+			DatabaseID int64     `json:"databaseId"`
+			UpdatedAt  time.Time `json:"updatedAt"`
+		}{comment.GetID(), comment.GetUpdatedAt()})
+	}
+	if after < len(comments) {
+		comments = comments[after:]
+	} else {
+		comments = nil
+	}
+	if len(comments) > 0 {
+		issue.Comments.PageInfo.EndCursor = strconv.Itoa(after + len(comments))
+	}
+	for _, comment := range comments {
+		node := struct {
+			ID         string       `json:"id"`
+			DatabaseID int64        `json:"databaseId"`
+			URL        string       `json:"url"`
+			Body       string       `json:"body"`
+			CreatedAt  time.Time    `json:"createdAt"`
+			UpdatedAt  time.Time    `json:"updatedAt"`
+			Author     graphQLActor `json:"author"`
+		}{
+			ID:         comment.GetNodeID(),
+			DatabaseID: comment.GetID(),
+			URL:        comment.GetHTMLURL(),
+			Body:       comment.GetBody(),
+			CreatedAt:  comment.GetCreatedAt(),
+			UpdatedAt:  comment.GetUpdatedAt(),
+			Author:     graphQLActor{Typename: "User", Login: comment.User.GetLogin()},
+		}
+		if comment.User.GetType() == "Bot" {
+			node.Author = graphQLActor{Typename: "Bot", Login: strings.TrimSuffix(comment.User.GetLogin(), "[bot]")}
+		}
+		issue.Comments.Nodes = append(issue.Comments.Nodes, node)
+	}
+	f.mu.Unlock()
+
+	data, err := json.Marshal(response)
+	if err != nil {
+		return nil, err
+	}
+	if result, ok := v.(*graphQLResponse); ok {
+		result.Data = data
+	}
+	return &github.Response{}, nil
+}
+
+func (f fakeRequests) collaboratorPermission(req *http.Request, v interface{}) (*github.Response, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	login := strings.TrimSuffix(req.URL.Path[strings.Index(req.URL.Path, "/collaborators/")+len("/collaborators/"):], "/permission")
+	permission, ok := f.permissions[login]
+	if !ok {
+		return nil, f.notFound()
+	}
+	if result, ok := v.(*collaboratorPermission); ok {
+		*result = permission
+	}
+	return &github.Response{}, nil
+}
+
+func (f fakeRequests) deleteIssue(nodeID interface{}) (*github.Response, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	for number, issue := range f.issues {
+		if issue.GetNodeID() == nodeID {
+			delete(f.issues, number)
+			delete(f.comments, number)
+			f.deleted = append(f.deleted, number)
+			return &github.Response{}, nil
+		}
+	}
+	return nil, fmt.Errorf("could not resolve to a node with the global id of %v", nodeID)
+}
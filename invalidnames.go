@@ -0,0 +1,154 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"unicode"
+
+	"github.com/google/go-github/v43/github"
+	"github.com/trstringer/manual-approval/pkg/approval"
+)
+
+// invalidNameReplier answers decisions whose deployment names can't be read,
+// which are not counted, so that the approver can correct them. Each comment
+// is answered once, and again only if it is edited and still not valid.
+type invalidNameReplier struct {
+	replied map[int64]string
+}
+
+func newInvalidNameReplier() *invalidNameReplier {
+	return &invalidNameReplier{replied: make(map[int64]string)}
+}
+
+// replies returns the replies to post for comments of the approvers that
+// make a decision but name deployments that can't be read.
+func (r *invalidNameReplier) replies(comments []*github.IssueComment, approvers []string, deploymentNames []string) []string {
+	if len(deploymentNames) == 0 {
+		return nil
+	}
+
+	var replies []string
+	for _, comment := range comments {
+		login := comment.User.GetLogin()
+		if approversIndex(approvers, login) < 0 {
+			continue
+		}
+		if body, ok := r.replied[comment.GetID()]; ok && body == comment.GetBody() {
+			continue
+		}
+
+		_, err := approval.ParseComment(comment.GetBody(), deploymentNames)
+		var parseErr *approval.ParseError
+		if !errors.As(err, &parseErr) || !isDecisionWithNames(comment.GetBody()) {
+			continue
+		}
+		r.replied[comment.GetID()] = comment.GetBody()
+		replies = append(replies, invalidNameReply(login, comment.GetBody(), parseErr, deploymentNames))
+	}
+	return replies
+}
+
+// isDecisionWithNames reports whether the text before the deployment names
+// of a comment is an approval or denial keyword, so that discussion that
+// happens to contain brackets isn't answered.
+func isDecisionWithNames(body string) bool {
+	first, _ := approval.SplitNote(body)
+	decision := first
+	if open := strings.IndexByte(first, '['); open >= 0 {
+		decision = first[:open]
+	}
+	approved, _ := isApproved(decision)
+	denied, _ := isDenied(decision)
+	return approved || denied
+}
+
+func invalidNameReply(login, body string, parseErr *approval.ParseError, deploymentNames []string) string {
+	var b strings.Builder
+	if parseErr.Name != "" {
+		fmt.Fprintf(&b, "@%s, `%s` is not one of the deployment names.", login, parseErr.Name)
+		if suggestions := approval.Suggest(parseErr.Name, deploymentNames); len(suggestions) > 0 {
+			fmt.Fprintf(&b, " Did you mean %s?", formatCodeList(suggestions, "or"))
+		}
+	} else {
+		fmt.Fprintf(&b, "@%s, the deployment names in your comment could not be read: %s.", login, parseErr.Message)
+	}
+	fmt.Fprintf(&b, " The valid names are %s. Your comment was not counted, please respond again with the names corrected", formatCodeList(deploymentNames, "and"))
+	if corrected := correctedDecision(body, deploymentNames); corrected != "" {
+		fmt.Fprintf(&b, ", such as `%s`", corrected)
+	}
+	b.WriteString(".")
+	return b.String()
+}
+
+// correctedDecision rewrites the first line of a decision whose deployment
+// names can't be read into one that can, for the reply to show: brackets
+// are closed and stray ones dropped, names separated by spaces instead of
+// commas are split, and a near miss is replaced by the deployment name it is
+// closest to, so that "approve [prod stagign" becomes
+// "approve [prod, staging]". It returns "" if a name matches no deployment.
+func correctedDecision(body string, deploymentNames []string) string {
+	first, _ := approval.SplitNote(body)
+	open := strings.IndexByte(first, '[')
+	if open < 0 {
+		return ""
+	}
+	decision := strings.TrimFunc(first[:open], func(r rune) bool {
+		return unicode.IsSpace(r) || strings.ContainsRune("*_~`", r)
+	})
+	content := first[open+1:]
+	if end := strings.LastIndexByte(content, ']'); end >= 0 {
+		content = content[:end]
+	}
+	content = strings.NewReplacer("[", "", "]", "", "\"", "", "'", "", "`", "").Replace(content)
+
+	valid := make(map[string]bool, len(deploymentNames))
+	for _, name := range deploymentNames {
+		valid[name] = true
+	}
+	resolve := func(name string) string {
+		if valid[name] {
+			return name
+		}
+		if suggestions := approval.Suggest(name, deploymentNames); len(suggestions) > 0 {
+			return suggestions[0]
+		}
+		return ""
+	}
+
+	var names []string
+	for _, segment := range strings.Split(content, ",") {
+		segment = strings.TrimSpace(segment)
+		if segment == "" {
+			continue
+		}
+		if name := resolve(segment); name != "" {
+			names = append(names, name)
+			continue
+		}
+		for _, field := range strings.Fields(segment) {
+			name := resolve(field)
+			if name == "" {
+				return ""
+			}
+			names = append(names, name)
+		}
+	}
+	if decision == "" || len(names) == 0 {
+		return ""
+	}
+	return fmt.Sprintf("%s [%s]", decision, strings.Join(names, ", "))
+}
+
+// formatCodeList formats names as inline code joined by conjunction, such as
+// "`prod`, `staging` and `dev`".
+func formatCodeList(names []string, conjunction string) string {
+	quoted := make([]string, len(names))
+	for i, name := range names {
+		quoted[i] = "`" + name + "`"
+	}
+	if len(quoted) == 1 {
+		return quoted[0]
+	}
+	return fmt.Sprintf("%s %s %s", strings.Join(quoted[:len(quoted)-1], ", "), conjunction, quoted[len(quoted)-1])
+}
@@ -0,0 +1,91 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/google/go-github/v43/github"
+)
+
+func TestInvalidNameReplies(t *testing.T) {
+	comment := func(id int64, login, body string) *github.IssueComment {
+		return &github.IssueComment{ID: github.Int64(id), User: &github.User{Login: github.String(login)}, Body: github.String(body)}
+	}
+	deploymentNames := []string{"prod", "staging", "dev"}
+	approvers := []string{"alice", "bob"}
+
+	replier := newInvalidNameReplier()
+	comments := []*github.IssueComment{
+		comment(1, "alice", "approve[prdo]"),
+		comment(2, "bob", "approve[prod"),
+		comment(3, "bob", "see [the runbook] first"),
+		comment(4, "mallory", "approve[qa]"),
+		comment(5, "alice", "approve[prod]"),
+	}
+	expected := []string{
+		"@alice, `prdo` is not one of the deployment names. Did you mean `prod`? The valid names are `prod`, `staging` and `dev`. Your comment was not counted, please respond again with the names corrected, such as `approve [prod]`.",
+		"@bob, the deployment names in your comment could not be read: missing closing bracket. The valid names are `prod`, `staging` and `dev`. Your comment was not counted, please respond again with the names corrected, such as `approve [prod]`.",
+	}
+	actual := replier.replies(comments, approvers, deploymentNames)
+	if len(actual) != len(expected) {
+		t.Fatalf("expected %q but got %q", expected, actual)
+	}
+	for i := range actual {
+		if actual[i] != expected[i] {
+			t.Fatalf("expected %q but got %q", expected[i], actual[i])
+		}
+	}
+
+	if again := replier.replies(comments, approvers, deploymentNames); len(again) != 0 {
+		t.Fatalf("expected comments to be answered once but got %q", again)
+	}
+
+	comments[0].Body = github.String("approve[qa]")
+	if edited := replier.replies(comments, approvers, deploymentNames); len(edited) != 1 {
+		t.Fatalf("expected an edited comment to be answered again but got %q", edited)
+	}
+}
+
+func TestCorrectedDecision(t *testing.T) {
+	deploymentNames := []string{"prod", "staging", "dev"}
+	testCases := []struct {
+		name     string
+		body     string
+		expected string
+	}{
+		{name: "missing closing bracket", body: "approve [prod, staging", expected: "approve [prod, staging]"},
+		{name: "spaces instead of commas", body: "approve [prod staging]", expected: "approve [prod, staging]"},
+		{name: "nested brackets", body: "deny [[prod], [dev]]", expected: "deny [prod, dev]"},
+		{name: "near miss", body: "**approve** [prdo, stagign]", expected: "approve [prod, staging]"},
+		{name: "unterminated quote", body: "approve [\"prod]\nlooks good", expected: "approve [prod]"},
+		{name: "unknown name", body: "approve [qa-eu-west]", expected: ""},
+		{name: "no brackets", body: "approve", expected: ""},
+		{name: "no decision", body: "[prod", expected: ""},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			if actual := correctedDecision(testCase.body, deploymentNames); actual != testCase.expected {
+				t.Fatalf("expected %q but got %q", testCase.expected, actual)
+			}
+		})
+	}
+}
+
+func TestFormatCodeList(t *testing.T) {
+	testCases := []struct {
+		names    []string
+		expected string
+	}{
+		{names: []string{"prod"}, expected: "`prod`"},
+		{names: []string{"prod", "dev"}, expected: "`prod` or `dev`"},
+		{names: []string{"prod", "staging", "dev"}, expected: "`prod`, `staging` or `dev`"},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.expected, func(t *testing.T) {
+			if actual := formatCodeList(testCase.names, "or"); actual != testCase.expected {
+				t.Fatalf("expected %q but got %q", testCase.expected, actual)
+			}
+		})
+	}
+}
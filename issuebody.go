@@ -0,0 +1,214 @@
+package main
+
+import (
+	"fmt"
+	"path/filepath"
+	"sort"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/google/go-github/v43/github"
+)
+
+// fencedBlock wraps content in a fenced code block of at most limit bytes.
+// Content that doesn't fit is truncated at a line boundary and followed by a
+// note pointing to the workflow run for the full output. The fence is longer
+// than any run of backticks in the content, so the content can't close it.
+func fencedBlock(content string, limit int, runURL string) string {
+	content = strings.TrimRight(content, "\n")
+	fence := "```"
+	for strings.Contains(content, fence) {
+		fence += "`"
+	}
+
+	block := func(content, note string) string {
+		return fmt.Sprintf("%s\n%s\n%s%s", fence, content, fence, note)
+	}
+	if full := block(content, ""); len(full) <= limit {
+		return full
+	}
+
+	note := fmt.Sprintf("\n\n*Truncated, see the [workflow run](%s) for the full output.*", runURL)
+	available := limit - len(block("", note))
+	if available <= 0 {
+		return strings.TrimPrefix(note, "\n\n")
+	}
+	truncated := content[:available]
+	if idx := strings.LastIndex(truncated, "\n"); idx > 0 {
+		truncated = truncated[:idx]
+	}
+	for !utf8.ValidString(truncated) {
+		truncated = truncated[:len(truncated)-1]
+	}
+	return block(truncated, note)
+}
+
+// workflowContext describes what is being approved: the workflow and job,
+// who triggered the run, and the branch, commit and pull request it runs for.
+func (a *approvalEnvironment) workflowContext() string {
+	var lines []string
+	if a.workflowName != "" {
+		workflow := a.workflowName
+		if a.jobName != "" {
+			workflow = fmt.Sprintf("%s (job `%s`)", workflow, a.jobName)
+		}
+		lines = append(lines, fmt.Sprintf("**Workflow:** %s", workflow))
+	}
+	if a.actor != "" {
+		lines = append(lines, fmt.Sprintf("**Triggered by:** [%s](https://github.com/%s)", a.actor, a.actor))
+	}
+	if a.branch != "" {
+		lines = append(lines, fmt.Sprintf("**Branch:** `%s`", a.branch))
+	}
+	if a.sha != "" {
+		shaShort := a.sha
+		if len(shaShort) > 7 {
+			shaShort = shaShort[:7]
+		}
+		lines = append(lines, fmt.Sprintf("**Commit:** [%s](https://github.com/%s/commit/%s)", shaShort, a.repoFullName, a.sha))
+	}
+	pullRequest := a.triggerPullRequest
+	if pullRequest == 0 {
+		pullRequest = a.pullRequestNumber
+	}
+	if pullRequest != 0 {
+		lines = append(lines, fmt.Sprintf("**Pull request:** #%d", pullRequest))
+	}
+	return strings.Join(lines, "\n")
+}
+
+// changedFilesSummary lists the changed files, collapsed in a details block
+// when there are more than collapseLineThreshold of them.
+func changedFilesSummary(files []*github.CommitFile) string {
+	if len(files) == 0 {
+		return ""
+	}
+	var lines []string
+	for _, file := range files {
+		line := fmt.Sprintf("- `%s`", file.GetFilename())
+		if previous := file.GetPreviousFilename(); previous != "" {
+			line = fmt.Sprintf("- `%s` → `%s`", previous, file.GetFilename())
+		}
+		if status := file.GetStatus(); status != "" && status != "modified" {
+			line = fmt.Sprintf("%s (%s)", line, status)
+		}
+		lines = append(lines, line)
+	}
+	list := strings.Join(lines, "\n")
+	if len(files) > collapseLineThreshold {
+		return fmt.Sprintf("<details>\n<summary>Changed files (%d)</summary>\n\n%s\n\n</details>", len(files), list)
+	}
+	return fmt.Sprintf("**Changed files (%d):**\n\n%s", len(files), list)
+}
+
+// inputsTable renders the inputs of a workflow_dispatch run as a markdown
+// table, sorted by input name.
+func inputsTable(inputs map[string]interface{}) string {
+	if len(inputs) == 0 {
+		return ""
+	}
+	names := make([]string, 0, len(inputs))
+	for name := range inputs {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	escape := strings.NewReplacer("|", "\\|", "\r\n", "<br>", "\n", "<br>")
+	rows := []string{"| Input | Value |", "| --- | --- |"}
+	for _, name := range names {
+		value := ""
+		if inputs[name] != nil {
+			value = fmt.Sprint(inputs[name])
+		}
+		if value != "" {
+			value = fmt.Sprintf("`%s`", escape.Replace(value))
+		}
+		rows = append(rows, fmt.Sprintf("| %s | %s |", escape.Replace(name), value))
+	}
+	return fmt.Sprintf("**Inputs:**\n\n%s", strings.Join(rows, "\n"))
+}
+
+// bodyFileSections renders the body file as fenced blocks, collapsed when it
+// is long. Content that doesn't fit in a single issue body or comment is
+// split at line boundaries into several parts, and content beyond
+// maxFollowUpComments parts is truncated.
+func bodyFileSections(name, content, runURL string) []string {
+	content = strings.TrimRight(content, "\n")
+	sectionLimit := maxIssueBodyLength - issueBodyReserve
+	parts := splitLines(content, sectionLimit-issueBodyReserve)
+	truncated := len(parts) > maxFollowUpComments
+	if truncated {
+		parts = parts[:maxFollowUpComments]
+	}
+
+	var sections []string
+	for i, part := range parts {
+		summary := fmt.Sprintf("`%s`", filepath.Base(name))
+		if len(parts) > 1 {
+			summary = fmt.Sprintf("%s (part %d of %d)", summary, i+1, len(parts))
+		}
+		block := fencedBlock(part, sectionLimit, runURL)
+		if truncated && i == len(parts)-1 {
+			block = fmt.Sprintf("%s\n\n*Truncated, see the [workflow run](%s) for the full output.*", block, runURL)
+		}
+		if i > 0 || strings.Count(part, "\n") >= collapseLineThreshold {
+			sections = append(sections, fmt.Sprintf("<details>\n<summary>%s</summary>\n\n%s\n\n</details>", summary, block))
+			continue
+		}
+		sections = append(sections, fmt.Sprintf("**%s:**\n\n%s", summary, block))
+	}
+	return sections
+}
+
+// splitLines splits content into parts of at most size bytes, at line
+// boundaries where possible.
+func splitLines(content string, size int) []string {
+	var parts []string
+	for len(content) > size {
+		cut := strings.LastIndex(content[:size+1], "\n")
+		if cut <= 0 {
+			cut = size
+			for cut > 0 && !utf8.RuneStart(content[cut]) {
+				cut--
+			}
+		}
+		parts = append(parts, content[:cut])
+		content = strings.TrimPrefix(content[cut:], "\n")
+	}
+	return append(parts, content)
+}
+
+// layoutIssueBody appends the sections to body for as long as they fit in an
+// issue body, followed by footer. The remaining sections are packed into
+// follow-up comments, keeping their order.
+func layoutIssueBody(body string, sections []string, footer string) (string, []string) {
+	limit := maxIssueBodyLength - issueBodyReserve
+	continued := "*Continued in the comments below.*"
+	reserved := len(footer) + len(continued) + 4
+
+	idx := 0
+	for ; idx < len(sections); idx++ {
+		if len(body)+len(sections[idx])+2+reserved > limit {
+			break
+		}
+		body = fmt.Sprintf("%s\n\n%s", body, sections[idx])
+	}
+
+	var comments []string
+	for _, section := range sections[idx:] {
+		last := len(comments) - 1
+		if last >= 0 && len(comments[last])+len(section)+2 <= limit {
+			comments[last] = fmt.Sprintf("%s\n\n%s", comments[last], section)
+			continue
+		}
+		comments = append(comments, section)
+	}
+
+	if len(comments) > 0 {
+		body = fmt.Sprintf("%s\n\n%s", body, continued)
+	}
+	if footer != "" {
+		body = fmt.Sprintf("%s\n\n%s", body, footer)
+	}
+	return body, comments
+}
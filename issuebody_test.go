@@ -0,0 +1,222 @@
+package main
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+	"testing"
+	"unicode/utf8"
+
+	"github.com/google/go-github/v43/github"
+)
+
+func TestFencedBlock(t *testing.T) {
+	runURL := "https://example.com/run"
+	note := "\n\n*Truncated, see the [workflow run](" + runURL + ") for the full output.*"
+	var lines []string
+	for i := 1; i <= 20; i++ {
+		lines = append(lines, fmt.Sprintf("line %02d", i))
+	}
+
+	testCases := []struct {
+		name     string
+		content  string
+		limit    int
+		expected string
+	}{
+		{
+			name:     "fits",
+			content:  "Plan: 1 to add, 0 to change, 0 to destroy.\n",
+			limit:    1000,
+			expected: "```\nPlan: 1 to add, 0 to change, 0 to destroy.\n```",
+		},
+		{
+			name:     "backticks_in_content",
+			content:  "```hcl\nresource {}\n```",
+			limit:    1000,
+			expected: "````\n```hcl\nresource {}\n```\n````",
+		},
+		{
+			name:     "truncated_at_line",
+			content:  strings.Join(lines, "\n"),
+			limit:    len(note) + 36,
+			expected: "```\nline 01\nline 02\nline 03\n```" + note,
+		},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			actual := fencedBlock(testCase.content, testCase.limit, runURL)
+			if actual != testCase.expected {
+				t.Fatalf("expected %q but got %q", testCase.expected, actual)
+			}
+			if len(actual) > testCase.limit {
+				t.Fatalf("expected at most %d bytes but got %d", testCase.limit, len(actual))
+			}
+		})
+	}
+
+	long := strings.Repeat("é", 5000)
+	if actual := fencedBlock(long, 500, runURL); len(actual) > 500 || !strings.Contains(actual, "Truncated") {
+		t.Fatalf("expected a truncated block of at most 500 bytes but got %d bytes", len(actual))
+	}
+}
+
+func TestWorkflowContext(t *testing.T) {
+	apprv := approvalEnvironment{
+		repoFullName:       "owner/repo",
+		workflowName:       "Deploy",
+		jobName:            "production",
+		actor:              "login1",
+		branch:             "main",
+		sha:                "0123456789abcdef",
+		triggerPullRequest: 12,
+	}
+
+	expected := strings.Join([]string{
+		"**Workflow:** Deploy (job `production`)",
+		"**Triggered by:** [login1](https://github.com/login1)",
+		"**Branch:** `main`",
+		"**Commit:** [0123456](https://github.com/owner/repo/commit/0123456789abcdef)",
+		"**Pull request:** #12",
+	}, "\n")
+	if actual := apprv.workflowContext(); actual != expected {
+		t.Fatalf("expected %q but got %q", expected, actual)
+	}
+
+	if actual := (&approvalEnvironment{}).workflowContext(); actual != "" {
+		t.Fatalf("expected no context but got %q", actual)
+	}
+}
+
+func TestChangedFilesSummary(t *testing.T) {
+	files := []*github.CommitFile{
+		{Filename: github.String("main.go"), Status: github.String("modified")},
+		{Filename: github.String("docs/new.md"), Status: github.String("added")},
+		{Filename: github.String("cmd/app.go"), PreviousFilename: github.String("app.go"), Status: github.String("renamed")},
+	}
+
+	expected := "**Changed files (3):**\n\n- `main.go`\n- `docs/new.md` (added)\n- `app.go` → `cmd/app.go` (renamed)"
+	if actual := changedFilesSummary(files); actual != expected {
+		t.Fatalf("expected %q but got %q", expected, actual)
+	}
+
+	var many []*github.CommitFile
+	for i := 0; i < collapseLineThreshold+1; i++ {
+		many = append(many, &github.CommitFile{Filename: github.String(fmt.Sprintf("file%d.go", i))})
+	}
+	if actual := changedFilesSummary(many); !strings.HasPrefix(actual, "<details>\n<summary>Changed files (21)</summary>") {
+		t.Fatalf("expected a collapsed list but got %q", actual)
+	}
+
+	if actual := changedFilesSummary(nil); actual != "" {
+		t.Fatalf("expected no summary but got %q", actual)
+	}
+}
+
+func TestInputsTable(t *testing.T) {
+	inputs := map[string]interface{}{
+		"version":     "1.2.3",
+		"environment": "production",
+		"dry-run":     false,
+		"notes":       "a | b\nc",
+		"empty":       "",
+	}
+
+	expected := strings.Join([]string{
+		"**Inputs:**",
+		"",
+		"| Input | Value |",
+		"| --- | --- |",
+		"| dry-run | `false` |",
+		"| empty |  |",
+		"| environment | `production` |",
+		"| notes | `a \\| b<br>c` |",
+		"| version | `1.2.3` |",
+	}, "\n")
+	if actual := inputsTable(inputs); actual != expected {
+		t.Fatalf("expected %q but got %q", expected, actual)
+	}
+
+	if actual := inputsTable(nil); actual != "" {
+		t.Fatalf("expected no table but got %q", actual)
+	}
+}
+
+func TestSplitLines(t *testing.T) {
+	expected := []string{"line 1\nline 2", "line 3", "abcdefghijkl"}
+	actual := splitLines("line 1\nline 2\nline 3\nabcdefghijkl", 13)
+	if !reflect.DeepEqual(actual, expected) {
+		t.Fatalf("expected %q but got %q", expected, actual)
+	}
+
+	expected = []string{"abcde", "fghij", "kl"}
+	actual = splitLines("abcdefghijkl", 5)
+	if !reflect.DeepEqual(actual, expected) {
+		t.Fatalf("expected %q but got %q", expected, actual)
+	}
+
+	for _, part := range splitLines(strings.Repeat("é", 10), 5) {
+		if !utf8.ValidString(part) {
+			t.Fatalf("expected parts to be split at rune boundaries but got %q", part)
+		}
+	}
+}
+
+func TestLayoutIssueBody(t *testing.T) {
+	small := "small section"
+	large := strings.Repeat("x", maxIssueBodyLength/2)
+
+	body, comments := layoutIssueBody("header", []string{small}, "<!-- marker -->")
+	if body != "header\n\nsmall section\n\n<!-- marker -->" || len(comments) != 0 {
+		t.Fatalf("expected the section in the body but got %q and %d comments", body, len(comments))
+	}
+
+	body, comments = layoutIssueBody("header", []string{large, large, small}, "<!-- marker -->")
+	if !strings.HasSuffix(body, "*Continued in the comments below.*\n\n<!-- marker -->") {
+		t.Fatalf("expected the body to point to the comments but got %q", body[len(body)-100:])
+	}
+	if len(body) > maxIssueBodyLength {
+		t.Fatalf("expected a body of at most %d bytes but got %d", maxIssueBodyLength, len(body))
+	}
+	if len(comments) != 1 || comments[0] != large+"\n\n"+small {
+		t.Fatalf("expected the remaining sections in one comment but got %d comments", len(comments))
+	}
+}
+
+func TestBodyFileSections(t *testing.T) {
+	runURL := "https://example.com/run"
+
+	sections := bodyFileSections("out/plan.txt", "Plan: 1 to add.", runURL)
+	if len(sections) != 1 || sections[0] != "**`plan.txt`:**\n\n```\nPlan: 1 to add.\n```" {
+		t.Fatalf("expected a single section but got %q", sections)
+	}
+
+	var lines []string
+	for i := 0; i < collapseLineThreshold+1; i++ {
+		lines = append(lines, "resource")
+	}
+	sections = bodyFileSections("plan.txt", strings.Join(lines, "\n"), runURL)
+	if len(sections) != 1 || !strings.HasPrefix(sections[0], "<details>\n<summary>`plan.txt`</summary>") {
+		t.Fatalf("expected a collapsed section but got %q", sections)
+	}
+
+	line := strings.Repeat("x", 99) + "\n"
+	sections = bodyFileSections("plan.txt", strings.Repeat(line, 2*maxIssueBodyLength/100), runURL)
+	if len(sections) != 3 {
+		t.Fatalf("expected 3 parts but got %d", len(sections))
+	}
+	for i, section := range sections {
+		if len(section) > maxIssueBodyLength-issueBodyReserve {
+			t.Fatalf("expected part %d to fit in a comment but got %d bytes", i+1, len(section))
+		}
+		if !strings.Contains(section, fmt.Sprintf("(part %d of 3)", i+1)) {
+			t.Fatalf("expected part %d to be numbered", i+1)
+		}
+	}
+
+	sections = bodyFileSections("plan.txt", strings.Repeat(line, (maxFollowUpComments+2)*maxIssueBodyLength/100), runURL)
+	if len(sections) != maxFollowUpComments || !strings.Contains(sections[len(sections)-1], "Truncated") {
+		t.Fatalf("expected %d parts ending in a truncation note but got %d", maxFollowUpComments, len(sections))
+	}
+}
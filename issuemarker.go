@@ -0,0 +1,157 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"github.com/google/go-github/v43/github"
+)
+
+const issueMarkerPrefix string = "<!-- manual-approval "
+
+// issueMarker is the machine-readable marker embedded in every approval
+// issue as a hidden HTML comment. Tooling such as the approval cache finds
+// approval issues by their marker rather than by their title.
+type issueMarker struct {
+	Gate       string
+	RunID      int
+	RunAttempt int
+	SHA        string
+	Branch     string
+	// SupersededBy is the number of the approval issue that replaced this
+	// one, once it has been superseded.
+	SupersededBy int
+	// Primary is the first approver selected by the round-robin rotation,
+	// from which the next run of the gate continues.
+	Primary string
+}
+
+func (a *approvalEnvironment) issueMarker() issueMarker {
+	return issueMarker{
+		Gate:       a.gateName,
+		RunID:      a.runID,
+		RunAttempt: a.runAttempt,
+		SHA:        a.sha,
+		Branch:     a.branch,
+		Primary:    a.rotationPrimary,
+	}
+}
+
+func (m issueMarker) String() string {
+	return fmt.Sprintf("%sgate=%s run=%d attempt=%d sha=%s branch=%s%s%s -->",
+		issueMarkerPrefix,
+		url.QueryEscape(m.Gate),
+		m.RunID,
+		m.RunAttempt,
+		url.QueryEscape(m.SHA),
+		url.QueryEscape(m.Branch),
+		m.supersededField(),
+		m.primaryField(),
+	)
+}
+
+func (m issueMarker) supersededField() string {
+	if m.SupersededBy == 0 {
+		return ""
+	}
+	return fmt.Sprintf(" superseded-by=%d", m.SupersededBy)
+}
+
+func (m issueMarker) primaryField() string {
+	if m.Primary == "" {
+		return ""
+	}
+	return " primary=" + url.QueryEscape(m.Primary)
+}
+
+// parseIssueMarker reads the marker from an issue body. It returns false if
+// the body has no marker.
+func parseIssueMarker(body string) (issueMarker, bool) {
+	start := strings.Index(body, issueMarkerPrefix)
+	if start < 0 {
+		return issueMarker{}, false
+	}
+	fields := body[start+len(issueMarkerPrefix):]
+	end := strings.Index(fields, "-->")
+	if end < 0 {
+		return issueMarker{}, false
+	}
+
+	var marker issueMarker
+	for _, field := range strings.Fields(fields[:end]) {
+		keyAndValue := strings.SplitN(field, "=", 2)
+		if len(keyAndValue) != 2 {
+			continue
+		}
+		value, err := url.QueryUnescape(keyAndValue[1])
+		if err != nil {
+			continue
+		}
+		switch keyAndValue[0] {
+		case "gate":
+			marker.Gate = value
+		case "run":
+			marker.RunID, _ = strconv.Atoi(value)
+		case "attempt":
+			marker.RunAttempt, _ = strconv.Atoi(value)
+		case "sha":
+			marker.SHA = value
+		case "branch":
+			marker.Branch = value
+		case "superseded-by":
+			marker.SupersededBy, _ = strconv.Atoi(value)
+		case "primary":
+			marker.Primary = value
+		}
+	}
+	return marker, true
+}
+
+// replaceIssueMarker replaces the marker in an issue body with marker.
+func replaceIssueMarker(body string, marker issueMarker) string {
+	start := strings.Index(body, issueMarkerPrefix)
+	if start < 0 {
+		return body
+	}
+	end := strings.Index(body[start:], "-->")
+	if end < 0 {
+		return body
+	}
+	return body[:start] + marker.String() + body[start+end+len("-->"):]
+}
+
+// findApprovalIssues lists the approval issues in the given state whose
+// marker satisfies match, most recently updated first. At most maxPages
+// pages of issues are searched.
+func (a *approvalEnvironment) findApprovalIssues(ctx context.Context, state string, maxPages int, match func(issueMarker) bool) ([]*github.Issue, error) {
+	opts := &github.IssueListByRepoOptions{
+		State:       state,
+		Sort:        "updated",
+		Direction:   "desc",
+		ListOptions: github.ListOptions{PerPage: 100},
+	}
+
+	var found []*github.Issue
+	for page := 0; page < maxPages; page++ {
+		issues, resp, err := a.client.Issues.ListByRepo(ctx, a.repoOwner, a.repo, opts)
+		if err != nil {
+			return nil, err
+		}
+		for _, issue := range issues {
+			if issue.IsPullRequest() {
+				continue
+			}
+			if marker, ok := parseIssueMarker(issue.GetBody()); ok && match(marker) {
+				found = append(found, issue)
+			}
+		}
+		if resp.NextPage == 0 {
+			break
+		}
+		opts.Page = resp.NextPage
+	}
+	return found, nil
+}
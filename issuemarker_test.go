@@ -0,0 +1,76 @@
+package main
+
+import (
+	"testing"
+)
+
+func TestIssueMarker(t *testing.T) {
+	marker := issueMarker{Gate: "pre deploy", RunID: 1234, RunAttempt: 2, SHA: "0123456789abcdef", Branch: "feature/x"}
+	body := "Workflow is pending manual review.\n\n" + marker.String()
+
+	if expected := "<!-- manual-approval gate=pre+deploy run=1234 attempt=2 sha=0123456789abcdef branch=feature%2Fx -->"; marker.String() != expected {
+		t.Fatalf("expected %q but got %q", expected, marker.String())
+	}
+
+	actual, ok := parseIssueMarker(body)
+	if !ok {
+		t.Fatal("expected a marker")
+	}
+	if actual != marker {
+		t.Fatalf("expected %+v but got %+v", marker, actual)
+	}
+
+	marker.Primary = "octo-cat"
+	if parsed, _ := parseIssueMarker(marker.String()); parsed != marker {
+		t.Fatalf("expected %+v but got %+v", marker, parsed)
+	}
+
+	if _, ok := parseIssueMarker("Workflow is pending manual review."); ok {
+		t.Fatal("expected no marker")
+	}
+	if _, ok := parseIssueMarker("<!-- manual-approval gate=x"); ok {
+		t.Fatal("expected no marker for an unterminated comment")
+	}
+}
+
+func TestReplaceIssueMarker(t *testing.T) {
+	marker := issueMarker{Gate: "pre-deploy", RunID: 1234, RunAttempt: 1, SHA: "abc", Branch: "main"}
+	body := "Workflow is pending manual review.\n\n" + marker.String() + "\n"
+
+	marker.SupersededBy = 42
+	actual := replaceIssueMarker(body, marker)
+	if expected := "Workflow is pending manual review.\n\n" + marker.String() + "\n"; actual != expected {
+		t.Fatalf("expected %q but got %q", expected, actual)
+	}
+	if parsed, _ := parseIssueMarker(actual); parsed != marker {
+		t.Fatalf("expected %+v but got %+v", marker, parsed)
+	}
+	if actual := replaceIssueMarker("no marker", marker); actual != "no marker" {
+		t.Fatalf("expected the body to be unchanged but got %q", actual)
+	}
+}
+
+func TestSupersedesMarker(t *testing.T) {
+	current := issueMarker{Gate: "pre-deploy", RunID: 2, Branch: "main"}
+
+	testCases := []struct {
+		name       string
+		older      issueMarker
+		supersedes bool
+	}{
+		{name: "same_gate_and_branch", older: issueMarker{Gate: "pre-deploy", RunID: 1, Branch: "main"}, supersedes: true},
+		{name: "same_run", older: issueMarker{Gate: "pre-deploy", RunID: 2, Branch: "main"}, supersedes: false},
+		{name: "newer_run", older: issueMarker{Gate: "pre-deploy", RunID: 3, Branch: "main"}, supersedes: false},
+		{name: "other_gate", older: issueMarker{Gate: "post-deploy", RunID: 1, Branch: "main"}, supersedes: false},
+		{name: "other_branch", older: issueMarker{Gate: "pre-deploy", RunID: 1, Branch: "dev"}, supersedes: false},
+		{name: "already_superseded", older: issueMarker{Gate: "pre-deploy", RunID: 1, Branch: "main", SupersededBy: 7}, supersedes: false},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			if actual := supersedesMarker(current, testCase.older); actual != testCase.supersedes {
+				t.Fatalf("expected %v but got %v", testCase.supersedes, actual)
+			}
+		})
+	}
+}
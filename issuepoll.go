@@ -0,0 +1,254 @@
+package main
+
+import (
+	"context"
+	"strings"
+	"time"
+
+	"github.com/google/go-github/v43/github"
+)
+
+// issuePollFields are the fields of an issue or pull request read on every
+// poll. The most recently updated comments and the number of comments show
+// whether comments already read were edited or deleted. Timeline events are
+// only fetched when labels or closing the issue can decide the gate.
+const issuePollFields = `
+state
+body
+comments(first: 100, after: $commentsCursor) {
+  totalCount
+  pageInfo { hasNextPage endCursor }
+  nodes { id databaseId url body createdAt updatedAt author { __typename login } }
+}
+updatedComments: comments(first: 20, orderBy: {field: UPDATED_AT, direction: DESC}) {
+  nodes { databaseId updatedAt }
+}
+timelineItems(first: 100, after: $eventsCursor, itemTypes: [CLOSED_EVENT, LABELED_EVENT]) @include(if: $withEvents) {
+  pageInfo { hasNextPage endCursor }
+  nodes {
+    __typename
+    ... on ClosedEvent { createdAt stateReason actor { __typename login } }
+    ... on LabeledEvent { createdAt label { name } actor { __typename login } }
+  }
+}`
+
+// issuePollQuery reads the comments, the label and close events and the
+// state of the approval issue in a single request, where the REST API needs
+// one request for each.
+const issuePollQuery = `query($owner: String!, $name: String!, $number: Int!, $commentsCursor: String, $eventsCursor: String, $withEvents: Boolean!) {
+  repository(owner: $owner, name: $name) {
+    issueOrPullRequest(number: $number) {
+      ... on Issue {` + issuePollFields + `}
+      ... on PullRequest {` + issuePollFields + `}
+    }
+  }
+}`
+
+type graphQLActor struct {
+	Typename string `json:"__typename"`
+	Login    string `json:"login"`
+}
+
+// user converts the actor into a REST API user. The GraphQL API leaves the
+// [bot] suffix off the logins of GitHub Apps, which the REST API includes.
+func (a graphQLActor) user() *github.User {
+	login := a.Login
+	userType := "User"
+	if a.Typename == "Bot" {
+		login += "[bot]"
+		userType = "Bot"
+	}
+	return &github.User{Login: github.String(login), Type: github.String(userType)}
+}
+
+type graphQLPageInfo struct {
+	HasNextPage bool   `json:"hasNextPage"`
+	EndCursor   string `json:"endCursor"`
+}
+
+type issuePollResponse struct {
+	Repository struct {
+		IssueOrPullRequest struct {
+			State    string `json:"state"`
+			Body     string `json:"body"`
+			Comments struct {
+				TotalCount int             `json:"totalCount"`
+				PageInfo   graphQLPageInfo `json:"pageInfo"`
+				Nodes      []struct {
+					ID         string       `json:"id"`
+					DatabaseID int64        `json:"databaseId"`
+					URL        string       `json:"url"`
+					Body       string       `json:"body"`
+					CreatedAt  time.Time    `json:"createdAt"`
+					UpdatedAt  time.Time    `json:"updatedAt"`
+					Author     graphQLActor `json:"author"`
+				} `json:"nodes"`
+			} `json:"comments"`
+			UpdatedComments struct {
+				Nodes []struct {
+					DatabaseID int64     `json:"databaseId"`
+					UpdatedAt  time.Time `json:"updatedAt"`
+				} `json:"nodes"`
+			} `json:"updatedComments"`
+			TimelineItems struct {
+				PageInfo graphQLPageInfo `json:"pageInfo"`
+				Nodes    []struct {
+					Typename    string       `json:"__typename"`
+					CreatedAt   time.Time    `json:"createdAt"`
+					StateReason string       `json:"stateReason"`
+					Actor       graphQLActor `json:"actor"`
+					Label       struct {
+						Name string `json:"name"`
+					} `json:"label"`
+				} `json:"nodes"`
+			} `json:"timelineItems"`
+		} `json:"issueOrPullRequest"`
+	} `json:"repository"`
+}
+
+// issuePoll is what the polls read from the approval issue. Polls only read
+// the comments and events after the cursors of the previous poll and add
+// them to the ones already read.
+type issuePoll struct {
+	state          string
+	body           string
+	comments       []*github.IssueComment
+	labelEvents    []*github.IssueEvent
+	closeEvents    []closeTimelineEvent
+	commentsCursor string
+	eventsCursor   string
+	reads          int
+	// commentsRead is the number of comments read, including those left out
+	// as older than the request.
+	commentsRead int
+}
+
+// add appends a page of the response to the poll, converting comments and
+// events to the REST API types the decision logic works with.
+func (p *issuePoll) add(response issuePollResponse) {
+	issue := response.Repository.IssueOrPullRequest
+	p.state = strings.ToLower(issue.State)
+	p.body = issue.Body
+	p.commentsRead += len(issue.Comments.Nodes)
+	for _, node := range issue.Comments.Nodes {
+		createdAt, updatedAt := node.CreatedAt, node.UpdatedAt
+		p.comments = append(p.comments, &github.IssueComment{
+			ID:        github.Int64(node.DatabaseID),
+			NodeID:    github.String(node.ID),
+			HTMLURL:   github.String(node.URL),
+			Body:      github.String(node.Body),
+			User:      node.Author.user(),
+			CreatedAt: &createdAt,
+			UpdatedAt: &updatedAt,
+		})
+	}
+	for _, node := range issue.TimelineItems.Nodes {
+		createdAt := node.CreatedAt
+		switch node.Typename {
+		case "LabeledEvent":
+			p.labelEvents = append(p.labelEvents, &github.IssueEvent{
+				Event:     github.String("labeled"),
+				Label:     &github.Label{Name: github.String(node.Label.Name)},
+				Actor:     node.Actor.user(),
+				CreatedAt: &createdAt,
+			})
+		case "ClosedEvent":
+			event := closeTimelineEvent{
+				Event:       "closed",
+				StateReason: strings.ToLower(node.StateReason),
+				CreatedAt:   &createdAt,
+			}
+			event.Actor.Login = node.Actor.user().GetLogin()
+			p.closeEvents = append(p.closeEvents, event)
+		}
+	}
+}
+
+// edited reports whether a comment the poll already read was edited since,
+// going by the most recently updated comments of the issue.
+func (p *issuePoll) edited(response issuePollResponse) bool {
+	read := make(map[int64]time.Time, len(p.comments))
+	for _, comment := range p.comments {
+		read[comment.GetID()] = comment.GetUpdatedAt()
+	}
+	for _, node := range response.Repository.IssueOrPullRequest.UpdatedComments.Nodes {
+		if updatedAt, ok := read[node.DatabaseID]; ok && node.UpdatedAt.After(updatedAt) {
+			return true
+		}
+	}
+	return false
+}
+
+// pollIssue reads what changed on the approval issue since the last poll,
+// usually in a single request, so that the work per poll doesn't grow with
+// the number of comments. Edits and deletions of comments already read
+// don't move the cursors, so when a poll finds one, or every
+// commentResyncPolls polls, everything is read again.
+func (a *approvalEnvironment) pollIssue(ctx context.Context) (*issuePoll, error) {
+	poll := a.lastPoll
+	if poll == nil || poll.reads%commentResyncPolls == 0 {
+		reads := 0
+		if poll != nil {
+			reads = poll.reads
+		}
+		poll = &issuePoll{reads: reads}
+	}
+
+	changed, err := a.readIssue(ctx, poll)
+	if err != nil {
+		return nil, err
+	}
+	if changed {
+		poll = &issuePoll{reads: poll.reads}
+		if _, err := a.readIssue(ctx, poll); err != nil {
+			return nil, err
+		}
+	}
+	poll.comments = a.commentsAfterRequest(poll.comments)
+	poll.reads++
+	a.lastPoll = poll
+	return poll, nil
+}
+
+// readIssue adds what changed on the approval issue after the cursors of
+// the poll to it. It returns true if a comment the poll already read was
+// edited or deleted, which the cursors don't show.
+func (a *approvalEnvironment) readIssue(ctx context.Context, poll *issuePoll) (bool, error) {
+	changed := false
+
+	withEvents := a.approvalIssue != nil && (a.approveLabel != "" || a.denyLabel != "" || a.closeDecisions)
+	variables := map[string]interface{}{
+		"owner":      a.repoOwner,
+		"name":       a.repo,
+		"number":     a.approvalIssueNumber,
+		"withEvents": withEvents,
+	}
+	for {
+		// A connection that is already exhausted returns no cursor, and the
+		// next poll continues from the last one it returned.
+		if poll.commentsCursor != "" {
+			variables["commentsCursor"] = poll.commentsCursor
+		}
+		if poll.eventsCursor != "" {
+			variables["eventsCursor"] = poll.eventsCursor
+		}
+
+		var response issuePollResponse
+		if err := a.graphQL(ctx, issuePollQuery, variables, &response); err != nil {
+			return false, err
+		}
+		changed = changed || poll.edited(response)
+		poll.add(response)
+
+		issue := response.Repository.IssueOrPullRequest
+		if issue.Comments.PageInfo.EndCursor != "" {
+			poll.commentsCursor = issue.Comments.PageInfo.EndCursor
+		}
+		if issue.TimelineItems.PageInfo.EndCursor != "" {
+			poll.eventsCursor = issue.TimelineItems.PageInfo.EndCursor
+		}
+		if !issue.Comments.PageInfo.HasNextPage && !issue.TimelineItems.PageInfo.HasNextPage {
+			return changed || issue.Comments.TotalCount < poll.commentsRead, nil
+		}
+	}
+}
@@ -0,0 +1,157 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strconv"
+	"testing"
+
+	"github.com/google/go-github/v43/github"
+)
+
+func TestIssuePollAdd(t *testing.T) {
+	data := `{"repository": {"issueOrPullRequest": {
+		"state": "CLOSED",
+		"body": "body",
+		"comments": {"nodes": [
+			{"databaseId": 1, "body": "approved", "createdAt": "2022-01-01T00:00:00Z", "updatedAt": "2022-01-01T00:00:00Z", "author": {"__typename": "User", "login": "login1"}},
+			{"databaseId": 2, "body": "approved", "createdAt": "2022-01-01T00:01:00Z", "updatedAt": "2022-01-01T00:01:00Z", "author": {"__typename": "Bot", "login": "deploy-bot"}}
+		]},
+		"timelineItems": {"nodes": [
+			{"__typename": "LabeledEvent", "createdAt": "2022-01-01T00:02:00Z", "label": {"name": "approved"}, "actor": {"__typename": "User", "login": "login2"}},
+			{"__typename": "ClosedEvent", "createdAt": "2022-01-01T00:03:00Z", "stateReason": "NOT_PLANNED", "actor": {"__typename": "User", "login": "login3"}}
+		]}
+	}}}`
+	var response issuePollResponse
+	if err := json.Unmarshal([]byte(data), &response); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	poll := &issuePoll{}
+	poll.add(response)
+
+	if poll.state != "closed" || poll.body != "body" {
+		t.Fatalf("expected closed issue with body but got %q with %q", poll.state, poll.body)
+	}
+	if len(poll.comments) != 2 {
+		t.Fatalf("expected 2 comments but got %d", len(poll.comments))
+	}
+	if poll.comments[0].GetID() != 1 || poll.comments[0].User.GetLogin() != "login1" {
+		t.Fatalf("expected comment 1 from login1 but got %d from %s", poll.comments[0].GetID(), poll.comments[0].User.GetLogin())
+	}
+	if poll.comments[1].User.GetLogin() != "deploy-bot[bot]" || poll.comments[1].User.GetType() != "Bot" {
+		t.Fatalf("expected comment from bot deploy-bot[bot] but got %s of type %s", poll.comments[1].User.GetLogin(), poll.comments[1].User.GetType())
+	}
+
+	labelComments := labelDecisionComments(poll.labelEvents, "approved", "denied")
+	if len(labelComments) != 1 || labelComments[0].User.GetLogin() != "login2" || labelComments[0].GetBody() != gateWords.Approved[0] {
+		t.Fatalf("expected approval label from login2 but got %v", labelComments)
+	}
+	closeComments := closeDecisionComments(poll.closeEvents)
+	if len(closeComments) != 1 || closeComments[0].User.GetLogin() != "login3" || closeComments[0].GetBody() != gateWords.Denied[0] {
+		t.Fatalf("expected denial close from login3 but got %v", closeComments)
+	}
+}
+
+func TestPollIssueIncremental(t *testing.T) {
+	var cursors []interface{}
+	requests := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var request graphQLRequest
+		if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+			t.Errorf("unexpected error: %v", err)
+		}
+		requests++
+		cursors = append(cursors, request.Variables["commentsCursor"])
+
+		// Every request returns one comment after the cursor it was given.
+		id := 1
+		if cursor, ok := request.Variables["commentsCursor"].(string); ok {
+			id, _ = strconv.Atoi(cursor)
+			id++
+		}
+		fmt.Fprintf(w, `{"data": {"repository": {"issueOrPullRequest": {"state": "OPEN", "comments": {
+			"totalCount": %d,
+			"pageInfo": {"hasNextPage": false, "endCursor": "%d"},
+			"nodes": [{"databaseId": %d, "body": "comment", "author": {"__typename": "User", "login": "login1"}}]
+		}}}}}`, id, id, id)
+	}))
+	defer server.Close()
+
+	client := github.NewClient(nil)
+	client.BaseURL, _ = url.Parse(server.URL + "/")
+	apprv := &approvalEnvironment{client: wrapGithubClient(client), repoOwner: "owner", repo: "repo", approvalIssueNumber: 1}
+
+	for i := 1; i <= commentResyncPolls; i++ {
+		poll, err := apprv.pollIssue(context.Background())
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if len(poll.comments) != i {
+			t.Fatalf("poll %d: expected %d comments but got %d", i, i, len(poll.comments))
+		}
+	}
+	if cursors[0] != nil || cursors[1] != "1" {
+		t.Fatalf("expected the second poll to continue after the first but got cursors %v", cursors)
+	}
+
+	poll, err := apprv.pollIssue(context.Background())
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if cursors[len(cursors)-1] != nil || len(poll.comments) != 1 {
+		t.Fatalf("expected all comments to be read again but got cursor %v and %d comments", cursors[len(cursors)-1], len(poll.comments))
+	}
+	if requests != commentResyncPolls+1 {
+		t.Fatalf("expected one request per poll but got %d", requests)
+	}
+}
+
+func TestPollIssueRereadsEditedComments(t *testing.T) {
+	ctx := context.Background()
+	fake := newFakeGitHub()
+	apprv, err := newApprovalEnvironment(fake.client(), "owner/repo", "owner", 1, []string{"user1"}, 1, nil)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if err := apprv.createApprovalIssue(ctx); err != nil {
+		t.Fatalf("error creating approval issue: %v", err)
+	}
+	number := apprv.approvalIssueNumber
+	fake.comment(number, "user1", "approve")
+	fake.comment(number, "user2", "looks good")
+	if _, err := apprv.pollIssue(ctx); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	approval := fake.comments[number][len(fake.comments[number])-2]
+	if _, _, err := fake.client().Issues.EditComment(ctx, "owner", "repo", approval.GetID(), &github.IssueComment{Body: github.String("deny")}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	poll, err := apprv.pollIssue(ctx)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	var bodies []string
+	for _, comment := range poll.comments {
+		bodies = append(bodies, comment.GetBody())
+	}
+	if len(bodies) < 2 || bodies[len(bodies)-2] != "deny" {
+		t.Fatalf("expected the edited comment to be read on the next poll, got %q", bodies)
+	}
+
+	fake.comments[number] = fake.comments[number][:len(fake.comments[number])-2]
+	poll, err = apprv.pollIssue(ctx)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	for _, comment := range poll.comments {
+		if comment.GetID() == approval.GetID() {
+			t.Fatalf("expected the deleted comment to be gone on the next poll, got %q", comment.GetBody())
+		}
+	}
+}
@@ -0,0 +1,167 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"path"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// issueTemplateDir is where a repository keeps its issue templates and forms.
+const issueTemplateDir string = ".github/ISSUE_TEMPLATE"
+
+// stringList decodes either a YAML sequence or a comma-delimited string, as
+// both are accepted for the labels of issue templates.
+type stringList []string
+
+func (l *stringList) UnmarshalYAML(value *yaml.Node) error {
+	if value.Kind == yaml.ScalarNode {
+		*l = splitInputList(value.Value)
+		return nil
+	}
+	var list []string
+	if err := value.Decode(&list); err != nil {
+		return err
+	}
+	*l = list
+	return nil
+}
+
+// issueFormElement is one element of the body of an issue form.
+type issueFormElement struct {
+	Type       string `yaml:"type"`
+	ID         string `yaml:"id"`
+	Attributes struct {
+		Label   string        `yaml:"label"`
+		Options []interface{} `yaml:"options"`
+	} `yaml:"attributes"`
+}
+
+// issueTemplate is a Markdown issue template or an issue form. Issues created
+// through the API skip the template, so the approval issue is rendered the
+// way GitHub renders an issue created from it.
+type issueTemplate struct {
+	Title  string             `yaml:"title"`
+	Labels stringList         `yaml:"labels"`
+	Body   []issueFormElement `yaml:"body"`
+	// markdown is the body of a Markdown template. It is empty for forms.
+	markdown string
+	isForm   bool
+}
+
+// parseIssueTemplate parses an issue form (.yml or .yaml) or a Markdown
+// template with YAML front matter (.md).
+func parseIssueTemplate(name string, content []byte) (*issueTemplate, error) {
+	var template issueTemplate
+	switch path.Ext(name) {
+	case ".yml", ".yaml":
+		if err := yaml.Unmarshal(content, &template); err != nil {
+			return nil, err
+		}
+		template.isForm = true
+	case ".md":
+		text := strings.ReplaceAll(string(content), "\r\n", "\n")
+		if strings.HasPrefix(text, "---\n") {
+			end := strings.Index(text[4:], "\n---")
+			if end < 0 {
+				return nil, fmt.Errorf("unterminated front matter")
+			}
+			if err := yaml.Unmarshal([]byte(text[4:4+end]), &template); err != nil {
+				return nil, err
+			}
+			text = strings.TrimPrefix(text[4+end+4:], "\n")
+		}
+		template.markdown = strings.TrimSpace(text)
+	default:
+		return nil, fmt.Errorf("unsupported issue template %s, expected a .md, .yml or .yaml file", name)
+	}
+	return &template, nil
+}
+
+// render builds the issue body from the template. Form fields whose ID
+// matches a placeholder of values are filled with its value, and the
+// approval details go into the first remaining textarea, or after the form
+// if there is none. For Markdown templates, placeholders are replaced and the
+// approval details are appended.
+func (t *issueTemplate) render(details string, values map[string]string, replace func(string) string) string {
+	if !t.isForm {
+		if t.markdown == "" {
+			return details
+		}
+		return fmt.Sprintf("%s\n\n%s", replace(t.markdown), details)
+	}
+
+	var sections []string
+	detailsPlaced := false
+	for _, element := range t.Body {
+		if element.Type == "markdown" {
+			// Markdown elements are only shown while filling in the form.
+			continue
+		}
+		value, ok := values[strings.ToLower(element.ID)]
+		if !ok || value == "" {
+			value = ""
+			if element.Type == "textarea" && !detailsPlaced {
+				value = details
+				detailsPlaced = true
+			}
+		}
+		switch element.Type {
+		case "checkboxes":
+			var options []string
+			for _, option := range element.Attributes.Options {
+				if option, ok := option.(map[string]interface{}); ok {
+					options = append(options, fmt.Sprintf("- [ ] %v", option["label"]))
+				}
+			}
+			value = strings.Join(options, "\n")
+		case "dropdown":
+			matched := ""
+			for _, option := range element.Attributes.Options {
+				if strings.EqualFold(fmt.Sprint(option), value) {
+					matched = fmt.Sprint(option)
+				}
+			}
+			value = matched
+		}
+		if value == "" {
+			value = "_No response_"
+		}
+		sections = append(sections, fmt.Sprintf("### %s\n\n%s", element.Attributes.Label, value))
+	}
+	if !detailsPlaced {
+		sections = append(sections, details)
+	}
+	return strings.Join(sections, "\n\n")
+}
+
+// readIssueTemplate fetches the named template from the issue template
+// directory of the default branch.
+func (a *approvalEnvironment) readIssueTemplate(ctx context.Context, name string) (*issueTemplate, error) {
+	file, _, _, err := a.client.Repositories.GetContents(ctx, a.repoOwner, a.repo, path.Join(issueTemplateDir, name), nil)
+	if err != nil {
+		return nil, err
+	}
+	if file == nil {
+		return nil, fmt.Errorf("%s is not a file", name)
+	}
+	content, err := file.GetContent()
+	if err != nil {
+		return nil, err
+	}
+	return parseIssueTemplate(name, []byte(content))
+}
+
+// applyIssueTemplate renders the issue body from the configured template and
+// returns the title and labels the template defines.
+func (a *approvalEnvironment) applyIssueTemplate(title, body string) (string, string, []string) {
+	if a.issueTemplate == nil {
+		return title, body, nil
+	}
+	if a.issueTemplate.Title != "" && a.issueTitleTemplate == "" {
+		title = a.replacePlaceholders(a.issueTemplate.Title) + title
+	}
+	return title, a.issueTemplate.render(body, a.templateValues(), a.replacePlaceholders), a.issueTemplate.Labels
+}
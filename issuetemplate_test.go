@@ -0,0 +1,85 @@
+package main
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestIssueTemplateForm(t *testing.T) {
+	content := `name: Deployment approval
+title: "[Deploy]: "
+labels: deployment, approval
+body:
+  - type: markdown
+    attributes:
+      value: Thanks for filling this in!
+  - type: input
+    id: environment
+    attributes:
+      label: Environment
+  - type: dropdown
+    id: branch
+    attributes:
+      label: Branch
+      options: [main, release]
+  - type: textarea
+    id: details
+    attributes:
+      label: Details
+  - type: textarea
+    id: rollback
+    attributes:
+      label: Rollback plan
+  - type: checkboxes
+    id: checks
+    attributes:
+      label: Checks
+      options:
+        - label: Change was announced
+          required: true
+`
+
+	template, err := parseIssueTemplate("deploy.yml", []byte(content))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !reflect.DeepEqual([]string(template.Labels), []string{"deployment", "approval"}) {
+		t.Fatalf("expected labels deployment and approval but got %v", template.Labels)
+	}
+
+	values := map[string]string{"environment": "production", "branch": "Main"}
+	expected := strings.Join([]string{
+		"### Environment\n\nproduction",
+		"### Branch\n\nmain",
+		"### Details\n\nWorkflow is pending manual review.",
+		"### Rollback plan\n\n_No response_",
+		"### Checks\n\n- [ ] Change was announced",
+	}, "\n\n")
+	actual := template.render("Workflow is pending manual review.", values, func(s string) string { return s })
+	if actual != expected {
+		t.Fatalf("expected %q but got %q", expected, actual)
+	}
+}
+
+func TestIssueTemplateMarkdown(t *testing.T) {
+	content := "---\nname: Approval\ntitle: \"[Approval] \"\nlabels: [approval]\n---\n\nDeploying to {environment}.\n"
+
+	template, err := parseIssueTemplate("approval.md", []byte(content))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if template.Title != "[Approval] " {
+		t.Fatalf("expected the template title but got %q", template.Title)
+	}
+
+	replace := strings.NewReplacer("{environment}", "production").Replace
+	expected := "Deploying to production.\n\nWorkflow is pending manual review."
+	if actual := template.render("Workflow is pending manual review.", nil, replace); actual != expected {
+		t.Fatalf("expected %q but got %q", expected, actual)
+	}
+
+	if _, err := parseIssueTemplate("config.json", []byte("{}")); err == nil {
+		t.Fatal("expected an error for an unsupported template")
+	}
+}
@@ -0,0 +1,93 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/google/go-github/v43/github"
+)
+
+// ensureLabel creates the label in the repository if it does not exist yet,
+// so that approvers can apply it from the issue sidebar.
+func (a *approvalEnvironment) ensureLabel(ctx context.Context, name, color, description string) error {
+	_, resp, err := a.client.Issues.GetLabel(ctx, a.repoOwner, a.repo, name)
+	if err == nil {
+		return nil
+	}
+	if resp == nil || resp.StatusCode != http.StatusNotFound {
+		return err
+	}
+
+	_, _, err = a.client.Issues.CreateLabel(ctx, a.repoOwner, a.repo, &github.Label{
+		Name:        &name,
+		Color:       &color,
+		Description: &description,
+	})
+	var errorResponse *github.ErrorResponse
+	if errors.As(err, &errorResponse) && errorResponse.Response.StatusCode == http.StatusUnprocessableEntity {
+		// Another gate created the label in the meantime.
+		return nil
+	}
+	return err
+}
+
+// labelDecisionComments converts labeled events on the approval issue into
+// synthetic issue comments. Applying the approve label counts as an approval
+// and applying the deny label as a denial by the user who applied it.
+func labelDecisionComments(events []*github.IssueEvent, approveLabel, denyLabel string) []*github.IssueComment {
+	var comments []*github.IssueComment
+	for _, event := range events {
+		if event.GetEvent() != "labeled" {
+			continue
+		}
+		var body string
+		switch event.GetLabel().GetName() {
+		case approveLabel:
+			body = gateWords.Approved[0]
+		case denyLabel:
+			body = gateWords.Denied[0]
+		default:
+			continue
+		}
+		comments = append(comments, &github.IssueComment{
+			Body:      github.String(body),
+			User:      event.Actor,
+			CreatedAt: event.CreatedAt,
+		})
+	}
+	return comments
+}
+
+func formatLabel(name string) string {
+	if name == "" {
+		return "-"
+	}
+	return fmt.Sprintf("`%s`", name)
+}
+
+func (a *approvalEnvironment) listIssueEvents(ctx context.Context) ([]*github.IssueEvent, error) {
+	var events []*github.IssueEvent
+	opts := &github.ListOptions{PerPage: 100}
+	for {
+		page, resp, err := a.client.Issues.ListIssueEvents(ctx, a.repoOwner, a.repo, a.approvalIssueNumber, opts)
+		if err != nil {
+			return nil, err
+		}
+		events = append(events, page...)
+		if resp.NextPage == 0 {
+			break
+		}
+		opts.Page = resp.NextPage
+	}
+	return events, nil
+}
+
+func (a *approvalEnvironment) listLabelComments(ctx context.Context) ([]*github.IssueComment, error) {
+	events, err := a.listIssueEvents(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return labelDecisionComments(events, a.approveLabel, a.denyLabel), nil
+}
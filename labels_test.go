@@ -0,0 +1,40 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/google/go-github/v43/github"
+)
+
+func TestLabelDecisionComments(t *testing.T) {
+	login1 := "login1"
+	login2 := "login2"
+	events := []*github.IssueEvent{
+		{Event: github.String("assigned"), Actor: &github.User{Login: &login1}},
+		{Event: github.String("labeled"), Actor: &github.User{Login: &login1}, Label: &github.Label{Name: github.String("bug")}},
+		{Event: github.String("labeled"), Actor: &github.User{Login: &login1}, Label: &github.Label{Name: github.String("approved")}},
+		{Event: github.String("unlabeled"), Actor: &github.User{Login: &login2}, Label: &github.Label{Name: github.String("approved")}},
+		{Event: github.String("labeled"), Actor: &github.User{Login: &login2}, Label: &github.Label{Name: github.String("denied")}},
+	}
+
+	comments := labelDecisionComments(events, "approved", "denied")
+	if len(comments) != 2 {
+		t.Fatalf("expected 2 comments but got %d", len(comments))
+	}
+
+	actual, _, err := approvalFromComments(comments[:1], []string{login1}, 1, []string{})
+	if err != nil {
+		t.Fatalf("error getting approval from comments: %v", err)
+	}
+	if actual != approvalStatusApproved {
+		t.Fatalf("expected %s but got %s", approvalStatusApproved, actual)
+	}
+
+	actual, _, err = approvalFromComments(comments, []string{login1, login2}, 2, []string{})
+	if err != nil {
+		t.Fatalf("error getting approval from comments: %v", err)
+	}
+	if actual != approvalStatusDenied {
+		t.Fatalf("expected %s but got %s", approvalStatusDenied, actual)
+	}
+}
@@ -0,0 +1,123 @@
+package main
+
+import (
+	"context"
+	"strings"
+	"testing"
+)
+
+func TestGateLifecycle(t *testing.T) {
+	type comment struct {
+		login string
+		body  string
+	}
+	testCases := []struct {
+		name             string
+		approvers        []string
+		minimumApprovals int
+		polls            [][]comment
+		expectedStatus   approvalStatus
+		expectedPolls    int
+	}{
+		{
+			name:             "approved_on_first_poll",
+			approvers:        []string{"user1", "user2"},
+			minimumApprovals: 1,
+			polls:            [][]comment{{{"user1", "approve"}}},
+			expectedStatus:   approvalStatusApproved,
+			expectedPolls:    1,
+		},
+		{
+			name:           "approved_by_all_over_several_polls",
+			approvers:      []string{"user1", "user2"},
+			polls:          [][]comment{{}, {{"user1", "lgtm"}}, {{"user3", "approve"}}, {{"user2", "yes"}}},
+			expectedStatus: approvalStatusApproved,
+			expectedPolls:  4,
+		},
+		{
+			name:             "denied",
+			approvers:        []string{"user1", "user2"},
+			minimumApprovals: 2,
+			polls:            [][]comment{{{"user1", "approve"}}, {{"user2", "deny"}}},
+			expectedStatus:   approvalStatusDenied,
+			expectedPolls:    2,
+		},
+		{
+			name:             "revoked_then_approved",
+			approvers:        []string{"user1", "user2"},
+			minimumApprovals: 2,
+			polls:            [][]comment{{{"user1", "approve"}, {"user1", "revoke"}}, {{"user2", "approve"}}, {{"user1", "approve"}}},
+			expectedStatus:   approvalStatusApproved,
+			expectedPolls:    3,
+		},
+		{
+			name:             "never_decided",
+			approvers:        []string{"user1"},
+			minimumApprovals: 1,
+			polls:            [][]comment{{{"user2", "approve"}}, {{"user1", "maybe"}}},
+			expectedStatus:   approvalStatusPending,
+			expectedPolls:    2,
+		},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			ctx := context.Background()
+			fake := newFakeGitHub()
+			apprv, err := newApprovalEnvironment(fake.client(), "owner/repo", "owner", 1, testCase.approvers, testCase.minimumApprovals, nil)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if err := apprv.createApprovalIssue(ctx); err != nil {
+				t.Fatalf("error creating approval issue: %v", err)
+			}
+			issue := fake.issues[apprv.approvalIssueNumber]
+			if issue == nil || len(issue.Assignees) != len(testCase.approvers) {
+				t.Fatalf("expected an issue assigned to the approvers but got %v", issue)
+			}
+
+			status := approvalStatusPending
+			polls := 0
+			for _, poll := range testCase.polls {
+				for _, c := range poll {
+					fake.comment(apprv.approvalIssueNumber, c.login, c.body)
+				}
+				comments, err := apprv.approvalComments(ctx)
+				if err != nil {
+					t.Fatalf("error getting comments: %v", err)
+				}
+				polls++
+				status, _, err = approvalFromComments(comments, testCase.approvers, testCase.minimumApprovals, nil)
+				if err != nil {
+					t.Fatalf("error getting approval from comments: %v", err)
+				}
+				if status == approvalStatusApproved || status == approvalStatusDenied {
+					break
+				}
+			}
+			if status != testCase.expectedStatus {
+				t.Fatalf("expected status %s but got %s", testCase.expectedStatus, status)
+			}
+			if polls != testCase.expectedPolls {
+				t.Fatalf("expected a decision after %d polls but got %d", testCase.expectedPolls, polls)
+			}
+			if status == approvalStatusPending {
+				if issue.GetState() != "open" {
+					t.Fatalf("expected the issue to stay open but it is %s", issue.GetState())
+				}
+				return
+			}
+
+			if err := apprv.resolveApproval(ctx, status, "Closing issue."); err != nil {
+				t.Fatalf("error resolving approval: %v", err)
+			}
+			if issue.GetState() != "closed" {
+				t.Fatalf("expected the issue to be closed but it is %s", issue.GetState())
+			}
+			comments := fake.comments[apprv.approvalIssueNumber]
+			if last := comments[len(comments)-1]; !strings.Contains(last.GetBody(), "Closing issue.") {
+				t.Fatalf("expected a closing comment but got %q", last.GetBody())
+			}
+		})
+	}
+}
@@ -0,0 +1,17 @@
+package main
+
+import "github.com/trstringer/manual-approval/pkg/approval"
+
+// configureWords adds the words of the locales and the custom words to the
+// accepted approval and denial words.
+func configureWords(locales []string, approved, denied []string) error {
+	for _, locale := range locales {
+		pack, err := approval.LocaleWords(locale)
+		if err != nil {
+			return err
+		}
+		gateWords = gateWords.WithPack(pack)
+	}
+	gateWords = gateWords.WithPack(approval.WordPack{Approved: approved, Denied: denied})
+	return nil
+}
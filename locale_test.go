@@ -0,0 +1,49 @@
+package main
+
+import (
+	"testing"
+)
+
+func TestConfigureWords(t *testing.T) {
+	defaultWords := gateWords
+	defer func() { gateWords = defaultWords }()
+
+	if err := configureWords([]string{"es", "pt-BR"}, []string{"ship it"}, []string{"hold off"}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	testCases := []struct {
+		body     string
+		approved bool
+		denied   bool
+	}{
+		{body: "approve", approved: true},
+		{body: "Aprobado!", approved: true},
+		{body: "aprovado", approved: true},
+		{body: "ship it", approved: true},
+		{body: "denegado", denied: true},
+		{body: "NÃO", denied: true},
+		{body: "hold off", denied: true},
+		{body: "genehmigt"},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.body, func(t *testing.T) {
+			approved, err := isApproved(testCase.body)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			denied, err := isDenied(testCase.body)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if approved != testCase.approved || denied != testCase.denied {
+				t.Fatalf("expected approved %t and denied %t but got %t and %t", testCase.approved, testCase.denied, approved, denied)
+			}
+		})
+	}
+
+	if err := configureWords([]string{"xx"}, nil, nil); err == nil {
+		t.Fatal("expected an error for an unknown locale")
+	}
+}
@@ -3,181 +3,1521 @@ package main
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"math/rand"
+	"net/http"
 	"os"
 	"os/signal"
 	"strconv"
 	"strings"
+	"syscall"
 	"time"
 
 	"github.com/google/go-github/v43/github"
+	"github.com/trstringer/manual-approval/pkg/approval"
 	"golang.org/x/oauth2"
 )
 
-func handleInterrupt(client *github.Client, ctx context.Context, apprv *approvalEnvironment) {
-	newState := "closed"
-	closeComment := "Workflow cancelled, closing issue."
+// handleInterrupt closes the approval request when the run is cancelled or
+// times out, so approvers don't approve a deployment that will never happen.
+// The runner kills the action shortly after signalling it, so the cleanup is
+// bounded by interruptTimeout.
+func handleInterrupt(ctx context.Context, apprv *approvalEnvironment) {
+	ctx, cancel := context.WithTimeout(ctx, interruptTimeout)
+	defer cancel()
+
+	closeComment := "Workflow was cancelled, approval is no longer needed. Closing issue."
 	fmt.Println(closeComment)
-	_, _, err := client.Issues.CreateComment(ctx, apprv.repoOwner, apprv.repo, apprv.approvalIssueNumber, &github.IssueComment{
-		Body: &closeComment,
-	})
-	if err != nil {
-		fmt.Printf("error commenting on issue: %v\n", err)
-		return
-	}
-	_, _, err = client.Issues.Edit(ctx, apprv.repoOwner, apprv.repo, apprv.approvalIssueNumber, &github.IssueRequest{State: &newState})
-	if err != nil {
+	if err := apprv.resolveApproval(ctx, approvalStatusCancelled, closeComment); err != nil {
 		fmt.Printf("error closing issue: %v\n", err)
 		return
 	}
 }
 
-func newCommentLoopChannel(ctx context.Context, apprv *approvalEnvironment, client *github.Client, approvers []string, minimumApprovals int) chan int {
-	channel := make(chan int)
+func newCommentLoopChannel(ctx context.Context, apprv *approvalEnvironment, approvers []string, minimumApprovals int, ignoreEditsAfterApproval bool) chan gateOutcome {
+	channel := make(chan gateOutcome)
 	go func() {
+		defer close(channel)
+		lastStatus := approvalStatusPending
+		editTracker := newCommentEditTracker(ignoreEditsAfterApproval)
+		editTracker.restore(apprv.gateState.ApprovalBodies)
+		invalidNames := newInvalidNameReplier()
+		schedule := newPollSchedule(rand.NewSource(time.Now().UnixNano()))
+		interval := pollingInterval
+		throttled := false
+		var lastComments []*github.IssueComment
+		var lastApprovers []string
 		for {
-			comments, _, err := client.Issues.ListComments(ctx, apprv.repoOwner, apprv.repo, apprv.approvalIssueNumber, &github.IssueListCommentsOptions{})
+			consumedBefore := githubRateLimits.consumedRequests()
+			pollStart := time.Now()
+			if len(apprv.emergencySenders) > 0 {
+				sender, err := apprv.findEmergencyRelease(ctx)
+				if err != nil {
+					fmt.Printf("error checking for emergency release: %v\n", err)
+					channel <- outcomeError
+					return
+				}
+				if sender != "" {
+					closeComment := fmt.Sprintf("Workflow released by emergency dispatch from %s, continuing workflow and closing this issue.", sender)
+					if err := apprv.resolveApproval(ctx, approvalStatusApproved, closeComment); err != nil {
+						fmt.Printf("error closing issue: %v\n", err)
+						channel <- outcomeError
+						return
+					}
+					setOutput("emergency-released-by", sender)
+					channel <- outcomeApproved
+					return
+				}
+			}
+
+			comments, err := apprv.approvalComments(ctx)
+			var abuseErr *github.AbuseRateLimitError
+			if errors.As(err, &abuseErr) {
+				// Still rate limited after retrying, try again on the next
+				// poll. The gate still times out meanwhile, with the
+				// comments of the last poll that read them.
+				fmt.Printf("::warning::Skipping poll, still rate limited: %v\n", err)
+				if reason := apprv.waitExceeded(apprv.metrics.polls, time.Now()); reason != "" {
+					channel <- apprv.timeOut(ctx, reason, lastComments, lastApprovers, minimumApprovals)
+					return
+				}
+				apprv.waitForNextPoll(ctx, interval)
+				continue
+			}
 			if err != nil {
 				fmt.Printf("error getting comments: %v\n", err)
-				channel <- 1
-				close(channel)
+				channel <- outcomeError
+				return
+			}
+			supersededBy, err := apprv.supersededBy(ctx)
+			if err != nil {
+				fmt.Printf("error checking whether the approval issue was superseded: %v\n", err)
+				channel <- outcomeError
+				return
+			}
+			if supersededBy != 0 {
+				// The approval issue of a newer run replaced this one, so
+				// comments on it no longer decide anything.
+				closeComment := fmt.Sprintf("Superseded by #%d, cancelling this workflow run.", supersededBy)
+				if err := apprv.resolveApproval(ctx, approvalStatusCancelled, closeComment); err != nil {
+					fmt.Printf("error closing issue: %v\n", err)
+				}
+				channel <- outcomeCancelled
+				return
+			}
+			editTracker.apply(comments, apprv.mutlipleDeploymentNames)
+			if err := apprv.saveGateState(ctx, editTracker.approvalBodies); err != nil {
+				fmt.Printf("error saving gate state: %v\n", err)
+			}
+
+			veto, err := vetoComment(comments, apprv.vetoUsers)
+			if err != nil {
+				fmt.Printf("error checking for a veto: %v\n", err)
+				channel <- outcomeError
+				return
+			}
+			if veto != nil {
+				closeComment := fmt.Sprintf("Request vetoed%s. %s", denialDetails(veto), apprv.denialClosing())
+				if err := apprv.resolveApproval(ctx, approvalStatusDenied, closeComment); err != nil {
+					fmt.Printf("error closing issue: %v\n", err)
+				}
+				apprv.reportDenial(veto)
+				channel <- outcomeDenied
+				return
+			}
+
+			eligibleApprovers := apprv.activeApprovers(approvers, time.Now())
+			if err := apprv.announcePoolFallback(ctx, time.Now()); err != nil {
+				fmt.Printf("error commenting on issue: %v\n", err)
+			}
+			if apprv.writeAccess {
+				eligibleApprovers, err = apprv.writeAccessApprovers(ctx, comments, approvers)
+				if err != nil {
+					fmt.Printf("error getting approvers with write access: %v\n", err)
+					channel <- outcomeError
+					return
+				}
 			}
 
-			approved, deploymentNames, err := approvalFromComments(comments, approvers, minimumApprovals, apprv.mutlipleDeploymentNames)
+			apprv.metrics.observe(comments, eligibleApprovers)
+			for _, reply := range invalidNames.replies(comments, eligibleApprovers, apprv.mutlipleDeploymentNames) {
+				if err := apprv.postStatusComment(ctx, reply); err != nil {
+					fmt.Printf("error commenting on issue: %v\n", err)
+				}
+			}
+			if err := apprv.minimizeOffTopic(ctx, comments, eligibleApprovers); err != nil {
+				fmt.Printf("error minimizing comments: %v\n", err)
+			}
+			debugComments(comments, eligibleApprovers, apprv.mutlipleDeploymentNames)
+			debugf("Minimum approvals: %d, additional requirements: %d", minimumApprovals, len(apprv.requirements))
+			approved, deploymentNames, err := approvalFromComments(comments, eligibleApprovers, minimumApprovals, apprv.mutlipleDeploymentNames, apprv.requirements...)
+			pollAttributes := apprv.traceAttributes()
+			pollAttributes["manual_approval.comments"] = strconv.Itoa(len(comments))
+			pollAttributes["manual_approval.status"] = strings.ToLower(string(approved))
+			apprv.tracer.record("poll", pollStart, pollAttributes, err)
 			if err != nil {
 				fmt.Printf("error getting approval from comments: %v\n", err)
-				channel <- 1
-				close(channel)
+				channel <- outcomeError
+				return
 			}
 			fmt.Printf("Workflow status: %s\n", approved)
+			if approved == approvalStatusHeld && lastStatus != approvalStatusHeld {
+				if err := apprv.postStatusComment(ctx, "This workflow is on hold. It will not continue until every hold is lifted with `/unhold`."); err != nil {
+					fmt.Printf("error commenting on issue: %v\n", err)
+				}
+			}
+			if approved == approvalStatusPending && lastStatus == approvalStatusHeld {
+				if err := apprv.postStatusComment(ctx, "All holds have been lifted, the workflow is pending approval again."); err != nil {
+					fmt.Printf("error commenting on issue: %v\n", err)
+				}
+			}
+			if approved == approvalStatusPending || approved == approvalStatusHeld {
+				required := minimumApprovals
+				if required == 0 {
+					required = len(eligibleApprovers)
+				}
+				if err := apprv.confirmApprovals(ctx, comments, eligibleApprovers, required); err != nil {
+					fmt.Printf("error confirming approvals: %v\n", err)
+				}
+				if err := apprv.mentionPending(ctx, comments, eligibleApprovers, required); err != nil {
+					fmt.Printf("error mentioning pending approvers: %v\n", err)
+				}
+			}
+			if approved == approvalStatusPending || approved == approvalStatusHeld {
+				if err := apprv.setStatusLabel(ctx, approved); err != nil {
+					fmt.Printf("error setting status label: %v\n", err)
+				}
+			}
+			lastStatus = approved
+			if (approved == approvalStatusPending || approved == approvalStatusHeld) && apprv.closeDecisions && apprv.approvalIssue != nil {
+				if err := apprv.reopenIfClosed(ctx); err != nil {
+					fmt.Printf("error reopening issue: %v\n", err)
+				}
+			}
 			switch approved {
 			case approvalStatusApproved:
 				if len(apprv.mutlipleDeploymentNames) > 0 && len(deploymentNames) == 0 {
 					fmt.Println("errors.please choose at least 1 of the multiple deployment names")
-					channel <- 1
-					close(channel)
+					channel <- outcomeError
+					return
 				}
 
-				newState := "closed"
 				closeComment := "All approvers have approved, continuing workflow and closing this issue."
-				_, _, err := client.Issues.CreateComment(ctx, apprv.repoOwner, apprv.repo, apprv.approvalIssueNumber, &github.IssueComment{
-					Body: &closeComment,
-				})
-				if err != nil {
-					fmt.Printf("error commenting on issue: %v\n", err)
-					channel <- 1
-					close(channel)
-				}
-				_, _, err = client.Issues.Edit(ctx, apprv.repoOwner, apprv.repo, apprv.approvalIssueNumber, &github.IssueRequest{State: &newState})
-				if err != nil {
+				if err := apprv.resolveApproval(ctx, approvalStatusApproved, closeComment); err != nil {
 					fmt.Printf("error closing issue: %v\n", err)
-					channel <- 1
-					close(channel)
+					channel <- outcomeError
+					return
 				}
-				channel <- 0
-				if len(deploymentNames) > 0 {
-					jsonDeploymentNames, _ := json.Marshal(deploymentNames)
-					fmt.Println(fmt.Sprintf("::set-output name=DEPLOYMENT_NAMES::%s", jsonDeploymentNames))
+				setDeploymentNamesOutput(deploymentNames)
+				if apprv.createDeployment {
+					deploymentIDs, err := apprv.createDeployments(ctx, deploymentNames)
+					setDeploymentIDsOutput(deploymentIDs)
+					if err != nil {
+						fmt.Printf("error creating deployments: %v\n", err)
+						channel <- outcomeError
+						return
+					}
 				}
 
 				fmt.Println("Workflow manual approval completed")
-				close(channel)
+				channel <- outcomeApproved
+				return
 			case approvalStatusDenied:
-				newState := "closed"
-				closeComment := "Request denied. Closing issue and failing workflow."
-				_, _, err := client.Issues.CreateComment(ctx, apprv.repoOwner, apprv.repo, apprv.approvalIssueNumber, &github.IssueComment{
-					Body: &closeComment,
-				})
-				if err != nil {
-					fmt.Printf("error commenting on issue: %v\n", err)
-					channel <- 1
-					close(channel)
-				}
-				_, _, err = client.Issues.Edit(ctx, apprv.repoOwner, apprv.repo, apprv.approvalIssueNumber, &github.IssueRequest{State: &newState})
-				if err != nil {
+				denial := gateWords.DecidingComment(comments, eligibleApprovers, minimumApprovals, apprv.mutlipleDeploymentNames, apprv.requirements...)
+				closeComment := fmt.Sprintf("Request denied%s. %s", denialDetails(denial), apprv.denialClosing())
+				if err := apprv.resolveApproval(ctx, approvalStatusDenied, closeComment); err != nil {
 					fmt.Printf("error closing issue: %v\n", err)
-					channel <- 1
-					close(channel)
 				}
-				channel <- 1
-				close(channel)
+				apprv.reportDenial(denial)
+				channel <- outcomeDenied
+				return
 			}
 
-			time.Sleep(pollingInterval)
+			if err := apprv.checkSLA(ctx, time.Now()); err != nil {
+				fmt.Printf("error reporting SLA breach: %v\n", err)
+			}
+			lastComments, lastApprovers = comments, eligibleApprovers
+			if reason := apprv.waitExceeded(apprv.metrics.polls, time.Now()); reason != "" {
+				channel <- apprv.timeOut(ctx, reason, comments, eligibleApprovers, minimumApprovals)
+				return
+			}
+
+			scheduled := schedule.next(comments)
+			if apprv.webhookDeliveries != nil || apprv.companionSignal {
+				scheduled = fallbackPollingInterval
+			}
+			interval = githubRateLimits.pollInterval(scheduled, githubRateLimits.consumedRequests()-consumedBefore, time.Now())
+			if interval > scheduled {
+				fmt.Printf("Polling every %s to stay within the rate limit\n", interval.Round(time.Second))
+				throttled = true
+			} else if throttled {
+				fmt.Println("Rate limit reset, polling at the usual interval again")
+				throttled = false
+			}
+			apprv.waitForNextPoll(ctx, interval)
 		}
 	}()
 	return channel
 }
 
-func newGithubClient(ctx context.Context) *github.Client {
+func setDeploymentNamesOutput(deploymentNames []string) {
+	if len(deploymentNames) > 0 {
+		jsonDeploymentNames, _ := json.Marshal(deploymentNames)
+		setOutput("DEPLOYMENT_NAMES", string(jsonDeploymentNames))
+		setOutput("deployment-names", string(jsonDeploymentNames))
+	}
+}
+
+// splitInputList splits a comma-delimited input, trimming whitespace and
+// dropping empty entries.
+func splitInputList(raw string) []string {
+	var values []string
+	for _, v := range strings.Split(raw, ",") {
+		v = strings.TrimSpace(v)
+		if v != "" {
+			values = append(values, v)
+		}
+	}
+	return values
+}
+
+// newGithubClient authenticates with the token input or, if app-id is set,
+// as an installation of that GitHub App, whose tokens are refreshed while
+// the gate waits.
+func newGithubClient(ctx context.Context, repoFullName string) (*github.Client, error) {
 	token := os.Getenv(envVarToken)
 	ts := oauth2.StaticTokenSource(
 		&oauth2.Token{AccessToken: token},
 	)
+	if appID := os.Getenv(envVarAppID); appID != "" {
+		key, err := parseAppPrivateKey(os.Getenv(envVarAppPrivateKey))
+		if err != nil {
+			return nil, err
+		}
+		var installationID int64
+		if installationIDRaw := os.Getenv(envVarAppInstallationID); installationIDRaw != "" {
+			installationID, err = strconv.ParseInt(installationIDRaw, 10, 64)
+			if err != nil {
+				return nil, fmt.Errorf("error parsing app installation ID: %w", err)
+			}
+		}
+		ts = oauth2.ReuseTokenSource(nil, &appTokenSource{
+			ctx:            ctx,
+			appID:          appID,
+			key:            key,
+			installationID: installationID,
+			repoFullName:   repoFullName,
+		})
+	}
+	ctx = context.WithValue(ctx, oauth2.HTTPClient, &http.Client{
+		Transport: permissionHintTransport{base: secondaryRateLimitTransport{
+			base: rateLimitTransport{base: http.DefaultTransport, tracker: githubRateLimits},
+		}},
+	})
 	tc := oauth2.NewClient(ctx, ts)
-	return github.NewClient(tc)
+	return github.NewClient(tc), nil
 }
 
 func main() {
+	startedAt := time.Now()
+	if err := redactOutput(); err != nil {
+		fmt.Printf("error redacting output: %v\n", err)
+		os.Exit(1)
+	}
+	if len(os.Args) > 1 && (os.Args[1] == "--version" || os.Args[1] == "-version") {
+		fmt.Println(buildInfo())
+		exit(0)
+	}
+	if err := setExitCodes(); err != nil {
+		fmt.Printf("error parsing exit codes: %v\n", err)
+		exit(1)
+	}
+
+	// The mode can also be given as a subcommand when running the binary
+	// directly.
+	mode := os.Getenv(envVarMode)
+	if mode == "" && len(os.Args) > 1 {
+		mode = os.Args[1]
+	}
+	if err := configureWords(splitInputList(os.Getenv(envVarLocale)), splitInputList(os.Getenv(envVarApprovedWords)), splitInputList(os.Getenv(envVarDeniedWords))); err != nil {
+		fmt.Printf("error configuring approval words: %v\n", err)
+		exitWith(outcomeError)
+	}
+	switch matchMode := approval.MatchMode(os.Getenv(envVarMatchMode)); matchMode {
+	case "":
+	case approval.MatchStrict, approval.MatchLenient:
+		gateWords.Mode = matchMode
+	default:
+		fmt.Printf("error: match-mode must be %s or %s but got %q\n", approval.MatchStrict, approval.MatchLenient, matchMode)
+		exitWith(outcomeError)
+	}
+	if mode == modeSimulate {
+		fixturePath := os.Getenv(envVarSimulateFixture)
+		if fixturePath == "" && len(os.Args) > 2 {
+			fixturePath = os.Args[2]
+		}
+		if fixturePath == "" {
+			fmt.Println("error: simulate mode requires a fixture")
+			exitWith(outcomeError)
+		}
+		if err := simulate(fixturePath); err != nil {
+			fmt.Printf("error simulating approval: %v\n", err)
+			exitWith(outcomeError)
+		}
+		exit(0)
+	}
+
+	maskSecrets(os.Getenv(envVarToken), os.Getenv(envVarDispatchSecret), os.Getenv(envVarOnCallAPIKey), os.Getenv(envVarAppPrivateKey), os.Getenv(envVarWebhookSecret), os.Getenv(envVarReceiptSecret))
+	maskSecrets(headerSecrets(os.Getenv(envVarApproversURLAuthHeader))...)
+	maskSecrets(urlSecrets(os.Getenv(envVarApproversURL))...)
+	maskSecrets(urlSecrets(os.Getenv(envVarPushgatewayURL))...)
+	maskSecrets(urlSecrets(os.Getenv(envVarAvailabilityURL))...)
+	maskSecrets(otelHeaderSecrets(os.Getenv)...)
+
+	fmt.Println(buildInfo())
+	setOutput("version", version)
+
 	repoFullName := os.Getenv(envVarRepoFullName)
 	runID, err := strconv.Atoi(os.Getenv(envVarRunID))
 	if err != nil {
 		fmt.Printf("error getting runID: %v\n", err)
-		os.Exit(1)
+		exitWith(outcomeError)
 	}
 	repoOwner := os.Getenv(envVarRepoOwner)
 
 	ctx := context.Background()
-	client := newGithubClient(ctx)
+	if err := configureTransport(os.Getenv(envVarProxyURL), os.Getenv(envVarCACertificates)); err != nil {
+		fmt.Printf("error configuring HTTP transport: %v\n", err)
+		exitWith(outcomeError)
+	}
+	client, err := newGithubClient(ctx, repoFullName)
+	if err != nil {
+		fmt.Printf("error creating GitHub client: %v\n", err)
+		exitWith(outcomeError)
+	}
+
+	if deploymentStatus := os.Getenv(envVarDeploymentStatus); deploymentStatus != "" {
+		repoOwnerAndName := strings.Split(repoFullName, "/")
+		if len(repoOwnerAndName) != 2 {
+			fmt.Printf("error: repo owner and name in unexpected format: %s\n", repoFullName)
+			exitWith(outcomeError)
+		}
+		deploymentIDs, err := parseDeploymentIDs(os.Getenv(envVarDeploymentIDs))
+		if err != nil {
+			fmt.Printf("error parsing deployment IDs: %v\n", err)
+			exitWith(outcomeError)
+		}
+		if len(deploymentIDs) == 0 {
+			fmt.Println("error: deployment status requires deployment IDs")
+			exitWith(outcomeError)
+		}
+		logURL := fmt.Sprintf("https://github.com/%s/actions/runs/%d", repoFullName, runID)
+		if err := updateDeploymentStatuses(ctx, wrapGithubClient(client), repoOwner, repoOwnerAndName[1], deploymentIDs, deploymentStatus, logURL); err != nil {
+			fmt.Printf("error updating deployment status: %v\n", err)
+			exitWith(outcomeError)
+		}
+		exit(0)
+	}
+
+	switch mode {
+	case "", modeGate, modeCreate, modeWait, modeResolve, modeCompanion:
+	case modeCleanup:
+		apprv, err := newApprovalEnvironment(wrapGithubClient(client), repoFullName, repoOwner, runID, nil, 0, nil)
+		if err != nil {
+			fmt.Printf("error creating approval environment: %v\n", err)
+			exitWith(outcomeError)
+		}
+		closed, err := apprv.cleanupApprovalIssues(ctx)
+		if err != nil {
+			fmt.Printf("error cleaning up approval issues: %v\n", err)
+			exitWith(outcomeError)
+		}
+		fmt.Printf("Closed %d stale approval issues\n", closed)
+		setOutput("closed-issues", strconv.Itoa(closed))
+		exit(0)
+	default:
+		fmt.Printf("error: unsupported mode %q, expected one of %s\n", mode, strings.Join([]string{modeGate, modeCreate, modeWait, modeResolve, modeCleanup, modeSimulate, modeCompanion}, ", "))
+		exitWith(outcomeError)
+	}
+
+	switch os.Getenv(envVarEventName) {
+	case "check_run", "repository_dispatch", "workflow_dispatch":
+		event, err := readWorkflowEvent(os.Getenv(envVarEventPath))
+		if err != nil {
+			fmt.Printf("error reading event: %v\n", err)
+			exitWith(outcomeError)
+		}
+		repoOwnerAndName := strings.Split(repoFullName, "/")
+		if len(repoOwnerAndName) != 2 {
+			fmt.Printf("error: repo owner and name in unexpected format: %s\n", repoFullName)
+			exitWith(outcomeError)
+		}
+		if event.Action == "requested_action" && event.RequestedAction != nil && event.CheckRun != nil {
+			dispatchSecret := os.Getenv(envVarDispatchSecret)
+			if dispatchSecret == "" {
+				fmt.Println("error: recording a check run decision requires a dispatch secret to sign it")
+				exitWith(outcomeError)
+			}
+			if err := recordCheckRunAction(ctx, client, repoOwner, repoOwnerAndName[1], dispatchSecret, event); err != nil {
+				fmt.Printf("error recording check run action: %v\n", err)
+				exitWith(outcomeError)
+			}
+			exit(0)
+		}
+		emergencyDispatchType := os.Getenv(envVarEmergencyDispatchType)
+		if emergencyDispatchType != "" && event.Action == emergencyDispatchType {
+			dispatchSecret := os.Getenv(envVarDispatchSecret)
+			if dispatchSecret == "" {
+				fmt.Println("error: emergency dispatch type requires a dispatch secret to sign the release")
+				exitWith(outcomeError)
+			}
+			release, err := parseEmergencyRelease(event, splitInputList(os.Getenv(envVarEmergencySenders)))
+			if err != nil {
+				fmt.Printf("error validating emergency release: %v\n", err)
+				exitWith(outcomeError)
+			}
+			if err := recordEmergencyRelease(ctx, client, repoOwner, repoOwnerAndName[1], dispatchSecret, release); err != nil {
+				fmt.Printf("error recording emergency release: %v\n", err)
+				exitWith(outcomeError)
+			}
+			exit(0)
+		}
+		if payload := event.dispatchPayload(); payload != nil {
+			dispatchSecret := os.Getenv(envVarDispatchSecret)
+			if dispatchSecret == "" {
+				fmt.Println("error: recording a dispatch decision requires a dispatch secret")
+				exitWith(outcomeError)
+			}
+			decision, err := parseDispatchDecision(payload)
+			if err != nil {
+				fmt.Printf("error parsing dispatch decision: %v\n", err)
+				exitWith(outcomeError)
+			}
+			if err := recordDispatchDecision(ctx, client, repoOwner, repoOwnerAndName[1], dispatchSecret, decision); err != nil {
+				fmt.Printf("error recording dispatch decision: %v\n", err)
+				exitWith(outcomeError)
+			}
+			exit(0)
+		}
+	}
+
+	// Bypassed runs skip the gate before any approvers are looked up or
+	// selected.
+	bypassActor, bypassRef := os.Getenv(envVarActor), bypassBranch(os.Getenv(envVarBaseRef), os.Getenv(envVarRefName))
+	bypass, err := shouldBypass(bypassActor, bypassRef, splitInputList(os.Getenv(envVarBypassActors)), splitInputList(os.Getenv(envVarBypassBranches)))
+	if err != nil {
+		fmt.Printf("error checking bypass rules: %v\n", err)
+		exitWith(outcomeError)
+	}
+	if bypass {
+		fmt.Printf("Bypassing manual approval for actor %s on branch %s\n", bypassActor, bypassRef)
+		setOutput("bypassed", "true")
+		exitWith(outcomeApproved)
+	}
 
 	requiredApproversRaw := os.Getenv(envVarApprovers)
 	fmt.Printf("Required approvers: %s\n", requiredApproversRaw)
-	approvers := strings.Split(requiredApproversRaw, ",")
+	approvers := parseApprovers(requiredApproversRaw)
+
+	if approversFile := os.Getenv(envVarApproversFile); approversFile != "" {
+		repoOwnerAndName := strings.Split(repoFullName, "/")
+		if len(repoOwnerAndName) != 2 {
+			fmt.Printf("error: repo owner and name in unexpected format: %s\n", repoFullName)
+			exitWith(outcomeError)
+		}
+		fileApprovers, err := readApproversFile(ctx, client, repoOwner, repoOwnerAndName[1], approversFile)
+		if err != nil {
+			fmt.Printf("error reading approvers file %s: %v\n", approversFile, err)
+			exitWith(outcomeError)
+		}
+		fmt.Printf("Approvers from %s: %s\n", approversFile, fileApprovers)
+		approvers = append(approvers, fileApprovers...)
+	}
+
+	if approversURL := os.Getenv(envVarApproversURL); approversURL != "" {
+		urlApprovers, err := fetchApproversURL(ctx, approversURL, os.Getenv(envVarApproversURLAuthHeader))
+		if err != nil {
+			fmt.Printf("error fetching approvers from %s: %v\n", approversURL, err)
+			exitWith(outcomeError)
+		}
+		fmt.Printf("Approvers from %s: %s\n", approversURL, urlApprovers)
+		approvers = append(approvers, urlApprovers...)
+	}
+
+	if onCallProvider := os.Getenv(envVarOnCallProvider); onCallProvider != "" {
+		scheduleID := os.Getenv(envVarOnCallScheduleID)
+		apiKey := os.Getenv(envVarOnCallAPIKey)
+		if scheduleID == "" || apiKey == "" {
+			fmt.Println("error: on-call lookup requires an API key and a schedule ID")
+			exitWith(outcomeError)
+		}
+		userMap, err := parseOnCallUserMap(os.Getenv(envVarOnCallUserMap))
+		if err != nil {
+			fmt.Printf("error parsing on-call user map: %v\n", err)
+			exitWith(outcomeError)
+		}
+		emails, err := onCallEmails(ctx, onCallProvider, apiKey, scheduleID)
+		if err != nil {
+			fmt.Printf("error getting on-call users for schedule %s: %v\n", scheduleID, err)
+			exitWith(outcomeError)
+		}
+		onCallApprovers, err := onCallLogins(ctx, client, emails, userMap)
+		if err != nil {
+			fmt.Printf("error resolving on-call users: %v\n", err)
+			exitWith(outcomeError)
+		}
+		fmt.Printf("On-call approvers: %s\n", onCallApprovers)
+		approvers = append(approvers, onCallApprovers...)
+	}
+
+	environment := os.Getenv(envVarEnvironment)
+	orgMinimumApprovals := 0
+	deploymentAliases := approval.Aliases{}
+	if orgConfigPath := os.Getenv(envVarOrgConfig); orgConfigPath != "" {
+		config, err := readOrgConfig(ctx, client, repoOwner, orgConfigPath)
+		if err != nil {
+			fmt.Printf("error reading org config %s/%s/%s: %v\n", repoOwner, orgConfigRepo, orgConfigPath, err)
+			exitWith(outcomeError)
+		}
+		repoName := strings.TrimPrefix(repoFullName, repoOwner+"/")
+		if policy, ok := config.policy(repoName, environment); ok {
+			fmt.Printf("Org policy approvers: %s\n", policy.Approvers)
+			approvers = append(approvers, parseApprovers(strings.Join(policy.Approvers, ","))...)
+			orgMinimumApprovals = policy.MinimumApprovals
+			for name, targets := range policy.DeploymentAliases {
+				deploymentAliases[name] = targets
+			}
+		}
+	}
+
+	var pathOwnerGroups [][]string
+	var files []*github.CommitFile
+	filesFetched := false
+	if pathApproversRaw := os.Getenv(envVarPathApprovers); pathApproversRaw != "" {
+		rules, err := parsePathApprovers(pathApproversRaw)
+		if err != nil {
+			fmt.Printf("error parsing path approvers: %v\n", err)
+			exitWith(outcomeError)
+		}
+		event, err := readWorkflowEvent(os.Getenv(envVarEventPath))
+		if err != nil {
+			fmt.Printf("error reading event: %v\n", err)
+			exitWith(outcomeError)
+		}
+		repoName := strings.TrimPrefix(repoFullName, repoOwner+"/")
+		files, err = changedFiles(ctx, client, repoOwner, repoName, event, os.Getenv(envVarSHA))
+		if err != nil {
+			fmt.Printf("error listing changed files: %v\n", err)
+			exitWith(outcomeError)
+		}
+		filesFetched = true
+		touchedRules, err := touchedPathRules(rules, commitFilePaths(files))
+		if err != nil {
+			fmt.Printf("error matching path approvers: %v\n", err)
+			exitWith(outcomeError)
+		}
+		for _, rule := range touchedRules {
+			group, err := expandTeams(ctx, client, rule.approvers)
+			if err != nil {
+				fmt.Printf("error expanding teams: %v\n", err)
+				exitWith(outcomeError)
+			}
+			fmt.Printf("Approvers for %s: %s\n", rule.pattern, group)
+			pathOwnerGroups = append(pathOwnerGroups, group)
+			approvers = append(approvers, group...)
+		}
+	}
+
+	deploymentApprovers := approval.DeploymentApprovers{}
+	if deploymentApproversRaw := os.Getenv(envVarDeploymentApprovers); deploymentApproversRaw != "" {
+		configured, err := parseDeploymentApprovers(deploymentApproversRaw)
+		if err != nil {
+			fmt.Printf("error parsing deployment approvers: %v\n", err)
+			exitWith(outcomeError)
+		}
+		for name, entries := range configured {
+			if invalid := invalidApprovers(entries); len(invalid) > 0 {
+				fmt.Printf("error: approvers of deployment %s must be GitHub user logins or org/team-slug, got %s\n", name, strings.Join(invalid, ", "))
+				exitWith(outcomeError)
+			}
+			group, err := expandTeams(ctx, client, entries)
+			if err != nil {
+				fmt.Printf("error expanding teams: %v\n", err)
+				exitWith(outcomeError)
+			}
+			fmt.Printf("Approvers for deployment %s: %s\n", name, group)
+			deploymentApprovers[name] = group
+			approvers = append(approvers, group...)
+		}
+	}
+
+	distinctTeams := 0
+	var teams []approverTeam
+	if distinctTeamsRaw := os.Getenv(envVarDistinctTeams); distinctTeamsRaw != "" {
+		distinctTeams, err = strconv.Atoi(distinctTeamsRaw)
+		if err != nil {
+			fmt.Printf("error parsing distinct teams: %v\n", err)
+			exitWith(outcomeError)
+		}
+		teams, err = approverTeams(ctx, client, approvers)
+		if err != nil {
+			fmt.Printf("error expanding teams: %v\n", err)
+			exitWith(outcomeError)
+		}
+		if distinctTeams > len(teams) {
+			fmt.Printf("error: distinct teams (%v) is greater than the number of teams in approvers (%v)\n", distinctTeams, len(teams))
+			exitWith(outcomeError)
+		}
+	}
+
+	if invalid := invalidApprovers(approvers); len(invalid) > 0 {
+		fmt.Printf("error: approvers must be GitHub user logins or org/team-slug, got %s\n", strings.Join(invalid, ", "))
+		exitWith(outcomeError)
+	}
+	approvers, err = expandTeams(ctx, client, approvers)
+	if err != nil {
+		fmt.Printf("error expanding teams: %v\n", err)
+		exitWith(outcomeError)
+	}
+
+	unavailableCount := 0
+	availabilityFile, availabilityURL := os.Getenv(envVarAvailabilityFile), os.Getenv(envVarAvailabilityURL)
+	if availabilityFile != "" || availabilityURL != "" {
+		unavailability := availability{}
+		if availabilityFile != "" {
+			repoName := strings.TrimPrefix(repoFullName, repoOwner+"/")
+			unavailability, err = readAvailabilityFile(ctx, client, repoOwner, repoName, availabilityFile)
+			if err != nil {
+				fmt.Printf("error reading availability file %s: %v\n", availabilityFile, err)
+				exitWith(outcomeError)
+			}
+		}
+		if availabilityURL != "" {
+			calendar, err := fetchAvailabilityCalendar(ctx, availabilityURL)
+			if err != nil {
+				fmt.Printf("error fetching availability calendar: %v\n", err)
+				exitWith(outcomeError)
+			}
+			for login, ranges := range calendar {
+				unavailability[login] = append(unavailability[login], ranges...)
+			}
+		}
+		unavailable := unavailability.unavailable(approvers, time.Now().UTC())
+		setOutput("unavailable-approvers", strings.Join(unavailable, ","))
+		if len(unavailable) > 0 {
+			fmt.Printf("Unavailable approvers: %s\n", strings.Join(unavailable, ", "))
+			available := removeApprovers(approvers, unavailable)
+			if len(available) == 0 {
+				escalation, err := expandTeams(ctx, client, parseApprovers(os.Getenv(envVarAvailabilityEscalation)))
+				if err != nil {
+					fmt.Printf("error expanding teams: %v\n", err)
+					exitWith(outcomeError)
+				}
+				available = removeApprovers(escalation, unavailability.unavailable(escalation, time.Now().UTC()))
+				if len(available) == 0 {
+					fmt.Println("::warning::No approver is available, requesting approval from all of them")
+					available = approvers
+				} else {
+					fmt.Printf("::warning::No approver is available, escalating to %s\n", strings.Join(available, ", "))
+				}
+			}
+			unavailableCount = len(approvers) - len(available)
+			approvers = available
+		}
+	}
+
+	writeAccess := false
+	if writeAccessRaw := os.Getenv(envVarWriteAccessApprovers); writeAccessRaw != "" {
+		writeAccess, err = strconv.ParseBool(writeAccessRaw)
+		if err != nil {
+			fmt.Printf("error parsing write access approvers: %v\n", err)
+			exitWith(outcomeError)
+		}
+	}
+	if len(approvers) == 0 && !writeAccess {
+		fmt.Println("error: no approvers configured")
+		exitWith(outcomeError)
+	}
 
 	minimumApprovalsRaw := os.Getenv(envVarMinimumApprovals)
 	minimumApprovals := len(approvers)
+	if writeAccess || len(pathOwnerGroups) > 0 {
+		minimumApprovals = 1
+	}
+	if distinctTeams > 0 {
+		minimumApprovals = distinctTeams
+	}
+	if orgMinimumApprovals > 0 {
+		minimumApprovals = orgMinimumApprovals
+	}
 	if minimumApprovalsRaw != "" {
 		minimumApprovals, err = strconv.Atoi(minimumApprovalsRaw)
 		if err != nil {
 			fmt.Printf("error parsing minimum number of approvals: %v\n", err)
-			os.Exit(1)
+			exitWith(outcomeError)
 		}
 	}
 
-	if minimumApprovals > len(approvers) {
+	if minimumApprovals < 0 {
+		fmt.Printf("error: minimum required approvals (%v) can't be negative\n", minimumApprovals)
+		exitWith(outcomeError)
+	}
+	if writeAccess && minimumApprovals < 1 {
+		fmt.Println("error: minimum required approvals must be at least 1 when approvers with write access count")
+		exitWith(outcomeError)
+	}
+	if unavailableCount > 0 && minimumApprovals > len(approvers) && !writeAccess {
+		fmt.Printf("::warning::Requiring %d approvals instead of %d, as only %d approvers are available\n", len(approvers), minimumApprovals, len(approvers))
+		minimumApprovals = len(approvers)
+	}
+	if minimumApprovals > len(approvers) && !writeAccess {
 		fmt.Printf("error: minimum required approvals (%v) is greater than the total number of approvers (%v)\n", minimumApprovals, len(approvers))
-		os.Exit(1)
+		exitWith(outcomeError)
+	}
+
+	selectCount := 0
+	if selectCountRaw := os.Getenv(envVarSelectApprovers); selectCountRaw != "" {
+		selectCount, err = strconv.Atoi(selectCountRaw)
+		if err != nil {
+			fmt.Printf("error parsing select approvers: %v\n", err)
+			exitWith(outcomeError)
+		}
+		if selectCount < 0 {
+			fmt.Printf("error: select approvers (%v) can't be negative\n", selectCount)
+			exitWith(outcomeError)
+		}
+	}
+	selectStrategy := os.Getenv(envVarSelectStrategy)
+	switch selectStrategy {
+	case "":
+		selectStrategy = selectStrategyRandom
+	case selectStrategyRoundRobin:
+		// Without select-approvers, the rotation selects one approver.
+		if selectCount == 0 {
+			selectCount = 1
+		}
+	case selectStrategyRandom:
+	default:
+		fmt.Printf("error: unknown select strategy %s, expected %s or %s\n", selectStrategy, selectStrategyRandom, selectStrategyRoundRobin)
+		exitWith(outcomeError)
+	}
+	if selectCount > 0 && writeAccess {
+		fmt.Println("error: select approvers can't be used when approvers with write access count")
+		exitWith(outcomeError)
+	}
+	if selectCount > 0 && selectCount < len(approvers) {
+		// Without minimum-approvals, every selected approver must approve.
+		if minimumApprovalsRaw == "" && minimumApprovals == len(approvers) {
+			minimumApprovals = selectCount
+		}
+		if minimumApprovals > selectCount {
+			fmt.Printf("error: minimum required approvals (%v) is greater than the number of selected approvers (%v)\n", minimumApprovals, selectCount)
+			exitWith(outcomeError)
+		}
 	}
 
 	multipleDeploymentNamesRaw := os.Getenv(envMultipleDeploymentNames)
-	var multipleDeploymentNames []string
+	multipleDeploymentNames, deploymentDescriptions := parseDeploymentNames(multipleDeploymentNamesRaw)
 	if multipleDeploymentNamesRaw != "" {
-		fmt.Printf("Multiple deployment names: %s\n", multipleDeploymentNamesRaw)
-		multipleDeploymentNames = strings.Split(multipleDeploymentNamesRaw, ",")
+		fmt.Printf("Multiple deployment names: %s\n", strings.Join(multipleDeploymentNames, ","))
+	}
+	if err := validateDeploymentNames(multipleDeploymentNames); err != nil {
+		fmt.Printf("error: %v\n", err)
+		exitWith(outcomeError)
+	}
+	inputAliases, err := parseDeploymentAliases(os.Getenv(envVarDeploymentAliases))
+	if err != nil {
+		fmt.Printf("error parsing deployment aliases: %v\n", err)
+		exitWith(outcomeError)
+	}
+	// Aliases of the input override those of the org policy.
+	for name, targets := range inputAliases {
+		deploymentAliases[name] = targets
+	}
+	if err := validateDeploymentAliases(deploymentAliases, multipleDeploymentNames); err != nil {
+		fmt.Printf("error: %v\n", err)
+		exitWith(outcomeError)
+	}
+	if err := validateDeploymentApprovers(deploymentApprovers, multipleDeploymentNames); err != nil {
+		fmt.Printf("error: %v\n", err)
+		exitWith(outcomeError)
 	}
 
-	apprv, err := newApprovalEnvironment(client, repoFullName, repoOwner, runID, approvers, minimumApprovals, multipleDeploymentNames)
+	apprv, err := newApprovalEnvironment(wrapGithubClient(client), repoFullName, repoOwner, runID, approvers, minimumApprovals, multipleDeploymentNames)
 	if err != nil {
 		fmt.Printf("error creating approval environment: %v\n", err)
-		os.Exit(1)
+		exitWith(outcomeError)
+	}
+	apprv.deploymentAliases = deploymentAliases
+	apprv.deploymentDescriptions = deploymentDescriptions
+	apprv.deploymentApprovers = deploymentApprovers
+	if partialApprovalRaw := os.Getenv(envVarPartialApproval); partialApprovalRaw != "" {
+		apprv.partialApproval, err = strconv.ParseBool(partialApprovalRaw)
+		if err != nil {
+			fmt.Printf("error parsing partial approval: %v\n", err)
+			exitWith(outcomeError)
+		}
+		if apprv.partialApproval && len(multipleDeploymentNames) == 0 {
+			fmt.Println("error: partial approval requires multiple deployment names")
+			exitWith(outcomeError)
+		}
+	}
+	apprv.selectApprovers = selectCount
+	apprv.selectStrategy = selectStrategy
+	if selectFallbackRaw := os.Getenv(envVarSelectApproversFallback); selectFallbackRaw != "" {
+		apprv.selectFallback, err = time.ParseDuration(selectFallbackRaw)
+		if err != nil {
+			fmt.Printf("error parsing select approvers fallback: %v\n", err)
+			exitWith(outcomeError)
+		}
+	}
+	apprv.sha = os.Getenv(envVarSHA)
+	apprv.botApprovers = splitInputList(os.Getenv(envVarBotApprovers))
+	apprv.writeAccess = writeAccess
+	apprv.vetoUsers = parseApprovers(os.Getenv(envVarVetoUsers))
+	if mentionOnlyRaw := os.Getenv(envVarMentionOnly); mentionOnlyRaw != "" {
+		apprv.mentionOnly, err = strconv.ParseBool(mentionOnlyRaw)
+		if err != nil {
+			fmt.Printf("error parsing mention only: %v\n", err)
+			exitWith(outcomeError)
+		}
+	}
+	if mentionPendingRaw := os.Getenv(envVarMentionPending); mentionPendingRaw != "" {
+		apprv.mentionPendingApprovers, err = strconv.ParseBool(mentionPendingRaw)
+		if err != nil {
+			fmt.Printf("error parsing mention pending: %v\n", err)
+			exitWith(outcomeError)
+		}
+	}
+	if distinctTeams > 0 {
+		apprv.requirements = append(apprv.requirements, distinctTeamsRequirement(teams, distinctTeams))
+	}
+	if len(pathOwnerGroups) > 0 {
+		apprv.requirements = append(apprv.requirements, pathOwnersRequirement(pathOwnerGroups))
+	}
+	apprv.gateName = os.Getenv(envVarGateName)
+	if apprv.gateName != "" {
+		setOutput("gate-name", apprv.gateName)
+	}
+	if mode != modeCompanion {
+		approvers, err = apprv.chooseApprovers(ctx, approvers)
+		if err != nil {
+			fmt.Printf("error selecting approvers: %v\n", err)
+			exitWith(outcomeError)
+		}
+	}
+
+	actor := os.Getenv(envVarActor)
+	branch := os.Getenv(envVarHeadRef)
+	if branch == "" {
+		branch = os.Getenv(envVarRefName)
+	}
+	apprv.branch = branch
+	apprv.environment = environment
+	apprv.issueTitleTemplate = os.Getenv(envVarIssueTitle)
+	apprv.issueTitlePrefix = strings.TrimSpace(os.Getenv(envVarIssueTitlePrefix))
+	apprv.actor = actor
+	apprv.workflowName = os.Getenv(envVarWorkflow)
+	apprv.jobName = os.Getenv(envVarJob)
+	if event, err := readWorkflowEvent(os.Getenv(envVarEventPath)); err == nil {
+		if event.PullRequest != nil {
+			apprv.triggerPullRequest = event.PullRequest.Number
+		}
+		if os.Getenv(envVarEventName) == "workflow_dispatch" {
+			apprv.dispatchInputs = event.Inputs
+		}
+		if !filesFetched && (event.PullRequest != nil || event.isPush()) {
+			files, err = changedFiles(ctx, client, repoOwner, apprv.repo, event, apprv.sha)
+			if err != nil {
+				fmt.Printf("error listing changed files, leaving them out of the approval issue: %v\n", err)
+			}
+		}
+		apprv.changedFiles = files
+	}
+	if bodyFile := os.Getenv(envVarBodyFile); bodyFile != "" {
+		content, err := os.ReadFile(bodyFile)
+		if err != nil {
+			fmt.Printf("error reading body file: %v\n", err)
+			exitWith(outcomeError)
+		}
+		apprv.bodyFileName = bodyFile
+		apprv.bodyFileContent = string(content)
+	}
+	apprv.reviewMode = os.Getenv(envVarReviewMode)
+	switch apprv.reviewMode {
+	case "", reviewModeNone:
+		apprv.reviewMode = reviewModeNone
+	case reviewModeInclude, reviewModeOnly:
+	default:
+		fmt.Printf("error: unknown review mode %s\n", apprv.reviewMode)
+		exitWith(outcomeError)
+	}
+	if apprv.reviewMode == reviewModeOnly && len(apprv.mutlipleDeploymentNames) > 0 {
+		fmt.Println("error: review mode only can't be used with multiple deployment names, as reviews don't name deployments")
+		exitWith(outcomeError)
+	}
+
+	pullRequestCommentRaw := os.Getenv(envVarPullRequestComment)
+	if pullRequestCommentRaw != "" {
+		apprv.pullRequestComment, err = strconv.ParseBool(pullRequestCommentRaw)
+		if err != nil {
+			fmt.Printf("error parsing pull request comment: %v\n", err)
+			exitWith(outcomeError)
+		}
+	}
+
+	apprv.discussionCategory = os.Getenv(envVarDiscussionCategory)
+	if apprv.discussionCategory != "" && apprv.pullRequestComment {
+		fmt.Println("error: discussion category and pull request comment cannot be used together")
+		exitWith(outcomeError)
+	}
+
+	if apprv.reviewMode != reviewModeNone || apprv.pullRequestComment {
+		apprv.pullRequestNumber, err = apprv.associatedPullRequest(ctx)
+		if err != nil {
+			fmt.Printf("error finding associated pull request: %v\n", err)
+			exitWith(outcomeError)
+		}
+		if apprv.pullRequestNumber == 0 {
+			fmt.Println("error: review mode and pull request comments require a pull request")
+			exitWith(outcomeError)
+		}
+		// Reviews are of the head commit of the pull request, which on pull
+		// request events is not GITHUB_SHA but the commit it is merged from.
+		apprv.reviewSHA = apprv.sha
+		if event, err := readWorkflowEvent(os.Getenv(envVarEventPath)); err == nil && event.PullRequest != nil && event.PullRequest.Head.SHA != "" {
+			apprv.reviewSHA = event.PullRequest.Head.SHA
+		}
 	}
 
-	err = apprv.createApprovalIssue(ctx)
+	autoApproveLabel := os.Getenv(envVarAutoApproveLabel)
+	if autoApproveLabel != "" {
+		pullRequestNumber, err := apprv.associatedPullRequest(ctx)
+		if err != nil {
+			fmt.Printf("error finding associated pull request: %v\n", err)
+			exitWith(outcomeError)
+		}
+		if pullRequestNumber != 0 {
+			labeler, err := apprv.autoApprovalFromLabel(ctx, pullRequestNumber, autoApproveLabel)
+			if err != nil {
+				fmt.Printf("error checking auto-approve label: %v\n", err)
+				exitWith(outcomeError)
+			}
+			if labeler != "" {
+				fmt.Printf("Pull request #%d has label %s applied by %s, skipping manual approval\n", pullRequestNumber, autoApproveLabel, labeler)
+				setOutput("auto-approved-by", labeler)
+				exitWith(outcomeApproved)
+			}
+		}
+	}
+
+	approvalCacheRaw := os.Getenv(envVarApprovalCache)
+	if approvalCacheRaw != "" {
+		approvalCache, err := strconv.ParseBool(approvalCacheRaw)
+		if err != nil {
+			fmt.Printf("error parsing approval cache: %v\n", err)
+			exitWith(outcomeError)
+		}
+		if approvalCache {
+			if apprv.sha == "" {
+				fmt.Printf("error: approval cache requires %s to be set\n", envVarSHA)
+				exitWith(outcomeError)
+			}
+			cachedIssue, deploymentNames, err := apprv.findCachedApproval(ctx)
+			if err != nil {
+				fmt.Printf("error looking up cached approval: %v\n", err)
+				exitWith(outcomeError)
+			}
+			if cachedIssue != nil {
+				fmt.Printf("Commit %s was already approved in %s, skipping manual approval\n", apprv.sha, cachedIssue.GetHTMLURL())
+				setOutput("cached-approval-url", cachedIssue.GetHTMLURL())
+				setDeploymentNamesOutput(deploymentNames)
+				exitWith(outcomeApproved)
+			}
+		}
+	}
+
+	createDeploymentRaw := os.Getenv(envVarCreateDeployment)
+	if createDeploymentRaw != "" {
+		apprv.createDeployment, err = strconv.ParseBool(createDeploymentRaw)
+		if err != nil {
+			fmt.Printf("error parsing create deployment: %v\n", err)
+			exitWith(outcomeError)
+		}
+	}
+	apprv.deploymentEnvironment = os.Getenv(envVarDeploymentEnvironment)
+	if apprv.createDeployment {
+		if apprv.sha == "" {
+			fmt.Printf("error: creating a deployment requires %s to be set\n", envVarSHA)
+			exitWith(outcomeError)
+		}
+		if apprv.deploymentEnvironment == "" && len(apprv.mutlipleDeploymentNames) == 0 {
+			fmt.Println("error: creating a deployment requires a deployment environment or multiple deployment names")
+			exitWith(outcomeError)
+		}
+	}
+
+	runAttemptRaw := os.Getenv(envVarRunAttempt)
+	if runAttemptRaw != "" {
+		apprv.runAttempt, err = strconv.Atoi(runAttemptRaw)
+		if err != nil {
+			fmt.Printf("error parsing run attempt: %v\n", err)
+			exitWith(outcomeError)
+		}
+	}
+	if apprv.runAttempt > 1 {
+		run, _, err := client.Actions.GetWorkflowRunByID(ctx, repoOwner, apprv.repo, int64(runID))
+		if err != nil {
+			fmt.Printf("error getting workflow run: %v\n", err)
+			exitWith(outcomeError)
+		}
+		apprv.runAttemptStartedAt = run.GetRunStartedAt().Time
+	}
+
+	rerunBehavior := os.Getenv(envVarRerunBehavior)
+	switch rerunBehavior {
+	case "", rerunBehaviorNew, rerunBehaviorReuse:
+	default:
+		fmt.Printf("error: unsupported rerun behavior %q, expected %s or %s\n", rerunBehavior, rerunBehaviorNew, rerunBehaviorReuse)
+		exitWith(outcomeError)
+	}
+	if apprv.runAttempt > 1 {
+		approved, deploymentNames, err := apprv.reusePreviousAttempt(ctx, rerunBehavior == rerunBehaviorReuse)
+		if err != nil {
+			fmt.Printf("error looking up the approval issue of an earlier attempt: %v\n", err)
+			exitWith(outcomeError)
+		}
+		if approved == approvalStatusApproved {
+			fmt.Printf("Workflow run %d was already approved in %s, skipping manual approval\n", runID, apprv.approvalIssue.GetHTMLURL())
+			setOutput("reused-approval-url", apprv.approvalIssue.GetHTMLURL())
+			setDeploymentNamesOutput(deploymentNames)
+			exitWith(outcomeApproved)
+		}
+	}
+
+	apprv.dispatchSecret = os.Getenv(envVarDispatchSecret)
+	if os.Getenv(envVarEmergencyDispatchType) != "" {
+		apprv.emergencySenders = splitInputList(os.Getenv(envVarEmergencySenders))
+		if len(apprv.emergencySenders) == 0 {
+			fmt.Println("error: emergency dispatch type requires emergency senders")
+			exitWith(outcomeError)
+		}
+		if apprv.dispatchSecret == "" {
+			fmt.Println("error: emergency dispatch type requires a dispatch secret to sign the release")
+			exitWith(outcomeError)
+		}
+	}
+	if apprv.dispatchSecret != "" || len(apprv.emergencySenders) > 0 {
+		run, _, err := client.Actions.GetWorkflowRunByID(ctx, repoOwner, apprv.repo, int64(runID))
+		if err != nil {
+			fmt.Printf("error getting workflow run: %v\n", err)
+			exitWith(outcomeError)
+		}
+		apprv.dispatchSHA = run.GetHeadSHA()
+	}
+
+	createIssueRaw := os.Getenv(envVarCreateIssue)
+	if createIssueRaw != "" {
+		apprv.createIssue, err = strconv.ParseBool(createIssueRaw)
+		if err != nil {
+			fmt.Printf("error parsing create issue: %v\n", err)
+			exitWith(outcomeError)
+		}
+	}
+	if !apprv.createIssue && apprv.dispatchSecret == "" && os.Getenv(envVarCheckRunName) == "" && apprv.reviewMode != reviewModeOnly {
+		fmt.Println("error: without an approval issue, a dispatch secret, check run or review mode only is required")
+		exitWith(outcomeError)
+	}
+
+	apprv.tracer, err = newGateTracer(os.Getenv, startedAt)
 	if err != nil {
-		fmt.Printf("error creating issue: %v", err)
-		os.Exit(1)
+		fmt.Printf("error configuring tracing: %v\n", err)
+		exitWith(outcomeError)
+	}
+	apprv.pushgatewayURL = os.Getenv(envVarPushgatewayURL)
+	apprv.pushgatewayJob = os.Getenv(envVarPushgatewayJob)
+	if apprv.pushgatewayJob == "" {
+		apprv.pushgatewayJob = "manual_approval"
 	}
 
+	apprv.auditFile = os.Getenv(envVarAuditFile)
+	if decisionCheckRunRaw := os.Getenv(envVarDecisionCheckRun); decisionCheckRunRaw != "" {
+		apprv.decisionCheckRun, err = strconv.ParseBool(decisionCheckRunRaw)
+		if err != nil {
+			fmt.Printf("error parsing decision check run: %v\n", err)
+			exitWith(outcomeError)
+		}
+		if apprv.decisionCheckRun && apprv.sha == "" {
+			fmt.Printf("error: decision check run requires %s to be set\n", envVarSHA)
+			exitWith(outcomeError)
+		}
+		apprv.workflowPath = workflowFilePath(os.Getenv(envVarWorkflowRef), repoFullName)
+	}
+	apprv.receiptSecret = os.Getenv(envVarReceiptSecret)
+	apprv.attestationFile = os.Getenv(envVarAttestationFile)
+	if apprv.attestationFile != "" {
+		if apprv.sha == "" {
+			fmt.Printf("error: attestation requires %s to be set\n", envVarSHA)
+			exitWith(outcomeError)
+		}
+		if os.Getenv(envVarOIDCRequestURL) == "" {
+			fmt.Println("error: attestation requires the id-token: write permission")
+			exitWith(outcomeError)
+		}
+		if attestationPublishRaw := os.Getenv(envVarAttestationPublish); attestationPublishRaw != "" {
+			apprv.attestationPublish, err = strconv.ParseBool(attestationPublishRaw)
+			if err != nil {
+				fmt.Printf("error parsing attestation publish: %v\n", err)
+				exitWith(outcomeError)
+			}
+		}
+		apprv.fulcioURL = os.Getenv(envVarFulcioURL)
+		if apprv.fulcioURL == "" {
+			apprv.fulcioURL = defaultFulcioURL
+		}
+		apprv.rekorURL = os.Getenv(envVarRekorURL)
+		if apprv.rekorURL == "" {
+			apprv.rekorURL = defaultRekorURL
+		}
+	}
+	if deleteIssueRaw := os.Getenv(envVarDeleteIssueOnCompletion); deleteIssueRaw != "" {
+		apprv.deleteIssue, err = strconv.ParseBool(deleteIssueRaw)
+		if err != nil {
+			fmt.Printf("error parsing delete issue on completion: %v\n", err)
+			exitWith(outcomeError)
+		}
+		if conflict := apprv.deleteIssueConflict(os.Getenv(envVarApprovalCache)); apprv.deleteIssue && conflict != "" {
+			fmt.Printf("error: delete-issue-on-completion can't be combined with %s, which keeps the approval issue as a record\n", conflict)
+			exitWith(outcomeError)
+		}
+	}
+	apprv.statsdAddress = os.Getenv(envVarStatsdAddress)
+	apprv.statsdPrefix = os.Getenv(envVarStatsdPrefix)
+	if apprv.statsdPrefix == "" {
+		apprv.statsdPrefix = "manual_approval."
+	}
+	apprv.approvalConfirmations = os.Getenv(envVarApprovalConfirmations)
+	switch apprv.approvalConfirmations {
+	case "":
+		apprv.approvalConfirmations = confirmationsComment
+	case confirmationsComment, confirmationsEdit, confirmationsNone:
+	default:
+		fmt.Printf("error: unknown approval confirmations %s, expected %s, %s or %s\n", apprv.approvalConfirmations, confirmationsComment, confirmationsEdit, confirmationsNone)
+		exitWith(outcomeError)
+	}
+	if statusLabelsRaw := os.Getenv(envVarStatusLabels); statusLabelsRaw != "" {
+		apprv.statusLabels, err = strconv.ParseBool(statusLabelsRaw)
+		if err != nil {
+			fmt.Printf("error parsing status labels: %v\n", err)
+			exitWith(outcomeError)
+		}
+	}
+	apprv.minimizeComments = os.Getenv(envVarMinimizeComments)
+	switch apprv.minimizeComments {
+	case "":
+		apprv.minimizeComments = minimizeNone
+	case minimizeNone, minimizeInvalid, minimizeNonApprovers:
+	default:
+		fmt.Printf("error: unknown minimize comments %s, expected %s, %s or %s\n", apprv.minimizeComments, minimizeNone, minimizeInvalid, minimizeNonApprovers)
+		exitWith(outcomeError)
+	}
+	apprv.onDenial = os.Getenv(envVarOnDenial)
+	switch apprv.onDenial {
+	case "":
+		apprv.onDenial = onDenialFail
+	case onDenialFail:
+	case onDenialSkip:
+		if os.Getenv(envVarExitCodeDenied) != "" {
+			fmt.Printf("error: on-denial %s can't be combined with exit-code-denied\n", onDenialSkip)
+			exitWith(outcomeError)
+		}
+		outcomeExitCodes[outcomeDenied] = 0
+	default:
+		fmt.Printf("error: unknown on-denial %s, expected %s or %s\n", apprv.onDenial, onDenialFail, onDenialSkip)
+		exitWith(outcomeError)
+	}
+	apprv.statsdFormat = os.Getenv(envVarStatsdFormat)
+	switch apprv.statsdFormat {
+	case "":
+		apprv.statsdFormat = statsdFormatDogStatsD
+	case statsdFormatDogStatsD, statsdFormatPlain:
+	default:
+		fmt.Printf("error: unknown StatsD format %s, expected %s or %s\n", apprv.statsdFormat, statsdFormatDogStatsD, statsdFormatPlain)
+		exitWith(outcomeError)
+	}
+
+	if maxWaitRaw := os.Getenv(envVarMaxWait); maxWaitRaw != "" {
+		apprv.maxWait, err = time.ParseDuration(maxWaitRaw)
+		if err != nil {
+			fmt.Printf("error parsing max wait: %v\n", err)
+			exitWith(outcomeError)
+		}
+	}
+	if slaRaw := os.Getenv(envVarSLA); slaRaw != "" {
+		apprv.sla, err = time.ParseDuration(slaRaw)
+		if err != nil {
+			fmt.Printf("error parsing SLA: %v\n", err)
+			exitWith(outcomeError)
+		}
+	}
+	if maxPollsRaw := os.Getenv(envVarMaxPolls); maxPollsRaw != "" {
+		apprv.maxPolls, err = strconv.Atoi(maxPollsRaw)
+		if err != nil {
+			fmt.Printf("error parsing max polls: %v\n", err)
+			exitWith(outcomeError)
+		}
+	}
+
+	closeDecisionsRaw := os.Getenv(envVarCloseDecisions)
+	if closeDecisionsRaw != "" {
+		apprv.closeDecisions, err = strconv.ParseBool(closeDecisionsRaw)
+		if err != nil {
+			fmt.Printf("error parsing close decisions: %v\n", err)
+			exitWith(outcomeError)
+		}
+	}
+
+	apprv.approveLabel = os.Getenv(envVarApproveLabel)
+	apprv.denyLabel = os.Getenv(envVarDenyLabel)
+	if apprv.approveLabel != "" {
+		if err := apprv.ensureLabel(ctx, apprv.approveLabel, "0e8a16", "Approves a manual approval issue"); err != nil {
+			fmt.Printf("error creating label %s: %v\n", apprv.approveLabel, err)
+			exitWith(outcomeError)
+		}
+	}
+	if apprv.denyLabel != "" {
+		if err := apprv.ensureLabel(ctx, apprv.denyLabel, "d93f0b", "Denies a manual approval issue"); err != nil {
+			fmt.Printf("error creating label %s: %v\n", apprv.denyLabel, err)
+			exitWith(outcomeError)
+		}
+	}
+
+	if issueTemplate := os.Getenv(envVarIssueTemplate); issueTemplate != "" {
+		apprv.issueTemplate, err = apprv.readIssueTemplate(ctx, issueTemplate)
+		if err != nil {
+			fmt.Printf("error reading issue template %s: %v\n", issueTemplate, err)
+			exitWith(outcomeError)
+		}
+	}
+
+	if milestone := os.Getenv(envVarMilestone); milestone != "" {
+		apprv.milestone, err = apprv.resolveMilestone(ctx, milestone)
+		if err != nil {
+			fmt.Printf("error finding milestone %s: %v\n", milestone, err)
+			exitWith(outcomeError)
+		}
+	}
+
+	if sharedIssueRaw := os.Getenv(envVarSharedIssue); sharedIssueRaw != "" {
+		apprv.sharedIssue, err = strconv.ParseBool(sharedIssueRaw)
+		if err != nil {
+			fmt.Printf("error parsing shared issue: %v\n", err)
+			exitWith(outcomeError)
+		}
+	}
+
+	// Signals are trapped from the moment the approval request exists, so that
+	// it is closed even if the run is cancelled before polling starts.
 	killSignalChannel := make(chan os.Signal, 1)
-	signal.Notify(killSignalChannel, os.Interrupt)
+	signal.Notify(killSignalChannel, os.Interrupt, syscall.SIGTERM)
+
+	switch mode {
+	case modeCreate, modeWait, modeResolve, modeCompanion:
+		if !apprv.createIssue || apprv.discussionCategory != "" || apprv.pullRequestComment || apprv.sharedIssue {
+			fmt.Printf("error: the %s mode requires a separate approval issue\n", mode)
+			exitWith(outcomeError)
+		}
+	}
+	var extraScopes []string
+	if os.Getenv(envVarProjectNumber) != "" {
+		extraScopes = append(extraScopes, "project")
+	}
+	if err := apprv.preflightRepository(ctx, extraScopes...); err != nil {
+		fmt.Printf("error: %v\n", err)
+		exitWith(outcomeError)
+	}
+	switch {
+	case mode == modeWait || mode == modeResolve:
+		issueNumber, err := strconv.Atoi(os.Getenv(envVarIssueNumber))
+		if err != nil {
+			fmt.Printf("error parsing issue number: %v\n", err)
+			exitWith(outcomeError)
+		}
+		if err := apprv.attachApprovalIssue(ctx, issueNumber); err != nil {
+			fmt.Printf("error getting approval issue #%d: %v\n", issueNumber, err)
+			exitWith(outcomeError)
+		}
+		apprv.leaveIssueOpen = mode == modeWait
+	case mode == modeCompanion:
+		event, err := readWorkflowEvent(os.Getenv(envVarEventPath))
+		if err != nil {
+			fmt.Printf("error reading event: %v\n", err)
+			exitWith(outcomeError)
+		}
+		if event.Issue == nil {
+			fmt.Println("The event isn't for an issue, nothing to do")
+			exit(0)
+		}
+		if err := apprv.attachApprovalIssue(ctx, event.Issue.Number); err != nil {
+			fmt.Printf("Issue #%d isn't an approval issue, nothing to do: %v\n", event.Issue.Number, err)
+			exit(0)
+		}
+		if apprv.approvalIssue.GetState() != "open" {
+			fmt.Printf("Approval issue #%d is closed, nothing to do\n", apprv.approvalIssueNumber)
+			exit(0)
+		}
+		// The comments count from when the approval was requested, not from
+		// when this run started.
+		apprv.runAttemptStartedAt = time.Time{}
+		// Approvers are selected for the run that requested the approval.
+		marker, _ := parseIssueMarker(apprv.approvalIssue.GetBody())
+		approvers = apprv.chooseApproversFor(marker, approvers)
+		status, err := apprv.runCompanion(ctx, approvers, minimumApprovals)
+		if err != nil {
+			fmt.Printf("error recording approval progress: %v\n", err)
+			exitWith(outcomeError)
+		}
+		fmt.Printf("Approval issue #%d is %s\n", apprv.approvalIssueNumber, status)
+		exit(0)
+	case apprv.approvalIssue == nil:
+		createStart := time.Now()
+		err = apprv.createApprovalIssue(ctx)
+		apprv.tracer.record("create approval issue", createStart, apprv.traceAttributes(), err)
+		if err != nil {
+			fmt.Printf("error creating issue: %v", err)
+			exitWith(outcomeError)
+		}
+	}
+
+	if err := apprv.setStatusLabel(ctx, approvalStatusPending); err != nil {
+		fmt.Printf("error setting status label: %v\n", err)
+	}
+
+	if supersedeRaw := os.Getenv(envVarSupersedeOlderIssues); supersedeRaw != "" {
+		supersede, err := strconv.ParseBool(supersedeRaw)
+		if err != nil {
+			fmt.Printf("error parsing supersede older issues: %v\n", err)
+			exitWith(outcomeError)
+		}
+		if supersede && apprv.approvalIssue != nil {
+			if err := apprv.supersedeOlderIssues(ctx); err != nil {
+				fmt.Printf("error closing superseded approval issues: %v\n", err)
+			}
+		}
+	}
 
-	commentLoopChannel := newCommentLoopChannel(ctx, apprv, client, approvers, minimumApprovals)
+	if projectNumberRaw := os.Getenv(envVarProjectNumber); projectNumberRaw != "" {
+		projectNumber, err := strconv.Atoi(projectNumberRaw)
+		if err != nil {
+			fmt.Printf("error parsing project number: %v\n", err)
+			exitWith(outcomeError)
+		}
+		projectOwner := os.Getenv(envVarProjectOwner)
+		if projectOwner == "" {
+			projectOwner = repoOwner
+		}
+		apprv.projectDoneStatus = os.Getenv(envVarProjectDoneStatus)
+		if apprv.projectDoneStatus == "" {
+			apprv.projectDoneStatus = "Done"
+		}
+		if err := apprv.addToProject(ctx, projectOwner, projectNumber, os.Getenv(envVarProjectStatus)); err != nil {
+			fmt.Printf("error adding approval issue to project %d: %v\n", projectNumber, err)
+		}
+	}
+
+	switch mode {
+	case modeCreate:
+		setOutput("issue-number", strconv.Itoa(apprv.approvalIssueNumber))
+		setOutput("issue-url", apprv.approvalIssue.GetHTMLURL())
+		exit(0)
+	case modeResolve:
+		resolved, err := apprv.resolveFromComments(ctx, approvers, minimumApprovals)
+		if err != nil {
+			fmt.Printf("error resolving approval issue: %v\n", err)
+			exitWith(outcomeError)
+		}
+		fmt.Printf("Approval issue #%d resolved as %s\n", apprv.approvalIssueNumber, resolved)
+		exit(0)
+	}
+
+	apprv.commitStatusContext = os.Getenv(envVarCommitStatusContext)
+	if apprv.commitStatusContext != "" {
+		if apprv.sha == "" {
+			fmt.Printf("error: commit status requires %s to be set\n", envVarSHA)
+			exitWith(outcomeError)
+		}
+		if err := apprv.setCommitStatus(ctx, approvalStatusPending); err != nil {
+			fmt.Printf("error setting commit status: %v\n", err)
+			exitWith(outcomeError)
+		}
+	}
+
+	apprv.checkRunName = os.Getenv(envVarCheckRunName)
+	if apprv.checkRunName != "" {
+		if apprv.dispatchSecret == "" {
+			fmt.Println("error: check run approval requires a dispatch secret to verify the decisions")
+			exitWith(outcomeError)
+		}
+		if apprv.sha == "" {
+			fmt.Printf("error: check run approval requires %s to be set\n", envVarSHA)
+			exitWith(outcomeError)
+		}
+		if err := apprv.createApprovalCheckRun(ctx); err != nil {
+			fmt.Printf("error creating check run: %v\n", err)
+			exitWith(outcomeError)
+		}
+	}
+
+	ignoreEditsAfterApproval := false
+	ignoreEditsAfterApprovalRaw := os.Getenv(envVarIgnoreEditsAfterApproval)
+	if ignoreEditsAfterApprovalRaw != "" {
+		ignoreEditsAfterApproval, err = strconv.ParseBool(ignoreEditsAfterApprovalRaw)
+		if err != nil {
+			fmt.Printf("error parsing ignore edits after approval: %v\n", err)
+			exitWith(outcomeError)
+		}
+	}
+
+	if companionSignalRaw := os.Getenv(envVarCompanionSignal); companionSignalRaw != "" {
+		apprv.companionSignal, err = strconv.ParseBool(companionSignalRaw)
+		if err != nil {
+			fmt.Printf("error parsing companion signal: %v\n", err)
+			exitWith(outcomeError)
+		}
+	}
+	if apprv.companionSignal && apprv.approvalIssue == nil {
+		fmt.Println("error: companion-signal requires an approval issue")
+		exitWith(outcomeError)
+	}
+
+	if webhookAddress := os.Getenv(envVarWebhookAddress); webhookAddress != "" && apprv.discussionCategory != "" {
+		fmt.Println("Webhooks aren't received for discussions, polling instead")
+	} else if webhookAddress != "" {
+		webhookSecret := os.Getenv(envVarWebhookSecret)
+		if webhookSecret == "" {
+			fmt.Println("error: webhook-address requires webhook-secret to be set")
+			exitWith(outcomeError)
+		}
+		if err := apprv.listenForWebhooks(webhookAddress, webhookSecret); err != nil {
+			fmt.Printf("error listening for webhooks: %v\n", err)
+			exitWith(outcomeError)
+		}
+	}
+
+	if err := apprv.saveWaitDeadline(ctx); err != nil {
+		fmt.Printf("error saving the wait deadline: %v\n", err)
+	}
+
+	commentLoopChannel := newCommentLoopChannel(ctx, apprv, approvers, minimumApprovals, ignoreEditsAfterApproval)
 
 	select {
-	case exitCode := <-commentLoopChannel:
-		os.Exit(exitCode)
+	case outcome := <-commentLoopChannel:
+		setRateLimitOutput()
+		exitWith(outcome)
 	case _ = <-killSignalChannel:
-		handleInterrupt(client, ctx, apprv)
-		os.Exit(1)
+		handleInterrupt(ctx, apprv)
+		setRateLimitOutput()
+		exitWith(outcomeCancelled)
 	}
 }
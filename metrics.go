@@ -0,0 +1,217 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"fmt"
+	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/google/go-github/v43/github"
+)
+
+// gateMetrics are the measurements of a gate, reported when it resolves.
+type gateMetrics struct {
+	status      approvalStatus
+	requestedAt time.Time
+	resolvedAt  time.Time
+	approvedBy  []string
+	deniedBy    []string
+	polls       int
+	comments    []*github.IssueComment
+	approvers   []string
+}
+
+// timeToDecision is how long the gate waited for its decision.
+func (m gateMetrics) timeToDecision() time.Duration {
+	if m.requestedAt.IsZero() || m.resolvedAt.Before(m.requestedAt) {
+		return 0
+	}
+	return m.resolvedAt.Sub(m.requestedAt)
+}
+
+// latestDecisions lists the approvers whose latest decision in the comments
+// is an approval, and those whose latest decision is a denial, in the order
+// they decided.
+func latestDecisions(comments []*github.IssueComment, approvers []string) (approvedBy, deniedBy []string) {
+	decisions := map[string]approvalStatus{}
+	var order []string
+	for _, comment := range comments {
+		login := comment.User.GetLogin()
+		if approversIndex(approvers, login) < 0 {
+			continue
+		}
+		key := strings.ToLower(login)
+		body := strings.Split(comment.GetBody(), "[")[0]
+		var decision approvalStatus
+		if approved, _ := isApproved(body); approved {
+			decision = approvalStatusApproved
+		} else if denied, _ := isDenied(body); denied {
+			decision = approvalStatusDenied
+		} else if revoked, _ := isRevoked(body); revoked {
+			delete(decisions, key)
+			continue
+		} else {
+			continue
+		}
+		if _, ok := decisions[key]; !ok {
+			order = append(order, login)
+		}
+		decisions[key] = decision
+	}
+	for _, login := range order {
+		switch decisions[strings.ToLower(login)] {
+		case approvalStatusApproved:
+			approvedBy = append(approvedBy, login)
+		case approvalStatusDenied:
+			deniedBy = append(deniedBy, login)
+		}
+	}
+	return approvedBy, deniedBy
+}
+
+// observe updates the decisions from the comments of a poll.
+func (m *gateMetrics) observe(comments []*github.IssueComment, approvers []string) {
+	m.polls++
+	m.comments, m.approvers = comments, approvers
+	m.approvedBy, m.deniedBy = latestDecisions(comments, approvers)
+}
+
+// metricLabels identify the gate in the metrics it reports.
+func (a *approvalEnvironment) metricLabels() map[string]string {
+	return map[string]string{
+		"repository":  a.repoFullName,
+		"environment": a.environment,
+		"gate":        a.gateName,
+	}
+}
+
+// prometheusMetrics renders the metrics in the Prometheus text exposition
+// format.
+func prometheusMetrics(metrics gateMetrics) string {
+	status := fmt.Sprintf(`{status="%s"}`, strings.ToLower(string(metrics.status)))
+	var b strings.Builder
+	for _, metric := range []struct {
+		name, kind, help string
+		value            float64
+	}{
+		{"manual_approval_gate_opened", "gauge", "Whether an approval gate was opened.", 1},
+		{"manual_approval_time_to_decision_seconds", "gauge", "Time from requesting approval to the decision.", metrics.timeToDecision().Seconds()},
+		{"manual_approval_approvals_count", "gauge", "Approvers whose latest decision was an approval.", float64(len(metrics.approvedBy))},
+		{"manual_approval_denials_count", "gauge", "Approvers whose latest decision was a denial.", float64(len(metrics.deniedBy))},
+		{"manual_approval_polls_total", "counter", "Polls made while waiting for the decision.", float64(metrics.polls)},
+	} {
+		fmt.Fprintf(&b, "# HELP %s %s\n# TYPE %s %s\n%s%s %g\n", metric.name, metric.help, metric.name, metric.kind, metric.name, status, metric.value)
+	}
+	return b.String()
+}
+
+// pushgatewayURL is the URL metrics are pushed to, grouped by job and the
+// labels. Label values that are empty or contain a slash are base64
+// encoded, as the Pushgateway requires.
+func pushgatewayURL(baseURL, job string, labels map[string]string) string {
+	names := make([]string, 0, len(labels))
+	for name := range labels {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	path := strings.TrimSuffix(baseURL, "/") + "/metrics/" + groupingKeyPart("job", job)
+	for _, name := range names {
+		path += "/" + groupingKeyPart(name, labels[name])
+	}
+	return path
+}
+
+func groupingKeyPart(name, value string) string {
+	if value == "" {
+		return name + "@base64/="
+	}
+	if strings.Contains(value, "/") {
+		return name + "@base64/" + base64.RawURLEncoding.EncodeToString([]byte(value))
+	}
+	return name + "/" + url.PathEscape(value)
+}
+
+// pushMetrics replaces the metrics of the gate's group on the Pushgateway.
+func pushMetrics(ctx context.Context, pushURL string, metrics gateMetrics) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPut, pushURL, bytes.NewBufferString(prometheusMetrics(metrics)))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "text/plain; version=0.0.4")
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return fmt.Errorf("unexpected status %s from %s", resp.Status, pushURL)
+	}
+	return nil
+}
+
+// reportMetrics records the resolution of the gate and sends its metrics to
+// the configured sinks. Failing to report is logged, as it must not fail
+// the gate.
+func (a *approvalEnvironment) reportMetrics(ctx context.Context, status approvalStatus) {
+	a.metrics.status = status
+	a.metrics.requestedAt = a.decisionsNotBefore()
+	a.metrics.resolvedAt = time.Now()
+
+	attributes := a.traceAttributes()
+	attributes["manual_approval.status"] = strings.ToLower(string(status))
+	attributes["manual_approval.approvers"] = strings.Join(a.metrics.approvedBy, ",")
+	attributes["manual_approval.deniers"] = strings.Join(a.metrics.deniedBy, ",")
+	a.tracer.record("decision", a.metrics.resolvedAt, attributes, nil)
+	if err := a.tracer.export(ctx, a.traceAttributes(), a.metrics); err != nil {
+		fmt.Printf("error exporting trace: %v\n", err)
+	}
+
+	a.reportTiming()
+	if a.sla > 0 {
+		setOutput("sla-breached", strconv.FormatBool(a.slaBreached(a.metrics.resolvedAt)))
+	}
+	if a.receiptSecret != "" {
+		setOutput("receipt", approvalReceipt(a.receiptSecret, a.runID, a.sha, a.gateName, status))
+	}
+	if a.auditFile != "" {
+		digest, err := writeAuditFile(a.auditFile, a.auditRecord())
+		if err != nil {
+			fmt.Printf("error writing audit file: %v\n", err)
+		} else {
+			fmt.Printf("Audit record written to %s\n", a.auditFile)
+			setOutput("audit-file", a.auditFile)
+			setOutput("audit-sha256", digest)
+		}
+	}
+	if a.attestationFile != "" {
+		logIndex, err := a.attest(ctx)
+		if err != nil {
+			fmt.Printf("error creating attestation: %v\n", err)
+		} else {
+			fmt.Printf("Attestation written to %s\n", a.attestationFile)
+			setOutput("attestation-file", a.attestationFile)
+			if logIndex >= 0 {
+				fmt.Printf("Attestation published to %s with log index %d\n", a.rekorURL, logIndex)
+				setOutput("attestation-log-index", strconv.FormatInt(logIndex, 10))
+			}
+		}
+	}
+	if a.statsdAddress != "" {
+		if err := sendStatsd(a.statsdAddress, statsdMetrics(a.statsdPrefix, a.statsdFormat, a.metricLabels(), a.metrics)); err != nil {
+			fmt.Printf("error sending StatsD metrics: %v\n", err)
+		}
+	}
+	if a.pushgatewayURL != "" {
+		pushURL := pushgatewayURL(a.pushgatewayURL, a.pushgatewayJob, a.metricLabels())
+		if err := pushMetrics(ctx, pushURL, a.metrics); err != nil {
+			fmt.Printf("error pushing metrics: %v\n", err)
+		}
+	}
+}
@@ -0,0 +1,86 @@
+package main
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/go-github/v43/github"
+)
+
+func TestLatestDecisions(t *testing.T) {
+	comment := func(login, body string) *github.IssueComment {
+		return &github.IssueComment{User: &github.User{Login: github.String(login)}, Body: github.String(body)}
+	}
+	comments := []*github.IssueComment{
+		comment("user1", "approved"),
+		comment("User2", "denied"),
+		comment("user3", "approved"),
+		comment("user3", "revoke"),
+		comment("user4", "approved"),
+		comment("user5", "lgtm[prod]"),
+		comment("user1", "thanks"),
+	}
+
+	approvedBy, deniedBy := latestDecisions(comments, []string{"user1", "user2", "user3", "user5"})
+	if strings.Join(approvedBy, ",") != "user1,user5" || strings.Join(deniedBy, ",") != "User2" {
+		t.Fatalf("expected approvals by user1,user5 and denials by User2 but got %v and %v", approvedBy, deniedBy)
+	}
+}
+
+func TestPrometheusMetrics(t *testing.T) {
+	requestedAt := time.Date(2022, 1, 1, 0, 0, 0, 0, time.UTC)
+	metrics := gateMetrics{
+		status:      approvalStatusApproved,
+		requestedAt: requestedAt,
+		resolvedAt:  requestedAt.Add(90 * time.Second),
+		approvedBy:  []string{"user1", "user2"},
+		polls:       7,
+	}
+
+	actual := prometheusMetrics(metrics)
+	for _, expected := range []string{
+		"# TYPE manual_approval_gate_opened gauge\nmanual_approval_gate_opened{status=\"approved\"} 1\n",
+		"manual_approval_time_to_decision_seconds{status=\"approved\"} 90\n",
+		"manual_approval_approvals_count{status=\"approved\"} 2\n",
+		"manual_approval_denials_count{status=\"approved\"} 0\n",
+		"# TYPE manual_approval_polls_total counter\nmanual_approval_polls_total{status=\"approved\"} 7\n",
+	} {
+		if !strings.Contains(actual, expected) {
+			t.Fatalf("expected metrics to contain %q but got:\n%s", expected, actual)
+		}
+	}
+}
+
+func TestPushgatewayURL(t *testing.T) {
+	actual := pushgatewayURL("http://pushgateway:9091/", "manual_approval", map[string]string{
+		"repository":  "owner/repo",
+		"environment": "prod",
+		"gate":        "",
+	})
+	expected := "http://pushgateway:9091/metrics/job/manual_approval/environment/prod/gate@base64/=/repository@base64/b3duZXIvcmVwbw"
+	if actual != expected {
+		t.Fatalf("expected %s but got %s", expected, actual)
+	}
+}
+
+func TestPushMetrics(t *testing.T) {
+	var method, body string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		method = r.Method
+		content, _ := io.ReadAll(r.Body)
+		body = string(content)
+	}))
+	defer server.Close()
+
+	if err := pushMetrics(context.Background(), server.URL+"/metrics/job/manual_approval", gateMetrics{status: approvalStatusDenied}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if method != http.MethodPut || !strings.Contains(body, `manual_approval_gate_opened{status="denied"} 1`) {
+		t.Fatalf("expected the metrics to be put but got %s with:\n%s", method, body)
+	}
+}
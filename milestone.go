@@ -0,0 +1,40 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/google/go-github/v43/github"
+)
+
+// resolveMilestone returns the number of the milestone given by number or by
+// title. Titles are matched case-insensitively against open milestones.
+func (a *approvalEnvironment) resolveMilestone(ctx context.Context, milestone string) (int, error) {
+	milestone = strings.TrimSpace(milestone)
+	if number, err := strconv.Atoi(milestone); err == nil {
+		return number, nil
+	}
+
+	opts := &github.MilestoneListOptions{
+		State:       "open",
+		ListOptions: github.ListOptions{PerPage: 100},
+	}
+	for {
+		milestones, resp, err := a.client.Issues.ListMilestones(ctx, a.repoOwner, a.repo, opts)
+		if err != nil {
+			return 0, err
+		}
+		for _, m := range milestones {
+			if strings.EqualFold(m.GetTitle(), milestone) {
+				return m.GetNumber(), nil
+			}
+		}
+		if resp.NextPage == 0 {
+			break
+		}
+		opts.Page = resp.NextPage
+	}
+	return 0, fmt.Errorf("no open milestone titled %q", milestone)
+}
@@ -0,0 +1,80 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/google/go-github/v43/github"
+)
+
+// The comments minimize-comments collapses. minimizeInvalid collapses every
+// comment that isn't a decision the gate reads, and minimizeNonApprovers
+// only those of users who aren't approvers, leaving the discussion among
+// approvers expanded.
+const (
+	minimizeNone         = "none"
+	minimizeInvalid      = "invalid"
+	minimizeNonApprovers = "non-approvers"
+)
+
+const minimizeCommentMutation = `mutation($subjectId: ID!) {
+  minimizeComment(input: {subjectId: $subjectId, classifier: OFF_TOPIC}) {
+    minimizedComment { isMinimized }
+  }
+}`
+
+// commentsToMinimize returns the comments to collapse as off-topic, leaving
+// out those already collapsed and those gateLogin posted, such as replies to
+// approvers and pending mentions, which approvers need to read. A comment
+// collapsed stays so even if it is edited into a decision later, which is
+// still counted.
+func commentsToMinimize(comments []*github.IssueComment, approvers []string, multipleDeploymentNames []string, mode, gateLogin string, minimized map[int64]bool) []*github.IssueComment {
+	if mode != minimizeInvalid && mode != minimizeNonApprovers {
+		return nil
+	}
+	var result []*github.IssueComment
+	for _, comment := range comments {
+		if minimized[comment.GetID()] || comment.GetNodeID() == "" {
+			continue
+		}
+		if gateLogin != "" && strings.EqualFold(comment.User.GetLogin(), gateLogin) {
+			continue
+		}
+		if approversIndex(approvers, comment.User.GetLogin()) >= 0 {
+			if mode == minimizeNonApprovers {
+				continue
+			}
+			if decision, err := commentDecision(comment.GetBody(), multipleDeploymentNames); err == nil && decision != "" {
+				continue
+			}
+		}
+		result = append(result, comment)
+	}
+	return result
+}
+
+// minimizeOffTopic collapses the comments on the approval issue that aren't
+// decisions, so that the approvals aren't buried in chatter on busy gates.
+func (a *approvalEnvironment) minimizeOffTopic(ctx context.Context, comments []*github.IssueComment, approvers []string) error {
+	if a.minimized == nil {
+		a.minimized = make(map[int64]bool)
+	}
+	for _, comment := range commentsToMinimize(comments, approvers, a.mutlipleDeploymentNames, a.minimizeComments, a.gateLogin(), a.minimized) {
+		if err := a.graphQL(ctx, minimizeCommentMutation, map[string]interface{}{"subjectId": comment.GetNodeID()}, nil); err != nil {
+			return fmt.Errorf("error minimizing comment %d: %w", comment.GetID(), err)
+		}
+		a.minimized[comment.GetID()] = true
+	}
+	return nil
+}
+
+// gateLogin returns the login the gate posts its comments as, which is the
+// author of the approval issue, or of the request comment when the approval
+// is requested on a pull request.
+func (a *approvalEnvironment) gateLogin() string {
+	if a.requestComment != nil {
+		return a.requestComment.GetUser().GetLogin()
+	}
+	return a.approvalIssue.GetUser().GetLogin()
+}
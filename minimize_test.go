@@ -0,0 +1,115 @@
+package main
+
+import (
+	"context"
+	"reflect"
+	"testing"
+
+	"github.com/google/go-github/v43/github"
+)
+
+func TestCommentsToMinimize(t *testing.T) {
+	comment := func(id int64, login, body string) *github.IssueComment {
+		return &github.IssueComment{
+			ID:     github.Int64(id),
+			NodeID: github.String("IC_" + login),
+			User:   &github.User{Login: github.String(login)},
+			Body:   github.String(body),
+		}
+	}
+	comments := []*github.IssueComment{
+		comment(1, "user1", "approve"),
+		comment(2, "user1", "is the migration done?"),
+		comment(3, "user3", "+1, ship it"),
+		comment(4, "user3", "approve"),
+		comment(5, "user2", "deny\nnot before the freeze ends"),
+		comment(6, "user2", "approve [prdo]"),
+		comment(7, "release-bot-user", "@user2, `prdo` is not one of the deployment names. Did you mean `prod`?"),
+	}
+	approvers := []string{"user1", "user2"}
+
+	testCases := []struct {
+		name            string
+		mode            string
+		deploymentNames []string
+		minimized       map[int64]bool
+		gateLogin       string
+		expected        []int64
+	}{
+		{
+			name: "none",
+			mode: minimizeNone,
+		},
+		{
+			name:     "invalid",
+			mode:     minimizeInvalid,
+			expected: []int64{2, 3, 4, 6, 7},
+		},
+		{
+			name:            "invalid_with_deployment_names",
+			mode:            minimizeInvalid,
+			deploymentNames: []string{"prod"},
+			gateLogin:       "release-bot-user",
+			expected:        []int64{2, 3, 4, 6},
+		},
+		{
+			name:      "gate_comments",
+			mode:      minimizeInvalid,
+			gateLogin: "Release-Bot-User",
+			expected:  []int64{2, 3, 4, 6},
+		},
+		{
+			name:      "non_approvers",
+			mode:      minimizeNonApprovers,
+			gateLogin: "release-bot-user",
+			expected:  []int64{3, 4},
+		},
+		{
+			name:      "already_minimized",
+			mode:      minimizeInvalid,
+			minimized: map[int64]bool{3: true},
+			gateLogin: "release-bot-user",
+			expected:  []int64{2, 4, 6},
+		},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			var actual []int64
+			for _, c := range commentsToMinimize(comments, approvers, testCase.deploymentNames, testCase.mode, testCase.gateLogin, testCase.minimized) {
+				actual = append(actual, c.GetID())
+			}
+			if !reflect.DeepEqual(actual, testCase.expected) {
+				t.Fatalf("expected %v, got %v", testCase.expected, actual)
+			}
+		})
+	}
+}
+
+func TestMinimizeOffTopic(t *testing.T) {
+	ctx := context.Background()
+	fake := newFakeGitHub()
+	apprv, err := newApprovalEnvironment(fake.client(), "owner/repo", "owner", 1, []string{"user1"}, 1, nil)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	apprv.minimizeComments = minimizeNonApprovers
+	if err := apprv.createApprovalIssue(ctx); err != nil {
+		t.Fatalf("error creating approval issue: %v", err)
+	}
+	fake.comment(apprv.approvalIssueNumber, "user2", "any news?")
+
+	for poll := 0; poll < 2; poll++ {
+		comments, err := apprv.approvalComments(ctx)
+		if err != nil {
+			t.Fatalf("error getting comments: %v", err)
+		}
+		if err := apprv.minimizeOffTopic(ctx, comments, []string{"user1"}); err != nil {
+			t.Fatalf("error minimizing comments: %v", err)
+		}
+	}
+	expected := []string{fake.comments[apprv.approvalIssueNumber][0].GetNodeID()}
+	if !reflect.DeepEqual(fake.minimized, expected) {
+		t.Fatalf("expected %v to be minimized once, got %v", expected, fake.minimized)
+	}
+}
@@ -0,0 +1,90 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/google/go-github/v43/github"
+	"github.com/trstringer/manual-approval/pkg/approval"
+)
+
+// decisionNote is the text an approver wrote below their decision.
+type decisionNote struct {
+	Approver string `json:"approver"`
+	Decision string `json:"decision"`
+	Note     string `json:"note"`
+}
+
+// decisionNotes returns the notes of the latest decision of each approver,
+// in the order they decided. A revocation drops the note of the approval it
+// withdraws.
+func decisionNotes(comments []*github.IssueComment, approvers []string) []decisionNote {
+	notes := map[string]decisionNote{}
+	var order []string
+	for _, comment := range comments {
+		login := comment.User.GetLogin()
+		if approversIndex(approvers, login) < 0 {
+			continue
+		}
+		key := strings.ToLower(login)
+		note := decisionNote{Approver: login}
+		if approved, _ := isApproved(comment.GetBody()); approved {
+			note.Decision = "approved"
+		} else if denied, _ := isDenied(comment.GetBody()); denied {
+			note.Decision = "denied"
+		} else if revoked, _ := isRevoked(comment.GetBody()); revoked {
+			delete(notes, key)
+			continue
+		} else {
+			continue
+		}
+		_, note.Note = approval.SplitNote(comment.GetBody())
+		if _, ok := notes[key]; !ok {
+			order = append(order, key)
+		}
+		notes[key] = note
+	}
+
+	var result []decisionNote
+	for _, key := range order {
+		if note, ok := notes[key]; ok && note.Note != "" {
+			result = append(result, note)
+		}
+	}
+	return result
+}
+
+// formatDecisionNotes renders the notes for the closing comment and the job
+// summary.
+func formatDecisionNotes(notes []decisionNote) string {
+	var b strings.Builder
+	b.WriteString("Notes from approvers:\n")
+	for _, note := range notes {
+		quoted := strings.ReplaceAll(note.Note, "\n", "\n  > ")
+		fmt.Fprintf(&b, "\n- @%s (%s):\n  > %s", note.Approver, note.Decision, quoted)
+	}
+	return b.String()
+}
+
+// reportDecisionNotes sets the decision-notes output and adds the notes to
+// the job summary.
+func reportDecisionNotes(notes []decisionNote) {
+	content, _ := json.Marshal(notes)
+	setOutput("decision-notes", string(content))
+
+	summaryPath := os.Getenv(envVarStepSummary)
+	if summaryPath == "" {
+		return
+	}
+	file, err := os.OpenFile(summaryPath, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0o644)
+	if err != nil {
+		fmt.Printf("error writing job summary: %v\n", err)
+		return
+	}
+	defer file.Close()
+	if _, err := fmt.Fprintf(file, "%s\n", secretRedactor.redact(formatDecisionNotes(notes))); err != nil {
+		fmt.Printf("error writing job summary: %v\n", err)
+	}
+}
@@ -0,0 +1,82 @@
+package main
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/google/go-github/v43/github"
+)
+
+func TestDecisionNotes(t *testing.T) {
+	comment := func(login, body string) *github.IssueComment {
+		return &github.IssueComment{User: &github.User{Login: github.String(login)}, Body: github.String(body)}
+	}
+	approvers := []string{"alice", "bob", "carol"}
+
+	testCases := []struct {
+		name     string
+		comments []*github.IssueComment
+		expected []decisionNote
+	}{
+		{
+			name: "notes_in_decision_order",
+			comments: []*github.IssueComment{
+				comment("bob", "deny\nThe migration isn't reviewed."),
+				comment("alice", "approve\r\n\r\nWatch the error rates."),
+				comment("carol", "approve"),
+			},
+			expected: []decisionNote{
+				{Approver: "bob", Decision: "denied", Note: "The migration isn't reviewed."},
+				{Approver: "alice", Decision: "approved", Note: "Watch the error rates."},
+			},
+		},
+		{
+			name: "latest_decision",
+			comments: []*github.IssueComment{
+				comment("alice", "deny\nnot yet"),
+				comment("alice", "approve\nfixed now"),
+			},
+			expected: []decisionNote{
+				{Approver: "alice", Decision: "approved", Note: "fixed now"},
+			},
+		},
+		{
+			name: "revoked",
+			comments: []*github.IssueComment{
+				comment("alice", "approve\nship it"),
+				comment("alice", "revoke"),
+			},
+		},
+		{
+			name: "not_an_approver",
+			comments: []*github.IssueComment{
+				comment("mallory", "approve\nI'm not on the list"),
+				comment("alice", "what does this change?\nplease explain"),
+			},
+		},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			actual := decisionNotes(testCase.comments, approvers)
+			if len(actual) != len(testCase.expected) {
+				t.Fatalf("expected %v but got %v", testCase.expected, actual)
+			}
+			for i := range actual {
+				if actual[i] != testCase.expected[i] {
+					t.Fatalf("expected %v but got %v", testCase.expected[i], actual[i])
+				}
+			}
+		})
+	}
+}
+
+func TestFormatDecisionNotes(t *testing.T) {
+	actual := formatDecisionNotes([]decisionNote{
+		{Approver: "alice", Decision: "approved", Note: "Watch the error rates.\nRoll back if needed."},
+	})
+	expected := "Notes from approvers:\n\n- @alice (approved):\n  > Watch the error rates.\n  > Roll back if needed."
+	if !strings.Contains(actual, expected) {
+		t.Fatalf("expected %q but got %q", expected, actual)
+	}
+}
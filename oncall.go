@@ -0,0 +1,150 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/google/go-github/v43/github"
+)
+
+const (
+	onCallProviderPagerDuty string = "pagerduty"
+	onCallProviderOpsgenie  string = "opsgenie"
+
+	pagerDutyOnCallsURL  string = "https://api.pagerduty.com/oncalls"
+	opsgenieSchedulesURL string = "https://api.opsgenie.com/v2/schedules"
+
+	onCallTimeout = 30 * time.Second
+)
+
+// onCallEmails returns the email addresses of the users currently on call for
+// the schedule.
+func onCallEmails(ctx context.Context, provider, apiKey, scheduleID string) ([]string, error) {
+	var (
+		requestURL string
+		authHeader string
+		accept     string
+		parse      func([]byte) ([]string, error)
+	)
+	switch provider {
+	case onCallProviderPagerDuty:
+		query := url.Values{}
+		query.Set("schedule_ids[]", scheduleID)
+		query.Set("include[]", "users")
+		query.Set("earliest", "true")
+		requestURL = pagerDutyOnCallsURL + "?" + query.Encode()
+		authHeader = "Token token=" + apiKey
+		accept = "application/vnd.pagerduty+json;version=2"
+		parse = parsePagerDutyOnCalls
+	case onCallProviderOpsgenie:
+		requestURL = fmt.Sprintf("%s/%s/on-calls?flat=true", opsgenieSchedulesURL, url.PathEscape(scheduleID))
+		authHeader = "GenieKey " + apiKey
+		accept = "application/json"
+		parse = parseOpsgenieOnCalls
+	default:
+		return nil, fmt.Errorf("unsupported on-call provider %q, expected %s or %s", provider, onCallProviderPagerDuty, onCallProviderOpsgenie)
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, onCallTimeout)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, requestURL, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Accept", accept)
+	req.Header.Set("Authorization", authHeader)
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("unexpected status %s", resp.Status)
+	}
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+	return parse(data)
+}
+
+func parsePagerDutyOnCalls(data []byte) ([]string, error) {
+	var response struct {
+		OnCalls []struct {
+			User struct {
+				Email string `json:"email"`
+			} `json:"user"`
+		} `json:"oncalls"`
+	}
+	if err := json.Unmarshal(data, &response); err != nil {
+		return nil, err
+	}
+	var emails []string
+	for _, onCall := range response.OnCalls {
+		if onCall.User.Email != "" {
+			emails = appendUniqueApprover(emails, onCall.User.Email)
+		}
+	}
+	return emails, nil
+}
+
+func parseOpsgenieOnCalls(data []byte) ([]string, error) {
+	var response struct {
+		Data struct {
+			OnCallRecipients []string `json:"onCallRecipients"`
+		} `json:"data"`
+	}
+	if err := json.Unmarshal(data, &response); err != nil {
+		return nil, err
+	}
+	var emails []string
+	for _, recipient := range response.Data.OnCallRecipients {
+		emails = appendUniqueApprover(emails, recipient)
+	}
+	return emails, nil
+}
+
+// parseOnCallUserMap parses comma-delimited email=login pairs.
+func parseOnCallUserMap(raw string) (map[string]string, error) {
+	userMap := map[string]string{}
+	for _, entry := range splitInputList(raw) {
+		emailAndLogin := strings.SplitN(entry, "=", 2)
+		if len(emailAndLogin) != 2 || strings.TrimSpace(emailAndLogin[0]) == "" || strings.TrimSpace(emailAndLogin[1]) == "" {
+			return nil, fmt.Errorf("expected email=login but got %q", entry)
+		}
+		email := strings.ToLower(strings.TrimSpace(emailAndLogin[0]))
+		userMap[email] = strings.TrimPrefix(strings.TrimSpace(emailAndLogin[1]), "@")
+	}
+	return userMap, nil
+}
+
+// onCallLogins maps on-call email addresses to GitHub logins. Addresses that
+// aren't in userMap are looked up by their public GitHub email.
+func onCallLogins(ctx context.Context, client *github.Client, emails []string, userMap map[string]string) ([]string, error) {
+	var logins []string
+	for _, email := range emails {
+		if login, ok := userMap[strings.ToLower(email)]; ok {
+			logins = appendUniqueApprover(logins, login)
+			continue
+		}
+
+		result, _, err := client.Search.Users(ctx, fmt.Sprintf("%s in:email", email), nil)
+		if err != nil {
+			return nil, fmt.Errorf("error searching for the GitHub user of %s: %w", email, err)
+		}
+		if len(result.Users) != 1 {
+			return nil, fmt.Errorf("no unique GitHub user has the public email %s, add it to oncall-user-map", email)
+		}
+		logins = appendUniqueApprover(logins, result.Users[0].GetLogin())
+	}
+	return logins, nil
+}
@@ -0,0 +1,79 @@
+package main
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParsePagerDutyOnCalls(t *testing.T) {
+	data := `{"oncalls": [
+		{"escalation_level": 1, "user": {"summary": "User One", "email": "one@example.com"}},
+		{"escalation_level": 2, "user": {"summary": "User Two", "email": "two@example.com"}},
+		{"escalation_level": 1, "user": {"summary": "User One", "email": "one@example.com"}}
+	]}`
+
+	expected := []string{"one@example.com", "two@example.com"}
+	actual, err := parsePagerDutyOnCalls([]byte(data))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !reflect.DeepEqual(actual, expected) {
+		t.Fatalf("expected %v but got %v", expected, actual)
+	}
+}
+
+func TestParseOpsgenieOnCalls(t *testing.T) {
+	data := `{"data": {"_parent": {"id": "sched"}, "onCallRecipients": ["one@example.com"]}}`
+
+	expected := []string{"one@example.com"}
+	actual, err := parseOpsgenieOnCalls([]byte(data))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !reflect.DeepEqual(actual, expected) {
+		t.Fatalf("expected %v but got %v", expected, actual)
+	}
+}
+
+func TestParseOnCallUserMap(t *testing.T) {
+	testCases := []struct {
+		name      string
+		raw       string
+		expected  map[string]string
+		expectErr bool
+	}{
+		{
+			name:     "pairs",
+			raw:      "One@Example.com=login1, two@example.com=@login2",
+			expected: map[string]string{"one@example.com": "login1", "two@example.com": "login2"},
+		},
+		{
+			name:     "empty",
+			raw:      "",
+			expected: map[string]string{},
+		},
+		{
+			name:      "missing login",
+			raw:       "one@example.com",
+			expectErr: true,
+		},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			actual, err := parseOnCallUserMap(testCase.raw)
+			if testCase.expectErr {
+				if err == nil {
+					t.Fatalf("expected an error but got %v", actual)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if !reflect.DeepEqual(actual, testCase.expected) {
+				t.Fatalf("expected %v but got %v", testCase.expected, actual)
+			}
+		})
+	}
+}
@@ -0,0 +1,79 @@
+package main
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/google/go-github/v43/github"
+	"gopkg.in/yaml.v3"
+)
+
+// orgConfigRepo is the repository that holds organization-wide settings.
+const orgConfigRepo string = ".github"
+
+// orgPolicy is the approval policy for a repository and environment.
+type orgPolicy struct {
+	Approvers         []string            `yaml:"approvers"`
+	MinimumApprovals  int                 `yaml:"minimum-approvals"`
+	DeploymentAliases map[string][]string `yaml:"deployment-aliases"`
+}
+
+// orgConfig maps repository names to environment names to policies. Either
+// key can be "*" to match any repository or environment, for example:
+//
+//	"*":
+//	  production:
+//	    approvers: [my-org/sre]
+//	my-repo:
+//	  "*":
+//	    approvers: [user1, user2]
+//	    minimum-approvals: 1
+//	    deployment-aliases:
+//	      eu: [eu-west-1, eu-central-1]
+type orgConfig map[string]map[string]orgPolicy
+
+func parseOrgConfig(content []byte) (orgConfig, error) {
+	var config orgConfig
+	if err := yaml.Unmarshal(content, &config); err != nil {
+		return nil, err
+	}
+	return config, nil
+}
+
+// policy returns the most specific policy for the repository and
+// environment, preferring an exact repository match over a wildcard and then
+// an exact environment match over a wildcard.
+func (c orgConfig) policy(repo, environment string) (orgPolicy, bool) {
+	for _, repoKey := range []string{repo, "*"} {
+		environments, ok := c[repoKey]
+		if !ok {
+			continue
+		}
+		for _, environmentKey := range []string{environment, "*"} {
+			if environmentKey == "" {
+				continue
+			}
+			if policy, ok := environments[environmentKey]; ok {
+				return policy, true
+			}
+		}
+	}
+	return orgPolicy{}, false
+}
+
+// readOrgConfig fetches the approval configuration from the organization's
+// .github repository.
+func readOrgConfig(ctx context.Context, client *github.Client, org, path string) (orgConfig, error) {
+	file, _, _, err := client.Repositories.GetContents(ctx, org, orgConfigRepo, path, nil)
+	if err != nil {
+		return nil, err
+	}
+	if file == nil {
+		return nil, fmt.Errorf("%s is not a file", path)
+	}
+	content, err := file.GetContent()
+	if err != nil {
+		return nil, err
+	}
+	return parseOrgConfig([]byte(content))
+}
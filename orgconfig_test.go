@@ -0,0 +1,76 @@
+package main
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestOrgConfigPolicy(t *testing.T) {
+	config, err := parseOrgConfig([]byte(`
+"*":
+  production:
+    approvers: [my-org/sre]
+    minimum-approvals: 2
+  "*":
+    approvers: [login1]
+my-repo:
+  "*":
+    approvers: [login2, login3]
+    minimum-approvals: 1
+`))
+	if err != nil {
+		t.Fatalf("error parsing org config: %v", err)
+	}
+
+	testCases := []struct {
+		name        string
+		repo        string
+		environment string
+		expected    orgPolicy
+		found       bool
+	}{
+		{
+			name:        "repo_wildcard_environment",
+			repo:        "my-repo",
+			environment: "production",
+			expected:    orgPolicy{Approvers: []string{"login2", "login3"}, MinimumApprovals: 1},
+			found:       true,
+		},
+		{
+			name:        "wildcard_repo_exact_environment",
+			repo:        "other-repo",
+			environment: "production",
+			expected:    orgPolicy{Approvers: []string{"my-org/sre"}, MinimumApprovals: 2},
+			found:       true,
+		},
+		{
+			name:        "wildcard_repo_wildcard_environment",
+			repo:        "other-repo",
+			environment: "staging",
+			expected:    orgPolicy{Approvers: []string{"login1"}},
+			found:       true,
+		},
+		{
+			name:     "no_environment",
+			repo:     "other-repo",
+			expected: orgPolicy{Approvers: []string{"login1"}},
+			found:    true,
+		},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			actual, found := config.policy(testCase.repo, testCase.environment)
+			if found != testCase.found {
+				t.Fatalf("expected found %v but got %v", testCase.found, found)
+			}
+			if !reflect.DeepEqual(actual, testCase.expected) {
+				t.Fatalf("expected %+v but got %+v", testCase.expected, actual)
+			}
+		})
+	}
+
+	if _, found := (orgConfig{}).policy("my-repo", "production"); found {
+		t.Fatalf("expected no policy in empty config")
+	}
+}
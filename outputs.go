@@ -0,0 +1,80 @@
+package main
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"os"
+	"strings"
+)
+
+// setOutput sets a step output. On runners that set GITHUB_OUTPUT the output
+// is appended to that file, so values can span lines and aren't read out of
+// the log. Older runners get the set-output workflow command instead. Secrets
+// are redacted from the value either way.
+func setOutput(name, value string) {
+	value = secretRedactor.redact(value)
+	outputPath := os.Getenv(envVarOutput)
+	if outputPath == "" {
+		fmt.Printf("::set-output name=%s::%s\n", name, escapeCommandValue(value))
+		return
+	}
+	entry, err := formatOutput(name, value)
+	if err == nil {
+		err = appendOutput(outputPath, entry)
+	}
+	if err != nil {
+		fmt.Printf("error writing output %s: %v\n", name, err)
+		fmt.Printf("::set-output name=%s::%s\n", name, escapeCommandValue(value))
+	}
+}
+
+// formatOutput renders an entry of the GITHUB_OUTPUT file. Values on one line
+// are written as name=value, and others between heredoc delimiters that
+// don't occur in the value.
+func formatOutput(name, value string) (string, error) {
+	if strings.ContainsAny(name, "=\r\n") {
+		return "", fmt.Errorf("output name %q is not valid", name)
+	}
+	if !strings.ContainsAny(value, "\r\n") {
+		return fmt.Sprintf("%s=%s\n", name, value), nil
+	}
+	delimiter, err := outputDelimiter()
+	if err != nil {
+		return "", err
+	}
+	for strings.Contains(value, delimiter) {
+		if delimiter, err = outputDelimiter(); err != nil {
+			return "", err
+		}
+	}
+	return fmt.Sprintf("%s<<%s\n%s\n%s\n", name, delimiter, value, delimiter), nil
+}
+
+func outputDelimiter() (string, error) {
+	random := make([]byte, 16)
+	if _, err := rand.Read(random); err != nil {
+		return "", fmt.Errorf("error generating a delimiter: %w", err)
+	}
+	return "ghadelimiter_" + hex.EncodeToString(random), nil
+}
+
+func appendOutput(path, entry string) error {
+	file, err := os.OpenFile(path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0o644)
+	if err != nil {
+		return err
+	}
+	if _, err := file.WriteString(entry); err != nil {
+		file.Close()
+		return err
+	}
+	return file.Close()
+}
+
+// escapeCommandValue escapes a value for a workflow command, which ends at
+// the first newline.
+func escapeCommandValue(value string) string {
+	value = strings.ReplaceAll(value, "%", "%25")
+	value = strings.ReplaceAll(value, "\r", "%0D")
+	return strings.ReplaceAll(value, "\n", "%0A")
+}
@@ -0,0 +1,87 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"regexp"
+	"testing"
+)
+
+func TestFormatOutput(t *testing.T) {
+	testCases := []struct {
+		name      string
+		output    string
+		value     string
+		expected  *regexp.Regexp
+		expectErr bool
+	}{
+		{
+			name:     "single_line",
+			output:   "issue-url",
+			value:    "https://github.com/owner/repo/issues/1",
+			expected: regexp.MustCompile(`^issue-url=https://github\.com/owner/repo/issues/1\n$`),
+		},
+		{
+			name:     "empty_value",
+			output:   "denier",
+			value:    "",
+			expected: regexp.MustCompile(`^denier=\n$`),
+		},
+		{
+			name:     "multiline_value",
+			output:   "decision-notes",
+			value:    "first\nsecond",
+			expected: regexp.MustCompile(`^decision-notes<<(ghadelimiter_[0-9a-f]{32})\nfirst\nsecond\n(ghadelimiter_[0-9a-f]{32})\n$`),
+		},
+		{
+			name:      "invalid_name",
+			output:    "name=value",
+			value:     "x",
+			expectErr: true,
+		},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			actual, err := formatOutput(testCase.output, testCase.value)
+			if testCase.expectErr {
+				if err == nil {
+					t.Fatalf("expected an error, got %q", actual)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			match := testCase.expected.FindStringSubmatch(actual)
+			if match == nil {
+				t.Fatalf("expected to match %s, got %q", testCase.expected, actual)
+			}
+			if len(match) == 3 && match[1] != match[2] {
+				t.Fatalf("expected matching delimiters, got %q", actual)
+			}
+		})
+	}
+}
+
+func TestSetOutputAppendsToFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "output")
+	t.Setenv(envVarOutput, path)
+
+	setOutput("issue-number", "1")
+	setOutput("timed-out", "true")
+
+	content, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("error reading output file: %v", err)
+	}
+	if expected := "issue-number=1\ntimed-out=true\n"; string(content) != expected {
+		t.Fatalf("expected %q, got %q", expected, content)
+	}
+}
+
+func TestEscapeCommandValue(t *testing.T) {
+	if actual := escapeCommandValue("100%\r\ndone"); actual != "100%25%0D%0Adone" {
+		t.Fatalf("unexpected escaped value %q", actual)
+	}
+}
@@ -0,0 +1,56 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"github.com/google/go-github/v43/github"
+)
+
+// approvePartially resolves a gate that timed out with the deployments that
+// were approved by then, when partial-approval is set, and returns them. It
+// returns none, leaving the gate to time out, if none of them was approved.
+// The approved deployments are set as the DEPLOYMENT_NAMES output and the
+// others as the unapproved-deployment-names output.
+func (a *approvalEnvironment) approvePartially(ctx context.Context, reason string, comments []*github.IssueComment, approvers []string, minimumApprovals int) ([]string, error) {
+	if !a.partialApproval || len(a.mutlipleDeploymentNames) == 0 {
+		return nil, nil
+	}
+	approved, err := gateWords.ApprovedDeployments(comments, approvers, minimumApprovals, a.mutlipleDeploymentNames, a.requirements...)
+	if err != nil || len(approved) == 0 {
+		return nil, err
+	}
+	unapproved := unapprovedDeployments(a.mutlipleDeploymentNames, approved)
+
+	closeComment := fmt.Sprintf("%s Continuing workflow with the approved deployments %s and closing this issue.", reason, formatCodeList(approved, "and"))
+	if len(unapproved) > 0 {
+		closeComment += fmt.Sprintf(" Not approved: %s.", formatCodeList(unapproved, "and"))
+	}
+	if err := a.resolveApproval(ctx, approvalStatusApproved, closeComment); err != nil {
+		return nil, err
+	}
+	setOutput("timed-out", "true")
+	setOutput("partial-approval", "true")
+	setDeploymentNamesOutput(approved)
+	jsonUnapproved, _ := json.Marshal(unapproved)
+	setOutput("unapproved-deployment-names", string(jsonUnapproved))
+	fmt.Printf("Approved deployments: %v, not approved: %v\n", approved, unapproved)
+	return approved, nil
+}
+
+// unapprovedDeployments returns the deployment names that are not approved,
+// in the order they were given.
+func unapprovedDeployments(deploymentNames, approved []string) []string {
+	isApproved := make(map[string]bool, len(approved))
+	for _, name := range approved {
+		isApproved[name] = true
+	}
+	unapproved := []string{}
+	for _, name := range deploymentNames {
+		if !isApproved[name] {
+			unapproved = append(unapproved, name)
+		}
+	}
+	return unapproved
+}
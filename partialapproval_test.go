@@ -0,0 +1,71 @@
+package main
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/google/go-github/v43/github"
+)
+
+func TestApprovePartially(t *testing.T) {
+	ctx := context.Background()
+	t.Setenv(envVarOutput, filepath.Join(t.TempDir(), "output"))
+	comment := func(login, body string) *github.IssueComment {
+		return &github.IssueComment{User: &github.User{Login: github.String(login)}, Body: github.String(body)}
+	}
+	fake := newFakeGitHub()
+	approvers := []string{"user1", "user2"}
+	apprv, err := newApprovalEnvironment(fake.client(), "owner/repo", "owner", 1, approvers, 1, []string{"us", "eu", "ap"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if err := apprv.createApprovalIssue(ctx); err != nil {
+		t.Fatalf("error creating approval issue: %v", err)
+	}
+	comments := []*github.IssueComment{comment("user1", "approve [us]"), comment("user2", "approve [ap]")}
+
+	approved, err := apprv.approvePartially(ctx, "Approval timed out.", comments, approvers, 1)
+	if err != nil || approved != nil {
+		t.Fatalf("expected no partial approval unless it is enabled, got %v %v", approved, err)
+	}
+
+	apprv.partialApproval = true
+	approved, err = apprv.approvePartially(ctx, "Approval timed out.", comments, approvers, 1)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if expected := []string{"us", "ap"}; !reflect.DeepEqual(approved, expected) {
+		t.Fatalf("expected %v to be approved, got %v", expected, approved)
+	}
+	issueComments := fake.comments[apprv.approvalIssueNumber]
+	closing := issueComments[len(issueComments)-1].GetBody()
+	if expected := "Approval timed out. Continuing workflow with the approved deployments `us` and `ap` and closing this issue. Not approved: `eu`."; !strings.HasPrefix(closing, expected) {
+		t.Fatalf("expected the closing comment to start with %q, got %q", expected, closing)
+	}
+	content, err := os.ReadFile(os.Getenv(envVarOutput))
+	if err != nil {
+		t.Fatalf("error reading output file: %v", err)
+	}
+	for _, output := range []string{"partial-approval=true\n", "DEPLOYMENT_NAMES=[\"us\",\"ap\"]\n", "unapproved-deployment-names=[\"eu\"]\n"} {
+		if !strings.Contains(string(content), output) {
+			t.Fatalf("expected output %q in %q", output, content)
+		}
+	}
+}
+
+func TestApprovePartiallyWithoutApprovals(t *testing.T) {
+	fake := newFakeGitHub()
+	apprv, err := newApprovalEnvironment(fake.client(), "owner/repo", "owner", 1, []string{"user1"}, 1, []string{"us", "eu"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	apprv.partialApproval = true
+	approved, err := apprv.approvePartially(context.Background(), "Approval timed out.", nil, []string{"user1"}, 1)
+	if err != nil || len(approved) != 0 {
+		t.Fatalf("expected the gate to time out without approvals, got %v %v", approved, err)
+	}
+}
@@ -0,0 +1,108 @@
+package main
+
+import (
+	"fmt"
+	"path"
+	"strings"
+)
+
+// pathApproverRule assigns the owners of the files matching pattern.
+type pathApproverRule struct {
+	pattern   string
+	approvers []string
+}
+
+// parsePathApprovers parses a CODEOWNERS style mapping with one path glob
+// followed by its approvers per line, e.g. "services/api/** @user1 my-org/api".
+// Blank lines and lines starting with # are ignored.
+func parsePathApprovers(content string) ([]pathApproverRule, error) {
+	var rules []pathApproverRule
+	for _, line := range strings.Split(content, "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		fields := strings.Fields(line)
+		if len(fields) < 2 {
+			return nil, fmt.Errorf("expected a path glob followed by approvers but got %q", line)
+		}
+		rules = append(rules, pathApproverRule{
+			pattern:   fields[0],
+			approvers: parseApprovers(strings.Join(fields[1:], ",")),
+		})
+	}
+	return rules, nil
+}
+
+// touchedPathRules returns the rules matching at least one of the paths, in
+// the order they were configured.
+func touchedPathRules(rules []pathApproverRule, paths []string) ([]pathApproverRule, error) {
+	var touched []pathApproverRule
+	for _, rule := range rules {
+		for _, p := range paths {
+			matched, err := matchPathGlob(rule.pattern, p)
+			if err != nil {
+				return nil, err
+			}
+			if matched {
+				touched = append(touched, rule)
+				break
+			}
+		}
+	}
+	return touched, nil
+}
+
+// matchPathGlob matches a slash separated path against a glob pattern. In
+// addition to the path.Match syntax, a "**" segment matches any number of
+// directories and a pattern ending in "/" matches everything below it.
+func matchPathGlob(pattern, name string) (bool, error) {
+	pattern = strings.TrimPrefix(pattern, "/")
+	if strings.HasSuffix(pattern, "/") {
+		pattern += "**"
+	}
+	return matchPathSegments(strings.Split(pattern, "/"), strings.Split(name, "/"))
+}
+
+func matchPathSegments(patterns, names []string) (bool, error) {
+	for len(patterns) > 0 {
+		if patterns[0] == "**" {
+			for i := 0; i <= len(names); i++ {
+				matched, err := matchPathSegments(patterns[1:], names[i:])
+				if err != nil || matched {
+					return matched, err
+				}
+			}
+			return false, nil
+		}
+		if len(names) == 0 {
+			return false, nil
+		}
+		matched, err := path.Match(patterns[0], names[0])
+		if err != nil || !matched {
+			return false, err
+		}
+		patterns, names = patterns[1:], names[1:]
+	}
+	return len(names) == 0, nil
+}
+
+// pathOwnersRequirement requires an approval from at least one approver of
+// each group.
+func pathOwnersRequirement(groups [][]string) approvalRequirement {
+	return func(approvedBy []string) bool {
+		for _, group := range groups {
+			approved := false
+			for _, approver := range group {
+				if approversIndex(approvedBy, approver) >= 0 {
+					approved = true
+					break
+				}
+			}
+			if !approved {
+				return false
+			}
+		}
+		return true
+	}
+}
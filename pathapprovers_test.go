@@ -0,0 +1,111 @@
+package main
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/google/go-github/v43/github"
+)
+
+func TestParsePathApprovers(t *testing.T) {
+	content := `# Service owners
+services/api/** @login1 my-org/api-team
+
+services/web/   login2
+`
+
+	expected := []pathApproverRule{
+		{pattern: "services/api/**", approvers: []string{"login1", "my-org/api-team"}},
+		{pattern: "services/web/", approvers: []string{"login2"}},
+	}
+	actual, err := parsePathApprovers(content)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !reflect.DeepEqual(actual, expected) {
+		t.Fatalf("expected %v but got %v", expected, actual)
+	}
+
+	if _, err := parsePathApprovers("services/api/**"); err == nil {
+		t.Fatal("expected an error for a rule without approvers")
+	}
+}
+
+func TestMatchPathGlob(t *testing.T) {
+	testCases := []struct {
+		pattern  string
+		name     string
+		expected bool
+	}{
+		{pattern: "services/api/**", name: "services/api/main.go", expected: true},
+		{pattern: "services/api/**", name: "services/api/handlers/v1/get.go", expected: true},
+		{pattern: "services/api/**", name: "services/web/main.go", expected: false},
+		{pattern: "services/api/", name: "services/api/main.go", expected: true},
+		{pattern: "/docs/*.md", name: "docs/README.md", expected: true},
+		{pattern: "docs/*.md", name: "docs/guides/setup.md", expected: false},
+		{pattern: "**/*.tf", name: "infra/prod/main.tf", expected: true},
+		{pattern: "**/*.tf", name: "main.tf", expected: true},
+		{pattern: "go.mod", name: "go.mod", expected: true},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.pattern+" "+testCase.name, func(t *testing.T) {
+			actual, err := matchPathGlob(testCase.pattern, testCase.name)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if actual != testCase.expected {
+				t.Fatalf("expected %v but got %v", testCase.expected, actual)
+			}
+		})
+	}
+}
+
+func TestTouchedPathRules(t *testing.T) {
+	rules := []pathApproverRule{
+		{pattern: "services/api/**", approvers: []string{"login1"}},
+		{pattern: "services/web/**", approvers: []string{"login2"}},
+		{pattern: "infra/**", approvers: []string{"login3"}},
+	}
+	paths := []string{"services/api/main.go", "infra/main.tf", "services/api/go.mod"}
+
+	expected := []pathApproverRule{rules[0], rules[2]}
+	actual, err := touchedPathRules(rules, paths)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !reflect.DeepEqual(actual, expected) {
+		t.Fatalf("expected %v but got %v", expected, actual)
+	}
+}
+
+func TestPathOwnersRequirement(t *testing.T) {
+	login1 := "login1"
+	login2 := "login2"
+	login3 := "login3"
+	bodyApproved := "approved"
+
+	approvers := []string{login1, login2, login3}
+	requirement := pathOwnersRequirement([][]string{{login1, login2}, {login3}})
+
+	comments := []*github.IssueComment{
+		{User: &github.User{Login: &login1}, Body: &bodyApproved},
+		{User: &github.User{Login: &login2}, Body: &bodyApproved},
+	}
+	status, _, err := approvalFromComments(comments, approvers, 1, []string{}, requirement)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if status != approvalStatusPending {
+		t.Fatalf("expected %s before every area approved but got %s", approvalStatusPending, status)
+	}
+
+	comments = append(comments, &github.IssueComment{User: &github.User{Login: &login3}, Body: &bodyApproved})
+	status, _, err = approvalFromComments(comments, approvers, 1, []string{}, requirement)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if status != approvalStatusApproved {
+		t.Fatalf("expected %s once every area approved but got %s", approvalStatusApproved, status)
+	}
+}
@@ -0,0 +1,85 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/google/go-github/v43/github"
+)
+
+// stillPending returns the approvers without a counted approval, in the
+// order they are listed.
+func stillPending(approvers []string, counted []countedApproval) []string {
+	var pending []string
+	for _, approver := range approvers {
+		approved := false
+		for _, approval := range counted {
+			if strings.EqualFold(approval.approver, approver) {
+				approved = true
+				break
+			}
+		}
+		if !approved {
+			pending = append(pending, approver)
+		}
+	}
+	return pending
+}
+
+// sameLogins reports whether both lists hold the same logins, in any order.
+func sameLogins(a, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for _, login := range a {
+		if approversIndex(b, login) < 0 {
+			return false
+		}
+	}
+	return true
+}
+
+// mentionPending narrows the assignees of the approval issue to the
+// approvers who haven't approved yet and, once the first approval is
+// counted, mentions only them, so that approvers who already acted aren't
+// notified again. The mention is recorded in the gate state so that it is
+// made once.
+func (a *approvalEnvironment) mentionPending(ctx context.Context, comments []*github.IssueComment, approvers []string, required int) error {
+	if !a.mentionPendingApprovers || a.approvalIssue == nil || a.sharedIssueFollower || a.discussionCategory != "" {
+		return nil
+	}
+	counted := countedApprovals(comments, approvers, a.mutlipleDeploymentNames)
+	pending := stillPending(approvers, counted)
+	if len(counted) == 0 || len(pending) == 0 {
+		return nil
+	}
+
+	if !a.mentionOnly {
+		assignees, _ := splitAssignees(pending)
+		var current []string
+		for _, assignee := range a.approvalIssue.Assignees {
+			current = append(current, assignee.GetLogin())
+		}
+		if !sameLogins(current, assignees) {
+			issue, _, err := a.client.Issues.Edit(ctx, a.repoOwner, a.repo, a.approvalIssueNumber, &github.IssueRequest{
+				Assignees: &assignees,
+			})
+			if err != nil {
+				return fmt.Errorf("error updating assignees: %w", err)
+			}
+			a.approvalIssue.Assignees = issue.Assignees
+		}
+	}
+
+	if a.gateState.MentionedPending {
+		return nil
+	}
+	comment := fmt.Sprintf("%s, %d of %d required approvals are in. This workflow is still waiting for your response.", mentionApprovers(pending), len(counted), required)
+	if err := a.postStatusComment(ctx, comment); err != nil {
+		return err
+	}
+	state := a.gateState
+	state.MentionedPending = true
+	return a.writeGateState(ctx, state)
+}
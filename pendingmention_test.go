@@ -0,0 +1,80 @@
+package main
+
+import (
+	"context"
+	"strings"
+	"testing"
+)
+
+func TestMentionPending(t *testing.T) {
+	ctx := context.Background()
+	fake := newFakeGitHub()
+	approvers := []string{"user1", "user2", "user3"}
+	apprv, err := newApprovalEnvironment(fake.client(), "owner/repo", "owner", 1, approvers, 3, nil)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	apprv.mentionPendingApprovers = true
+	if err := apprv.createApprovalIssue(ctx); err != nil {
+		t.Fatalf("error creating approval issue: %v", err)
+	}
+
+	poll := func() {
+		comments, err := apprv.approvalComments(ctx)
+		if err != nil {
+			t.Fatalf("error getting comments: %v", err)
+		}
+		if err := apprv.mentionPending(ctx, comments, approvers, 3); err != nil {
+			t.Fatalf("error mentioning pending approvers: %v", err)
+		}
+	}
+	assignees := func() string {
+		var logins []string
+		for _, assignee := range fake.issues[apprv.approvalIssueNumber].Assignees {
+			logins = append(logins, assignee.GetLogin())
+		}
+		return strings.Join(logins, ",")
+	}
+	mentions := func() []string {
+		var bodies []string
+		for _, comment := range fake.comments[apprv.approvalIssueNumber] {
+			if comment.User.GetType() == "Bot" && !strings.HasPrefix(comment.GetBody(), gateStatePrefix) {
+				bodies = append(bodies, comment.GetBody())
+			}
+		}
+		return bodies
+	}
+
+	poll()
+	if actual := mentions(); len(actual) != 0 {
+		t.Fatalf("expected no mention before the first approval but got %q", actual)
+	}
+
+	fake.comment(apprv.approvalIssueNumber, "user2", "approve")
+	poll()
+	poll()
+	expected := "@user1 @user3, 1 of 3 required approvals are in. This workflow is still waiting for your response."
+	if actual := mentions(); len(actual) != 1 || actual[0] != expected {
+		t.Fatalf("expected a single mention %q but got %q", expected, actual)
+	}
+	if actual := assignees(); actual != "user1,user3" {
+		t.Fatalf("expected the pending approvers to be assigned but got %s", actual)
+	}
+
+	fake.comment(apprv.approvalIssueNumber, "user1", "approve")
+	poll()
+	if actual := mentions(); len(actual) != 1 {
+		t.Fatalf("expected the pending approvers to be mentioned once but got %q", actual)
+	}
+	if actual := assignees(); actual != "user3" {
+		t.Fatalf("expected the pending approvers to be assigned but got %s", actual)
+	}
+}
+
+func TestStillPending(t *testing.T) {
+	counted := []countedApproval{{approver: "User2", commentID: 1}}
+	actual := stillPending([]string{"user1", "user2", "user3"}, counted)
+	if strings.Join(actual, ",") != "user1,user3" {
+		t.Fatalf("expected user1,user3 but got %v", actual)
+	}
+}
@@ -0,0 +1,90 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/google/go-github/v43/github"
+)
+
+// collaboratorPermission mirrors the repository permission response. The
+// go-github type only exposes the legacy permission, which reports maintain
+// as write and triage as read, so role_name is decoded as well.
+type collaboratorPermission struct {
+	Permission string `json:"permission"`
+	RoleName   string `json:"role_name"`
+}
+
+// builtInRoles are the repository roles defined by GitHub. On organizations
+// with custom repository roles, role_name holds the name of the custom role
+// instead.
+var builtInRoles = map[string]bool{"admin": true, "maintain": true, "write": true, "triage": true, "read": true}
+
+// collaboratorRole returns the repository role of the user, such as write
+// or maintain. Custom roles fall back to the legacy permission of the role
+// they are based on.
+func (a *approvalEnvironment) collaboratorRole(ctx context.Context, login string) (string, error) {
+	req, err := a.client.NewRequest("GET", fmt.Sprintf("repos/%s/%s/collaborators/%s/permission", a.repoOwner, a.repo, login), nil)
+	if err != nil {
+		return "", err
+	}
+	var permission collaboratorPermission
+	if _, err := a.client.Do(ctx, req, &permission); err != nil {
+		return "", err
+	}
+	if builtInRoles[permission.RoleName] {
+		return permission.RoleName, nil
+	}
+	return permission.Permission, nil
+}
+
+// hasMaintainAccess reports whether the user can maintain or administer the
+// repository.
+func (a *approvalEnvironment) hasMaintainAccess(ctx context.Context, login string) (bool, error) {
+	role, err := a.collaboratorRole(ctx, login)
+	if err != nil {
+		return false, err
+	}
+	return role == "admin" || role == "maintain", nil
+}
+
+// hasWriteAccess reports whether the user can push to the repository.
+func (a *approvalEnvironment) hasWriteAccess(ctx context.Context, login string) (bool, error) {
+	role, err := a.collaboratorRole(ctx, login)
+	if err != nil {
+		return false, err
+	}
+	return role == "admin" || role == "maintain" || role == "write", nil
+}
+
+// writeAccessApprovers adds every commenter with write access to approvers.
+// Permission lookups are cached on the environment so each commenter is only
+// checked once per run.
+func (a *approvalEnvironment) writeAccessApprovers(ctx context.Context, comments []*github.IssueComment, approvers []string) ([]string, error) {
+	eligible := make([]string, len(approvers))
+	copy(eligible, approvers)
+	if a.writeAccessLogins == nil {
+		a.writeAccessLogins = make(map[string]bool)
+	}
+	for _, comment := range comments {
+		login := comment.User.GetLogin()
+		if login == "" {
+			continue
+		}
+		key := strings.ToLower(login)
+		canWrite, ok := a.writeAccessLogins[key]
+		if !ok {
+			var err error
+			canWrite, err = a.hasWriteAccess(ctx, login)
+			if err != nil {
+				return nil, fmt.Errorf("error checking permission of %s: %w", login, err)
+			}
+			a.writeAccessLogins[key] = canWrite
+		}
+		if canWrite {
+			eligible = appendUniqueApprover(eligible, login)
+		}
+	}
+	return eligible, nil
+}
@@ -0,0 +1,53 @@
+package main
+
+import (
+	"context"
+	"testing"
+)
+
+func TestCollaboratorRole(t *testing.T) {
+	testCases := []struct {
+		name       string
+		permission collaboratorPermission
+		expected   string
+		maintain   bool
+		write      bool
+	}{
+		{name: "admin", permission: collaboratorPermission{Permission: "admin", RoleName: "admin"}, expected: "admin", maintain: true, write: true},
+		{name: "maintain", permission: collaboratorPermission{Permission: "write", RoleName: "maintain"}, expected: "maintain", maintain: true, write: true},
+		{name: "write", permission: collaboratorPermission{Permission: "write", RoleName: "write"}, expected: "write", write: true},
+		{name: "triage", permission: collaboratorPermission{Permission: "read", RoleName: "triage"}, expected: "triage"},
+		{name: "custom_role_based_on_write", permission: collaboratorPermission{Permission: "write", RoleName: "release-manager"}, expected: "write", write: true},
+		{name: "custom_role_based_on_admin", permission: collaboratorPermission{Permission: "admin", RoleName: "security-admin"}, expected: "admin", maintain: true, write: true},
+		{name: "custom_role_based_on_read", permission: collaboratorPermission{Permission: "read", RoleName: "auditor"}, expected: "read"},
+		{name: "without_role_name", permission: collaboratorPermission{Permission: "write"}, expected: "write", write: true},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			ctx := context.Background()
+			fake := newFakeGitHub()
+			fake.permissions["user1"] = testCase.permission
+			apprv, err := newApprovalEnvironment(fake.client(), "owner/repo", "owner", 1, nil, 0, nil)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			role, err := apprv.collaboratorRole(ctx, "user1")
+			if err != nil {
+				t.Fatalf("error getting role: %v", err)
+			}
+			if role != testCase.expected {
+				t.Fatalf("expected role %s but got %s", testCase.expected, role)
+			}
+			maintain, err := apprv.hasMaintainAccess(ctx, "user1")
+			if err != nil || maintain != testCase.maintain {
+				t.Fatalf("expected maintain access %t but got %t (%v)", testCase.maintain, maintain, err)
+			}
+			write, err := apprv.hasWriteAccess(ctx, "user1")
+			if err != nil || write != testCase.write {
+				t.Fatalf("expected write access %t but got %t (%v)", testCase.write, write, err)
+			}
+		})
+	}
+}
@@ -0,0 +1,77 @@
+package approval
+
+import (
+	"strings"
+
+	"github.com/google/go-github/v43/github"
+)
+
+// Aliases maps names that approvers can give in brackets, such as "eu", to
+// the deployment names they stand for, such as "eu-west-1" and
+// "eu-central-1".
+type Aliases map[string][]string
+
+// ExpandAliases rewrites the first line of a comment so that each alias in
+// its brackets is replaced by the deployment names it stands for, for
+// example "approve [eu]" as "approve [eu-west-1, eu-central-1]". Comments
+// without aliases, or whose names can't be read, are returned unchanged.
+func ExpandAliases(body string, deploymentNames []string, aliases Aliases) string {
+	if len(aliases) == 0 || len(deploymentNames) == 0 {
+		return body
+	}
+	names := append([]string{}, deploymentNames...)
+	for alias := range aliases {
+		names = appendName(names, alias)
+	}
+	parsed, err := ParseComment(body, names)
+	if err != nil || len(parsed.DeploymentNames) == 0 {
+		return body
+	}
+
+	var expanded []string
+	hasAlias := false
+	for _, name := range parsed.DeploymentNames {
+		targets, ok := aliases[name]
+		if !ok {
+			expanded = appendName(expanded, name)
+			continue
+		}
+		hasAlias = true
+		for _, target := range targets {
+			expanded = appendName(expanded, target)
+		}
+	}
+	if !hasAlias {
+		return body
+	}
+
+	first, _ := SplitNote(body)
+	line := first[:strings.IndexByte(first, '[')] + "[" + strings.Join(expanded, ", ") + "]"
+	if parsed.Trailing != "" {
+		line += " " + parsed.Trailing
+	}
+	if parsed.Note != "" {
+		line += "\n" + parsed.Note
+	}
+	return line
+}
+
+// ExpandAliasComments expands the aliases of each comment with
+// ExpandAliases. Comments that change are copied, so that comments kept by
+// the caller are left as they were written.
+func ExpandAliasComments(comments []*github.IssueComment, deploymentNames []string, aliases Aliases) []*github.IssueComment {
+	if len(aliases) == 0 {
+		return comments
+	}
+	result := make([]*github.IssueComment, 0, len(comments))
+	for _, comment := range comments {
+		body := ExpandAliases(comment.GetBody(), deploymentNames, aliases)
+		if body != comment.GetBody() {
+			expanded := *comment
+			expanded.Body = &body
+			comment = &expanded
+		}
+		result = append(result, comment)
+	}
+	return result
+}
@@ -0,0 +1,76 @@
+package approval
+
+import (
+	"testing"
+
+	"github.com/google/go-github/v43/github"
+)
+
+func TestExpandAliases(t *testing.T) {
+	deploymentNames := []string{"eu-west-1", "eu-central-1", "us-east-1"}
+	aliases := Aliases{
+		"eu": {"eu-west-1", "eu-central-1"},
+		"us": {"us-east-1"},
+	}
+	testCases := []struct {
+		name     string
+		body     string
+		aliases  Aliases
+		expected string
+	}{
+		{
+			name:     "alias",
+			body:     "approved[eu]",
+			aliases:  aliases,
+			expected: "approved[eu-west-1, eu-central-1]",
+		},
+		{
+			name:     "alias_and_name",
+			body:     "approve [us-east-1, eu, eu-west-1] after the freeze\nchecked the dashboards",
+			aliases:  aliases,
+			expected: "approve [us-east-1, eu-west-1, eu-central-1] after the freeze\nchecked the dashboards",
+		},
+		{
+			name:     "no_alias",
+			body:     "approve [us-east-1]",
+			aliases:  aliases,
+			expected: "approve [us-east-1]",
+		},
+		{
+			name:     "unreadable",
+			body:     "approve [eu",
+			aliases:  aliases,
+			expected: "approve [eu",
+		},
+		{
+			name:     "no_aliases",
+			body:     "approve [eu]",
+			expected: "approve [eu]",
+		},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			actual := ExpandAliases(testCase.body, deploymentNames, testCase.aliases)
+			if actual != testCase.expected {
+				t.Fatalf("expected %q, got %q", testCase.expected, actual)
+			}
+		})
+	}
+}
+
+func TestExpandAliasCommentsCopies(t *testing.T) {
+	comment := &github.IssueComment{ID: github.Int64(1), User: &github.User{Login: github.String("user1")}, Body: github.String("approve [eu]")}
+	expanded := ExpandAliasComments([]*github.IssueComment{comment}, []string{"eu-west-1"}, Aliases{"eu": {"eu-west-1"}})
+	if expanded[0].GetBody() != "approve [eu-west-1]" || expanded[0].GetID() != 1 {
+		t.Fatalf("unexpected expanded comment %v", expanded[0])
+	}
+	if comment.GetBody() != "approve [eu]" {
+		t.Fatalf("expected the original comment to be left as written, got %q", comment.GetBody())
+	}
+
+	status, names, err := DefaultWords().Evaluate(expanded, []string{"user1"}, 1, []string{"eu-west-1"})
+	if err != nil || status != StatusApproved || len(names) != 1 || names[0] != "eu-west-1" {
+		t.Fatalf("expected the expanded comment to approve eu-west-1, got %s %v %v", status, names, err)
+	}
+}
@@ -0,0 +1,84 @@
+package approval
+
+import (
+	"strings"
+
+	"github.com/google/go-github/v43/github"
+)
+
+// DeploymentApprovers maps deployment names to the only approvers who can
+// approve them, such as the database team for "prod-db". Deployments that
+// are not in the map can be approved by any approver.
+type DeploymentApprovers map[string][]string
+
+// CanApprove reports whether login can approve the deployment name.
+func (d DeploymentApprovers) CanApprove(login, name string) bool {
+	approvers, ok := d[name]
+	return !ok || ApproversIndex(approvers, login) >= 0
+}
+
+// RestrictApproval rewrites the first line of an approval by login so that
+// it only names the deployments login can approve, for example
+// "approve [prod-db, prod-web]" by a member of the web team as
+// "approve [prod-web]". It returns false if login can approve none of them,
+// in which case the approval must not be counted. Other comments, and
+// approvals whose names can't be read, are returned unchanged.
+func (w Words) RestrictApproval(body, login string, deploymentNames []string, approvers DeploymentApprovers) (string, bool) {
+	if len(approvers) == 0 || len(deploymentNames) == 0 {
+		return body, true
+	}
+	parsed, err := ParseComment(body, deploymentNames)
+	if err != nil || len(parsed.DeploymentNames) == 0 || w.quotesDecision(parsed.Quoted) {
+		return body, true
+	}
+	if isApproval, err := w.IsApproved(parsed.Decision); err != nil || !isApproval {
+		return body, true
+	}
+
+	var allowed []string
+	for _, name := range parsed.DeploymentNames {
+		if approvers.CanApprove(login, name) {
+			allowed = append(allowed, name)
+		}
+	}
+	if len(allowed) == 0 {
+		return body, false
+	}
+	if len(allowed) == len(parsed.DeploymentNames) {
+		return body, true
+	}
+
+	first, _ := SplitNote(body)
+	line := first[:strings.IndexByte(first, '[')] + "[" + strings.Join(allowed, ", ") + "]"
+	if parsed.Trailing != "" {
+		line += " " + parsed.Trailing
+	}
+	if parsed.Note != "" {
+		line += "\n" + parsed.Note
+	}
+	return line, true
+}
+
+// RestrictApprovalComments restricts the approval of each comment with
+// RestrictApproval, leaving out approvals of deployments their author can't
+// approve. Comments that change are copied, so that comments kept by the
+// caller are left as they were written.
+func (w Words) RestrictApprovalComments(comments []*github.IssueComment, deploymentNames []string, approvers DeploymentApprovers) []*github.IssueComment {
+	if len(approvers) == 0 {
+		return comments
+	}
+	result := make([]*github.IssueComment, 0, len(comments))
+	for _, comment := range comments {
+		body, ok := w.RestrictApproval(comment.GetBody(), comment.User.GetLogin(), deploymentNames, approvers)
+		if !ok {
+			continue
+		}
+		if body != comment.GetBody() {
+			restricted := *comment
+			restricted.Body = &body
+			comment = &restricted
+		}
+		result = append(result, comment)
+	}
+	return result
+}
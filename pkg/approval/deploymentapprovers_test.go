@@ -0,0 +1,101 @@
+package approval
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/google/go-github/v43/github"
+)
+
+func TestRestrictApproval(t *testing.T) {
+	deploymentNames := []string{"prod-db", "prod-web", "dev"}
+	approvers := DeploymentApprovers{
+		"prod-db":  {"dba1", "dba2"},
+		"prod-web": {"web1"},
+	}
+	testCases := []struct {
+		name       string
+		body       string
+		login      string
+		expected   string
+		expectedOK bool
+	}{
+		{
+			name:       "allowed",
+			body:       "approve [prod-db]",
+			login:      "DBA1",
+			expected:   "approve [prod-db]",
+			expectedOK: true,
+		},
+		{
+			name:       "partly_allowed",
+			body:       "approve [prod-db, prod-web, dev] after the freeze\nchecked the dashboards",
+			login:      "web1",
+			expected:   "approve [prod-web, dev] after the freeze\nchecked the dashboards",
+			expectedOK: true,
+		},
+		{
+			name:       "not_allowed",
+			body:       "approve [prod-db]",
+			login:      "web1",
+			expected:   "approve [prod-db]",
+			expectedOK: false,
+		},
+		{
+			name:       "all",
+			body:       "approve [all]",
+			login:      "dba2",
+			expected:   "approve [prod-db, dev]",
+			expectedOK: true,
+		},
+		{
+			name:       "denial",
+			body:       "deny [prod-db]",
+			login:      "web1",
+			expected:   "deny [prod-db]",
+			expectedOK: true,
+		},
+		{
+			name:       "unreadable",
+			body:       "approve [prod-db",
+			login:      "web1",
+			expected:   "approve [prod-db",
+			expectedOK: true,
+		},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			actual, ok := DefaultWords().RestrictApproval(testCase.body, testCase.login, deploymentNames, approvers)
+			if actual != testCase.expected || ok != testCase.expectedOK {
+				t.Fatalf("expected %q %v, got %q %v", testCase.expected, testCase.expectedOK, actual, ok)
+			}
+		})
+	}
+}
+
+func TestRestrictApprovalComments(t *testing.T) {
+	comment := func(id int64, login, body string) *github.IssueComment {
+		return &github.IssueComment{ID: github.Int64(id), User: &github.User{Login: github.String(login)}, Body: github.String(body)}
+	}
+	deploymentNames := []string{"prod-db", "prod-web"}
+	approvers := DeploymentApprovers{"prod-db": {"dba1"}, "prod-web": {"web1"}}
+	original := comment(2, "web1", "approve [prod-db, prod-web]")
+	comments := []*github.IssueComment{
+		comment(1, "web1", "approve [prod-db]"),
+		original,
+	}
+
+	restricted := DefaultWords().RestrictApprovalComments(comments, deploymentNames, approvers)
+	if len(restricted) != 1 || restricted[0].GetID() != 2 || restricted[0].GetBody() != "approve [prod-web]" {
+		t.Fatalf("expected only the approval of prod-web to be kept, got %v", restricted)
+	}
+	if original.GetBody() != "approve [prod-db, prod-web]" {
+		t.Fatalf("expected the original comment to be left as written, got %q", original.GetBody())
+	}
+
+	status, names, err := DefaultWords().Evaluate(restricted, []string{"dba1", "web1"}, 1, deploymentNames)
+	if err != nil || status != StatusApproved || !reflect.DeepEqual(names, []string{"prod-web"}) {
+		t.Fatalf("expected the restricted comment to approve prod-web, got %s %v %v", status, names, err)
+	}
+}
@@ -0,0 +1,133 @@
+package approval
+
+import (
+	"context"
+	"strings"
+	"time"
+
+	"github.com/google/go-github/v43/github"
+)
+
+// IssuesService is the part of the GitHub issues API the approval issue
+// uses. *github.IssuesService implements it.
+type IssuesService interface {
+	Create(ctx context.Context, owner string, repo string, issue *github.IssueRequest) (*github.Issue, *github.Response, error)
+	CreateComment(ctx context.Context, owner string, repo string, number int, comment *github.IssueComment) (*github.IssueComment, *github.Response, error)
+	Edit(ctx context.Context, owner string, repo string, number int, issue *github.IssueRequest) (*github.Issue, *github.Response, error)
+	ListComments(ctx context.Context, owner string, repo string, number int, opts *github.IssueListCommentsOptions) ([]*github.IssueComment, *github.Response, error)
+}
+
+// Issue is an approval issue, on which approvers comment their decisions.
+type Issue struct {
+	issues IssuesService
+	owner  string
+	repo   string
+	// Number is the number of the issue.
+	Number int
+	// NotBefore is when the approval was requested. Comments made before it
+	// are not decisions.
+	NotBefore time.Time
+	// AllowedBots are the bot accounts whose comments are decisions. The
+	// comments of other bots, such as GitHub Apps posting status messages,
+	// are not.
+	AllowedBots []string
+}
+
+// CreateIssue opens an approval issue in the repository. Decisions count
+// from when it was created.
+func CreateIssue(ctx context.Context, issues IssuesService, owner, repo string, request *github.IssueRequest) (*Issue, *github.Issue, error) {
+	created, _, err := issues.Create(ctx, owner, repo, request)
+	if err != nil {
+		return nil, nil, err
+	}
+	issue := OpenIssue(issues, owner, repo, created.GetNumber())
+	issue.NotBefore = created.GetCreatedAt()
+	return issue, created, nil
+}
+
+// OpenIssue returns the approval issue with the given number, such as one
+// created by an earlier run.
+func OpenIssue(issues IssuesService, owner, repo string, number int) *Issue {
+	return &Issue{issues: issues, owner: owner, repo: repo, Number: number}
+}
+
+// Comments lists every comment of the issue, in the order they were made.
+func (i *Issue) Comments(ctx context.Context) ([]*github.IssueComment, error) {
+	var comments []*github.IssueComment
+	opts := &github.IssueListCommentsOptions{ListOptions: github.ListOptions{PerPage: 100}}
+	for {
+		page, resp, err := i.issues.ListComments(ctx, i.owner, i.repo, i.Number, opts)
+		if err != nil {
+			return nil, err
+		}
+		comments = append(comments, page...)
+		if resp.NextPage == 0 {
+			break
+		}
+		opts.Page = resp.NextPage
+	}
+	return comments, nil
+}
+
+// Decisions leaves out the comments that can't be decisions: those of bots
+// that aren't in AllowedBots and those made before NotBefore.
+func (i *Issue) Decisions(comments []*github.IssueComment) []*github.IssueComment {
+	return FilterCommentsBefore(FilterBotComments(comments, i.AllowedBots), i.NotBefore)
+}
+
+// Poll lists the comments of the issue and evaluates the decisions among
+// them with Evaluate.
+func (i *Issue) Poll(ctx context.Context, w Words, approvers []string, minimumApprovals int, deploymentNames []string, requirements ...Requirement) (Status, []string, error) {
+	comments, err := i.Comments(ctx)
+	if err != nil {
+		return StatusPending, []string{}, err
+	}
+	return w.Evaluate(i.Decisions(comments), approvers, minimumApprovals, deploymentNames, requirements...)
+}
+
+// Comment posts a comment on the issue.
+func (i *Issue) Comment(ctx context.Context, body string) error {
+	_, _, err := i.issues.CreateComment(ctx, i.owner, i.repo, i.Number, &github.IssueComment{Body: &body})
+	return err
+}
+
+// Close leaves a final comment on the issue and closes it.
+func (i *Issue) Close(ctx context.Context, comment string) error {
+	if err := i.Comment(ctx, comment); err != nil {
+		return err
+	}
+	state := "closed"
+	_, _, err := i.issues.Edit(ctx, i.owner, i.repo, i.Number, &github.IssueRequest{State: &state})
+	return err
+}
+
+// FilterBotComments drops comments made by bot accounts unless the bot is
+// in allowedBots.
+func FilterBotComments(comments []*github.IssueComment, allowedBots []string) []*github.IssueComment {
+	var filtered []*github.IssueComment
+	for _, comment := range comments {
+		login := comment.User.GetLogin()
+		isBot := comment.User.GetType() == "Bot" || strings.HasSuffix(login, "[bot]")
+		if isBot && ApproversIndex(allowedBots, login) < 0 {
+			continue
+		}
+		filtered = append(filtered, comment)
+	}
+	return filtered
+}
+
+// FilterCommentsBefore drops comments created before notBefore. Comments
+// without a creation time are kept.
+func FilterCommentsBefore(comments []*github.IssueComment, notBefore time.Time) []*github.IssueComment {
+	if notBefore.IsZero() {
+		return comments
+	}
+	var filtered []*github.IssueComment
+	for _, comment := range comments {
+		if comment.CreatedAt != nil && comment.CreatedAt.Before(notBefore) {
+			continue
+		}
+		filtered = append(filtered, comment)
+	}
+	return filtered
+}
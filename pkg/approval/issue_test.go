@@ -0,0 +1,149 @@
+package approval
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/google/go-github/v43/github"
+)
+
+// fakeIssues keeps the issues and comments of a repository in memory, and
+// lists comments a page at a time.
+type fakeIssues struct {
+	now      time.Time
+	issues   map[int]*github.Issue
+	comments map[int][]*github.IssueComment
+}
+
+func newFakeIssues() *fakeIssues {
+	return &fakeIssues{
+		now:      time.Date(2022, 3, 1, 10, 0, 0, 0, time.UTC),
+		issues:   map[int]*github.Issue{},
+		comments: map[int][]*github.IssueComment{},
+	}
+}
+
+func (f *fakeIssues) tick() time.Time {
+	f.now = f.now.Add(time.Second)
+	return f.now
+}
+
+func (f *fakeIssues) comment(number int, login, userType, body string) {
+	createdAt := f.tick()
+	f.comments[number] = append(f.comments[number], &github.IssueComment{
+		Body:      github.String(body),
+		User:      &github.User{Login: github.String(login), Type: github.String(userType)},
+		CreatedAt: &createdAt,
+	})
+}
+
+func (f *fakeIssues) Create(ctx context.Context, owner string, repo string, request *github.IssueRequest) (*github.Issue, *github.Response, error) {
+	createdAt := f.tick()
+	issue := &github.Issue{Number: github.Int(len(f.issues) + 1), Title: request.Title, State: github.String("open"), CreatedAt: &createdAt}
+	f.issues[issue.GetNumber()] = issue
+	return issue, &github.Response{}, nil
+}
+
+func (f *fakeIssues) CreateComment(ctx context.Context, owner string, repo string, number int, comment *github.IssueComment) (*github.IssueComment, *github.Response, error) {
+	f.comment(number, "github-actions[bot]", "Bot", comment.GetBody())
+	return comment, &github.Response{}, nil
+}
+
+func (f *fakeIssues) Edit(ctx context.Context, owner string, repo string, number int, request *github.IssueRequest) (*github.Issue, *github.Response, error) {
+	issue, ok := f.issues[number]
+	if !ok {
+		return nil, nil, fmt.Errorf("issue #%d not found", number)
+	}
+	if request.State != nil {
+		issue.State = request.State
+	}
+	return issue, &github.Response{}, nil
+}
+
+func (f *fakeIssues) ListComments(ctx context.Context, owner string, repo string, number int, opts *github.IssueListCommentsOptions) ([]*github.IssueComment, *github.Response, error) {
+	comments := f.comments[number]
+	page := opts.Page
+	if page == 0 {
+		page = 1
+	}
+	start, end := (page-1)*opts.PerPage, page*opts.PerPage
+	if start > len(comments) {
+		start = len(comments)
+	}
+	resp := &github.Response{}
+	if end < len(comments) {
+		resp.NextPage = page + 1
+	} else {
+		end = len(comments)
+	}
+	return comments[start:end], resp, nil
+}
+
+func TestIssueLifecycle(t *testing.T) {
+	ctx := context.Background()
+	fake := newFakeIssues()
+	fake.comment(1, "user1", "User", "approve")
+
+	// The approval issue is the second issue, after one with an approval
+	// from before this request.
+	if _, _, err := fake.Create(ctx, "owner", "repo", &github.IssueRequest{Title: github.String("Earlier request")}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	issue, created, err := CreateIssue(ctx, fake, "owner", "repo", &github.IssueRequest{Title: github.String("Manual approval required")})
+	if err != nil {
+		t.Fatalf("error creating issue: %v", err)
+	}
+	if issue.Number != 2 || created.GetTitle() != "Manual approval required" {
+		t.Fatalf("expected issue #2 to be created but got #%d %q", issue.Number, created.GetTitle())
+	}
+
+	// A bot approval and an approval from before the request don't count,
+	// and the comments are read past the first page.
+	fake.comments[2] = append(fake.comments[2], &github.IssueComment{
+		Body:      github.String("approve"),
+		User:      &github.User{Login: github.String("user1"), Type: github.String("User")},
+		CreatedAt: &time.Time{},
+	})
+	fake.comment(2, "deploy-bot[bot]", "Bot", "approve")
+	for i := 0; i < 150; i++ {
+		fake.comment(2, "user3", "User", "Looking into it")
+	}
+	status, _, err := issue.Poll(ctx, DefaultWords(), []string{"user1", "user2", "deploy-bot[bot]"}, 1, nil)
+	if err != nil {
+		t.Fatalf("error polling: %v", err)
+	}
+	if status != StatusPending {
+		t.Fatalf("expected %s but got %s", StatusPending, status)
+	}
+
+	fake.comment(2, "user2", "User", "approve")
+	status, _, err = issue.Poll(ctx, DefaultWords(), []string{"user1", "user2", "deploy-bot[bot]"}, 1, nil)
+	if err != nil {
+		t.Fatalf("error polling: %v", err)
+	}
+	if status != StatusApproved {
+		t.Fatalf("expected the approval after the first page to count, got %s", status)
+	}
+
+	issue.AllowedBots = []string{"deploy-bot[bot]"}
+	comments, err := issue.Comments(ctx)
+	if err != nil {
+		t.Fatalf("error listing comments: %v", err)
+	}
+	if decisions := issue.Decisions(comments); len(decisions) != 152 {
+		t.Fatalf("expected the allowed bot's comment to count, got %d decisions of %d comments", len(decisions), len(comments))
+	}
+
+	if err := issue.Close(ctx, "All approvers have approved."); err != nil {
+		t.Fatalf("error closing issue: %v", err)
+	}
+	if state := fake.issues[2].GetState(); state != "closed" {
+		t.Fatalf("expected the issue to be closed but it is %s", state)
+	}
+	last := fake.comments[2][len(fake.comments[2])-1]
+	if last.GetBody() != "All approvers have approved." {
+		t.Fatalf("expected the closing comment but got %q", last.GetBody())
+	}
+}
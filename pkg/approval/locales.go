@@ -0,0 +1,87 @@
+package approval
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// WordPack is a set of approval and denial words.
+type WordPack struct {
+	Approved []string
+	Denied   []string
+}
+
+// Locales are the built-in word packs of languages other than English,
+// whose words are always accepted.
+var Locales = map[string]WordPack{
+	"de": {
+		Approved: []string{"genehmigt", "genehmigen", "freigegeben", "ja"},
+		Denied:   []string{"abgelehnt", "ablehnen", "nein"},
+	},
+	"es": {
+		Approved: []string{"aprobado", "aprobar", "apruebo", "sí"},
+		Denied:   []string{"denegado", "denegar", "rechazado"},
+	},
+	"fr": {
+		Approved: []string{"approuvé", "approuver", "validé", "oui"},
+		Denied:   []string{"refusé", "refuser", "rejeté", "non"},
+	},
+	"ja": {
+		Approved: []string{"承認", "承認します", "はい"},
+		Denied:   []string{"却下", "拒否", "いいえ"},
+	},
+	"pt": {
+		Approved: []string{"aprovado", "aprovar", "aprovo", "sim"},
+		Denied:   []string{"negado", "negar", "rejeitado", "não"},
+	},
+}
+
+// LocaleWords returns the word pack of a locale such as "es" or "pt-BR".
+// Regional variants use the words of their language.
+func LocaleWords(locale string) (WordPack, error) {
+	language := strings.ToLower(strings.SplitN(strings.ReplaceAll(locale, "_", "-"), "-", 2)[0])
+	if language == "en" {
+		return WordPack{}, nil
+	}
+	pack, ok := Locales[language]
+	if !ok {
+		var known []string
+		for name := range Locales {
+			known = append(known, name)
+		}
+		sort.Strings(known)
+		return WordPack{}, fmt.Errorf("unknown locale %q, expected en or one of %s", locale, strings.Join(known, ", "))
+	}
+	return pack, nil
+}
+
+// WithPack returns the words with those of the pack added to the approval
+// and denial words. Words that are already accepted are skipped, and w is
+// left as it was.
+func (w Words) WithPack(pack WordPack) Words {
+	w.Approved = appendWords(w.Approved, pack.Approved)
+	w.Denied = appendWords(w.Denied, pack.Denied)
+	return w
+}
+
+func appendWords(words []string, added []string) []string {
+	words = append([]string{}, words...)
+	for _, word := range added {
+		word = strings.TrimSpace(word)
+		if word == "" || containsWord(words, word) {
+			continue
+		}
+		words = append(words, word)
+	}
+	return words
+}
+
+func containsWord(words []string, word string) bool {
+	for _, existing := range words {
+		if strings.EqualFold(existing, word) {
+			return true
+		}
+	}
+	return false
+}
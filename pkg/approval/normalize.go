@@ -0,0 +1,52 @@
+package approval
+
+import (
+	"strings"
+	"unicode"
+
+	"golang.org/x/text/unicode/norm"
+)
+
+// invisibleCharacters are dropped from comments. They are left behind by
+// editors and mail clients, and are invisible to the approver.
+var invisibleCharacters = strings.NewReplacer(
+	"\u200b", "", // zero width space
+	"\u200c", "", // zero width non-joiner
+	"\u200d", "", // zero width joiner
+	"\u2060", "", // word joiner
+	"\ufeff", "", // byte order mark
+	"\u00ad", "", // soft hyphen
+)
+
+// smartPunctuation replaces the typographic quotes and spaces that word
+// processors substitute with their plain equivalents.
+var smartPunctuation = strings.NewReplacer(
+	"\u2018", "'",
+	"\u2019", "'",
+	"\u201c", `"`,
+	"\u201d", `"`,
+	"\u00a0", " ", // no-break space
+	"\u202f", " ", // narrow no-break space
+)
+
+// surroundingMarkers are the markdown emphasis and code markers, and
+// quotation marks, trimmed from around a comment. Quote and strikethrough
+// markers are kept, so that quoted or struck through text is never read as
+// a decision.
+const surroundingMarkers = "*_`\"'"
+
+// Normalize prepares a comment for matching decision words: line endings
+// become \n, the text is NFC normalized so that composed and decomposed
+// accents match, invisible characters are dropped, smart quotes and
+// non-breaking spaces are replaced, and surrounding whitespace and markdown
+// markers such as "**" are trimmed.
+func Normalize(body string) string {
+	body = strings.ReplaceAll(body, "\r\n", "\n")
+	body = strings.ReplaceAll(body, "\r", "\n")
+	body = norm.NFC.String(body)
+	body = invisibleCharacters.Replace(body)
+	body = smartPunctuation.Replace(body)
+	return strings.TrimFunc(body, func(r rune) bool {
+		return unicode.IsSpace(r) || strings.ContainsRune(surroundingMarkers, r)
+	})
+}
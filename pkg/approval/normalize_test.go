@@ -0,0 +1,67 @@
+package approval
+
+import "testing"
+
+func TestNormalize(t *testing.T) {
+	testCases := []struct {
+		name     string
+		body     string
+		expected string
+	}{
+		{name: "plain", body: "approve", expected: "approve"},
+		{name: "bold", body: "**Approved**", expected: "Approved"},
+		{name: "italic", body: "_lgtm_", expected: "lgtm"},
+		{name: "blockquote", body: "> approve", expected: "> approve"},
+		{name: "strikethrough", body: "~~approve~~", expected: "~~approve~~"},
+		{name: "inline_code", body: "`approve`", expected: "approve"},
+		{name: "smart_quotes", body: "\u201capproved\u201d", expected: "approved"},
+		{name: "crlf", body: "approve\r\n\r\n", expected: "approve"},
+		{name: "zero_width", body: "\u200bap\u200dprove\ufeff", expected: "approve"},
+		{name: "no_break_space", body: "ship\u00a0it", expected: "ship it"},
+		{name: "decomposed_accent", body: "aprove\u0301", expected: "aprov\u00e9"},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			actual := Normalize(testCase.body)
+			if actual != testCase.expected {
+				t.Fatalf("expected %q but got %q", testCase.expected, actual)
+			}
+		})
+	}
+}
+
+func TestIsApprovedNormalized(t *testing.T) {
+	words := DefaultWords().WithPack(WordPack{Approved: []string{"approuv\u00e9"}})
+
+	for _, body := range []string{"**Approved**", "`lgtm!`", "\u201cyes\u201d", "approve\r\n", "approuve\u0301"} {
+		approved, err := words.IsApproved(body)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if !approved {
+			t.Fatalf("expected %q to be an approval", body)
+		}
+	}
+}
+
+func TestIsApprovedQuotedOrStruckThrough(t *testing.T) {
+	for _, mode := range []MatchMode{MatchStrict, MatchLenient} {
+		words := DefaultWords()
+		words.Mode = mode
+		for _, body := range []string{
+			"> approved\n\nWhy would you approve this? We are not ready.",
+			"> approved",
+			"~~approve~~",
+			"~~approved~~ **denied**",
+		} {
+			approved, err := words.IsApproved(body)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if approved {
+				t.Fatalf("expected %q not to be an approval in %s mode", body, mode)
+			}
+		}
+	}
+}
@@ -0,0 +1,197 @@
+package approval
+
+import (
+	"fmt"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// ParsedComment is a comment split into its decision and the deployment
+// names it is given for.
+type ParsedComment struct {
+	// Decision is the text before the deployment names, without markdown
+	// formatting, such as "approve" in "**approve** [prod]".
+	Decision string
+	// DeploymentNames are the names in brackets after the decision, if any.
+	DeploymentNames []string
+	// Trailing is the text after the closing bracket, which is ignored.
+	Trailing string
+	// Note is the text below the first line, such as the caveats of an
+	// approval.
+	Note string
+	// Quoted are the lines quoted from other comments, without their quote
+	// markers. They are never the decision.
+	Quoted []string
+}
+
+// ParseError is a comment that names deployments but can't be read. Offset
+// is the byte offset of the problem in the normalized comment body, and Name
+// the deployment name that is not valid, if that is the problem.
+type ParseError struct {
+	Offset  int
+	Message string
+	Name    string
+}
+
+func (e *ParseError) Error() string {
+	return fmt.Sprintf("comment body is not valid at position %d: %s", e.Offset, e.Message)
+}
+
+// allDeploymentNames are the names that stand for every deployment name,
+// such as "approve [all]", unless a deployment has that name itself.
+var allDeploymentNames = []string{"all", "*"}
+
+// formattingMarkers are the characters of markdown emphasis and inline code,
+// which are dropped from around decisions and names.
+const formattingMarkers = "*_`"
+
+// stripFormatting trims whitespace and markdown formatting from around text.
+func stripFormatting(text string) string {
+	return strings.TrimFunc(text, func(r rune) bool {
+		return unicode.IsSpace(r) || strings.ContainsRune(formattingMarkers, r)
+	})
+}
+
+// SplitNote splits a normalized comment into its first line, which holds
+// the decision, and the note written below it. Quoted lines, such as those
+// of a reply, are someone else's words: they are skipped to find the
+// decision and left out of the note.
+func SplitNote(body string) (string, string) {
+	first, note, _ := splitQuoted(body)
+	return first, note
+}
+
+// splitQuoted splits a comment like SplitNote, also returning its quoted
+// lines without their quote markers.
+func splitQuoted(body string) (string, string, []string) {
+	var first string
+	var noteLines, quoted []string
+	found := false
+	for _, line := range strings.Split(Normalize(body), "\n") {
+		trimmed := strings.TrimSpace(line)
+		switch {
+		case strings.HasPrefix(trimmed, ">"):
+			quoted = append(quoted, strings.TrimSpace(strings.TrimLeft(trimmed, "> ")))
+		case !found && trimmed == "":
+		case !found:
+			first, found = line, true
+		default:
+			noteLines = append(noteLines, line)
+		}
+	}
+	return Normalize(first), strings.TrimSpace(strings.Join(noteLines, "\n")), quoted
+}
+
+// ParseComment splits the first line of a comment into its decision and,
+// when deployments can be named, the deployment names in brackets after it,
+// such as "approve [prod, staging]". Names can be quoted and surrounded by
+// whitespace or markdown formatting, and text after the closing bracket is
+// ignored. Each name must be one of deploymentNames, or "all" or "*" for
+// every one of them, and names given more than once are returned once.
+// Without deploymentNames the whole first line is the decision. The comment
+// is normalized first, and the lines below the first are returned as its
+// note, leaving out quoted lines, which are returned on their own.
+func ParseComment(body string, deploymentNames []string) (ParsedComment, error) {
+	body, note, quoted := splitQuoted(body)
+	open := strings.IndexByte(body, '[')
+	if len(deploymentNames) == 0 || open < 0 {
+		return ParsedComment{Decision: stripFormatting(body), Note: note, Quoted: quoted}, nil
+	}
+	parsed := ParsedComment{Decision: stripFormatting(body[:open]), Note: note, Quoted: quoted}
+
+	valid := make(map[string]bool, len(deploymentNames))
+	for _, name := range deploymentNames {
+		valid[name] = true
+	}
+
+	pos := open + 1
+	for {
+		pos = skipSpace(body, pos)
+		if pos >= len(body) {
+			return ParsedComment{}, &ParseError{Offset: len(body), Message: "missing closing bracket"}
+		}
+
+		start := pos
+		var name string
+		if quote := body[pos]; quote == '"' || quote == '\'' {
+			end := strings.IndexByte(body[pos+1:], quote)
+			if end < 0 {
+				return ParsedComment{}, &ParseError{Offset: start, Message: "unterminated quote"}
+			}
+			name = body[pos+1 : pos+1+end]
+			pos += end + 2
+			pos = skipSpace(body, pos)
+		} else {
+			end := strings.IndexAny(body[pos:], ",]")
+			if end < 0 {
+				return ParsedComment{}, &ParseError{Offset: len(body), Message: "missing closing bracket"}
+			}
+			raw := strings.TrimSpace(body[pos : pos+end])
+			name = stripFormatting(raw)
+			if name == "" && strings.Trim(raw, "_`") == "*" {
+				// An asterisk is a markdown marker itself, but on its own
+				// it stands for every deployment name.
+				name = "*"
+			}
+			pos += end
+		}
+
+		if name == "" {
+			return ParsedComment{}, &ParseError{Offset: start, Message: "empty deployment name"}
+		}
+		switch {
+		case valid[name]:
+			parsed.DeploymentNames = appendName(parsed.DeploymentNames, name)
+		case isAllDeploymentNames(name):
+			for _, deploymentName := range deploymentNames {
+				parsed.DeploymentNames = appendName(parsed.DeploymentNames, deploymentName)
+			}
+		default:
+			return ParsedComment{}, &ParseError{Offset: start, Message: fmt.Sprintf("deployment name %q is invalid", name), Name: name}
+		}
+
+		if pos >= len(body) {
+			return ParsedComment{}, &ParseError{Offset: len(body), Message: "missing closing bracket"}
+		}
+		switch body[pos] {
+		case ',':
+			pos++
+		case ']':
+			parsed.Trailing = strings.TrimSpace(body[pos+1:])
+			return parsed, nil
+		default:
+			return ParsedComment{}, &ParseError{Offset: pos, Message: "expected a comma or closing bracket after the quoted name"}
+		}
+	}
+}
+
+func skipSpace(text string, pos int) int {
+	for pos < len(text) {
+		r, size := utf8.DecodeRuneInString(text[pos:])
+		if !unicode.IsSpace(r) {
+			break
+		}
+		pos += size
+	}
+	return pos
+}
+
+func isAllDeploymentNames(name string) bool {
+	for _, all := range allDeploymentNames {
+		if strings.EqualFold(name, all) {
+			return true
+		}
+	}
+	return false
+}
+
+// appendName appends name to names unless it is already there.
+func appendName(names []string, name string) []string {
+	for _, existing := range names {
+		if existing == name {
+			return names
+		}
+	}
+	return append(names, name)
+}
@@ -0,0 +1,203 @@
+package approval
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestParseComment(t *testing.T) {
+	deploymentNames := []string{"prod", "staging", "eu west"}
+	testCases := []struct {
+		name                    string
+		body                    string
+		deploymentNames         []string
+		expectedDecision        string
+		expectedDeploymentNames []string
+		expectedTrailing        string
+		expectedNote            string
+		expectedQuoted          []string
+		expectedErrorOffset     int
+		expectedError           string
+	}{
+		{
+			name:             "no_deployment_names",
+			body:             "approve[prod]",
+			expectedDecision: "approve[prod]",
+		},
+		{
+			name:             "no_brackets",
+			body:             "approve",
+			deploymentNames:  deploymentNames,
+			expectedDecision: "approve",
+		},
+		{
+			name:                    "single_name",
+			body:                    "approve[prod]",
+			deploymentNames:         deploymentNames,
+			expectedDecision:        "approve",
+			expectedDeploymentNames: []string{"prod"},
+		},
+		{
+			name:                    "whitespace",
+			body:                    "  approve [ prod ,  staging ]\n",
+			deploymentNames:         deploymentNames,
+			expectedDecision:        "approve",
+			expectedDeploymentNames: []string{"prod", "staging"},
+		},
+		{
+			name:                    "quotes",
+			body:                    `approve ["prod", 'eu west']`,
+			deploymentNames:         deploymentNames,
+			expectedDecision:        "approve",
+			expectedDeploymentNames: []string{"prod", "eu west"},
+		},
+		{
+			name:                    "markdown",
+			body:                    "**approve** [`prod`, _staging_]",
+			deploymentNames:         deploymentNames,
+			expectedDecision:        "approve",
+			expectedDeploymentNames: []string{"prod", "staging"},
+		},
+		{
+			name:                    "all",
+			body:                    "approved[all]",
+			deploymentNames:         deploymentNames,
+			expectedDecision:        "approved",
+			expectedDeploymentNames: []string{"prod", "staging", "eu west"},
+		},
+		{
+			name:                    "asterisk",
+			body:                    "approved [*]",
+			deploymentNames:         deploymentNames,
+			expectedDecision:        "approved",
+			expectedDeploymentNames: []string{"prod", "staging", "eu west"},
+		},
+		{
+			name:                    "all_with_names",
+			body:                    "approve [staging, ALL, staging]",
+			deploymentNames:         deploymentNames,
+			expectedDecision:        "approve",
+			expectedDeploymentNames: []string{"staging", "prod", "eu west"},
+		},
+		{
+			name:                    "deployment_named_all",
+			body:                    "approve [all]",
+			deploymentNames:         []string{"prod", "all"},
+			expectedDecision:        "approve",
+			expectedDeploymentNames: []string{"all"},
+		},
+		{
+			name:                    "trailing_text",
+			body:                    "lgtm [prod] ship it!",
+			deploymentNames:         deploymentNames,
+			expectedDecision:        "lgtm",
+			expectedDeploymentNames: []string{"prod"},
+			expectedTrailing:        "ship it!",
+		},
+		{
+			name:                    "note",
+			body:                    "approve [prod]\r\n\r\nWatch the error rates.\r\nRoll back if needed.\r\n",
+			deploymentNames:         deploymentNames,
+			expectedDecision:        "approve",
+			expectedDeploymentNames: []string{"prod"},
+			expectedNote:            "Watch the error rates.\nRoll back if needed.",
+		},
+		{
+			name:             "note_without_deployment_names",
+			body:             "**LGTM**\nbut keep an eye on it",
+			expectedDecision: "LGTM",
+			expectedNote:     "but keep an eye on it",
+		},
+		{
+			name:                "invalid_name",
+			body:                "approve[prod,dev]",
+			deploymentNames:     deploymentNames,
+			expectedErrorOffset: 13,
+			expectedError:       `deployment name "dev" is invalid`,
+		},
+		{
+			name:                "empty_name",
+			body:                "approve[prod,]",
+			deploymentNames:     deploymentNames,
+			expectedErrorOffset: 13,
+			expectedError:       "empty deployment name",
+		},
+		{
+			name:                "missing_closing_bracket",
+			body:                "approve[prod",
+			deploymentNames:     deploymentNames,
+			expectedErrorOffset: 12,
+			expectedError:       "missing closing bracket",
+		},
+		{
+			name:                "unterminated_quote",
+			body:                `approve["prod]`,
+			deploymentNames:     deploymentNames,
+			expectedErrorOffset: 8,
+			expectedError:       "unterminated quote",
+		},
+		{
+			name:                "text_after_quoted_name",
+			body:                `approve["prod" staging]`,
+			deploymentNames:     deploymentNames,
+			expectedErrorOffset: 15,
+			expectedError:       "expected a comma",
+		},
+		{
+			name:                    "quote_reply",
+			body:                    "> Can we ship the fix today?\n\napprove [prod]\nWatch the error rates.",
+			deploymentNames:         deploymentNames,
+			expectedDecision:        "approve",
+			expectedDeploymentNames: []string{"prod"},
+			expectedNote:            "Watch the error rates.",
+			expectedQuoted:          []string{"Can we ship the fix today?"},
+		},
+		{
+			name:             "quote_below_decision",
+			body:             "approved\n> deny",
+			expectedDecision: "approved",
+			expectedQuoted:   []string{"deny"},
+		},
+		{
+			name:             "only_quoted",
+			body:             "> approved",
+			expectedDecision: "",
+			expectedQuoted:   []string{"approved"},
+		},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			parsed, err := ParseComment(testCase.body, testCase.deploymentNames)
+			if testCase.expectedError != "" {
+				var parseErr *ParseError
+				if !errors.As(err, &parseErr) {
+					t.Fatalf("expected a parse error but got %v", err)
+				}
+				if parseErr.Offset != testCase.expectedErrorOffset || !strings.Contains(parseErr.Message, testCase.expectedError) {
+					t.Fatalf("expected %q at %d but got %q at %d", testCase.expectedError, testCase.expectedErrorOffset, parseErr.Message, parseErr.Offset)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if parsed.Decision != testCase.expectedDecision {
+				t.Fatalf("expected decision %q but got %q", testCase.expectedDecision, parsed.Decision)
+			}
+			if strings.Join(parsed.DeploymentNames, "|") != strings.Join(testCase.expectedDeploymentNames, "|") {
+				t.Fatalf("expected deployment names %v but got %v", testCase.expectedDeploymentNames, parsed.DeploymentNames)
+			}
+			if parsed.Trailing != testCase.expectedTrailing {
+				t.Fatalf("expected trailing text %q but got %q", testCase.expectedTrailing, parsed.Trailing)
+			}
+			if parsed.Note != testCase.expectedNote {
+				t.Fatalf("expected note %q but got %q", testCase.expectedNote, parsed.Note)
+			}
+			if strings.Join(parsed.Quoted, "|") != strings.Join(testCase.expectedQuoted, "|") {
+				t.Fatalf("expected quoted lines %v but got %v", testCase.expectedQuoted, parsed.Quoted)
+			}
+		})
+	}
+}
@@ -0,0 +1,53 @@
+package approval
+
+import (
+	"github.com/google/go-github/v43/github"
+)
+
+// ApprovedDeployments returns the deployment names that the comments
+// approve on their own, in the order of deploymentNames: those named by
+// enough approvals to meet minimumApprovals and the requirements. It is
+// used when the gate can continue with only some of the deployments, as
+// Evaluate only counts the approvals of the deployments named by the
+// approval that completes the quorum.
+func (w Words) ApprovedDeployments(comments []*github.IssueComment, approvers []string, minimumApprovals int, deploymentNames []string, requirements ...Requirement) ([]string, error) {
+	var approved []string
+	for _, name := range deploymentNames {
+		var nameComments []*github.IssueComment
+		for _, comment := range comments {
+			if !w.namesOtherDeployments(comment.GetBody(), name, deploymentNames) {
+				nameComments = append(nameComments, comment)
+			}
+		}
+		status, _, err := w.Evaluate(nameComments, approvers, minimumApprovals, deploymentNames, requirements...)
+		if err != nil {
+			return nil, err
+		}
+		if status == StatusApproved {
+			approved = append(approved, name)
+		}
+	}
+	return approved, nil
+}
+
+// namesOtherDeployments reports whether body is an approval that doesn't
+// approve name, either because it names other deployments or because it
+// names none.
+func (w Words) namesOtherDeployments(body, name string, deploymentNames []string) bool {
+	parsed, err := ParseComment(body, deploymentNames)
+	if err != nil {
+		return false
+	}
+	if w.quotesDecision(parsed.Quoted) {
+		return false
+	}
+	if isApproval, err := w.IsApproved(parsed.Decision); err != nil || !isApproval {
+		return false
+	}
+	for _, approvedName := range parsed.DeploymentNames {
+		if approvedName == name {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,82 @@
+package approval
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/google/go-github/v43/github"
+)
+
+func TestApprovedDeployments(t *testing.T) {
+	comment := func(login, body string) *github.IssueComment {
+		return &github.IssueComment{User: &github.User{Login: github.String(login)}, Body: github.String(body)}
+	}
+	deploymentNames := []string{"us-east-1", "us-west-2", "eu-west-1", "eu-central-1", "ap-south-1"}
+	approvers := []string{"user1", "user2", "user3"}
+	testCases := []struct {
+		name             string
+		comments         []*github.IssueComment
+		minimumApprovals int
+		expected         []string
+	}{
+		{
+			name: "subset",
+			comments: []*github.IssueComment{
+				comment("user1", "approve [us-east-1, eu-west-1]"),
+				comment("user2", "approve [eu-west-1, us-west-2]"),
+				comment("user3", "approve [us-west-2, us-east-1]"),
+			},
+			minimumApprovals: 2,
+			expected:         []string{"us-east-1", "us-west-2", "eu-west-1"},
+		},
+		{
+			name: "not_enough_approvals",
+			comments: []*github.IssueComment{
+				comment("user1", "approve [us-east-1]"),
+				comment("user2", "approve [eu-west-1]"),
+			},
+			minimumApprovals: 2,
+		},
+		{
+			name: "all_and_non_approvers",
+			comments: []*github.IssueComment{
+				comment("user1", "approve [all]"),
+				comment("user4", "approve [us-east-1]"),
+				comment("user2", "approve"),
+			},
+			minimumApprovals: 1,
+			expected:         deploymentNames,
+		},
+		{
+			name: "revoked",
+			comments: []*github.IssueComment{
+				comment("user1", "approve [ap-south-1, eu-central-1]"),
+				comment("user1", "revoke"),
+				comment("user2", "approve [ap-south-1, eu-central-1]"),
+				comment("user3", "approve [eu-central-1]"),
+			},
+			minimumApprovals: 2,
+			expected:         []string{"eu-central-1"},
+		},
+		{
+			name: "held",
+			comments: []*github.IssueComment{
+				comment("user2", "/hold"),
+				comment("user1", "approve [ap-south-1]"),
+			},
+			minimumApprovals: 1,
+		},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			actual, err := DefaultWords().ApprovedDeployments(testCase.comments, approvers, testCase.minimumApprovals, deploymentNames)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if !reflect.DeepEqual(actual, testCase.expected) {
+				t.Fatalf("expected %v, got %v", testCase.expected, actual)
+			}
+		})
+	}
+}
@@ -0,0 +1,178 @@
+package approval
+
+import (
+	"errors"
+	"strings"
+
+	"github.com/google/go-github/v43/github"
+)
+
+// Requirement is an additional condition that the approvers who approved
+// must satisfy, on top of the minimum number of approvals.
+type Requirement func(approvedBy []string) bool
+
+// Evaluate reads the decision of the approvers from the comments with the
+// words, in the order they were made. The gate is approved once minimumApprovals of the
+// approvers approved and the requirements are met, or all of them if
+// minimumApprovals is 0, and denied as soon as one of them denies. When
+// deploymentNames is set, approvals can name the deployments they approve,
+// such as "approve[prod,staging]", and the named deployments are returned.
+// Comments whose deployment names can't be parsed are not counted.
+func (w Words) Evaluate(comments []*github.IssueComment, approvers []string, minimumApprovals int, deploymentNames []string, requirements ...Requirement) (Status, []string, error) {
+	remainingApprovers := make([]string, len(approvers))
+	copy(remainingApprovers, approvers)
+	var approvedBy []string
+
+	if minimumApprovals == 0 {
+		minimumApprovals = len(approvers)
+	}
+
+	// Approvers with an active hold suspend the approval until they lift it.
+	// Once enough approvals were given, the gate is approved as soon as the
+	// last hold is lifted, unless approvals are revoked in the meantime.
+	holders := make(map[string]bool)
+	quorumReached := false
+	var quorumDeploymentNames []string
+
+	for _, comment := range comments {
+		commentUser := comment.User.GetLogin()
+		if ApproversIndex(approvers, commentUser) >= 0 {
+			isHoldComment, err := w.IsHold(comment.GetBody())
+			if err != nil {
+				return StatusPending, []string{}, err
+			}
+			if isHoldComment {
+				holders[commentUser] = true
+				continue
+			}
+			isUnholdComment, err := w.IsUnhold(comment.GetBody())
+			if err != nil {
+				return StatusPending, []string{}, err
+			}
+			if isUnholdComment {
+				delete(holders, commentUser)
+				if quorumReached && len(holders) == 0 {
+					return StatusApproved, quorumDeploymentNames, nil
+				}
+				continue
+			}
+		}
+
+		approverIdx := ApproversIndex(remainingApprovers, commentUser)
+		if approverIdx < 0 {
+			// Approvers who already approved can only withdraw their approval,
+			// which also undoes a quorum still waiting on a hold.
+			if ApproversIndex(approvers, commentUser) >= 0 {
+				isRevokeComment, err := w.IsRevoked(comment.GetBody())
+				if err != nil {
+					return StatusPending, []string{}, err
+				}
+				if isRevokeComment {
+					remainingApprovers = append(remainingApprovers, commentUser)
+					approvedBy = removeApprover(approvedBy, commentUser)
+					if quorumReached && (len(approvedBy) < minimumApprovals || !requirementsMet(requirements, approvedBy)) {
+						quorumReached = false
+						quorumDeploymentNames = nil
+					}
+				}
+			}
+			continue
+		}
+
+		parsed, err := ParseComment(comment.GetBody(), deploymentNames)
+		var parseErr *ParseError
+		if errors.As(err, &parseErr) {
+			// A decision that names deployments that can't be read isn't
+			// counted, the approver can correct it with a new comment.
+			continue
+		}
+		if err != nil {
+			return StatusPending, []string{}, err
+		}
+		if w.quotesDecision(parsed.Quoted) {
+			continue
+		}
+		commentBody, bodyDeploymentNames := parsed.Decision, parsed.DeploymentNames
+
+		isApprovalComment, err := w.IsApproved(commentBody)
+		if err != nil {
+			return StatusPending, []string{}, err
+		}
+		if isApprovalComment {
+			remainingApprovers[approverIdx] = remainingApprovers[len(remainingApprovers)-1]
+			remainingApprovers = remainingApprovers[:len(remainingApprovers)-1]
+			approvedBy = append(approvedBy, commentUser)
+			if quorumReached {
+				// Counted in case an approval of the quorum is revoked
+				// before the hold is lifted.
+				continue
+			}
+			if len(approvedBy) >= minimumApprovals && requirementsMet(requirements, approvedBy) {
+				if len(holders) == 0 {
+					return StatusApproved, bodyDeploymentNames, nil
+				}
+				quorumReached = true
+				quorumDeploymentNames = bodyDeploymentNames
+			}
+			continue
+		}
+
+		isDenialComment, err := w.IsDenied(commentBody)
+		if err != nil {
+			return StatusPending, []string{}, err
+		}
+		if isDenialComment {
+			return StatusDenied, []string{}, nil
+		}
+	}
+
+	if len(holders) > 0 {
+		return StatusHeld, []string{}, nil
+	}
+	return StatusPending, []string{}, nil
+}
+
+func requirementsMet(requirements []Requirement, approvedBy []string) bool {
+	for _, requirement := range requirements {
+		if !requirement(approvedBy) {
+			return false
+		}
+	}
+	return true
+}
+
+func removeApprover(approvers []string, approver string) []string {
+	idx := ApproversIndex(approvers, approver)
+	if idx < 0 {
+		return approvers
+	}
+	return append(approvers[:idx], approvers[idx+1:]...)
+}
+
+// ApproversIndex returns the index of name in approvers. GitHub logins are
+// case-insensitive, so they are compared accordingly.
+func ApproversIndex(approvers []string, name string) int {
+	for idx, approver := range approvers {
+		if strings.EqualFold(approver, name) {
+			return idx
+		}
+	}
+	return -1
+}
+
+// DecidingComment returns the comment that decided the gate, such as the
+// denial that denied it or the approval that completed the quorum, or nil
+// while the gate is pending or held. It is the last comment of the shortest
+// run of comments that Evaluate decides.
+func (w Words) DecidingComment(comments []*github.IssueComment, approvers []string, minimumApprovals int, deploymentNames []string, requirements ...Requirement) *github.IssueComment {
+	for i := range comments {
+		status, _, err := w.Evaluate(comments[:i+1], approvers, minimumApprovals, deploymentNames, requirements...)
+		if err != nil {
+			return nil
+		}
+		if status == StatusApproved || status == StatusDenied {
+			return comments[i]
+		}
+	}
+	return nil
+}
@@ -0,0 +1,205 @@
+package approval
+
+import (
+	"testing"
+
+	"github.com/google/go-github/v43/github"
+)
+
+func comment(login, body string) *github.IssueComment {
+	return &github.IssueComment{User: &github.User{Login: github.String(login)}, Body: github.String(body)}
+}
+
+func TestEvaluate(t *testing.T) {
+	testCases := []struct {
+		name                    string
+		comments                []*github.IssueComment
+		approvers               []string
+		minimumApprovals        int
+		deploymentNames         []string
+		expectedStatus          Status
+		expectedDeploymentNames []string
+		expectError             bool
+	}{
+		{
+			name:             "single_approval",
+			comments:         []*github.IssueComment{comment("user1", "approve")},
+			approvers:        []string{"user1", "user2"},
+			minimumApprovals: 1,
+			expectedStatus:   StatusApproved,
+		},
+		{
+			name:           "all_approvers_required",
+			comments:       []*github.IssueComment{comment("user1", "approve")},
+			approvers:      []string{"user1", "user2"},
+			expectedStatus: StatusPending,
+		},
+		{
+			name:           "denial",
+			comments:       []*github.IssueComment{comment("user1", "approve"), comment("user2", "deny")},
+			approvers:      []string{"user1", "user2"},
+			expectedStatus: StatusDenied,
+		},
+		{
+			name:             "not_an_approver",
+			comments:         []*github.IssueComment{comment("user3", "approve")},
+			approvers:        []string{"user1"},
+			minimumApprovals: 1,
+			expectedStatus:   StatusPending,
+		},
+		{
+			name:             "revoked",
+			comments:         []*github.IssueComment{comment("user1", "approve"), comment("user1", "revoke"), comment("user2", "approve")},
+			approvers:        []string{"user1", "user2"},
+			minimumApprovals: 2,
+			expectedStatus:   StatusPending,
+		},
+		{
+			name:             "held",
+			comments:         []*github.IssueComment{comment("user2", "/hold"), comment("user1", "approve")},
+			approvers:        []string{"user1", "user2"},
+			minimumApprovals: 1,
+			expectedStatus:   StatusHeld,
+		},
+		{
+			name:             "hold_lifted",
+			comments:         []*github.IssueComment{comment("user2", "/hold"), comment("user1", "approve"), comment("user2", "/unhold")},
+			approvers:        []string{"user1", "user2"},
+			minimumApprovals: 1,
+			expectedStatus:   StatusApproved,
+		},
+		{
+			name:             "approval_quoting_a_denial",
+			comments:         []*github.IssueComment{comment("user1", "approved\n> deny")},
+			approvers:        []string{"user1"},
+			minimumApprovals: 1,
+			expectedStatus:   StatusPending,
+		},
+		{
+			name:             "approval_after_quote_reply",
+			comments:         []*github.IssueComment{comment("user1", "> Is the migration done?\n\napproved")},
+			approvers:        []string{"user1"},
+			minimumApprovals: 1,
+			expectedStatus:   StatusApproved,
+		},
+		{
+			name:             "revoked_while_held",
+			comments:         []*github.IssueComment{comment("user3", "/hold"), comment("user1", "approve"), comment("user2", "approve"), comment("user2", "revoke"), comment("user3", "/unhold")},
+			approvers:        []string{"user1", "user2", "user3"},
+			minimumApprovals: 2,
+			expectedStatus:   StatusPending,
+		},
+		{
+			name:             "approved_again_while_held",
+			comments:         []*github.IssueComment{comment("user3", "/hold"), comment("user1", "approve"), comment("user2", "approve"), comment("user2", "revoke"), comment("user3", "approve"), comment("user3", "/unhold")},
+			approvers:        []string{"user1", "user2", "user3"},
+			minimumApprovals: 2,
+			expectedStatus:   StatusApproved,
+		},
+		{
+			name:                    "deployment_names",
+			comments:                []*github.IssueComment{comment("user1", "approve[prod]")},
+			approvers:               []string{"user1"},
+			minimumApprovals:        1,
+			deploymentNames:         []string{"prod", "staging"},
+			expectedStatus:          StatusApproved,
+			expectedDeploymentNames: []string{"prod"},
+		},
+		{
+			name:             "invalid_deployment_name",
+			comments:         []*github.IssueComment{comment("user1", "approve[dev]")},
+			approvers:        []string{"user1"},
+			minimumApprovals: 1,
+			deploymentNames:  []string{"prod", "staging"},
+			expectedStatus:   StatusPending,
+		},
+		{
+			name:                    "invalid_deployment_name_corrected",
+			comments:                []*github.IssueComment{comment("user1", "approve[prdo]"), comment("user1", "approve[prod]")},
+			approvers:               []string{"user1"},
+			minimumApprovals:        1,
+			deploymentNames:         []string{"prod", "staging"},
+			expectedStatus:          StatusApproved,
+			expectedDeploymentNames: []string{"prod"},
+		},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			status, deploymentNames, err := DefaultWords().Evaluate(testCase.comments, testCase.approvers, testCase.minimumApprovals, testCase.deploymentNames)
+			if testCase.expectError != (err != nil) {
+				t.Fatalf("expected error %t but got %v", testCase.expectError, err)
+			}
+			if status != testCase.expectedStatus {
+				t.Fatalf("expected status %s but got %s", testCase.expectedStatus, status)
+			}
+			if len(deploymentNames) != len(testCase.expectedDeploymentNames) {
+				t.Fatalf("expected deployment names %v but got %v", testCase.expectedDeploymentNames, deploymentNames)
+			}
+			for i := range deploymentNames {
+				if deploymentNames[i] != testCase.expectedDeploymentNames[i] {
+					t.Fatalf("expected deployment names %v but got %v", testCase.expectedDeploymentNames, deploymentNames)
+				}
+			}
+		})
+	}
+}
+
+func TestEvaluateRequirements(t *testing.T) {
+	comments := []*github.IssueComment{comment("user1", "approve"), comment("user2", "approve")}
+	requireUser2 := func(approvedBy []string) bool {
+		return ApproversIndex(approvedBy, "user2") >= 0
+	}
+	status, _, err := DefaultWords().Evaluate(comments[:1], []string{"user1", "user2"}, 1, nil, requireUser2)
+	if err != nil || status != StatusPending {
+		t.Fatalf("expected pending until the requirement is met but got %s, %v", status, err)
+	}
+	status, _, err = DefaultWords().Evaluate(comments, []string{"user1", "user2"}, 1, nil, requireUser2)
+	if err != nil || status != StatusApproved {
+		t.Fatalf("expected approved once the requirement is met but got %s, %v", status, err)
+	}
+}
+
+func TestDecidingComment(t *testing.T) {
+	testCases := []struct {
+		name     string
+		comments []*github.IssueComment
+		expected int
+	}{
+		{
+			name:     "denial",
+			comments: []*github.IssueComment{comment("user1", "approve"), comment("user3", "deny"), comment("user2", "deny"), comment("user1", "deny")},
+			expected: 2,
+		},
+		{
+			name:     "quorum",
+			comments: []*github.IssueComment{comment("user1", "approve"), comment("user2", "lgtm"), comment("user3", "deny")},
+			expected: 1,
+		},
+		{
+			name:     "hold_lifted",
+			comments: []*github.IssueComment{comment("user1", "/hold"), comment("user1", "approve"), comment("user2", "approve"), comment("user1", "/unhold")},
+			expected: 3,
+		},
+		{
+			name:     "pending",
+			comments: []*github.IssueComment{comment("user1", "approve")},
+			expected: -1,
+		},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			actual := DefaultWords().DecidingComment(testCase.comments, []string{"user1", "user2"}, 2, nil)
+			if testCase.expected < 0 {
+				if actual != nil {
+					t.Fatalf("expected no deciding comment but got %q", actual.GetBody())
+				}
+				return
+			}
+			if actual != testCase.comments[testCase.expected] {
+				t.Fatalf("expected comment %d but got %v", testCase.expected, actual)
+			}
+		})
+	}
+}
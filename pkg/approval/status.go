@@ -0,0 +1,22 @@
+// Package approval implements the approval semantics of the manual-approval
+// action: reading decisions from comments, evaluating them against the
+// approvers and the minimum number of approvals, and the lifecycle of the
+// approval issue they are made on, from creating it to closing it. It lets
+// other programs, such as deployment controllers, gate on the same
+// decisions as the action.
+package approval
+
+// Status is the state of an approval.
+type Status string
+
+const (
+	StatusPending  Status = "Pending"
+	StatusApproved Status = "Approved"
+	StatusDenied   Status = "Denied"
+	StatusHeld     Status = "Held"
+	// StatusCancelled is never the result of evaluating comments; it is used
+	// when the workflow is cancelled while waiting.
+	StatusCancelled Status = "Cancelled"
+	// StatusTimedOut is used when the wait is exceeded without a decision.
+	StatusTimedOut Status = "TimedOut"
+)
@@ -0,0 +1,74 @@
+package approval
+
+import (
+	"sort"
+	"strings"
+	"unicode/utf8"
+)
+
+// Suggest returns the candidates that are close to name, such as "prod" for
+// "prdo", closest first. Candidates are compared case-insensitively and are
+// close when at most a third of their characters, and at least one, differ.
+func Suggest(name string, candidates []string) []string {
+	type suggestion struct {
+		candidate string
+		distance  int
+	}
+	var suggestions []suggestion
+	for _, candidate := range candidates {
+		distance := levenshtein(strings.ToLower(name), strings.ToLower(candidate))
+		maxDistance := utf8.RuneCountInString(candidate) / 3
+		if maxDistance < 1 {
+			maxDistance = 1
+		}
+		if distance <= maxDistance {
+			suggestions = append(suggestions, suggestion{candidate: candidate, distance: distance})
+		}
+	}
+	sort.SliceStable(suggestions, func(i, j int) bool {
+		return suggestions[i].distance < suggestions[j].distance
+	})
+
+	result := make([]string, len(suggestions))
+	for i, suggestion := range suggestions {
+		result[i] = suggestion.candidate
+	}
+	return result
+}
+
+// levenshtein returns the number of single character insertions, deletions,
+// substitutions and transpositions of adjacent characters that turn a into b.
+func levenshtein(a, b string) int {
+	ra, rb := []rune(a), []rune(b)
+	rows := make([][]int, len(ra)+1)
+	for i := range rows {
+		rows[i] = make([]int, len(rb)+1)
+		rows[i][0] = i
+	}
+	for j := range rows[0] {
+		rows[0][j] = j
+	}
+	for i := 1; i <= len(ra); i++ {
+		for j := 1; j <= len(rb); j++ {
+			cost := 1
+			if ra[i-1] == rb[j-1] {
+				cost = 0
+			}
+			rows[i][j] = min3(rows[i-1][j]+1, rows[i][j-1]+1, rows[i-1][j-1]+cost)
+			if i > 1 && j > 1 && ra[i-1] == rb[j-2] && ra[i-2] == rb[j-1] && rows[i-2][j-2]+1 < rows[i][j] {
+				rows[i][j] = rows[i-2][j-2] + 1
+			}
+		}
+	}
+	return rows[len(ra)][len(rb)]
+}
+
+func min3(a, b, c int) int {
+	if b < a {
+		a = b
+	}
+	if c < a {
+		a = c
+	}
+	return a
+}
@@ -0,0 +1,51 @@
+package approval
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestSuggest(t *testing.T) {
+	candidates := []string{"prod", "staging", "production", "dev"}
+	testCases := []struct {
+		name     string
+		expected []string
+	}{
+		{name: "prdo", expected: []string{"prod"}},
+		{name: "PROD", expected: []string{"prod"}},
+		{name: "stagign", expected: []string{"staging"}},
+		{name: "prodution", expected: []string{"production"}},
+		{name: "de", expected: []string{"dev"}},
+		{name: "qa"},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			actual := Suggest(testCase.name, candidates)
+			if strings.Join(actual, "|") != strings.Join(testCase.expected, "|") {
+				t.Fatalf("expected %v but got %v", testCase.expected, actual)
+			}
+		})
+	}
+}
+
+func TestLevenshtein(t *testing.T) {
+	testCases := []struct {
+		a, b     string
+		expected int
+	}{
+		{a: "prod", b: "prod", expected: 0},
+		{a: "prod", b: "prdo", expected: 1},
+		{a: "prod", b: "pro", expected: 1},
+		{a: "staging", b: "stage", expected: 3},
+		{a: "", b: "dev", expected: 3},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.a+"_"+testCase.b, func(t *testing.T) {
+			if actual := levenshtein(testCase.a, testCase.b); actual != testCase.expected {
+				t.Fatalf("expected %d but got %d", testCase.expected, actual)
+			}
+		})
+	}
+}
@@ -0,0 +1,151 @@
+package approval
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+	"unicode/utf8"
+)
+
+// Words are the words of a comment that decide an approval, and how
+// comments are matched against them. They match the whole comment, ignoring
+// case. Each gate has its own, so that gates in one process can accept
+// different words.
+type Words struct {
+	Approved []string
+	Denied   []string
+	Revoked  []string
+	Hold     []string
+	Unhold   []string
+	Mode     MatchMode
+}
+
+// DefaultWords returns the English words, matched strictly.
+func DefaultWords() Words {
+	return Words{
+		Approved: []string{"approved", "approve", "lgtm", "yes"},
+		Denied:   []string{"denied", "deny", "no"},
+		Revoked:  []string{"revoke", "/revoke", "unapprove", "/unapprove"},
+		Hold:     []string{"/hold"},
+		Unhold:   []string{"/unhold"},
+		Mode:     MatchStrict,
+	}
+}
+
+// MatchMode is how comments are matched against the words.
+type MatchMode string
+
+const (
+	// MatchStrict requires the comment to be exactly one of the words.
+	MatchStrict MatchMode = "strict"
+	// MatchLenient also accepts a word that starts a longer comment, such as
+	// "Approved, but please watch error rates".
+	MatchLenient MatchMode = "lenient"
+)
+
+// lenientMinLength is the length in characters a word needs to start a
+// longer comment when matching leniently. Shorter words such as "no" and
+// "yes" start too many sentences, as in "No objections from me, approve", so
+// they must stand alone.
+const lenientMinLength = 4
+
+// lenientSeparator is what must follow a word that starts a longer comment
+// when matching leniently: a period, exclamation marks, a comma, colon,
+// semicolon or dash, and then the rest of the line as a note. A trailing
+// ellipsis doesn't separate a note, so "approved... NOT" isn't an approval.
+const lenientSeparator = `(\.|!*|!*[,:;]|\s*[-\x{2013}\x{2014}])(\s.*)?`
+
+// matches reports whether the first line of the normalized comment is one
+// of the words followed by what suffix allows or, when matching leniently,
+// starts with one of the words followed by a separator and a note. The lines
+// below are a note and don't affect the match. A comment that quotes a
+// decision never matches, as it isn't clear which decision its author
+// meant.
+func (w Words) matches(words []string, suffix, commentBody string) (bool, error) {
+	commentBody, _, quoted := splitQuoted(commentBody)
+	if w.quotesDecision(quoted) {
+		return false, nil
+	}
+	return w.lineMatches(words, suffix, commentBody)
+}
+
+// quotesDecision reports whether one of the quoted lines of a comment is a
+// decision itself, as in "approved\n> deny".
+func (w Words) quotesDecision(quoted []string) bool {
+	for _, line := range quoted {
+		if open := strings.IndexByte(line, '['); open >= 0 {
+			line = line[:open]
+		}
+		line = stripFormatting(line)
+		for _, words := range [][]string{w.Approved, w.Denied, w.Revoked, w.Hold, w.Unhold} {
+			if matched, err := w.lineMatches(words, "[.!]*", line); err == nil && matched {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// lineMatches reports whether the line is one of the words followed by what
+// suffix allows or, when matching leniently, starts with one of them.
+func (w Words) lineMatches(words []string, suffix, line string) (bool, error) {
+	for _, word := range words {
+		patterns := []string{fmt.Sprintf("(?i)^%s%s$", regexp.QuoteMeta(word), suffix)}
+		if w.Mode == MatchLenient && utf8.RuneCountInString(word) >= lenientMinLength {
+			patterns = append(patterns, fmt.Sprintf(`(?i)^%s%s$`, regexp.QuoteMeta(word), lenientSeparator))
+		}
+		for _, pattern := range patterns {
+			matched, err := regexp.MatchString(pattern, line)
+			if err != nil {
+				return false, err
+			}
+			if matched {
+				return true, nil
+			}
+		}
+	}
+
+	return false, nil
+}
+
+// IsApproved reports whether the comment approves.
+func (w Words) IsApproved(commentBody string) (bool, error) {
+	return w.matches(w.Approved, "[.!]*\n*", commentBody)
+}
+
+// IsDenied reports whether the comment denies.
+func (w Words) IsDenied(commentBody string) (bool, error) {
+	return w.matches(w.Denied, "[.!]?", commentBody)
+}
+
+// IsRevoked reports whether the comment withdraws an earlier approval.
+func (w Words) IsRevoked(commentBody string) (bool, error) {
+	return w.matches(w.Revoked, "[.!]?\n*", commentBody)
+}
+
+// IsHold reports whether the comment puts the approval on hold.
+func (w Words) IsHold(commentBody string) (bool, error) {
+	return w.matches(w.Hold, "\n*", commentBody)
+}
+
+// IsUnhold reports whether the comment lifts a hold.
+func (w Words) IsUnhold(commentBody string) (bool, error) {
+	return w.matches(w.Unhold, "\n*", commentBody)
+}
+
+// FormatAcceptedWords quotes the words for the approval issue, with the
+// deployment names to approve if there are several.
+func FormatAcceptedWords(words []string, deploymentNames []string) string {
+	var quotedWords []string
+
+	var names string
+	if len(deploymentNames) > 1 {
+		names = "[" + strings.Join(deploymentNames, ",") + "]"
+	}
+
+	for _, word := range words {
+		quotedWords = append(quotedWords, fmt.Sprintf("\"%s%s\"", word, names))
+	}
+
+	return strings.Join(quotedWords, ", ")
+}
@@ -0,0 +1,128 @@
+package approval
+
+import "testing"
+
+func TestIsApproved(t *testing.T) {
+	testCases := []struct {
+		body     string
+		expected bool
+	}{
+		{body: "approve", expected: true},
+		{body: "LGTM!!", expected: true},
+		{body: "approved.\n", expected: true},
+		{body: "approve this later", expected: false},
+		{body: "approve\n\nWatch the error rates after rollout.", expected: true},
+		{body: "looks fine\napprove", expected: false},
+		{body: "deny", expected: false},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.body, func(t *testing.T) {
+			actual, err := DefaultWords().IsApproved(testCase.body)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if actual != testCase.expected {
+				t.Fatalf("expected %t but got %t", testCase.expected, actual)
+			}
+		})
+	}
+}
+
+func TestFormatAcceptedWords(t *testing.T) {
+	testCases := []struct {
+		name            string
+		words           []string
+		deploymentNames []string
+		expected        string
+	}{
+		{
+			name:     "words",
+			words:    []string{"approve", "lgtm"},
+			expected: `"approve", "lgtm"`,
+		},
+		{
+			name:            "single_deployment_name",
+			words:           []string{"approve"},
+			deploymentNames: []string{"prod"},
+			expected:        `"approve"`,
+		},
+		{
+			name:            "deployment_names",
+			words:           []string{"approve"},
+			deploymentNames: []string{"prod", "staging"},
+			expected:        `"approve[prod,staging]"`,
+		},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			actual := FormatAcceptedWords(testCase.words, testCase.deploymentNames)
+			if actual != testCase.expected {
+				t.Fatalf("expected %s but got %s", testCase.expected, actual)
+			}
+		})
+	}
+}
+
+func TestMatchMode(t *testing.T) {
+	testCases := []struct {
+		body     string
+		mode     MatchMode
+		approved bool
+		denied   bool
+	}{
+		{body: "approved", mode: MatchStrict, approved: true},
+		{body: "Approved, but please watch error rates after rollout", mode: MatchStrict},
+		{body: "Approved, but please watch error rates after rollout", mode: MatchLenient, approved: true},
+		{body: "lgtm\n\nNice work on the migration.", mode: MatchLenient, approved: true},
+		{body: "approvedish", mode: MatchLenient},
+		{body: "I approve", mode: MatchLenient},
+		{body: "Denied. The dashboards are red.", mode: MatchLenient, denied: true},
+		{body: "No. The dashboards are red.", mode: MatchLenient},
+		{body: "No objections from me, approve", mode: MatchLenient},
+		{body: "Yes, ship it", mode: MatchLenient},
+		{body: "no", mode: MatchLenient, denied: true},
+		{body: "Not yet", mode: MatchLenient},
+		{body: "approved... NOT", mode: MatchLenient},
+		{body: "approved...", mode: MatchStrict, approved: true},
+		{body: "approve - watch the error rates", mode: MatchLenient, approved: true},
+		{body: "Approved: rollout plan reviewed", mode: MatchLenient, approved: true},
+		{body: "approved\n> deny", mode: MatchStrict},
+		{body: "approved\n> deny", mode: MatchLenient},
+		{body: "> approved", mode: MatchStrict},
+		{body: "> approved", mode: MatchLenient},
+		{body: "> Can we ship today?\n\napproved", mode: MatchStrict, approved: true},
+		{body: "> Can we ship today?\n\nno", mode: MatchStrict, denied: true},
+		{body: "> approved\n\nWhy would you approve this? We are not ready.", mode: MatchLenient},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(string(testCase.mode)+"_"+testCase.body, func(t *testing.T) {
+			words := DefaultWords()
+			words.Mode = testCase.mode
+			approved, err := words.IsApproved(testCase.body)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			denied, err := words.IsDenied(testCase.body)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if approved != testCase.approved || denied != testCase.denied {
+				t.Fatalf("expected approved %t and denied %t but got %t and %t", testCase.approved, testCase.denied, approved, denied)
+			}
+		})
+	}
+}
+
+func TestWithPackLeavesWordsUnchanged(t *testing.T) {
+	english := DefaultWords()
+	spanish := english.WithPack(WordPack{Approved: []string{"aprobado"}})
+	if approved, _ := spanish.IsApproved("aprobado"); !approved {
+		t.Fatal("expected the words with the pack to accept its words")
+	}
+	if approved, _ := english.IsApproved("aprobado"); approved {
+		t.Fatal("expected the words without the pack to be left unchanged")
+	}
+}
@@ -0,0 +1,93 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"math/rand"
+	"time"
+
+	"github.com/google/go-github/v43/github"
+)
+
+// pollSchedule adapts the polling interval to the activity on the approval
+// issue: right after a new or edited comment it polls every
+// minPollingInterval, as a decision is likely to follow, and while the issue
+// stays quiet it backs off up to maxPollingInterval. Every interval is
+// jittered so that many gates started at once don't poll in lockstep.
+type pollSchedule struct {
+	interval time.Duration
+	activity string
+	random   *rand.Rand
+}
+
+func newPollSchedule(source rand.Source) *pollSchedule {
+	return &pollSchedule{
+		interval: pollingInterval,
+		random:   rand.New(source),
+	}
+}
+
+// commentActivity summarizes the comments so that a change between polls,
+// a new comment or an edit, can be detected.
+func commentActivity(comments []*github.IssueComment) string {
+	var latest time.Time
+	for _, comment := range comments {
+		if comment.GetUpdatedAt().After(latest) {
+			latest = comment.GetUpdatedAt()
+		}
+	}
+	return fmt.Sprintf("%d/%s", len(comments), latest.Format(time.RFC3339Nano))
+}
+
+// next returns how long to wait before polling again, given the comments
+// read by the poll that just finished.
+func (s *pollSchedule) next(comments []*github.IssueComment) time.Duration {
+	activity := commentActivity(comments)
+	switch {
+	case s.activity == "":
+	case activity != s.activity:
+		s.interval = minPollingInterval
+	default:
+		s.interval = s.interval * 3 / 2
+		if s.interval > maxPollingInterval {
+			s.interval = maxPollingInterval
+		}
+	}
+	s.activity = activity
+
+	jitter := time.Duration((s.random.Float64()*2 - 1) * pollingJitter * float64(s.interval))
+	return s.interval + jitter
+}
+
+// waitForNextPoll waits for interval, or less if a webhook reports activity
+// on the approval issue or, with companion-signal, the issue changes.
+func (a *approvalEnvironment) waitForNextPoll(ctx context.Context, interval time.Duration) {
+	timer := time.NewTimer(interval)
+	defer timer.Stop()
+	var watch <-chan time.Time
+	if a.companionSignal {
+		ticker := time.NewTicker(minPollingInterval)
+		defer ticker.Stop()
+		watch = ticker.C
+	}
+
+	for {
+		select {
+		case <-a.webhookDeliveries:
+			fmt.Println("Webhook received, polling now")
+			return
+		case <-watch:
+			changed, err := a.issueChanged(ctx)
+			if err != nil {
+				debugf("Checking the approval issue for changes failed: %v", err)
+				continue
+			}
+			if changed {
+				fmt.Println("Approval issue changed, polling now")
+				return
+			}
+		case <-timer.C:
+			return
+		}
+	}
+}
@@ -0,0 +1,55 @@
+package main
+
+import (
+	"math/rand"
+	"testing"
+	"time"
+
+	"github.com/google/go-github/v43/github"
+)
+
+func TestPollScheduleNext(t *testing.T) {
+	at := func(minutes int) *time.Time {
+		updatedAt := time.Date(2022, 1, 1, 0, minutes, 0, 0, time.UTC)
+		return &updatedAt
+	}
+	quiet := []*github.IssueComment{{ID: github.Int64(1), UpdatedAt: at(0)}}
+	commented := []*github.IssueComment{{ID: github.Int64(1), UpdatedAt: at(0)}, {ID: github.Int64(2), UpdatedAt: at(1)}}
+	edited := []*github.IssueComment{{ID: github.Int64(1), UpdatedAt: at(0)}, {ID: github.Int64(2), UpdatedAt: at(2)}}
+
+	polls := []struct {
+		comments []*github.IssueComment
+		expected time.Duration
+	}{
+		{comments: quiet, expected: pollingInterval},
+		{comments: quiet, expected: 15 * time.Second},
+		{comments: quiet, expected: 22500 * time.Millisecond},
+		{comments: commented, expected: minPollingInterval},
+		{comments: commented, expected: 7500 * time.Millisecond},
+		{comments: edited, expected: minPollingInterval},
+	}
+
+	schedule := newPollSchedule(rand.NewSource(1))
+	for i, poll := range polls {
+		actual := schedule.next(poll.comments)
+		margin := time.Duration(pollingJitter * float64(poll.expected))
+		if actual < poll.expected-margin || actual > poll.expected+margin {
+			t.Fatalf("poll %d: expected %s with jitter of at most %s but got %s", i, poll.expected, margin, actual)
+		}
+	}
+
+	for i := 0; i < 20; i++ {
+		schedule.next(quiet)
+	}
+	if actual := schedule.next(quiet); actual > maxPollingInterval+time.Duration(pollingJitter*float64(maxPollingInterval)) {
+		t.Fatalf("expected at most %s with jitter but got %s", maxPollingInterval, actual)
+	}
+}
+
+func TestPollScheduleJitter(t *testing.T) {
+	first := newPollSchedule(rand.NewSource(1))
+	second := newPollSchedule(rand.NewSource(2))
+	if first.next(nil) == second.next(nil) {
+		t.Fatal("expected gates with different random sources to poll at different intervals")
+	}
+}
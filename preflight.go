@@ -0,0 +1,84 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"regexp"
+	"strings"
+
+	"github.com/google/go-github/v43/github"
+)
+
+var (
+	// userLoginPattern matches GitHub user logins, including GitHub App bots
+	// such as dependabot[bot].
+	userLoginPattern = regexp.MustCompile(`^[A-Za-z0-9][A-Za-z0-9-]{0,38}(\[bot\])?$`)
+	// teamPattern matches org/team-slug.
+	teamPattern = regexp.MustCompile(`^[A-Za-z0-9][A-Za-z0-9-]{0,38}/[A-Za-z0-9_.-]+$`)
+)
+
+// invalidApprovers returns the approvers that are neither a user login nor
+// an org/team-slug, such as email addresses or names with spaces.
+func invalidApprovers(approvers []string) []string {
+	var invalid []string
+	for _, approver := range approvers {
+		if !userLoginPattern.MatchString(approver) && !teamPattern.MatchString(approver) {
+			invalid = append(invalid, approver)
+		}
+	}
+	return invalid
+}
+
+// validateDeploymentNames checks that multiple deployment names can be told
+// apart in an approval comment.
+func validateDeploymentNames(names []string) error {
+	seen := map[string]bool{}
+	for _, name := range names {
+		trimmed := strings.TrimSpace(name)
+		switch {
+		case trimmed == "":
+			return fmt.Errorf("multiple deployment names contain an empty name")
+		case strings.ContainsAny(trimmed, "[]"):
+			return fmt.Errorf("deployment name %q can't contain brackets", trimmed)
+		case seen[trimmed]:
+			return fmt.Errorf("deployment name %q is listed more than once", trimmed)
+		}
+		seen[trimmed] = true
+	}
+	return nil
+}
+
+// preflightRepository checks that the approval request can be made in the
+// repository before anything is created, so that a token without access, a
+// classic token without the scopes the enabled features need or a
+// repository with issues disabled fails with a clear message instead of a
+// bare API error. extraScopes are the classic token scopes needed by
+// optional features, on top of access to the repository.
+func (a *approvalEnvironment) preflightRepository(ctx context.Context, extraScopes ...string) error {
+	repo, resp, err := a.client.Repositories.Get(ctx, a.repoOwner, a.repo)
+	var errorResponse *github.ErrorResponse
+	if errors.As(err, &errorResponse) && errorResponse.Response.StatusCode == http.StatusNotFound {
+		return fmt.Errorf("repository %s was not found, check that the token has access to it", a.repoFullName)
+	}
+	if err != nil {
+		return fmt.Errorf("error getting repository %s: %w", a.repoFullName, err)
+	}
+	// Only classic tokens report their scopes. Other tokens have their
+	// missing permissions explained when a request is rejected.
+	if scopes, ok := resp.Header["X-Oauth-Scopes"]; ok {
+		repoScope := "public_repo"
+		if repo.GetPrivate() {
+			repoScope = "repo"
+		}
+		if missing := missingScopes(strings.Join(scopes, ","), append([]string{repoScope}, extraScopes...)); len(missing) > 0 {
+			return fmt.Errorf("the token is missing the %s scopes", strings.Join(missing, ", "))
+		}
+	}
+	usesIssues := a.createIssue && a.discussionCategory == "" && !a.pullRequestComment
+	if usesIssues && !repo.GetHasIssues() {
+		return fmt.Errorf("issues are disabled in %s, enable them or set create-issue to false", a.repoFullName)
+	}
+	return nil
+}
@@ -0,0 +1,42 @@
+package main
+
+import "testing"
+
+func TestInvalidApprovers(t *testing.T) {
+	invalid := invalidApprovers([]string{"login1", "my-org/my_team.slug", "dependabot[bot]", "user@example.com", "first last", "-login"})
+	expected := []string{"user@example.com", "first last", "-login"}
+	if len(invalid) != len(expected) {
+		t.Fatalf("expected %v but got %v", expected, invalid)
+	}
+	for i := range expected {
+		if invalid[i] != expected[i] {
+			t.Fatalf("expected %v but got %v", expected, invalid)
+		}
+	}
+}
+
+func TestValidateDeploymentNames(t *testing.T) {
+	testCases := []struct {
+		name    string
+		names   []string
+		isError bool
+	}{
+		{name: "valid", names: []string{"staging", "production"}},
+		{name: "none", names: nil},
+		{name: "empty", names: []string{"staging", ""}, isError: true},
+		{name: "duplicate", names: []string{"staging", "staging"}, isError: true},
+		{name: "brackets", names: []string{"[staging]"}, isError: true},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			err := validateDeploymentNames(testCase.names)
+			if testCase.isError && err == nil {
+				t.Fatal("expected error")
+			}
+			if !testCase.isError && err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+		})
+	}
+}
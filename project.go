@@ -0,0 +1,129 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"strings"
+)
+
+const (
+	projectQuery = `query($owner: String!, $number: Int!) {
+  repositoryOwner(login: $owner) {
+    ... on ProjectV2Owner {
+      projectV2(number: $number) {
+        id
+        field(name: "Status") {
+          ... on ProjectV2SingleSelectField {
+            id
+            options { id name }
+          }
+        }
+      }
+    }
+  }
+}`
+
+	addProjectItemMutation = `mutation($projectId: ID!, $contentId: ID!) {
+  addProjectV2ItemById(input: {projectId: $projectId, contentId: $contentId}) {
+    item { id }
+  }
+}`
+
+	updateProjectItemStatusMutation = `mutation($projectId: ID!, $itemId: ID!, $fieldId: ID!, $optionId: String!) {
+  updateProjectV2ItemFieldValue(input: {projectId: $projectId, itemId: $itemId, fieldId: $fieldId, value: {singleSelectOptionId: $optionId}}) {
+    projectV2Item { id }
+  }
+}`
+)
+
+// projectItem tracks the approval issue on a Projects v2 board.
+type projectItem struct {
+	projectID     string
+	itemID        string
+	statusFieldID string
+	// statusOptions maps the lowercase names of the options of the Status
+	// field to their IDs.
+	statusOptions map[string]string
+}
+
+// addToProject adds the approval issue to the project and, when status is
+// set, moves it to that status.
+func (a *approvalEnvironment) addToProject(ctx context.Context, owner string, number int, status string) error {
+	if a.approvalIssue == nil {
+		return fmt.Errorf("only approval issues can be added to a project")
+	}
+
+	var project struct {
+		RepositoryOwner *struct {
+			ProjectV2 *struct {
+				ID    string `json:"id"`
+				Field *struct {
+					ID      string `json:"id"`
+					Options []struct {
+						ID   string `json:"id"`
+						Name string `json:"name"`
+					} `json:"options"`
+				} `json:"field"`
+			} `json:"projectV2"`
+		} `json:"repositoryOwner"`
+	}
+	if err := a.graphQL(ctx, projectQuery, map[string]interface{}{
+		"owner":  owner,
+		"number": number,
+	}, &project); err != nil {
+		return err
+	}
+	if project.RepositoryOwner == nil || project.RepositoryOwner.ProjectV2 == nil {
+		return fmt.Errorf("project %d of %s not found", number, owner)
+	}
+
+	item := &projectItem{
+		projectID:     project.RepositoryOwner.ProjectV2.ID,
+		statusOptions: make(map[string]string),
+	}
+	if field := project.RepositoryOwner.ProjectV2.Field; field != nil {
+		item.statusFieldID = field.ID
+		for _, option := range field.Options {
+			item.statusOptions[strings.ToLower(option.Name)] = option.ID
+		}
+	}
+
+	var added struct {
+		AddProjectV2ItemByID struct {
+			Item struct {
+				ID string `json:"id"`
+			} `json:"item"`
+		} `json:"addProjectV2ItemById"`
+	}
+	if err := a.graphQL(ctx, addProjectItemMutation, map[string]interface{}{
+		"projectId": item.projectID,
+		"contentId": a.approvalIssue.GetNodeID(),
+	}, &added); err != nil {
+		return err
+	}
+	item.itemID = added.AddProjectV2ItemByID.Item.ID
+	a.projectItem = item
+
+	if status == "" {
+		return nil
+	}
+	return a.setProjectStatus(ctx, status)
+}
+
+// setProjectStatus sets the Status field of the approval issue's project
+// item to the option with the given name.
+func (a *approvalEnvironment) setProjectStatus(ctx context.Context, status string) error {
+	if a.projectItem == nil {
+		return nil
+	}
+	optionID, ok := a.projectItem.statusOptions[strings.ToLower(status)]
+	if a.projectItem.statusFieldID == "" || !ok {
+		return fmt.Errorf("project has no %q status", status)
+	}
+	return a.graphQL(ctx, updateProjectItemStatusMutation, map[string]interface{}{
+		"projectId": a.projectItem.projectID,
+		"itemId":    a.projectItem.itemID,
+		"fieldId":   a.projectItem.statusFieldID,
+		"optionId":  optionID,
+	}, nil)
+}
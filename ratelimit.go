@@ -0,0 +1,148 @@
+package main
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// githubRateLimits tracks the rate limits reported on every response of the
+// GitHub client.
+var githubRateLimits = newRateLimitTracker()
+
+// rateLimit is the state of one rate limit resource, such as core or
+// graphql, as of the last response that reported it.
+type rateLimit struct {
+	resource  string
+	limit     int
+	remaining int
+	reset     time.Time
+}
+
+// rateLimitTracker records the rate limits reported by GitHub and how much
+// of them was consumed since the action started.
+type rateLimitTracker struct {
+	mu       sync.Mutex
+	limits   map[string]rateLimit
+	consumed int
+	warned   map[string]time.Time
+}
+
+func newRateLimitTracker() *rateLimitTracker {
+	return &rateLimitTracker{
+		limits: make(map[string]rateLimit),
+		warned: make(map[string]time.Time),
+	}
+}
+
+// parseRateLimit reads the rate limit headers of a response. ok is false
+// when the response doesn't report a rate limit.
+func parseRateLimit(header http.Header) (rateLimit, bool) {
+	limit, err := strconv.Atoi(header.Get("X-RateLimit-Limit"))
+	if err != nil {
+		return rateLimit{}, false
+	}
+	remaining, err := strconv.Atoi(header.Get("X-RateLimit-Remaining"))
+	if err != nil {
+		return rateLimit{}, false
+	}
+	reset, err := strconv.ParseInt(header.Get("X-RateLimit-Reset"), 10, 64)
+	if err != nil {
+		return rateLimit{}, false
+	}
+	resource := header.Get("X-RateLimit-Resource")
+	if resource == "" {
+		resource = "core"
+	}
+	return rateLimit{resource: resource, limit: limit, remaining: remaining, reset: time.Unix(reset, 0)}, true
+}
+
+// record updates the tracker with the rate limit of a response. What was
+// consumed is the drop in the remaining quota since the previous response in
+// the same window, or a single request for the first response of a window.
+// Requests made with the same token by other workflows are counted as well.
+func (t *rateLimitTracker) record(current rateLimit) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	previous, seen := t.limits[current.resource]
+	if seen && previous.reset.Equal(current.reset) {
+		if current.remaining >= previous.remaining {
+			// Responses can arrive out of order, keep the lowest count.
+			return
+		}
+		t.consumed += previous.remaining - current.remaining
+	} else {
+		t.consumed++
+	}
+	t.limits[current.resource] = current
+
+	if current.limit > 0 && float64(current.remaining) < float64(current.limit)*rateLimitWarningFraction && !t.warned[current.resource].Equal(current.reset) {
+		t.warned[current.resource] = current.reset
+		fmt.Printf("::warning::Only %d of %d %s API requests remain until %s, polling will slow down to stay within the rate limit\n", current.remaining, current.limit, current.resource, current.reset.UTC().Format(time.RFC3339))
+	}
+}
+
+// consumedRequests is how much of the rate limits was consumed since the
+// action started.
+func (t *rateLimitTracker) consumedRequests() int {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return t.consumed
+}
+
+// pollInterval stretches the polling interval so that the remaining quota of
+// every resource lasts until it resets, given what a single poll consumes.
+// Once only rateLimitReserve is left, it waits for the reset, keeping the
+// reserve for closing the approval issue.
+func (t *rateLimitTracker) pollInterval(interval time.Duration, costPerPoll int, now time.Time) time.Duration {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if costPerPoll < 1 {
+		costPerPoll = 1
+	}
+	stretched := interval
+	for _, limit := range t.limits {
+		untilReset := limit.reset.Sub(now)
+		if untilReset <= 0 {
+			continue
+		}
+		var wait time.Duration
+		polls := (limit.remaining - rateLimitReserve) / costPerPoll
+		if polls < 1 {
+			// The reset time has a resolution of a second.
+			wait = untilReset + time.Second
+		} else {
+			wait = untilReset / time.Duration(polls)
+		}
+		if wait > stretched {
+			stretched = wait
+		}
+	}
+	return stretched
+}
+
+// rateLimitTransport records the rate limits of every response.
+type rateLimitTransport struct {
+	base    http.RoundTripper
+	tracker *rateLimitTracker
+}
+
+func (t rateLimitTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	resp, err := t.base.RoundTrip(req)
+	if err != nil {
+		return resp, err
+	}
+	if limit, ok := parseRateLimit(resp.Header); ok {
+		t.tracker.record(limit)
+	}
+	return resp, nil
+}
+
+// setRateLimitOutput sets the rate-limit-consumed output.
+func setRateLimitOutput() {
+	setOutput("rate-limit-consumed", strconv.Itoa(githubRateLimits.consumedRequests()))
+}
@@ -0,0 +1,73 @@
+package main
+
+import (
+	"net/http"
+	"testing"
+	"time"
+)
+
+func TestParseRateLimit(t *testing.T) {
+	header := http.Header{}
+	if _, ok := parseRateLimit(header); ok {
+		t.Fatal("expected no rate limit without headers")
+	}
+
+	header.Set("X-RateLimit-Limit", "5000")
+	header.Set("X-RateLimit-Remaining", "4999")
+	header.Set("X-RateLimit-Reset", "1640995200")
+	limit, ok := parseRateLimit(header)
+	if !ok {
+		t.Fatal("expected a rate limit")
+	}
+	if limit.resource != "core" || limit.limit != 5000 || limit.remaining != 4999 || !limit.reset.Equal(time.Unix(1640995200, 0)) {
+		t.Fatalf("unexpected rate limit %+v", limit)
+	}
+
+	header.Set("X-RateLimit-Resource", "graphql")
+	limit, _ = parseRateLimit(header)
+	if limit.resource != "graphql" {
+		t.Fatalf("expected graphql resource but got %s", limit.resource)
+	}
+}
+
+func TestRateLimitTrackerConsumed(t *testing.T) {
+	reset := time.Unix(1640995200, 0)
+	tracker := newRateLimitTracker()
+	tracker.record(rateLimit{resource: "core", limit: 5000, remaining: 4999, reset: reset})
+	tracker.record(rateLimit{resource: "core", limit: 5000, remaining: 4995, reset: reset})
+	tracker.record(rateLimit{resource: "core", limit: 5000, remaining: 4997, reset: reset})
+	tracker.record(rateLimit{resource: "graphql", limit: 5000, remaining: 4990, reset: reset})
+	tracker.record(rateLimit{resource: "core", limit: 5000, remaining: 4999, reset: reset.Add(time.Hour)})
+
+	if consumed := tracker.consumedRequests(); consumed != 7 {
+		t.Fatalf("expected 7 consumed but got %d", consumed)
+	}
+}
+
+func TestRateLimitTrackerPollInterval(t *testing.T) {
+	now := time.Unix(1640995200, 0)
+	testCases := []struct {
+		name        string
+		remaining   int
+		costPerPoll int
+		expected    time.Duration
+	}{
+		{name: "plenty_remaining", remaining: 4000, costPerPoll: 1, expected: pollingInterval},
+		{name: "stretched", remaining: rateLimitReserve + 60, costPerPoll: 1, expected: time.Minute},
+		{name: "stretched_by_cost", remaining: rateLimitReserve + 60, costPerPoll: 2, expected: 2 * time.Minute},
+		{name: "reserve_only", remaining: rateLimitReserve, costPerPoll: 1, expected: time.Hour + time.Second},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			tracker := newRateLimitTracker()
+			tracker.limits["core"] = rateLimit{resource: "core", limit: 5000, remaining: testCase.remaining, reset: now.Add(time.Hour)}
+			tracker.limits["graphql"] = rateLimit{resource: "graphql", limit: 5000, remaining: 0, reset: now.Add(-time.Minute)}
+
+			actual := tracker.pollInterval(pollingInterval, testCase.costPerPoll, now)
+			if actual != testCase.expected {
+				t.Fatalf("expected %s but got %s", testCase.expected, actual)
+			}
+		})
+	}
+}
@@ -0,0 +1,34 @@
+package main
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"strconv"
+	"strings"
+)
+
+// receiptVersion is the first line of the receipt message, so that the
+// message can change without old receipts verifying against new ones.
+const receiptVersion string = "manual-approval-receipt/v1"
+
+// receiptMessage is what the receipt signs: the version, run ID, SHA, gate
+// name and decision, one per line.
+func receiptMessage(runID int, sha, gateName string, status approvalStatus) string {
+	return strings.Join([]string{
+		receiptVersion,
+		strconv.Itoa(runID),
+		sha,
+		gateName,
+		strings.ToLower(string(status)),
+	}, "\n")
+}
+
+// approvalReceipt is the hex encoded HMAC-SHA256 of the receipt message with
+// the secret. A downstream job that knows the secret can recompute it to
+// check that the decision came from the gate.
+func approvalReceipt(secret string, runID int, sha, gateName string, status approvalStatus) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(receiptMessage(runID, sha, gateName, status)))
+	return hex.EncodeToString(mac.Sum(nil))
+}
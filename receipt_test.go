@@ -0,0 +1,37 @@
+package main
+
+import "testing"
+
+func TestApprovalReceipt(t *testing.T) {
+	receipt := approvalReceipt("secret", 1, "0123456789abcdef", "production", approvalStatusApproved)
+	if len(receipt) != 64 {
+		t.Fatalf("expected a hex SHA-256 HMAC but got %q", receipt)
+	}
+	if receipt != approvalReceipt("secret", 1, "0123456789abcdef", "production", approvalStatusApproved) {
+		t.Fatalf("expected the receipt to be deterministic")
+	}
+
+	testCases := []struct {
+		name     string
+		secret   string
+		runID    int
+		sha      string
+		gateName string
+		status   approvalStatus
+	}{
+		{name: "other_secret", secret: "other", runID: 1, sha: "0123456789abcdef", gateName: "production", status: approvalStatusApproved},
+		{name: "other_run", secret: "secret", runID: 2, sha: "0123456789abcdef", gateName: "production", status: approvalStatusApproved},
+		{name: "other_sha", secret: "secret", runID: 1, sha: "fedcba9876543210", gateName: "production", status: approvalStatusApproved},
+		{name: "other_gate", secret: "secret", runID: 1, sha: "0123456789abcdef", gateName: "staging", status: approvalStatusApproved},
+		{name: "other_decision", secret: "secret", runID: 1, sha: "0123456789abcdef", gateName: "production", status: approvalStatusDenied},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			actual := approvalReceipt(testCase.secret, testCase.runID, testCase.sha, testCase.gateName, testCase.status)
+			if actual == receipt {
+				t.Fatalf("expected a different receipt")
+			}
+		})
+	}
+}
@@ -0,0 +1,227 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/url"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/google/go-github/v43/github"
+)
+
+// minimumMaskLength keeps short values, such as a query parameter set to
+// "1", from being masked everywhere in the log.
+const minimumMaskLength int = 4
+
+// headerSecrets returns the values to mask for an authorization header: the
+// header itself and its credential, e.g. both "Bearer abc123" and "abc123",
+// as the credential alone can end up in an error message.
+func headerSecrets(header string) []string {
+	header = strings.TrimSpace(header)
+	if header == "" {
+		return nil
+	}
+	secrets := []string{header}
+	fields := strings.FieldsFunc(header, func(r rune) bool { return r == ' ' || r == '=' })
+	if len(fields) == 0 {
+		return secrets
+	}
+	if credential := fields[len(fields)-1]; credential != header {
+		secrets = append(secrets, credential)
+	}
+	return secrets
+}
+
+// urlSecrets returns the password and query values of a URL, which often
+// carry API keys.
+func urlSecrets(raw string) []string {
+	parsed, err := url.Parse(raw)
+	if err != nil {
+		return nil
+	}
+	var secrets []string
+	if password, ok := parsed.User.Password(); ok {
+		secrets = append(secrets, password)
+	}
+	for _, values := range parsed.Query() {
+		secrets = append(secrets, values...)
+	}
+	return secrets
+}
+
+// maskCommand is the workflow command registering a mask with the runner.
+// Its lines are the only ones of the output that are not redacted.
+const maskCommand string = "::add-mask::"
+
+// maskSecrets registers secrets with secretRedactor, and with the runner,
+// which then replaces them with *** in everything the action logs
+// afterwards. Masks are only registered with the runner when running in
+// GitHub Actions, as elsewhere the command would print the secret.
+func maskSecrets(secrets ...string) {
+	for _, secret := range secrets {
+		for _, line := range strings.Split(secret, "\n") {
+			line = strings.TrimSpace(line)
+			if len(line) < minimumMaskLength {
+				continue
+			}
+			secretRedactor.add(line)
+			if os.Getenv(envVarGitHubActions) == "true" {
+				fmt.Printf("%s%s\n", maskCommand, line)
+			}
+		}
+	}
+}
+
+// secretRedactor replaces the secrets given to the action with *** in what
+// the gate logs, in its outputs and in the issues and comments it writes,
+// so they don't depend on the runner's masks, which don't apply outside the
+// log.
+var secretRedactor = &redactor{}
+
+// redactor replaces secrets with *** in text.
+type redactor struct {
+	mu       sync.Mutex
+	secrets  []string
+	replacer *strings.Replacer
+}
+
+func (r *redactor) add(secrets ...string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.secrets = append(r.secrets, secrets...)
+	// The longest secret is replaced first, so that a header is redacted
+	// as a whole rather than only its credential.
+	sort.SliceStable(r.secrets, func(i, j int) bool { return len(r.secrets[i]) > len(r.secrets[j]) })
+	var oldnew []string
+	for _, secret := range r.secrets {
+		oldnew = append(oldnew, secret, "***")
+	}
+	r.replacer = strings.NewReplacer(oldnew...)
+}
+
+func (r *redactor) redact(s string) string {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.replacer == nil {
+		return s
+	}
+	return r.replacer.Replace(s)
+}
+
+// redactError returns err with the secrets in its message redacted. Errors
+// without secrets are returned as is, so they can still be unwrapped.
+func (r *redactor) redactError(err error) error {
+	if err == nil {
+		return nil
+	}
+	message := err.Error()
+	if redacted := r.redact(message); redacted != message {
+		return errors.New(redacted)
+	}
+	return err
+}
+
+// redactLines copies src to dst a line at a time, redacting every line but
+// mask commands.
+func (r *redactor) redactLines(dst io.Writer, src io.Reader) error {
+	reader := bufio.NewReader(src)
+	for {
+		line, err := reader.ReadString('\n')
+		if !strings.HasPrefix(line, maskCommand) {
+			line = r.redact(line)
+		}
+		if _, writeErr := io.WriteString(dst, line); writeErr != nil {
+			return writeErr
+		}
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+	}
+}
+
+// flushOutput waits for the redacted output to be written. It is replaced
+// by redactOutput.
+var flushOutput = func() {}
+
+// redactOutput sends everything the gate prints through secretRedactor
+// before it reaches the log.
+func redactOutput() error {
+	reader, writer, err := os.Pipe()
+	if err != nil {
+		return err
+	}
+	stdout := os.Stdout
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		if err := secretRedactor.redactLines(stdout, reader); err != nil {
+			fmt.Fprintf(os.Stderr, "error writing output: %v\n", err)
+		}
+	}()
+	os.Stdout = writer
+	flushOutput = func() {
+		os.Stdout = stdout
+		writer.Close()
+		<-done
+	}
+	return nil
+}
+
+// exit flushes the redacted output and exits with code.
+func exit(code int) {
+	flushOutput()
+	os.Exit(code)
+}
+
+// redactingIssues redacts the bodies of the issues and comments the gate
+// writes, and the errors the API returns.
+type redactingIssues struct {
+	issuesService
+}
+
+func (r redactingIssues) Create(ctx context.Context, owner string, repo string, issue *github.IssueRequest) (*github.Issue, *github.Response, error) {
+	created, resp, err := r.issuesService.Create(ctx, owner, repo, redactIssueRequest(issue))
+	return created, resp, secretRedactor.redactError(err)
+}
+
+func (r redactingIssues) CreateComment(ctx context.Context, owner string, repo string, number int, comment *github.IssueComment) (*github.IssueComment, *github.Response, error) {
+	created, resp, err := r.issuesService.CreateComment(ctx, owner, repo, number, redactComment(comment))
+	return created, resp, secretRedactor.redactError(err)
+}
+
+func (r redactingIssues) Edit(ctx context.Context, owner string, repo string, number int, issue *github.IssueRequest) (*github.Issue, *github.Response, error) {
+	edited, resp, err := r.issuesService.Edit(ctx, owner, repo, number, redactIssueRequest(issue))
+	return edited, resp, secretRedactor.redactError(err)
+}
+
+func (r redactingIssues) EditComment(ctx context.Context, owner string, repo string, commentID int64, comment *github.IssueComment) (*github.IssueComment, *github.Response, error) {
+	edited, resp, err := r.issuesService.EditComment(ctx, owner, repo, commentID, redactComment(comment))
+	return edited, resp, secretRedactor.redactError(err)
+}
+
+func redactIssueRequest(issue *github.IssueRequest) *github.IssueRequest {
+	if issue == nil || issue.Body == nil {
+		return issue
+	}
+	redacted := *issue
+	redacted.Body = github.String(secretRedactor.redact(*issue.Body))
+	return &redacted
+}
+
+func redactComment(comment *github.IssueComment) *github.IssueComment {
+	if comment == nil || comment.Body == nil {
+		return comment
+	}
+	redacted := *comment
+	redacted.Body = github.String(secretRedactor.redact(*comment.Body))
+	return &redacted
+}
@@ -0,0 +1,91 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/google/go-github/v43/github"
+)
+
+const (
+	// rerunBehaviorNew creates a new approval issue for each run attempt,
+	// unless the issue of an earlier attempt is still open.
+	rerunBehaviorNew string = "new"
+	// rerunBehaviorReuse carries the approval issue of an earlier attempt of
+	// the run over to a re-run.
+	rerunBehaviorReuse string = "reuse"
+)
+
+// latestAttemptIssue returns the issue whose marker has the highest run
+// attempt below attempt, or nil if there is none. Issues closed as
+// duplicates are skipped.
+func latestAttemptIssue(issues []*github.Issue, attempt int) *github.Issue {
+	var latest *github.Issue
+	latestAttempt := 0
+	for _, issue := range issues {
+		marker, ok := parseIssueMarker(issue.GetBody())
+		if !ok || marker.SupersededBy != 0 || marker.RunAttempt >= attempt {
+			continue
+		}
+		if latest == nil || marker.RunAttempt > latestAttempt {
+			latest = issue
+			latestAttempt = marker.RunAttempt
+		}
+	}
+	return latest
+}
+
+// previousAttemptIssue returns the approval issue created for the same gate
+// by the latest earlier attempt of this run, or nil if there is none.
+func (a *approvalEnvironment) previousAttemptIssue(ctx context.Context) (*github.Issue, error) {
+	issues, err := a.findApprovalIssues(ctx, "all", approvalCacheSearchPages, func(marker issueMarker) bool {
+		return marker.RunID == a.runID && marker.Gate == a.gateName
+	})
+	if err != nil {
+		return nil, err
+	}
+	return latestAttemptIssue(issues, a.runAttempt), nil
+}
+
+// reusePreviousAttempt carries the approval issue of an earlier attempt of
+// the run over to this attempt. If that issue is still open, for example
+// because the runner of the earlier attempt was evicted, this attempt waits
+// on it, decisions made during earlier attempts keep counting and the gate
+// state left by the earlier attempt, including its deadline, is restored. If
+// it was approved and reuseApproval is set, the approval status and
+// deployment names are returned, with the issue set as the approval issue.
+// Otherwise a new approval issue is needed and pending is returned with no
+// issue set.
+func (a *approvalEnvironment) reusePreviousAttempt(ctx context.Context, reuseApproval bool) (approvalStatus, []string, error) {
+	issue, err := a.previousAttemptIssue(ctx)
+	if err != nil || issue == nil {
+		return approvalStatusPending, nil, err
+	}
+
+	if issue.GetState() == "closed" {
+		if !reuseApproval {
+			return approvalStatusPending, nil, nil
+		}
+		approved, deploymentNames, err := a.closedIssueApproval(ctx, issue)
+		if err != nil {
+			fmt.Printf("ignoring approval issue %d of an earlier attempt: %v\n", issue.GetNumber(), err)
+			return approvalStatusPending, nil, nil
+		}
+		if approved == approvalStatusApproved {
+			a.approvalIssue = issue
+			return approved, deploymentNames, nil
+		}
+		return approvalStatusPending, nil, nil
+	}
+
+	fmt.Printf("Waiting for approval on issue %s of an earlier attempt of this run\n", issue.GetHTMLURL())
+	a.approvalIssue = issue
+	a.approvalIssueNumber = issue.GetNumber()
+	a.requestedAt = issue.GetCreatedAt()
+	a.runAttemptStartedAt = time.Time{}
+	if err := a.loadGateState(ctx); err != nil {
+		return approvalStatusPending, nil, err
+	}
+	return approvalStatusPending, nil, nil
+}
@@ -0,0 +1,170 @@
+package main
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/google/go-github/v43/github"
+)
+
+func TestLatestAttemptIssue(t *testing.T) {
+	issue := func(number, attempt int, supersededBy int) *github.Issue {
+		marker := issueMarker{RunID: 1234, RunAttempt: attempt, SupersededBy: supersededBy}
+		return &github.Issue{Number: github.Int(number), Body: github.String(marker.String())}
+	}
+
+	testCases := []struct {
+		name     string
+		issues   []*github.Issue
+		attempt  int
+		expected int
+	}{
+		{
+			name:     "no_issues",
+			attempt:  2,
+			expected: 0,
+		},
+		{
+			name:     "latest_earlier_attempt",
+			issues:   []*github.Issue{issue(1, 1, 0), issue(3, 3, 0), issue(2, 2, 0)},
+			attempt:  3,
+			expected: 2,
+		},
+		{
+			name:     "skips_duplicates",
+			issues:   []*github.Issue{issue(1, 1, 0), issue(2, 1, 1)},
+			attempt:  2,
+			expected: 1,
+		},
+		{
+			name:     "only_current_attempt",
+			issues:   []*github.Issue{issue(1, 2, 0)},
+			attempt:  2,
+			expected: 0,
+		},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			if actual := latestAttemptIssue(testCase.issues, testCase.attempt).GetNumber(); actual != testCase.expected {
+				t.Fatalf("expected issue %d but got %d", testCase.expected, actual)
+			}
+		})
+	}
+}
+
+func TestReusePreviousAttempt(t *testing.T) {
+	testCases := []struct {
+		name          string
+		approver      string
+		reuseApproval bool
+		expected      approvalStatus
+	}{
+		{name: "approval_after_the_first_page", approver: "user1", reuseApproval: true, expected: approvalStatusApproved},
+		{name: "bot_approval", approver: "deploy-bot[bot]", reuseApproval: true, expected: approvalStatusPending},
+		{name: "approval_not_reused", approver: "user1", reuseApproval: false, expected: approvalStatusPending},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			ctx := context.Background()
+			t.Setenv(envVarOutput, filepath.Join(t.TempDir(), "output"))
+			fake := newFakeGitHub()
+			attempt := func(runAttempt int) *approvalEnvironment {
+				apprv, err := newApprovalEnvironment(fake.client(), "owner/repo", "owner", 1234, []string{"user1", "deploy-bot[bot]"}, 1, nil)
+				if err != nil {
+					t.Fatalf("unexpected error: %v", err)
+				}
+				apprv.runAttempt = runAttempt
+				return apprv
+			}
+
+			first := attempt(1)
+			if err := first.createApprovalIssue(ctx); err != nil {
+				t.Fatalf("error creating approval issue: %v", err)
+			}
+			for i := 0; i < 40; i++ {
+				fake.comment(first.approvalIssueNumber, "user2", "Looking into it")
+			}
+			fake.comment(first.approvalIssueNumber, testCase.approver, "approve")
+			if err := first.resolveApproval(ctx, approvalStatusApproved, "Closing issue."); err != nil {
+				t.Fatalf("error closing issue: %v", err)
+			}
+
+			approved, _, err := attempt(2).reusePreviousAttempt(ctx, testCase.reuseApproval)
+			if err != nil {
+				t.Fatalf("error reusing the earlier attempt: %v", err)
+			}
+			if approved != testCase.expected {
+				t.Fatalf("expected %s but got %s", testCase.expected, approved)
+			}
+		})
+	}
+}
+
+func TestResumePreviousAttempt(t *testing.T) {
+	ctx := context.Background()
+	fake := newFakeGitHub()
+	attempt := func(runAttempt int, maxWait time.Duration) *approvalEnvironment {
+		apprv, err := newApprovalEnvironment(fake.client(), "owner/repo", "owner", 1234, []string{"user1", "user2"}, 2, nil)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		apprv.runAttempt = runAttempt
+		apprv.maxWait = maxWait
+		return apprv
+	}
+
+	// The runner of the first attempt is evicted while it waits.
+	first := attempt(1, time.Hour)
+	if err := first.createApprovalIssue(ctx); err != nil {
+		t.Fatalf("error creating approval issue: %v", err)
+	}
+	if err := first.saveWaitDeadline(ctx); err != nil {
+		t.Fatalf("error saving the wait deadline: %v", err)
+	}
+	fake.comment(first.approvalIssueNumber, "user1", "approve")
+
+	// The re-run resumes the open issue even though it doesn't reuse
+	// approvals, and keeps the deadline of the first attempt.
+	second := attempt(2, 10*time.Hour)
+	second.runAttemptStartedAt = time.Now().Add(time.Minute)
+	approved, _, err := second.reusePreviousAttempt(ctx, false)
+	if err != nil {
+		t.Fatalf("error resuming the earlier attempt: %v", err)
+	}
+	if approved != approvalStatusPending || second.approvalIssueNumber != first.approvalIssueNumber {
+		t.Fatalf("expected to wait on issue #%d but got %s on #%d", first.approvalIssueNumber, approved, second.approvalIssueNumber)
+	}
+	if deadline := second.waitDeadline(); !deadline.Equal(first.waitDeadline()) {
+		t.Fatalf("expected the deadline %s of the first attempt but got %s", first.waitDeadline(), deadline)
+	}
+	if reason := second.waitExceeded(1, first.waitDeadline()); reason == "" {
+		t.Fatal("expected the re-run to time out at the deadline of the first attempt")
+	}
+	if err := second.saveWaitDeadline(ctx); err != nil {
+		t.Fatalf("error saving the wait deadline: %v", err)
+	}
+
+	comments, err := second.approvalComments(ctx)
+	if err != nil {
+		t.Fatalf("error getting comments: %v", err)
+	}
+	status, _, err := approvalFromComments(comments, []string{"user1", "user2"}, 2, nil)
+	if err != nil {
+		t.Fatalf("error evaluating comments: %v", err)
+	}
+	if status != approvalStatusPending {
+		t.Fatalf("expected the first approval to keep counting while pending but got %s", status)
+	}
+	fake.comment(first.approvalIssueNumber, "user2", "approve")
+	comments, err = second.approvalComments(ctx)
+	if err != nil {
+		t.Fatalf("error getting comments: %v", err)
+	}
+	if status, _, _ = approvalFromComments(comments, []string{"user1", "user2"}, 2, nil); status != approvalStatusApproved {
+		t.Fatalf("expected the approval of the first attempt to count but got %s", status)
+	}
+}
@@ -0,0 +1,96 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// secondaryRateLimitDelay is how long a request rejected by a secondary rate
+// limit waits for retrying, if the response doesn't say. GitHub asks for at
+// least a minute.
+const secondaryRateLimitDelay time.Duration = time.Minute
+
+// maxSecondaryRateLimitRetries bounds how often a single request is retried
+// after being rejected by a secondary rate limit.
+const maxSecondaryRateLimitRetries int = 5
+
+// secondaryRateLimitDelayFor returns how long to wait before retrying a
+// request whose response was a secondary rate limit, or abuse detection,
+// error. ok is false for any other response, including the primary rate
+// limit running out.
+func secondaryRateLimitDelayFor(resp *http.Response, body []byte, now time.Time) (time.Duration, bool) {
+	if resp.StatusCode != http.StatusForbidden && resp.StatusCode != http.StatusTooManyRequests {
+		return 0, false
+	}
+
+	if retryAfter := resp.Header.Get("Retry-After"); retryAfter != "" {
+		if seconds, err := strconv.Atoi(retryAfter); err == nil {
+			return time.Duration(seconds) * time.Second, true
+		}
+		if date, err := http.ParseTime(retryAfter); err == nil {
+			if delay := date.Sub(now); delay > 0 {
+				return delay, true
+			}
+			return 0, true
+		}
+	}
+
+	message := strings.ToLower(string(body))
+	if resp.StatusCode == http.StatusTooManyRequests || strings.Contains(message, "secondary rate limit") || strings.Contains(message, "abuse") {
+		return secondaryRateLimitDelay, true
+	}
+	return 0, false
+}
+
+// secondaryRateLimitTransport retries requests rejected by a secondary rate
+// limit after the delay GitHub asks for. Many gates polling with the same
+// token at once run into these limits, and they pass within minutes.
+type secondaryRateLimitTransport struct {
+	base http.RoundTripper
+}
+
+func (t secondaryRateLimitTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	for retries := 0; ; retries++ {
+		resp, err := t.base.RoundTrip(req)
+		if err != nil || retries == maxSecondaryRateLimitRetries {
+			return resp, err
+		}
+		if resp.StatusCode != http.StatusForbidden && resp.StatusCode != http.StatusTooManyRequests {
+			return resp, nil
+		}
+
+		body, err := io.ReadAll(resp.Body)
+		resp.Body.Close()
+		if err != nil {
+			return nil, err
+		}
+		resp.Body = io.NopCloser(bytes.NewReader(body))
+		delay, ok := secondaryRateLimitDelayFor(resp, body, time.Now())
+		if !ok || (req.Body != nil && req.GetBody == nil) {
+			return resp, nil
+		}
+
+		fmt.Printf("::warning::Secondary rate limit hit on %s %s, retrying in %s\n", req.Method, req.URL.Path, delay)
+		timer := time.NewTimer(delay)
+		select {
+		case <-req.Context().Done():
+			timer.Stop()
+			return nil, req.Context().Err()
+		case <-timer.C:
+		}
+
+		// The request is sent again with a fresh copy of its body.
+		if req.GetBody != nil {
+			retry := req.Clone(req.Context())
+			if retry.Body, err = req.GetBody(); err != nil {
+				return nil, err
+			}
+			req = retry
+		}
+	}
+}
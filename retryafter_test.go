@@ -0,0 +1,75 @@
+package main
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestSecondaryRateLimitDelayFor(t *testing.T) {
+	now := time.Date(2022, 1, 1, 0, 0, 0, 0, time.UTC)
+	testCases := []struct {
+		name       string
+		statusCode int
+		retryAfter string
+		body       string
+		expected   time.Duration
+		ok         bool
+	}{
+		{name: "ok", statusCode: http.StatusOK, ok: false},
+		{name: "retry_after_seconds", statusCode: http.StatusForbidden, retryAfter: "30", expected: 30 * time.Second, ok: true},
+		{name: "retry_after_date", statusCode: http.StatusForbidden, retryAfter: now.Add(time.Minute).Format(http.TimeFormat), expected: time.Minute, ok: true},
+		{name: "secondary_message", statusCode: http.StatusForbidden, body: `{"message": "You have exceeded a secondary rate limit."}`, expected: secondaryRateLimitDelay, ok: true},
+		{name: "abuse_message", statusCode: http.StatusForbidden, body: `{"documentation_url": "https://docs.github.com/rest/overview/resources-in-the-rest-api#abuse-rate-limits"}`, expected: secondaryRateLimitDelay, ok: true},
+		{name: "too_many_requests", statusCode: http.StatusTooManyRequests, expected: secondaryRateLimitDelay, ok: true},
+		{name: "primary_rate_limit", statusCode: http.StatusForbidden, body: `{"message": "API rate limit exceeded"}`, ok: false},
+		{name: "forbidden", statusCode: http.StatusForbidden, body: `{"message": "Resource not accessible by integration"}`, ok: false},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			resp := &http.Response{StatusCode: testCase.statusCode, Header: http.Header{}}
+			if testCase.retryAfter != "" {
+				resp.Header.Set("Retry-After", testCase.retryAfter)
+			}
+			actual, ok := secondaryRateLimitDelayFor(resp, []byte(testCase.body), now)
+			if ok != testCase.ok || actual != testCase.expected {
+				t.Fatalf("expected %s, %t but got %s, %t", testCase.expected, testCase.ok, actual, ok)
+			}
+		})
+	}
+}
+
+func TestSecondaryRateLimitTransport(t *testing.T) {
+	requests := 0
+	var bodies []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		body, _ := io.ReadAll(r.Body)
+		bodies = append(bodies, string(body))
+		if requests == 1 {
+			w.Header().Set("Retry-After", "0")
+			w.WriteHeader(http.StatusForbidden)
+			return
+		}
+		w.WriteHeader(http.StatusCreated)
+	}))
+	defer server.Close()
+
+	client := &http.Client{Transport: secondaryRateLimitTransport{base: http.DefaultTransport}}
+	resp, err := client.Post(server.URL, "application/json", strings.NewReader(`{"body": "comment"}`))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	resp.Body.Close()
+
+	if resp.StatusCode != http.StatusCreated {
+		t.Fatalf("expected status %d but got %d", http.StatusCreated, resp.StatusCode)
+	}
+	if requests != 2 || bodies[1] != `{"body": "comment"}` {
+		t.Fatalf("expected the request to be retried with its body but got %d requests with bodies %q", requests, bodies)
+	}
+}
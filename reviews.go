@@ -0,0 +1,70 @@
+package main
+
+import (
+	"context"
+	"sort"
+
+	"github.com/google/go-github/v43/github"
+)
+
+const (
+	reviewModeNone    string = "none"
+	reviewModeInclude string = "include"
+	reviewModeOnly    string = "only"
+)
+
+// reviewComments converts pull request reviews into synthetic issue comments
+// so that they can be evaluated with the same quorum rules as comments. An
+// APPROVED review counts as an approval and a CHANGES_REQUESTED review as a
+// denial; all other review states are ignored. Reviews of other commits than
+// sha are ignored too, so that approving an earlier commit doesn't approve
+// the code pushed after it.
+func reviewComments(reviews []*github.PullRequestReview, sha string) []*github.IssueComment {
+	var comments []*github.IssueComment
+	for _, review := range reviews {
+		if review.GetCommitID() != sha {
+			continue
+		}
+		var body string
+		switch review.GetState() {
+		case "APPROVED":
+			body = gateWords.Approved[0]
+		case "CHANGES_REQUESTED":
+			body = gateWords.Denied[0]
+		default:
+			continue
+		}
+		comments = append(comments, &github.IssueComment{
+			Body:      github.String(body),
+			User:      review.User,
+			CreatedAt: review.SubmittedAt,
+			HTMLURL:   review.HTMLURL,
+		})
+	}
+	return comments
+}
+
+func (a *approvalEnvironment) listReviewComments(ctx context.Context) ([]*github.IssueComment, error) {
+	var reviews []*github.PullRequestReview
+	opts := &github.ListOptions{PerPage: 100}
+	for {
+		page, resp, err := a.client.PullRequests.ListReviews(ctx, a.repoOwner, a.repo, a.pullRequestNumber, opts)
+		if err != nil {
+			return nil, err
+		}
+		reviews = append(reviews, page...)
+		if resp.NextPage == 0 {
+			break
+		}
+		opts.Page = resp.NextPage
+	}
+	return reviewComments(reviews, a.reviewSHA), nil
+}
+
+// sortCommentsByCreation orders comments from different sources so that they
+// are evaluated in the order they were made.
+func sortCommentsByCreation(comments []*github.IssueComment) {
+	sort.SliceStable(comments, func(i, j int) bool {
+		return comments[i].GetCreatedAt().Before(comments[j].GetCreatedAt())
+	})
+}
@@ -0,0 +1,79 @@
+package main
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/google/go-github/v43/github"
+)
+
+func TestReviewComments(t *testing.T) {
+	login1 := "login1"
+	login2 := "login2"
+	login3 := "login3"
+	submitted := time.Date(2022, 3, 1, 0, 0, 0, 0, time.UTC)
+
+	head := github.String("abc123")
+	reviews := []*github.PullRequestReview{
+		{User: &github.User{Login: &login1}, State: github.String("APPROVED"), CommitID: head, SubmittedAt: &submitted},
+		{User: &github.User{Login: &login2}, State: github.String("COMMENTED"), CommitID: head, SubmittedAt: &submitted},
+		{User: &github.User{Login: &login2}, State: github.String("APPROVED"), CommitID: github.String("def456"), SubmittedAt: &submitted},
+		{User: &github.User{Login: &login3}, State: github.String("CHANGES_REQUESTED"), CommitID: head, SubmittedAt: &submitted},
+	}
+
+	comments := reviewComments(reviews, "abc123")
+	if len(comments) != 2 {
+		t.Fatalf("expected 2 comments but got %d", len(comments))
+	}
+
+	approved, err := gateWords.IsApproved(comments[0].GetBody())
+	if err != nil {
+		t.Fatalf("error getting approval: %v", err)
+	}
+	if !approved || comments[0].User.GetLogin() != login1 {
+		t.Fatalf("expected approval from %s but got %q from %s", login1, comments[0].GetBody(), comments[0].User.GetLogin())
+	}
+
+	denied, err := gateWords.IsDenied(comments[1].GetBody())
+	if err != nil {
+		t.Fatalf("error getting denial: %v", err)
+	}
+	if !denied || comments[1].User.GetLogin() != login3 {
+		t.Fatalf("expected denial from %s but got %q from %s", login3, comments[1].GetBody(), comments[1].User.GetLogin())
+	}
+}
+
+func TestReviewsBeforeRequest(t *testing.T) {
+	ctx := context.Background()
+	fake := newFakeGitHub()
+	apprv, err := newApprovalEnvironment(fake.client(), "owner/repo", "owner", 1, []string{"user1", "user2"}, 1, nil)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	apprv.reviewMode = reviewModeOnly
+	apprv.pullRequestNumber = 7
+	apprv.reviewSHA = "abc123"
+
+	fake.review("user1", "APPROVED", "abc123")
+	if err := apprv.createApprovalIssue(ctx); err != nil {
+		t.Fatalf("error creating approval issue: %v", err)
+	}
+
+	comments, err := apprv.approvalComments(ctx)
+	if err != nil {
+		t.Fatalf("error getting comments: %v", err)
+	}
+	if len(comments) != 0 {
+		t.Fatalf("expected the review before the request to be ignored but got %d comments", len(comments))
+	}
+
+	fake.review("user2", "APPROVED", "abc123")
+	comments, err = apprv.approvalComments(ctx)
+	if err != nil {
+		t.Fatalf("error getting comments: %v", err)
+	}
+	if len(comments) != 1 || comments[0].User.GetLogin() != "user2" {
+		t.Fatalf("expected the review from user2 to count but got %d comments", len(comments))
+	}
+}
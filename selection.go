@@ -0,0 +1,155 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"math/rand"
+	"sort"
+	"strings"
+	"time"
+)
+
+const (
+	// selectStrategyRandom selects approvers at random, seeded by the run
+	// ID.
+	selectStrategyRandom string = "random"
+	// selectStrategyRoundRobin selects the approvers after the ones the
+	// previous run of the gate selected.
+	selectStrategyRoundRobin string = "round-robin"
+)
+
+// selectApprovers picks k approvers from the pool at random, seeded by seed
+// so that every job of a run picks the same ones. The selected approvers
+// keep the order of the pool.
+func selectApprovers(pool []string, k int, seed int64) []string {
+	if k <= 0 || k >= len(pool) {
+		return pool
+	}
+	indexes := rand.New(rand.NewSource(seed)).Perm(len(pool))[:k]
+	sort.Ints(indexes)
+	selected := make([]string, k)
+	for i, index := range indexes {
+		selected[i] = pool[index]
+	}
+	return selected
+}
+
+// rotateApprovers picks k consecutive approvers of the pool starting at
+// start, wrapping around to its beginning.
+func rotateApprovers(pool []string, k int, start int) []string {
+	if k <= 0 || k >= len(pool) {
+		return pool
+	}
+	selected := make([]string, k)
+	for i := range selected {
+		selected[i] = pool[(start+i)%len(pool)]
+	}
+	return selected
+}
+
+// rotationStart returns where in the pool the rotation continues for this
+// run: after the approvers selected by the latest earlier run of the gate,
+// or where this run started if it was re-run. The cursor is the Primary of
+// the approval issue markers, so it needs no state of its own.
+func (a *approvalEnvironment) rotationStart(ctx context.Context, pool []string) (int, error) {
+	issues, err := a.findApprovalIssues(ctx, "all", approvalCacheSearchPages, func(marker issueMarker) bool {
+		return marker.Gate == a.gateName && marker.Primary != ""
+	})
+	if err != nil {
+		return 0, err
+	}
+	var latest issueMarker
+	for _, issue := range issues {
+		if marker, _ := parseIssueMarker(issue.GetBody()); marker.RunID > latest.RunID {
+			latest = marker
+		}
+	}
+	index := approversIndex(pool, latest.Primary)
+	switch {
+	case index < 0:
+		return 0, nil
+	case latest.RunID == a.runID:
+		return index, nil
+	}
+	return (index + a.selectApprovers) % len(pool), nil
+}
+
+// chooseApprovers selects the approvers of this run from the pool, when
+// select-approvers is set, and returns the approvers the gate waits for.
+func (a *approvalEnvironment) chooseApprovers(ctx context.Context, pool []string) ([]string, error) {
+	if a.selectApprovers <= 0 || a.selectApprovers >= len(pool) {
+		return pool, nil
+	}
+	if a.selectStrategy != selectStrategyRoundRobin {
+		return a.applySelection(pool, selectApprovers(pool, a.selectApprovers, int64(a.runID))), nil
+	}
+	start, err := a.rotationStart(ctx, pool)
+	if err != nil {
+		return nil, err
+	}
+	selected := rotateApprovers(pool, a.selectApprovers, start)
+	a.rotationPrimary = selected[0]
+	return a.applySelection(pool, selected), nil
+}
+
+// chooseApproversFor selects the same approvers from the pool as the run
+// that created the approval issue with marker did.
+func (a *approvalEnvironment) chooseApproversFor(marker issueMarker, pool []string) []string {
+	if a.selectApprovers <= 0 || a.selectApprovers >= len(pool) {
+		return pool
+	}
+	if a.selectStrategy != selectStrategyRoundRobin {
+		return a.applySelection(pool, selectApprovers(pool, a.selectApprovers, int64(marker.RunID)))
+	}
+	start := approversIndex(pool, marker.Primary)
+	if start < 0 {
+		start = 0
+	}
+	return a.applySelection(pool, rotateApprovers(pool, a.selectApprovers, start))
+}
+
+// applySelection narrows the approvers to the ones selected from the pool.
+func (a *approvalEnvironment) applySelection(pool []string, selected []string) []string {
+	a.approverPool = pool
+	a.approvers = selected
+	fmt.Printf("Selected %s from %d approvers\n", strings.Join(selected, ", "), len(pool))
+	setOutput("selected-approvers", strings.Join(selected, ","))
+	return selected
+}
+
+// selectionFallbackDue reports whether the selected approvers had
+// select-approvers-fallback to decide, after which the whole pool can.
+func (a *approvalEnvironment) selectionFallbackDue(now time.Time) bool {
+	return len(a.approverPool) > 0 && a.selectFallback > 0 && !a.requestedAt.IsZero() && now.Sub(a.requestedAt) >= a.selectFallback
+}
+
+// activeApprovers returns the approvers whose responses count: the selected
+// approvers and, once the fallback is due, the whole pool.
+func (a *approvalEnvironment) activeApprovers(selected []string, now time.Time) []string {
+	if a.selectionFallbackDue(now) {
+		return a.approverPool
+	}
+	return selected
+}
+
+// announcePoolFallback tells the rest of the pool that they can respond once
+// the fallback is due. The announcement is recorded in the gate state so
+// that it is made once.
+func (a *approvalEnvironment) announcePoolFallback(ctx context.Context, now time.Time) error {
+	if !a.selectionFallbackDue(now) || a.gateState.PoolFallback {
+		return nil
+	}
+	var others []string
+	for _, approver := range a.approverPool {
+		if approversIndex(a.approvers, approver) < 0 {
+			others = append(others, approver)
+		}
+	}
+	comment := fmt.Sprintf("The selected approvers haven't decided within %s. %s, you can now respond as well.", a.selectFallback, mentionApprovers(others))
+	if err := a.postStatusComment(ctx, comment); err != nil {
+		return err
+	}
+	state := a.gateState
+	state.PoolFallback = true
+	return a.writeGateState(ctx, state)
+}
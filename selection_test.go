@@ -0,0 +1,154 @@
+package main
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestSelectApprovers(t *testing.T) {
+	pool := []string{"user1", "user2", "user3", "user4", "user5", "user6"}
+
+	selected := selectApprovers(pool, 2, 42)
+	if len(selected) != 2 {
+		t.Fatalf("expected 2 approvers but got %v", selected)
+	}
+	if again := selectApprovers(pool, 2, 42); strings.Join(again, ",") != strings.Join(selected, ",") {
+		t.Fatalf("expected the same seed to select %v but got %v", selected, again)
+	}
+	if approversIndex(pool, selected[0]) >= approversIndex(pool, selected[1]) {
+		t.Fatalf("expected the selected approvers in the order of the pool but got %v", selected)
+	}
+
+	differs := false
+	for seed := int64(0); seed < 10 && !differs; seed++ {
+		differs = strings.Join(selectApprovers(pool, 2, seed), ",") != strings.Join(selected, ",")
+	}
+	if !differs {
+		t.Fatal("expected other seeds to select other approvers")
+	}
+
+	for _, k := range []int{0, 6, 7} {
+		if actual := selectApprovers(pool, k, 42); len(actual) != len(pool) {
+			t.Fatalf("expected %d to select the whole pool but got %v", k, actual)
+		}
+	}
+}
+
+func TestSelectionFallback(t *testing.T) {
+	ctx := context.Background()
+	fake := newFakeGitHub()
+	pool := []string{"user1", "user2", "user3", "user4"}
+	apprv, err := newApprovalEnvironment(fake.client(), "owner/repo", "owner", 7, pool, 1, nil)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	apprv.selectApprovers = 1
+	apprv.selectFallback = time.Hour
+	selected, err := apprv.chooseApprovers(ctx, pool)
+	if err != nil {
+		t.Fatalf("error selecting approvers: %v", err)
+	}
+	if len(selected) != 1 || len(apprv.approvers) != 1 {
+		t.Fatalf("expected a single selected approver but got %v", selected)
+	}
+	if err := apprv.createApprovalIssue(ctx); err != nil {
+		t.Fatalf("error creating approval issue: %v", err)
+	}
+	if assignees := fake.issues[apprv.approvalIssueNumber].Assignees; len(assignees) != 1 || assignees[0].GetLogin() != selected[0] {
+		t.Fatalf("expected only %s to be assigned but got %v", selected[0], assignees)
+	}
+
+	before := apprv.requestedAt.Add(59 * time.Minute)
+	if actual := apprv.activeApprovers(selected, before); len(actual) != 1 {
+		t.Fatalf("expected only the selected approver before the fallback but got %v", actual)
+	}
+	if err := apprv.announcePoolFallback(ctx, before); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	after := apprv.requestedAt.Add(time.Hour)
+	if actual := apprv.activeApprovers(selected, after); len(actual) != len(pool) {
+		t.Fatalf("expected the pool after the fallback but got %v", actual)
+	}
+	for i := 0; i < 2; i++ {
+		if err := apprv.announcePoolFallback(ctx, after); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	}
+	var announcements []string
+	for _, comment := range fake.comments[apprv.approvalIssueNumber] {
+		if strings.HasPrefix(comment.GetBody(), "The selected approvers") {
+			announcements = append(announcements, comment.GetBody())
+		}
+	}
+	if len(announcements) != 1 || strings.Contains(announcements[0], "@"+selected[0]) {
+		t.Fatalf("expected one announcement to the rest of the pool but got %q", announcements)
+	}
+}
+
+func TestRotateApprovers(t *testing.T) {
+	pool := []string{"user1", "user2", "user3"}
+	testCases := []struct {
+		k        int
+		start    int
+		expected string
+	}{
+		{k: 1, start: 0, expected: "user1"},
+		{k: 2, start: 2, expected: "user3,user1"},
+		{k: 3, start: 1, expected: "user1,user2,user3"},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.expected, func(t *testing.T) {
+			if actual := rotateApprovers(pool, testCase.k, testCase.start); strings.Join(actual, ",") != testCase.expected {
+				t.Fatalf("expected %s but got %v", testCase.expected, actual)
+			}
+		})
+	}
+}
+
+func TestRoundRobinRotation(t *testing.T) {
+	ctx := context.Background()
+	fake := newFakeGitHub()
+	pool := []string{"user1", "user2", "user3"}
+
+	run := func(runID int) (*approvalEnvironment, string) {
+		apprv, err := newApprovalEnvironment(fake.client(), "owner/repo", "owner", runID, pool, 1, nil)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		apprv.gateName = "deploy"
+		apprv.selectApprovers = 1
+		apprv.selectStrategy = selectStrategyRoundRobin
+		selected, err := apprv.chooseApprovers(ctx, pool)
+		if err != nil {
+			t.Fatalf("error selecting approvers: %v", err)
+		}
+		return apprv, strings.Join(selected, ",")
+	}
+
+	var rotation []string
+	for runID := 1; runID <= 4; runID++ {
+		apprv, selected := run(runID)
+		if err := apprv.createApprovalIssue(ctx); err != nil {
+			t.Fatalf("error creating approval issue: %v", err)
+		}
+		rotation = append(rotation, selected)
+
+		// A re-run of the run and the companion workflow keep its approvers.
+		if _, again := run(runID); again != selected {
+			t.Fatalf("expected a re-run of run %d to select %s but got %s", runID, selected, again)
+		}
+		marker, _ := parseIssueMarker(apprv.approvalIssue.GetBody())
+		companion, _ := newApprovalEnvironment(fake.client(), "owner/repo", "owner", 99, pool, 1, nil)
+		companion.selectApprovers, companion.selectStrategy = 1, selectStrategyRoundRobin
+		if actual := strings.Join(companion.chooseApproversFor(marker, pool), ","); actual != selected {
+			t.Fatalf("expected the companion of run %d to select %s but got %s", runID, selected, actual)
+		}
+	}
+	if actual := strings.Join(rotation, "|"); actual != "user1|user2|user3|user1" {
+		t.Fatalf("expected the approvers to rotate but got %s", actual)
+	}
+}
@@ -0,0 +1,88 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/google/go-github/v43/github"
+)
+
+// sharedIssueSettleDelay is how long a job waits after creating its approval
+// issue before looking for the issues of the other jobs of the run, so that
+// issues created at the same moment show up in the listing.
+const sharedIssueSettleDelay time.Duration = 5 * time.Second
+
+// sharesGateRun reports whether two approval issues were created for the
+// same gate by jobs of the same run attempt, such as the jobs of a matrix.
+func sharesGateRun(current, other issueMarker) bool {
+	return other.RunID == current.RunID &&
+		other.RunAttempt == current.RunAttempt &&
+		other.Gate == current.Gate &&
+		other.SupersededBy == 0
+}
+
+// sharedApprovalIssue returns the open approval issue shared by the jobs of
+// this run attempt and gate, or nil if none of them has created one yet.
+// When several jobs created an issue at the same time, the one with the
+// lowest number is shared.
+func (a *approvalEnvironment) sharedApprovalIssue(ctx context.Context) (*github.Issue, error) {
+	current := a.issueMarker()
+	issues, err := a.findApprovalIssues(ctx, "open", 1, func(marker issueMarker) bool {
+		return sharesGateRun(current, marker)
+	})
+	if err != nil {
+		return nil, err
+	}
+	var shared *github.Issue
+	for _, issue := range issues {
+		if shared == nil || issue.GetNumber() < shared.GetNumber() {
+			shared = issue
+		}
+	}
+	return shared, nil
+}
+
+// joinApprovalIssue waits on an approval issue created by another job. The
+// job that created it is responsible for commenting on and closing it.
+func (a *approvalEnvironment) joinApprovalIssue(issue *github.Issue) {
+	fmt.Printf("Waiting for approval on issue %s, shared by the jobs of this run\n", issue.GetHTMLURL())
+	a.approvalIssue = issue
+	a.approvalIssueNumber = issue.GetNumber()
+	a.requestedAt = issue.GetCreatedAt()
+	a.sharedIssueFollower = true
+}
+
+// yieldSharedIssue checks whether another job of the run created an approval
+// issue at the same time as this one. If so, the issue with the lowest number
+// wins, and the issue of this job is closed as a duplicate of it. It returns
+// true when this job joined the issue of another job.
+func (a *approvalEnvironment) yieldSharedIssue(ctx context.Context) (bool, error) {
+	time.Sleep(sharedIssueSettleDelay)
+	shared, err := a.sharedApprovalIssue(ctx)
+	if err != nil {
+		return false, err
+	}
+	if shared == nil || shared.GetNumber() >= a.approvalIssueNumber {
+		return false, nil
+	}
+
+	comment := fmt.Sprintf("Duplicate of #%d, which is shared by all jobs of this run.", shared.GetNumber())
+	if _, _, err := a.client.Issues.CreateComment(ctx, a.repoOwner, a.repo, a.approvalIssueNumber, &github.IssueComment{
+		Body: &comment,
+	}); err != nil {
+		return false, err
+	}
+	marker := a.issueMarker()
+	marker.SupersededBy = shared.GetNumber()
+	body := replaceIssueMarker(a.approvalIssue.GetBody(), marker)
+	state := "closed"
+	if _, _, err := a.client.Issues.Edit(ctx, a.repoOwner, a.repo, a.approvalIssueNumber, &github.IssueRequest{
+		Body:  &body,
+		State: &state,
+	}); err != nil {
+		return false, err
+	}
+	a.joinApprovalIssue(shared)
+	return true, nil
+}
@@ -0,0 +1,27 @@
+package main
+
+import "testing"
+
+func TestSharesGateRun(t *testing.T) {
+	current := issueMarker{Gate: "pre-deploy", RunID: 1234, RunAttempt: 1}
+
+	testCases := []struct {
+		name   string
+		other  issueMarker
+		shares bool
+	}{
+		{name: "same_run_and_gate", other: issueMarker{Gate: "pre-deploy", RunID: 1234, RunAttempt: 1}, shares: true},
+		{name: "other_run", other: issueMarker{Gate: "pre-deploy", RunID: 1235, RunAttempt: 1}, shares: false},
+		{name: "other_attempt", other: issueMarker{Gate: "pre-deploy", RunID: 1234, RunAttempt: 2}, shares: false},
+		{name: "other_gate", other: issueMarker{Gate: "post-deploy", RunID: 1234, RunAttempt: 1}, shares: false},
+		{name: "duplicate", other: issueMarker{Gate: "pre-deploy", RunID: 1234, RunAttempt: 1, SupersededBy: 7}, shares: false},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			if actual := sharesGateRun(current, testCase.other); actual != testCase.shares {
+				t.Fatalf("expected %v but got %v", testCase.shares, actual)
+			}
+		})
+	}
+}
@@ -0,0 +1,137 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/google/go-github/v43/github"
+	"gopkg.in/yaml.v3"
+)
+
+// simulationComment is a comment of a simulation fixture.
+type simulationComment struct {
+	User string `yaml:"user"`
+	Body string `yaml:"body"`
+}
+
+// simulationFixture is the input of simulate mode. It is YAML or JSON, and
+// either a list of comments or an object that also sets the approval inputs,
+// which otherwise come from the environment like in a workflow run.
+type simulationFixture struct {
+	Approvers               stringList          `yaml:"approvers"`
+	MinimumApprovals        int                 `yaml:"minimum-approvals"`
+	MultipleDeploymentNames stringList          `yaml:"multiple-deployment-names"`
+	Comments                []simulationComment `yaml:"comments"`
+}
+
+func parseSimulationFixture(content []byte) (*simulationFixture, error) {
+	var node yaml.Node
+	if err := yaml.Unmarshal(content, &node); err != nil {
+		return nil, err
+	}
+	var fixture simulationFixture
+	if len(node.Content) > 0 && node.Content[0].Kind == yaml.SequenceNode {
+		if err := node.Content[0].Decode(&fixture.Comments); err != nil {
+			return nil, err
+		}
+		return &fixture, nil
+	}
+	if err := node.Decode(&fixture); err != nil {
+		return nil, err
+	}
+	return &fixture, nil
+}
+
+// simulationComments converts fixture comments into issue comments.
+func simulationComments(comments []simulationComment) []*github.IssueComment {
+	var issueComments []*github.IssueComment
+	for i, comment := range comments {
+		issueComments = append(issueComments, &github.IssueComment{
+			ID:   github.Int64(int64(i + 1)),
+			Body: github.String(comment.Body),
+			User: &github.User{Login: github.String(strings.TrimPrefix(comment.User, "@"))},
+		})
+	}
+	return issueComments
+}
+
+// pendingApprovers lists the approvers who haven't approved or whose approval
+// was withdrawn.
+func pendingApprovers(comments []*github.IssueComment, approvers []string) []string {
+	approvedBy := map[string]bool{}
+	for _, comment := range comments {
+		login := comment.User.GetLogin()
+		if approversIndex(approvers, login) < 0 {
+			continue
+		}
+		body := strings.Split(comment.GetBody(), "[")[0]
+		if approved, _ := isApproved(body); approved {
+			approvedBy[strings.ToLower(login)] = true
+		} else if revoked, _ := isRevoked(body); revoked {
+			delete(approvedBy, strings.ToLower(login))
+		}
+	}
+	var pending []string
+	for _, approver := range approvers {
+		if !approvedBy[strings.ToLower(approver)] {
+			pending = append(pending, approver)
+		}
+	}
+	return pending
+}
+
+// simulate prints how the comments of a fixture would resolve the gate, so
+// that approval flows can be tried out locally without a workflow run.
+// Fields missing from the fixture are read from the same inputs as a
+// workflow run. Teams can't be expanded without a token, so approvers must
+// be users.
+func simulate(fixturePath string) error {
+	content, err := os.ReadFile(fixturePath)
+	if err != nil {
+		return err
+	}
+	fixture, err := parseSimulationFixture(content)
+	if err != nil {
+		return fmt.Errorf("error parsing fixture %s: %w", fixturePath, err)
+	}
+
+	approvers := []string(fixture.Approvers)
+	if len(approvers) == 0 {
+		approvers = parseApprovers(os.Getenv(envVarApprovers))
+	}
+	if len(approvers) == 0 {
+		return fmt.Errorf("no approvers configured")
+	}
+	minimumApprovals := fixture.MinimumApprovals
+	if minimumRaw := os.Getenv(envVarMinimumApprovals); minimumApprovals == 0 && minimumRaw != "" {
+		if minimumApprovals, err = strconv.Atoi(minimumRaw); err != nil {
+			return fmt.Errorf("error parsing minimum number of approvals: %w", err)
+		}
+	}
+	if minimumApprovals == 0 {
+		minimumApprovals = len(approvers)
+	}
+	multipleDeploymentNames := []string(fixture.MultipleDeploymentNames)
+	if raw := os.Getenv(envMultipleDeploymentNames); len(multipleDeploymentNames) == 0 && raw != "" {
+		multipleDeploymentNames, _ = parseDeploymentNames(raw)
+	}
+
+	comments := simulationComments(fixture.Comments)
+	status, deploymentNames, err := approvalFromComments(comments, approvers, minimumApprovals, multipleDeploymentNames)
+	if err != nil {
+		return fmt.Errorf("error getting approval from comments: %w", err)
+	}
+
+	fmt.Printf("Approvers: %s\n", strings.Join(approvers, ", "))
+	fmt.Printf("Minimum approvals: %d\n", minimumApprovals)
+	fmt.Printf("Status: %s\n", status)
+	if len(deploymentNames) > 0 {
+		fmt.Printf("Deployments: %s\n", strings.Join(deploymentNames, ", "))
+	}
+	if status == approvalStatusPending || status == approvalStatusHeld {
+		fmt.Printf("Remaining approvers: %s\n", strings.Join(pendingApprovers(comments, approvers), ", "))
+	}
+	return nil
+}
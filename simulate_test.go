@@ -0,0 +1,57 @@
+package main
+
+import "testing"
+
+func TestParseSimulationFixture(t *testing.T) {
+	testCases := []struct {
+		name              string
+		content           string
+		expectedApprovers int
+		expectedComments  int
+	}{
+		{
+			name:             "comment_list",
+			content:          "- user: login1\n  body: approved\n- user: login2\n  body: lgtm\n",
+			expectedComments: 2,
+		},
+		{
+			name:              "object",
+			content:           "approvers: login1, login2\nminimum-approvals: 1\ncomments:\n  - user: login1\n    body: approved\n",
+			expectedApprovers: 2,
+			expectedComments:  1,
+		},
+		{
+			name:             "json",
+			content:          `[{"user": "login1", "body": "deny"}]`,
+			expectedComments: 1,
+		},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			fixture, err := parseSimulationFixture([]byte(testCase.content))
+			if err != nil {
+				t.Fatalf("error parsing fixture: %v", err)
+			}
+			if len(fixture.Approvers) != testCase.expectedApprovers {
+				t.Fatalf("expected %d approvers but got %v", testCase.expectedApprovers, fixture.Approvers)
+			}
+			if len(fixture.Comments) != testCase.expectedComments {
+				t.Fatalf("expected %d comments but got %+v", testCase.expectedComments, fixture.Comments)
+			}
+		})
+	}
+}
+
+func TestPendingApprovers(t *testing.T) {
+	comments := simulationComments([]simulationComment{
+		{User: "login1", Body: "approved"},
+		{User: "login2", Body: "approved"},
+		{User: "login2", Body: "revoke"},
+		{User: "login4", Body: "approved"},
+	})
+	pending := pendingApprovers(comments, []string{"login1", "login2", "login3"})
+	if len(pending) != 2 || pending[0] != "login2" || pending[1] != "login3" {
+		t.Fatalf("expected login2 and login3 to be pending but got %v", pending)
+	}
+}
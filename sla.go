@@ -0,0 +1,35 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"time"
+)
+
+// slaBreached reports whether the approval was requested longer than the
+// SLA before now.
+func (a *approvalEnvironment) slaBreached(now time.Time) bool {
+	return a.sla > 0 && !a.requestedAt.IsZero() && now.Sub(a.requestedAt) > a.sla
+}
+
+// checkSLA reports a breach of the SLA once the gate has been pending past
+// it: as a warning on the run, by labeling the approval issue and in the
+// sla-breached output.
+func (a *approvalEnvironment) checkSLA(ctx context.Context, now time.Time) error {
+	if a.slaReported || !a.slaBreached(now) {
+		return nil
+	}
+	a.slaReported = true
+	fmt.Printf("::warning::Approval has been pending for %s, breaching its SLA of %s\n", now.Sub(a.requestedAt).Round(time.Second), a.sla)
+	setOutput("sla-breached", "true")
+	if a.approvalIssue == nil || a.sharedIssueFollower {
+		return nil
+	}
+	if err := a.ensureLabel(ctx, slaBreachedLabel, "d93f0b", "The manual approval was pending past its SLA"); err != nil {
+		return err
+	}
+	if _, _, err := a.client.Issues.AddLabelsToIssue(ctx, a.repoOwner, a.repo, a.approvalIssueNumber, []string{slaBreachedLabel}); err != nil {
+		return fmt.Errorf("error labeling issue: %w", err)
+	}
+	return nil
+}
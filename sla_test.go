@@ -0,0 +1,50 @@
+package main
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestCheckSLA(t *testing.T) {
+	ctx := context.Background()
+	fake := newFakeGitHub()
+	apprv, err := newApprovalEnvironment(fake.client(), "owner/repo", "owner", 1, []string{"user1"}, 1, nil)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	apprv.sla = time.Hour
+	if err := apprv.createApprovalIssue(ctx); err != nil {
+		t.Fatalf("error creating approval issue: %v", err)
+	}
+	labeled := func() int {
+		count := 0
+		for _, label := range fake.labels[apprv.approvalIssueNumber] {
+			if label.GetName() == slaBreachedLabel {
+				count++
+			}
+		}
+		return count
+	}
+
+	if err := apprv.checkSLA(ctx, apprv.requestedAt.Add(time.Hour)); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if labeled() != 0 || apprv.slaBreached(apprv.requestedAt.Add(time.Hour)) {
+		t.Fatal("expected the SLA not to be breached within it")
+	}
+
+	for i := 0; i < 2; i++ {
+		if err := apprv.checkSLA(ctx, apprv.requestedAt.Add(time.Hour+time.Second)); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	}
+	if labeled() != 1 {
+		t.Fatalf("expected the issue to be labeled once but it was labeled %d times", labeled())
+	}
+
+	apprv.sla = 0
+	if apprv.slaBreached(apprv.requestedAt.Add(24 * time.Hour)) {
+		t.Fatal("expected the SLA not to be breached when none is set")
+	}
+}
@@ -0,0 +1,74 @@
+package main
+
+import (
+	"fmt"
+	"net"
+	"sort"
+	"strings"
+	"time"
+)
+
+// statsdFormatDogStatsD adds the labels of the gate as DogStatsD tags, and
+// statsdFormatPlain leaves them out for StatsD servers without tags.
+const (
+	statsdFormatDogStatsD string = "dogstatsd"
+	statsdFormatPlain     string = "statsd"
+)
+
+// statsdTimeout bounds sending the metrics, as the StatsD server may be
+// unreachable from the runner.
+const statsdTimeout time.Duration = 5 * time.Second
+
+// statsdMetrics renders the metrics of the gate as StatsD lines: counters of
+// the opened and resolved gates, the approvals, denials and polls, and the
+// time to the decision as a timing.
+func statsdMetrics(prefix, format string, labels map[string]string, metrics gateMetrics) string {
+	tags := map[string]string{"outcome": strings.ToLower(string(metrics.status))}
+	for name, value := range labels {
+		if value != "" {
+			tags[name] = value
+		}
+	}
+	var suffix string
+	if format == statsdFormatDogStatsD {
+		names := make([]string, 0, len(tags))
+		for name := range tags {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		pairs := make([]string, len(names))
+		for i, name := range names {
+			pairs[i] = name + ":" + statsdTagValue(tags[name])
+		}
+		suffix = "|#" + strings.Join(pairs, ",")
+	}
+
+	lines := []string{
+		fmt.Sprintf("%sgate.opened:1|c%s", prefix, suffix),
+		fmt.Sprintf("%sgate.resolved:1|c%s", prefix, suffix),
+		fmt.Sprintf("%sapprovals:%d|c%s", prefix, len(metrics.approvedBy), suffix),
+		fmt.Sprintf("%sdenials:%d|c%s", prefix, len(metrics.deniedBy), suffix),
+		fmt.Sprintf("%spolls:%d|c%s", prefix, metrics.polls, suffix),
+		fmt.Sprintf("%stime_to_decision:%d|ms%s", prefix, metrics.timeToDecision().Milliseconds(), suffix),
+	}
+	return strings.Join(lines, "\n")
+}
+
+// statsdTagValue replaces the characters that separate tags and values.
+func statsdTagValue(value string) string {
+	return strings.NewReplacer(",", "_", "|", "_", "#", "_").Replace(value)
+}
+
+// sendStatsd sends the metrics in a single UDP datagram.
+func sendStatsd(address, payload string) error {
+	conn, err := net.DialTimeout("udp", address, statsdTimeout)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+	if err := conn.SetWriteDeadline(time.Now().Add(statsdTimeout)); err != nil {
+		return err
+	}
+	_, err = conn.Write([]byte(payload))
+	return err
+}
@@ -0,0 +1,82 @@
+package main
+
+import (
+	"net"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestStatsdMetrics(t *testing.T) {
+	requestedAt := time.Date(2022, 1, 1, 0, 0, 0, 0, time.UTC)
+	metrics := gateMetrics{
+		status:      approvalStatusDenied,
+		requestedAt: requestedAt,
+		resolvedAt:  requestedAt.Add(1500 * time.Millisecond),
+		deniedBy:    []string{"user1"},
+		polls:       3,
+	}
+	labels := map[string]string{"repository": "owner/repo", "gate": "prod,eu", "environment": ""}
+
+	testCases := []struct {
+		name     string
+		format   string
+		expected []string
+	}{
+		{
+			name:   "dogstatsd",
+			format: statsdFormatDogStatsD,
+			expected: []string{
+				"approval.gate.opened:1|c|#gate:prod_eu,outcome:denied,repository:owner/repo",
+				"approval.gate.resolved:1|c|#gate:prod_eu,outcome:denied,repository:owner/repo",
+				"approval.approvals:0|c|#gate:prod_eu,outcome:denied,repository:owner/repo",
+				"approval.denials:1|c|#gate:prod_eu,outcome:denied,repository:owner/repo",
+				"approval.polls:3|c|#gate:prod_eu,outcome:denied,repository:owner/repo",
+				"approval.time_to_decision:1500|ms|#gate:prod_eu,outcome:denied,repository:owner/repo",
+			},
+		},
+		{
+			name:   "plain",
+			format: statsdFormatPlain,
+			expected: []string{
+				"approval.gate.opened:1|c",
+				"approval.gate.resolved:1|c",
+				"approval.approvals:0|c",
+				"approval.denials:1|c",
+				"approval.polls:3|c",
+				"approval.time_to_decision:1500|ms",
+			},
+		},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			actual := statsdMetrics("approval.", testCase.format, labels, metrics)
+			expected := strings.Join(testCase.expected, "\n")
+			if actual != expected {
+				t.Fatalf("expected:\n%s\nbut got:\n%s", expected, actual)
+			}
+		})
+	}
+}
+
+func TestSendStatsd(t *testing.T) {
+	conn, err := net.ListenPacket("udp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	defer conn.Close()
+
+	if err := sendStatsd(conn.LocalAddr().String(), "approval.polls:3|c"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	buffer := make([]byte, 1024)
+	conn.SetReadDeadline(time.Now().Add(time.Second))
+	n, _, err := conn.ReadFrom(buffer)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if string(buffer[:n]) != "approval.polls:3|c" {
+		t.Fatalf("expected the metrics to be sent but got %q", buffer[:n])
+	}
+}
@@ -0,0 +1,78 @@
+package main
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/google/go-github/v43/github"
+)
+
+type statusLabel struct {
+	name        string
+	color       string
+	description string
+}
+
+// statusLabels are the labels status-labels moves the approval issue
+// between as the gate progresses, for dashboards and saved searches.
+var statusLabels = map[approvalStatus]statusLabel{
+	approvalStatusPending:   {"approval:pending", "fbca04", "The manual approval is waiting for approvers"},
+	approvalStatusHeld:      {"approval:held", "c5def5", "The manual approval is on hold"},
+	approvalStatusApproved:  {"approval:approved", "0e8a16", "The manual approval was approved"},
+	approvalStatusDenied:    {"approval:denied", "b60205", "The manual approval was denied"},
+	approvalStatusTimedOut:  {"approval:timed-out", "d93f0b", "The manual approval timed out"},
+	approvalStatusCancelled: {"approval:cancelled", "ededed", "The workflow was cancelled before a decision"},
+}
+
+// isStatusLabel reports whether name is one of the status labels.
+func isStatusLabel(name string) bool {
+	for _, label := range statusLabels {
+		if label.name == name {
+			return true
+		}
+	}
+	return false
+}
+
+// setStatusLabel labels the approval issue with the label of status,
+// creating the label if it is missing, and removes the labels of the other
+// statuses. The label applied last is remembered, so that polls that don't
+// change the status make no requests.
+func (a *approvalEnvironment) setStatusLabel(ctx context.Context, status approvalStatus) error {
+	if !a.statusLabels || a.approvalIssue == nil || a.sharedIssueFollower || a.discussionCategory != "" || a.pullRequestComment {
+		return nil
+	}
+	label, ok := statusLabels[status]
+	if !ok || a.statusLabel == label.name {
+		return nil
+	}
+	if err := a.ensureLabel(ctx, label.name, label.color, label.description); err != nil {
+		return err
+	}
+
+	current, _, err := a.client.Issues.ListLabelsByIssue(ctx, a.repoOwner, a.repo, a.approvalIssueNumber, &github.ListOptions{PerPage: 100})
+	if err != nil {
+		return fmt.Errorf("error listing labels: %w", err)
+	}
+	applied := false
+	for _, existing := range current {
+		name := existing.GetName()
+		if name == label.name {
+			applied = true
+			continue
+		}
+		if !isStatusLabel(name) {
+			continue
+		}
+		if _, err := a.client.Issues.RemoveLabelForIssue(ctx, a.repoOwner, a.repo, a.approvalIssueNumber, name); err != nil {
+			return fmt.Errorf("error removing label %s: %w", name, err)
+		}
+	}
+	if !applied {
+		if _, _, err := a.client.Issues.AddLabelsToIssue(ctx, a.repoOwner, a.repo, a.approvalIssueNumber, []string{label.name}); err != nil {
+			return fmt.Errorf("error labeling issue: %w", err)
+		}
+	}
+	a.statusLabel = label.name
+	return nil
+}
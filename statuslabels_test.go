@@ -0,0 +1,65 @@
+package main
+
+import (
+	"context"
+	"reflect"
+	"testing"
+
+	"github.com/google/go-github/v43/github"
+)
+
+func TestSetStatusLabel(t *testing.T) {
+	ctx := context.Background()
+	fake := newFakeGitHub()
+	apprv, err := newApprovalEnvironment(fake.client(), "owner/repo", "owner", 1, []string{"user1"}, 1, nil)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	apprv.statusLabels = true
+	if err := apprv.createApprovalIssue(ctx); err != nil {
+		t.Fatalf("error creating approval issue: %v", err)
+	}
+	number := apprv.approvalIssueNumber
+	fake.labels[number] = append(fake.labels[number], &github.Label{Name: github.String("deploy")})
+
+	steps := []struct {
+		status   approvalStatus
+		expected []string
+	}{
+		{approvalStatusPending, []string{"deploy", "approval:pending"}},
+		{approvalStatusPending, []string{"deploy", "approval:pending"}},
+		{approvalStatusHeld, []string{"deploy", "approval:held"}},
+		{approvalStatusPending, []string{"deploy", "approval:pending"}},
+		{approvalStatusDenied, []string{"deploy", "approval:denied"}},
+	}
+	for _, step := range steps {
+		if err := apprv.setStatusLabel(ctx, step.status); err != nil {
+			t.Fatalf("error setting status label %s: %v", step.status, err)
+		}
+		var actual []string
+		for _, label := range fake.labels[number] {
+			actual = append(actual, label.GetName())
+		}
+		if !reflect.DeepEqual(actual, step.expected) {
+			t.Fatalf("expected labels %v after %s, got %v", step.expected, step.status, actual)
+		}
+	}
+}
+
+func TestSetStatusLabelDisabled(t *testing.T) {
+	ctx := context.Background()
+	fake := newFakeGitHub()
+	apprv, err := newApprovalEnvironment(fake.client(), "owner/repo", "owner", 1, []string{"user1"}, 1, nil)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if err := apprv.createApprovalIssue(ctx); err != nil {
+		t.Fatalf("error creating approval issue: %v", err)
+	}
+	if err := apprv.setStatusLabel(ctx, approvalStatusPending); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if labels := fake.labels[apprv.approvalIssueNumber]; len(labels) != 0 {
+		t.Fatalf("expected no labels, got %v", labels)
+	}
+}
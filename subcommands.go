@@ -0,0 +1,66 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"time"
+)
+
+// attachApprovalIssue uses an approval issue created by an earlier job of the
+// run, so that one job can request approval and another wait for it or
+// resolve it.
+func (a *approvalEnvironment) attachApprovalIssue(ctx context.Context, number int) error {
+	issue, _, err := a.client.Issues.Get(ctx, a.repoOwner, a.repo, number)
+	if err != nil {
+		return err
+	}
+	if _, ok := parseIssueMarker(issue.GetBody()); !ok {
+		return fmt.Errorf("issue #%d is not an approval issue", number)
+	}
+	a.approvalIssue = issue
+	a.approvalIssueNumber = issue.GetNumber()
+	a.requestedAt = issue.GetCreatedAt()
+	return nil
+}
+
+// resolveFromComments closes the approval issue with the decision made on it
+// so far. A gate that is still pending, for example because the workflow
+// went ahead without waiting, is closed as cancelled.
+func (a *approvalEnvironment) resolveFromComments(ctx context.Context, approvers []string, minimumApprovals int) (approvalStatus, error) {
+	comments, err := a.approvalComments(ctx)
+	if err != nil {
+		return "", fmt.Errorf("error getting comments: %w", err)
+	}
+
+	veto, err := vetoComment(comments, a.vetoUsers)
+	if err != nil {
+		return "", fmt.Errorf("error checking for a veto: %w", err)
+	}
+	if veto != nil {
+		a.reportDenial(veto)
+		return approvalStatusDenied, a.resolveApproval(ctx, approvalStatusDenied, fmt.Sprintf("Request vetoed%s. Closing issue.", denialDetails(veto)))
+	}
+
+	eligibleApprovers := a.activeApprovers(approvers, time.Now())
+	if a.writeAccess {
+		eligibleApprovers, err = a.writeAccessApprovers(ctx, comments, approvers)
+		if err != nil {
+			return "", fmt.Errorf("error getting approvers with write access: %w", err)
+		}
+	}
+	approved, _, err := approvalFromComments(comments, eligibleApprovers, minimumApprovals, a.mutlipleDeploymentNames, a.requirements...)
+	if err != nil {
+		return "", fmt.Errorf("error getting approval from comments: %w", err)
+	}
+
+	switch approved {
+	case approvalStatusApproved:
+		return approved, a.resolveApproval(ctx, approvalStatusApproved, "All approvers have approved, closing this issue.")
+	case approvalStatusDenied:
+		denial := gateWords.DecidingComment(comments, eligibleApprovers, minimumApprovals, a.mutlipleDeploymentNames, a.requirements...)
+		a.reportDenial(denial)
+		return approved, a.resolveApproval(ctx, approvalStatusDenied, fmt.Sprintf("Request denied%s. Closing issue.", denialDetails(denial)))
+	default:
+		return approvalStatusCancelled, a.resolveApproval(ctx, approvalStatusCancelled, "Workflow finished without a decision, approval is no longer needed. Closing issue.")
+	}
+}
@@ -0,0 +1,83 @@
+package main
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/google/go-github/v43/github"
+)
+
+// supersedesMarker reports whether an approval issue of this run supersedes
+// the approval issue with the given marker, which is the case for open
+// issues of earlier runs of the same gate on the same branch. Run IDs grow
+// with every run, so a re-run of an older run never supersedes a newer one.
+func supersedesMarker(current, older issueMarker) bool {
+	return older.RunID != 0 &&
+		older.SupersededBy == 0 &&
+		older.RunID < current.RunID &&
+		older.Gate == current.Gate &&
+		older.Branch == current.Branch
+}
+
+// supersedeOlderIssues closes the open approval issues of earlier runs of
+// the same gate on the same branch, with a comment linking to the approval
+// issue of this run, so approvers don't approve a run that is out of date.
+func (a *approvalEnvironment) supersedeOlderIssues(ctx context.Context) error {
+	current := a.issueMarker()
+	issues, err := a.findApprovalIssues(ctx, "open", cleanupSearchPages, func(marker issueMarker) bool {
+		return supersedesMarker(current, marker)
+	})
+	if err != nil {
+		return err
+	}
+
+	for _, issue := range issues {
+		if issue.GetNumber() == a.approvalIssueNumber {
+			continue
+		}
+		fmt.Printf("Closing approval issue #%d, superseded by #%d\n", issue.GetNumber(), a.approvalIssueNumber)
+		comment := fmt.Sprintf("Superseded by #%d for workflow run %s. Please review that issue instead.", a.approvalIssueNumber, a.runURL())
+		if _, _, err := a.client.Issues.CreateComment(ctx, a.repoOwner, a.repo, issue.GetNumber(), &github.IssueComment{
+			Body: &comment,
+		}); err != nil {
+			return fmt.Errorf("error commenting on issue #%d: %w", issue.GetNumber(), err)
+		}
+		// The superseded issue is marked so that its run, if it is still
+		// waiting, doesn't reopen it.
+		marker, _ := parseIssueMarker(issue.GetBody())
+		marker.SupersededBy = a.approvalIssueNumber
+		body := replaceIssueMarker(issue.GetBody(), marker)
+		state := "closed"
+		if _, _, err := a.client.Issues.Edit(ctx, a.repoOwner, a.repo, issue.GetNumber(), &github.IssueRequest{
+			Body:  &body,
+			State: &state,
+		}); err != nil {
+			return fmt.Errorf("error closing issue #%d: %w", issue.GetNumber(), err)
+		}
+	}
+	return nil
+}
+
+// supersededBy returns the number of the approval issue of a newer run that
+// superseded the approval issue of this run, or 0 while it hasn't been. The
+// body read by the last poll is used when there is one.
+func (a *approvalEnvironment) supersededBy(ctx context.Context) (int, error) {
+	if a.approvalIssue == nil || a.sharedIssueFollower || a.discussionCategory != "" {
+		return 0, nil
+	}
+	var body string
+	if a.lastPoll != nil {
+		body = a.lastPoll.body
+	} else {
+		issue, _, err := a.client.Issues.Get(ctx, a.repoOwner, a.repo, a.approvalIssueNumber)
+		if err != nil {
+			return 0, err
+		}
+		body = issue.GetBody()
+	}
+	marker, ok := parseIssueMarker(body)
+	if !ok {
+		return 0, nil
+	}
+	return marker.SupersededBy, nil
+}
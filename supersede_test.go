@@ -0,0 +1,52 @@
+package main
+
+import (
+	"context"
+	"testing"
+)
+
+func TestSupersedeOlderIssues(t *testing.T) {
+	ctx := context.Background()
+	fake := newFakeGitHub()
+	gate := func(runID int) *approvalEnvironment {
+		apprv, err := newApprovalEnvironment(fake.client(), "owner/repo", "owner", runID, []string{"user1"}, 1, nil)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		apprv.branch = "main"
+		if err := apprv.createApprovalIssue(ctx); err != nil {
+			t.Fatalf("error creating approval issue: %v", err)
+		}
+		if err := apprv.supersedeOlderIssues(ctx); err != nil {
+			t.Fatalf("error superseding older issues: %v", err)
+		}
+		return apprv
+	}
+	state := func(apprv *approvalEnvironment) string {
+		return fake.issues[apprv.approvalIssueNumber].GetState()
+	}
+
+	newer := gate(20)
+	// A re-run of an older run must not close the issue of a newer run.
+	rerun := gate(10)
+	if state(newer) != "open" {
+		t.Fatalf("expected the issue of the newer run to stay open but it is %s", state(newer))
+	}
+
+	newest := gate(30)
+	for _, apprv := range []*approvalEnvironment{newer, rerun} {
+		if state(apprv) != "closed" {
+			t.Fatalf("expected the issue of run %d to be closed but it is %s", apprv.runID, state(apprv))
+		}
+		supersededBy, err := apprv.supersededBy(ctx)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if supersededBy != newest.approvalIssueNumber {
+			t.Fatalf("expected the issue of run %d to be superseded by #%d but got #%d", apprv.runID, newest.approvalIssueNumber, supersededBy)
+		}
+	}
+	if supersededBy, err := newest.supersededBy(ctx); err != nil || supersededBy != 0 {
+		t.Fatalf("expected the newest issue not to be superseded but got #%d (%v)", supersededBy, err)
+	}
+}
@@ -0,0 +1,83 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/google/go-github/v43/github"
+)
+
+// waitExceeded returns why the gate stopped waiting for a decision after
+// polls polls, or an empty string while it can keep waiting. The wait is
+// measured from when the decisions started counting, so waiting in a later
+// job or run attempt doesn't reset it.
+func (a *approvalEnvironment) waitExceeded(polls int, now time.Time) string {
+	if a.maxPolls > 0 && polls >= a.maxPolls {
+		return fmt.Sprintf("Approval timed out after %d polls without a decision.", polls)
+	}
+	if deadline := a.waitDeadline(); !deadline.IsZero() && !now.Before(deadline) {
+		if started := a.decisionsNotBefore(); !started.IsZero() {
+			return fmt.Sprintf("Approval timed out after waiting %s without a decision.", now.Sub(started).Round(time.Second))
+		}
+		return "Approval timed out without a decision."
+	}
+	return ""
+}
+
+// waitDeadline returns when the gate times out, or the zero time if it waits
+// indefinitely. The deadline saved by an earlier attempt takes precedence.
+func (a *approvalEnvironment) waitDeadline() time.Time {
+	if a.gateState.Deadline != nil {
+		return *a.gateState.Deadline
+	}
+	if a.maxWait <= 0 {
+		return time.Time{}
+	}
+	started := a.decisionsNotBefore()
+	if started.IsZero() {
+		return time.Time{}
+	}
+	return started.Add(a.maxWait)
+}
+
+// saveWaitDeadline stores the deadline in the gate state, so that a re-run
+// that resumes the approval issue times out when this attempt would have.
+func (a *approvalEnvironment) saveWaitDeadline(ctx context.Context) error {
+	deadline := a.waitDeadline()
+	if a.gateState.Deadline != nil || deadline.IsZero() {
+		return nil
+	}
+	state := a.gateState
+	state.Deadline = &deadline
+	return a.writeGateState(ctx, state)
+}
+
+// timeOut resolves a gate that waited too long for reason, continuing with
+// the deployments approved in comments when partial-approval is set, and
+// returns the outcome of the gate.
+func (a *approvalEnvironment) timeOut(ctx context.Context, reason string, comments []*github.IssueComment, approvers []string, minimumApprovals int) gateOutcome {
+	approvedDeployments, err := a.approvePartially(ctx, reason, comments, approvers, minimumApprovals)
+	if err != nil {
+		fmt.Printf("error approving deployments partially: %v\n", err)
+		return outcomeError
+	}
+	if len(approvedDeployments) > 0 {
+		if a.createDeployment {
+			deploymentIDs, err := a.createDeployments(ctx, approvedDeployments)
+			setDeploymentIDsOutput(deploymentIDs)
+			if err != nil {
+				fmt.Printf("error creating deployments: %v\n", err)
+				return outcomeError
+			}
+		}
+		fmt.Println("Workflow manual approval completed with some of the deployments")
+		return outcomeApproved
+	}
+	closeComment := reason + " Closing issue and failing workflow."
+	if err := a.resolveApproval(ctx, approvalStatusTimedOut, closeComment); err != nil {
+		fmt.Printf("error closing issue: %v\n", err)
+	}
+	setOutput("timed-out", "true")
+	return outcomeTimedOut
+}
@@ -0,0 +1,69 @@
+package main
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestWaitExceeded(t *testing.T) {
+	requestedAt := time.Date(2022, 1, 1, 0, 0, 0, 0, time.UTC)
+	testCases := []struct {
+		name     string
+		maxWait  time.Duration
+		maxPolls int
+		polls    int
+		now      time.Time
+		exceeded bool
+	}{
+		{name: "no_limits", polls: 1000, now: requestedAt.Add(48 * time.Hour), exceeded: false},
+		{name: "within_max_wait", maxWait: time.Hour, polls: 1, now: requestedAt.Add(59 * time.Minute), exceeded: false},
+		{name: "max_wait_exceeded", maxWait: time.Hour, polls: 1, now: requestedAt.Add(time.Hour), exceeded: true},
+		{name: "within_max_polls", maxPolls: 3, polls: 2, now: requestedAt, exceeded: false},
+		{name: "max_polls_exceeded", maxPolls: 3, polls: 3, now: requestedAt, exceeded: true},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			apprv := &approvalEnvironment{requestedAt: requestedAt, maxWait: testCase.maxWait, maxPolls: testCase.maxPolls}
+			reason := apprv.waitExceeded(testCase.polls, testCase.now)
+			if (reason != "") != testCase.exceeded {
+				t.Fatalf("expected exceeded %t but got reason %q", testCase.exceeded, reason)
+			}
+		})
+	}
+}
+
+func TestWaitExceededAfterRerun(t *testing.T) {
+	requestedAt := time.Date(2022, 1, 1, 0, 0, 0, 0, time.UTC)
+	apprv := &approvalEnvironment{
+		requestedAt:         requestedAt,
+		runAttemptStartedAt: requestedAt.Add(2 * time.Hour),
+		maxWait:             time.Hour,
+	}
+	if reason := apprv.waitExceeded(1, requestedAt.Add(150*time.Minute)); reason != "" {
+		t.Fatalf("expected the wait to count from the run attempt but got %q", reason)
+	}
+}
+
+func TestTimeOut(t *testing.T) {
+	ctx := context.Background()
+	t.Setenv(envVarOutput, filepath.Join(t.TempDir(), "output"))
+	fake := newFakeGitHub()
+	apprv, err := newApprovalEnvironment(fake.client(), "owner/repo", "owner", 1, []string{"user1"}, 1, nil)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if err := apprv.createApprovalIssue(ctx); err != nil {
+		t.Fatalf("error creating approval issue: %v", err)
+	}
+
+	// A poll that was rate limited has no comments to time out with.
+	if outcome := apprv.timeOut(ctx, "Approval timed out.", nil, nil, 1); outcome != outcomeTimedOut {
+		t.Fatalf("expected the gate to time out but got %s", outcome)
+	}
+	if state := fake.issues[apprv.approvalIssueNumber].GetState(); state != "closed" {
+		t.Fatalf("expected the approval issue to be closed but it is %s", state)
+	}
+}
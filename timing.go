@@ -0,0 +1,77 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// approvalTiming is when an approval that counted arrived, and how long
+// after the approval was requested.
+type approvalTiming struct {
+	Approver    string  `json:"approver"`
+	ApprovedAt  string  `json:"approved_at"`
+	WaitSeconds float64 `json:"wait_seconds"`
+}
+
+// isoDuration formats a duration as an ISO 8601 duration, such as PT1H2M3S.
+func isoDuration(d time.Duration) string {
+	if d < time.Second {
+		return "PT0S"
+	}
+	seconds := int64(d.Round(time.Second) / time.Second)
+	var b strings.Builder
+	b.WriteString("PT")
+	if hours := seconds / 3600; hours > 0 {
+		fmt.Fprintf(&b, "%dH", hours)
+	}
+	if minutes := seconds % 3600 / 60; minutes > 0 {
+		fmt.Fprintf(&b, "%dM", minutes)
+	}
+	if seconds%60 > 0 {
+		fmt.Fprintf(&b, "%dS", seconds%60)
+	}
+	return b.String()
+}
+
+// waitSeconds is the seconds from requested to t, rounded to the second.
+func waitSeconds(requested, t time.Time) float64 {
+	if requested.IsZero() || t.Before(requested) {
+		return 0
+	}
+	return math.Round(t.Sub(requested).Seconds())
+}
+
+// reportTiming sets the outputs of when the approval was requested, when
+// each approval arrived and when it was decided.
+func (a *approvalEnvironment) reportTiming() {
+	requestedAt := a.requestedAt
+	if requestedAt.IsZero() {
+		requestedAt = a.metrics.requestedAt
+	}
+	decidedAt := a.metrics.resolvedAt
+
+	timings := []approvalTiming{}
+	for _, approval := range countedApprovals(a.metrics.comments, a.metrics.approvers, a.mutlipleDeploymentNames) {
+		timings = append(timings, approvalTiming{
+			Approver:    approval.approver,
+			ApprovedAt:  approval.approvedAt.UTC().Format(time.RFC3339),
+			WaitSeconds: waitSeconds(requestedAt, approval.approvedAt),
+		})
+	}
+	content, _ := json.Marshal(timings)
+
+	if !requestedAt.IsZero() {
+		setOutput("requested-at", requestedAt.UTC().Format(time.RFC3339))
+	}
+	setOutput("decided-at", decidedAt.UTC().Format(time.RFC3339))
+	setOutput("approval-times", string(content))
+	if !requestedAt.IsZero() {
+		seconds := waitSeconds(requestedAt, decidedAt)
+		setOutput("wait-seconds", strconv.FormatFloat(seconds, 'g', -1, 64))
+		setOutput("wait-duration", isoDuration(time.Duration(seconds)*time.Second))
+	}
+}
@@ -0,0 +1,49 @@
+package main
+
+import (
+	"testing"
+	"time"
+)
+
+func TestISODuration(t *testing.T) {
+	testCases := []struct {
+		duration time.Duration
+		expected string
+	}{
+		{duration: 0, expected: "PT0S"},
+		{duration: 45 * time.Second, expected: "PT45S"},
+		{duration: 90 * time.Minute, expected: "PT1H30M"},
+		{duration: 26*time.Hour + 3*time.Second, expected: "PT26H3S"},
+		{duration: 1499 * time.Millisecond, expected: "PT1S"},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.expected, func(t *testing.T) {
+			if actual := isoDuration(testCase.duration); actual != testCase.expected {
+				t.Fatalf("expected %s but got %s", testCase.expected, actual)
+			}
+		})
+	}
+}
+
+func TestWaitSeconds(t *testing.T) {
+	requested := time.Date(2026, 8, 1, 10, 0, 0, 0, time.UTC)
+	testCases := []struct {
+		name      string
+		requested time.Time
+		t         time.Time
+		expected  float64
+	}{
+		{name: "after", requested: requested, t: requested.Add(90*time.Second + 400*time.Millisecond), expected: 90},
+		{name: "before", requested: requested, t: requested.Add(-time.Minute), expected: 0},
+		{name: "not_requested", t: requested, expected: 0},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			if actual := waitSeconds(testCase.requested, testCase.t); actual != testCase.expected {
+				t.Fatalf("expected %g but got %g", testCase.expected, actual)
+			}
+		})
+	}
+}
@@ -0,0 +1,108 @@
+package main
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+)
+
+// scopeImplications lists the classic token scopes that grant another
+// scope.
+var scopeImplications = map[string][]string{
+	"public_repo":  {"repo"},
+	"read:org":     {"write:org", "admin:org"},
+	"read:project": {"project"},
+}
+
+// missingScopes returns the required scopes that the comma-delimited
+// X-OAuth-Scopes header of a classic token doesn't grant.
+func missingScopes(granted string, required []string) []string {
+	grantedScopes := map[string]bool{}
+	for _, scope := range splitInputList(granted) {
+		grantedScopes[scope] = true
+	}
+	var missing []string
+	for _, scope := range required {
+		if grantedScopes[scope] {
+			continue
+		}
+		implied := false
+		for _, broader := range scopeImplications[scope] {
+			if grantedScopes[broader] {
+				implied = true
+			}
+		}
+		if !implied {
+			missing = append(missing, scope)
+		}
+	}
+	return missing
+}
+
+// permissionHint explains which permission a fine-grained token, GitHub App
+// or GITHUB_TOKEN is missing, from the X-Accepted-GitHub-Permissions header
+// GitHub sets on responses to requests the token isn't allowed to make. The
+// header lists alternatives separated by semicolons, each a comma-delimited
+// set of permissions, e.g. "issues=write; pull_requests=write".
+func permissionHint(method, path string, header http.Header) string {
+	accepted := header.Get("X-Accepted-GitHub-Permissions")
+	if accepted == "" {
+		return scopeHint(method, path, header)
+	}
+	var alternatives []string
+	for _, alternative := range strings.Split(accepted, ";") {
+		var permissions []string
+		for _, permission := range splitInputList(alternative) {
+			nameAndLevel := strings.SplitN(permission, "=", 2)
+			name := strings.ReplaceAll(nameAndLevel[0], "_", "-")
+			if len(nameAndLevel) == 2 {
+				name = fmt.Sprintf("%s: %s", name, nameAndLevel[1])
+			}
+			permissions = append(permissions, name)
+		}
+		if len(permissions) > 0 {
+			alternatives = append(alternatives, strings.Join(permissions, " and "))
+		}
+	}
+	return fmt.Sprintf("The token isn't allowed to %s %s, grant it %s", method, path, strings.Join(alternatives, " or "))
+}
+
+// scopeHint explains which scope a classic token is missing, from the
+// X-Accepted-OAuth-Scopes header listing the scopes that the endpoint
+// accepts, any one of which is enough.
+func scopeHint(method, path string, header http.Header) string {
+	accepted := splitInputList(header.Get("X-Accepted-OAuth-Scopes"))
+	if len(accepted) == 0 {
+		return ""
+	}
+	granted := map[string]bool{}
+	for _, scope := range splitInputList(header.Get("X-OAuth-Scopes")) {
+		granted[scope] = true
+	}
+	for _, scope := range accepted {
+		if granted[scope] {
+			return ""
+		}
+	}
+	return fmt.Sprintf("The token isn't allowed to %s %s, grant it one of the scopes %s", method, path, strings.Join(accepted, ", "))
+}
+
+// permissionHintTransport logs which permission the token is missing when
+// GitHub rejects a request for lack of one, as the error returned to the
+// caller only says 403 or 404.
+type permissionHintTransport struct {
+	base http.RoundTripper
+}
+
+func (t permissionHintTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	resp, err := t.base.RoundTrip(req)
+	if err != nil {
+		return resp, err
+	}
+	if resp.StatusCode == http.StatusForbidden || resp.StatusCode == http.StatusNotFound {
+		if hint := permissionHint(req.Method, req.URL.Path, resp.Header); hint != "" {
+			fmt.Println(hint)
+		}
+	}
+	return resp, nil
+}
@@ -0,0 +1,59 @@
+package main
+
+import (
+	"net/http"
+	"testing"
+)
+
+func TestMissingScopes(t *testing.T) {
+	testCases := []struct {
+		name     string
+		granted  string
+		required []string
+		expected []string
+	}{
+		{name: "granted", granted: "repo, read:org", required: []string{"repo", "read:org"}},
+		{name: "implied", granted: "repo, admin:org", required: []string{"public_repo", "read:org"}},
+		{name: "missing", granted: "public_repo", required: []string{"repo", "project"}, expected: []string{"repo", "project"}},
+		{name: "none_granted", granted: "", required: []string{"public_repo"}, expected: []string{"public_repo"}},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			missing := missingScopes(testCase.granted, testCase.required)
+			if len(missing) != len(testCase.expected) {
+				t.Fatalf("expected %v but got %v", testCase.expected, missing)
+			}
+			for i := range missing {
+				if missing[i] != testCase.expected[i] {
+					t.Fatalf("expected %v but got %v", testCase.expected, missing)
+				}
+			}
+		})
+	}
+}
+
+func TestPermissionHint(t *testing.T) {
+	header := http.Header{}
+	if hint := permissionHint("POST", "/repos/o/r/issues", header); hint != "" {
+		t.Fatalf("expected no hint but got %q", hint)
+	}
+
+	header.Set("X-Accepted-GitHub-Permissions", "issues=write; pull_requests=write")
+	expected := "The token isn't allowed to POST /repos/o/r/issues, grant it issues: write or pull-requests: write"
+	if hint := permissionHint("POST", "/repos/o/r/issues", header); hint != expected {
+		t.Fatalf("expected %q but got %q", expected, hint)
+	}
+
+	scopes := http.Header{}
+	scopes.Set("X-Accepted-OAuth-Scopes", "read:org, admin:org")
+	scopes.Set("X-OAuth-Scopes", "repo")
+	expected = "The token isn't allowed to GET /orgs/o/teams/t/members, grant it one of the scopes read:org, admin:org"
+	if hint := permissionHint("GET", "/orgs/o/teams/t/members", scopes); hint != expected {
+		t.Fatalf("expected %q but got %q", expected, hint)
+	}
+	scopes.Set("X-OAuth-Scopes", "repo, read:org")
+	if hint := permissionHint("GET", "/orgs/o/teams/t/members", scopes); hint != "" {
+		t.Fatalf("expected no hint but got %q", hint)
+	}
+}
@@ -0,0 +1,302 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// traceSpan is a finished span of the gate's trace.
+type traceSpan struct {
+	spanID     string
+	name       string
+	start      time.Time
+	end        time.Time
+	attributes map[string]string
+	failed     bool
+}
+
+// gateTracer records spans of the gate lifecycle, such as creating the
+// approval issue and every poll, and exports them with the root span of the
+// gate over OTLP/HTTP once it resolves. A nil tracer records nothing, so
+// tracing costs nothing unless an OTLP endpoint is configured.
+type gateTracer struct {
+	endpoint     string
+	headers      map[string]string
+	resource     map[string]string
+	traceID      string
+	rootSpanID   string
+	parentSpanID string
+	start        time.Time
+
+	mu    sync.Mutex
+	spans []traceSpan
+}
+
+// newGateTracer configures tracing from the standard OTEL_* environment
+// variables. It returns nil if no OTLP endpoint is set or tracing is
+// disabled. The trace continues the one given by TRACEPARENT, if set, so
+// that the gate shows up in the trace of the pipeline that runs it.
+func newGateTracer(getenv func(string) string, start time.Time) (*gateTracer, error) {
+	if strings.EqualFold(getenv("OTEL_SDK_DISABLED"), "true") || getenv("OTEL_TRACES_EXPORTER") == "none" {
+		return nil, nil
+	}
+	endpoint := getenv("OTEL_EXPORTER_OTLP_TRACES_ENDPOINT")
+	if endpoint == "" {
+		base := getenv("OTEL_EXPORTER_OTLP_ENDPOINT")
+		if base == "" {
+			return nil, nil
+		}
+		endpoint = strings.TrimSuffix(base, "/") + "/v1/traces"
+	}
+	if protocol := getenv("OTEL_EXPORTER_OTLP_PROTOCOL"); protocol != "" && protocol != "http/json" && protocol != "http/protobuf" {
+		return nil, fmt.Errorf("unsupported OTLP protocol %q, only http/json is supported", protocol)
+	}
+
+	headers, err := parseOTelList(getenv("OTEL_EXPORTER_OTLP_HEADERS"))
+	if err != nil {
+		return nil, fmt.Errorf("error parsing OTEL_EXPORTER_OTLP_HEADERS: %w", err)
+	}
+	traceHeaders, err := parseOTelList(getenv("OTEL_EXPORTER_OTLP_TRACES_HEADERS"))
+	if err != nil {
+		return nil, fmt.Errorf("error parsing OTEL_EXPORTER_OTLP_TRACES_HEADERS: %w", err)
+	}
+	for name, value := range traceHeaders {
+		headers[name] = value
+	}
+	resource, err := parseOTelList(getenv("OTEL_RESOURCE_ATTRIBUTES"))
+	if err != nil {
+		return nil, fmt.Errorf("error parsing OTEL_RESOURCE_ATTRIBUTES: %w", err)
+	}
+	if serviceName := getenv("OTEL_SERVICE_NAME"); serviceName != "" {
+		resource["service.name"] = serviceName
+	} else if resource["service.name"] == "" {
+		resource["service.name"] = "manual-approval"
+	}
+	resource["service.version"] = version
+
+	tracer := &gateTracer{
+		endpoint:   endpoint,
+		headers:    headers,
+		resource:   resource,
+		traceID:    randomHex(16),
+		rootSpanID: randomHex(8),
+		start:      start,
+	}
+	if traceID, spanID, ok := parseTraceparent(getenv("TRACEPARENT")); ok {
+		tracer.traceID, tracer.parentSpanID = traceID, spanID
+	}
+	return tracer, nil
+}
+
+// parseOTelList parses the comma-separated key=value lists of OTEL_*
+// variables, whose values are URL encoded.
+func parseOTelList(raw string) (map[string]string, error) {
+	values := map[string]string{}
+	for _, pair := range splitInputList(raw) {
+		parts := strings.SplitN(pair, "=", 2)
+		if len(parts) != 2 {
+			return nil, fmt.Errorf("expected key=value but got %q", pair)
+		}
+		value, err := url.QueryUnescape(strings.TrimSpace(parts[1]))
+		if err != nil {
+			return nil, err
+		}
+		values[strings.TrimSpace(parts[0])] = value
+	}
+	return values, nil
+}
+
+// otelHeaderSecrets returns the values of the OTLP exporter headers, which
+// usually carry credentials.
+func otelHeaderSecrets(getenv func(string) string) []string {
+	var secrets []string
+	for _, name := range []string{"OTEL_EXPORTER_OTLP_HEADERS", "OTEL_EXPORTER_OTLP_TRACES_HEADERS"} {
+		headers, _ := parseOTelList(getenv(name))
+		for _, value := range headers {
+			secrets = append(secrets, value)
+		}
+	}
+	return secrets
+}
+
+// parseTraceparent parses a W3C traceparent such as
+// 00-0af7651916cd43dd8448eb211c80319c-b7ad6b7169203331-01.
+func parseTraceparent(traceparent string) (traceID, spanID string, ok bool) {
+	parts := strings.Split(traceparent, "-")
+	if len(parts) < 4 || len(parts[1]) != 32 || len(parts[2]) != 16 {
+		return "", "", false
+	}
+	if _, err := hex.DecodeString(parts[1]); err != nil || strings.Trim(parts[1], "0") == "" {
+		return "", "", false
+	}
+	if _, err := hex.DecodeString(parts[2]); err != nil || strings.Trim(parts[2], "0") == "" {
+		return "", "", false
+	}
+	return strings.ToLower(parts[1]), strings.ToLower(parts[2]), true
+}
+
+func randomHex(n int) string {
+	b := make([]byte, n)
+	_, _ = rand.Read(b)
+	return hex.EncodeToString(b)
+}
+
+// traceAttributes are the attributes of the gate's spans.
+func (a *approvalEnvironment) traceAttributes() map[string]string {
+	attributes := map[string]string{}
+	for name, value := range a.metricLabels() {
+		attributes["manual_approval."+name] = value
+	}
+	if a.approvalIssueNumber != 0 {
+		attributes["manual_approval.issue_number"] = strconv.Itoa(a.approvalIssueNumber)
+	}
+	return attributes
+}
+
+// record adds a span that ran from start until now as a child of the gate's
+// root span.
+func (t *gateTracer) record(name string, start time.Time, attributes map[string]string, err error) {
+	if t == nil {
+		return
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if err != nil {
+		attributes["error.message"] = err.Error()
+	}
+	t.spans = append(t.spans, traceSpan{
+		spanID:     randomHex(8),
+		name:       name,
+		start:      start,
+		end:        time.Now(),
+		attributes: attributes,
+		failed:     err != nil,
+	})
+}
+
+type otlpAttribute struct {
+	Key   string `json:"key"`
+	Value struct {
+		StringValue string `json:"stringValue"`
+	} `json:"value"`
+}
+
+type otlpSpan struct {
+	TraceID           string          `json:"traceId"`
+	SpanID            string          `json:"spanId"`
+	ParentSpanID      string          `json:"parentSpanId,omitempty"`
+	Name              string          `json:"name"`
+	Kind              int             `json:"kind"`
+	StartTimeUnixNano string          `json:"startTimeUnixNano"`
+	EndTimeUnixNano   string          `json:"endTimeUnixNano"`
+	Attributes        []otlpAttribute `json:"attributes,omitempty"`
+	Status            struct {
+		Code int `json:"code"`
+	} `json:"status"`
+}
+
+func otlpAttributes(attributes map[string]string) []otlpAttribute {
+	keys := make([]string, 0, len(attributes))
+	for key, value := range attributes {
+		if value != "" {
+			keys = append(keys, key)
+		}
+	}
+	sort.Strings(keys)
+	result := make([]otlpAttribute, len(keys))
+	for i, key := range keys {
+		result[i].Key = key
+		result[i].Value.StringValue = attributes[key]
+	}
+	return result
+}
+
+func (t *gateTracer) otlpSpan(span traceSpan, parentSpanID string) otlpSpan {
+	result := otlpSpan{
+		TraceID:           t.traceID,
+		SpanID:            span.spanID,
+		ParentSpanID:      parentSpanID,
+		Name:              span.name,
+		Kind:              1,
+		StartTimeUnixNano: strconv.FormatInt(span.start.UnixNano(), 10),
+		EndTimeUnixNano:   strconv.FormatInt(span.end.UnixNano(), 10),
+		Attributes:        otlpAttributes(span.attributes),
+	}
+	// The status codes are unset (0), ok (1) and error (2).
+	result.Status.Code = 1
+	if span.failed {
+		result.Status.Code = 2
+	}
+	return result
+}
+
+// payload renders the recorded spans and the root span as an OTLP JSON
+// export request.
+func (t *gateTracer) payload(root traceSpan) ([]byte, error) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	spans := []otlpSpan{t.otlpSpan(root, t.parentSpanID)}
+	for _, span := range t.spans {
+		spans = append(spans, t.otlpSpan(span, t.rootSpanID))
+	}
+	return json.Marshal(map[string]interface{}{
+		"resourceSpans": []interface{}{map[string]interface{}{
+			"resource": map[string]interface{}{"attributes": otlpAttributes(t.resource)},
+			"scopeSpans": []interface{}{map[string]interface{}{
+				"scope": map[string]string{"name": "manual-approval", "version": version},
+				"spans": spans,
+			}},
+		}},
+	})
+}
+
+// export sends the trace of the gate, ending its root span with the outcome.
+func (t *gateTracer) export(ctx context.Context, attributes map[string]string, metrics gateMetrics) error {
+	if t == nil {
+		return nil
+	}
+	attributes["manual_approval.status"] = strings.ToLower(string(metrics.status))
+	attributes["manual_approval.approvers"] = strings.Join(metrics.approvedBy, ",")
+	attributes["manual_approval.deniers"] = strings.Join(metrics.deniedBy, ",")
+	attributes["manual_approval.polls"] = strconv.Itoa(metrics.polls)
+	body, err := t.payload(traceSpan{
+		spanID:     t.rootSpanID,
+		name:       "manual approval",
+		start:      t.start,
+		end:        metrics.resolvedAt,
+		attributes: attributes,
+		failed:     metrics.status != approvalStatusApproved,
+	})
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, t.endpoint, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	for name, value := range t.headers {
+		req.Header.Set(name, value)
+	}
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return fmt.Errorf("unexpected status %s from %s", resp.Status, t.endpoint)
+	}
+	return nil
+}
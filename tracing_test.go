@@ -0,0 +1,104 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestNewGateTracer(t *testing.T) {
+	testCases := []struct {
+		name             string
+		env              map[string]string
+		expectedEndpoint string
+		expectedParent   string
+		expectedErr      bool
+	}{
+		{name: "unconfigured", env: map[string]string{}},
+		{name: "disabled", env: map[string]string{"OTEL_EXPORTER_OTLP_ENDPOINT": "http://collector:4318", "OTEL_SDK_DISABLED": "true"}},
+		{name: "endpoint", env: map[string]string{"OTEL_EXPORTER_OTLP_ENDPOINT": "http://collector:4318/"}, expectedEndpoint: "http://collector:4318/v1/traces"},
+		{name: "traces_endpoint", env: map[string]string{"OTEL_EXPORTER_OTLP_ENDPOINT": "http://collector:4318", "OTEL_EXPORTER_OTLP_TRACES_ENDPOINT": "http://traces:4318/custom"}, expectedEndpoint: "http://traces:4318/custom"},
+		{name: "traceparent", env: map[string]string{"OTEL_EXPORTER_OTLP_ENDPOINT": "http://collector:4318", "TRACEPARENT": "00-0af7651916cd43dd8448eb211c80319c-b7ad6b7169203331-01"}, expectedEndpoint: "http://collector:4318/v1/traces", expectedParent: "b7ad6b7169203331"},
+		{name: "grpc", env: map[string]string{"OTEL_EXPORTER_OTLP_ENDPOINT": "http://collector:4317", "OTEL_EXPORTER_OTLP_PROTOCOL": "grpc"}, expectedErr: true},
+		{name: "invalid_headers", env: map[string]string{"OTEL_EXPORTER_OTLP_ENDPOINT": "http://collector:4318", "OTEL_EXPORTER_OTLP_HEADERS": "invalid"}, expectedErr: true},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			tracer, err := newGateTracer(func(name string) string { return testCase.env[name] }, time.Now())
+			if (err != nil) != testCase.expectedErr {
+				t.Fatalf("expected error %t but got %v", testCase.expectedErr, err)
+			}
+			if testCase.expectedEndpoint == "" {
+				if tracer != nil {
+					t.Fatalf("expected no tracer but got one for %s", tracer.endpoint)
+				}
+				return
+			}
+			if tracer.endpoint != testCase.expectedEndpoint || tracer.parentSpanID != testCase.expectedParent {
+				t.Fatalf("expected endpoint %s with parent %q but got %s with %q", testCase.expectedEndpoint, testCase.expectedParent, tracer.endpoint, tracer.parentSpanID)
+			}
+		})
+	}
+}
+
+func TestGateTracerExport(t *testing.T) {
+	var authorization string
+	var request struct {
+		ResourceSpans []struct {
+			Resource struct {
+				Attributes []otlpAttribute `json:"attributes"`
+			} `json:"resource"`
+			ScopeSpans []struct {
+				Spans []otlpSpan `json:"spans"`
+			} `json:"scopeSpans"`
+		} `json:"resourceSpans"`
+	}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		authorization = r.Header.Get("Authorization")
+		if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+			t.Errorf("unexpected error: %v", err)
+		}
+	}))
+	defer server.Close()
+
+	env := map[string]string{
+		"OTEL_EXPORTER_OTLP_ENDPOINT": server.URL,
+		"OTEL_EXPORTER_OTLP_HEADERS":  "Authorization=Bearer%20token",
+		"OTEL_SERVICE_NAME":           "deploys",
+		"TRACEPARENT":                 "00-0af7651916cd43dd8448eb211c80319c-b7ad6b7169203331-01",
+	}
+	tracer, err := newGateTracer(func(name string) string { return env[name] }, time.Now())
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	tracer.record("poll", time.Now(), map[string]string{"manual_approval.comments": "2"}, nil)
+	if err := tracer.export(context.Background(), map[string]string{"manual_approval.gate": "prod"}, gateMetrics{status: approvalStatusApproved, resolvedAt: time.Now(), approvedBy: []string{"user1"}}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if authorization != "Bearer token" {
+		t.Fatalf("expected the configured headers to be sent but got %q", authorization)
+	}
+	spans := request.ResourceSpans[0].ScopeSpans[0].Spans
+	if len(spans) != 2 {
+		t.Fatalf("expected 2 spans but got %d", len(spans))
+	}
+	root, poll := spans[0], spans[1]
+	if root.TraceID != "0af7651916cd43dd8448eb211c80319c" || root.ParentSpanID != "b7ad6b7169203331" || root.Status.Code != 1 {
+		t.Fatalf("expected the root span to continue the traceparent but got %+v", root)
+	}
+	if poll.Name != "poll" || poll.ParentSpanID != root.SpanID || poll.TraceID != root.TraceID {
+		t.Fatalf("expected the poll span to be a child of the root span but got %+v", poll)
+	}
+	attributes := map[string]string{}
+	for _, attribute := range root.Attributes {
+		attributes[attribute.Key] = attribute.Value.StringValue
+	}
+	if attributes["manual_approval.approvers"] != "user1" || attributes["manual_approval.status"] != "approved" || attributes["manual_approval.gate"] != "prod" {
+		t.Fatalf("unexpected root span attributes %v", attributes)
+	}
+}
@@ -0,0 +1,74 @@
+package main
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+
+	"golang.org/x/net/http/httpproxy"
+)
+
+// proxyFunc returns the proxy to use for each request. HTTP_PROXY,
+// HTTPS_PROXY and NO_PROXY are honored, and proxyURL, if set, replaces the
+// proxies of the environment while NO_PROXY still applies.
+func proxyFunc(proxyURL string) (func(*http.Request) (*url.URL, error), error) {
+	config := httpproxy.FromEnvironment()
+	if proxyURL != "" {
+		if _, err := url.Parse(proxyURL); err != nil {
+			return nil, fmt.Errorf("error parsing proxy URL: %w", err)
+		}
+		config.HTTPProxy = proxyURL
+		config.HTTPSProxy = proxyURL
+	}
+	proxy := config.ProxyFunc()
+	return func(req *http.Request) (*url.URL, error) {
+		return proxy(req.URL)
+	}, nil
+}
+
+// certPool returns the system certificate pool with the certificates of
+// caCertificates added. caCertificates is either PEM-encoded certificates or
+// the path to a file of them.
+func certPool(caCertificates string) (*x509.CertPool, error) {
+	pem := []byte(caCertificates)
+	if !strings.Contains(caCertificates, "-----BEGIN") {
+		var err error
+		if pem, err = os.ReadFile(caCertificates); err != nil {
+			return nil, fmt.Errorf("error reading CA certificates: %w", err)
+		}
+	}
+	pool, err := x509.SystemCertPool()
+	if err != nil {
+		pool = x509.NewCertPool()
+	}
+	if !pool.AppendCertsFromPEM(pem) {
+		return nil, fmt.Errorf("no PEM-encoded certificates found in the CA certificates")
+	}
+	return pool, nil
+}
+
+// configureTransport sets up the transport shared by every request of the
+// action, to GitHub as well as to on-call providers and approver URLs.
+// caCertificates, if set, are trusted in addition to the system
+// certificates.
+func configureTransport(proxyURL, caCertificates string) error {
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	proxy, err := proxyFunc(proxyURL)
+	if err != nil {
+		return err
+	}
+	transport.Proxy = proxy
+	if caCertificates != "" {
+		pool, err := certPool(caCertificates)
+		if err != nil {
+			return err
+		}
+		transport.TLSClientConfig = &tls.Config{RootCAs: pool, MinVersion: tls.VersionTLS12}
+	}
+	http.DefaultTransport = transport
+	return nil
+}
@@ -0,0 +1,90 @@
+package main
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"math/big"
+	"net/http"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestProxyFunc(t *testing.T) {
+	t.Setenv("HTTPS_PROXY", "http://env-proxy:3128")
+	t.Setenv("NO_PROXY", "internal.example.com")
+
+	testCases := []struct {
+		name       string
+		proxyURL   string
+		requestURL string
+		expected   string
+	}{
+		{name: "environment", requestURL: "https://api.github.com/repos", expected: "http://env-proxy:3128"},
+		{name: "no_proxy", requestURL: "https://internal.example.com/approvers", expected: ""},
+		{name: "input", proxyURL: "http://input-proxy:8080", requestURL: "https://api.github.com/repos", expected: "http://input-proxy:8080"},
+		{name: "input_no_proxy", proxyURL: "http://input-proxy:8080", requestURL: "https://internal.example.com/approvers", expected: ""},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			proxy, err := proxyFunc(testCase.proxyURL)
+			if err != nil {
+				t.Fatalf("error creating proxy func: %v", err)
+			}
+			req, _ := http.NewRequest("GET", testCase.requestURL, nil)
+			proxyURL, err := proxy(req)
+			if err != nil {
+				t.Fatalf("error getting proxy: %v", err)
+			}
+			actual := ""
+			if proxyURL != nil {
+				actual = proxyURL.String()
+			}
+			if actual != testCase.expected {
+				t.Fatalf("expected proxy %q but got %q", testCase.expected, actual)
+			}
+		})
+	}
+}
+
+func TestCertPool(t *testing.T) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("error generating key: %v", err)
+	}
+	template := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "Test CA"},
+		NotBefore:             time.Now(),
+		NotAfter:              time.Now().Add(time.Hour),
+		IsCA:                  true,
+		BasicConstraintsValid: true,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	if err != nil {
+		t.Fatalf("error creating certificate: %v", err)
+	}
+	certificate := string(pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}))
+	path := filepath.Join(t.TempDir(), "ca.pem")
+	if err := os.WriteFile(path, []byte(certificate), 0o600); err != nil {
+		t.Fatalf("error writing certificate: %v", err)
+	}
+
+	for _, caCertificates := range []string{certificate, path} {
+		if _, err := certPool(caCertificates); err != nil {
+			t.Fatalf("error loading CA certificates: %v", err)
+		}
+	}
+	if _, err := certPool("-----BEGIN CERTIFICATE-----\nnot a certificate\n-----END CERTIFICATE-----\n"); err == nil {
+		t.Fatal("expected an error for invalid certificates")
+	}
+	if _, err := certPool("/nonexistent/ca.pem"); err == nil {
+		t.Fatal("expected an error for a missing file")
+	}
+}
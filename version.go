@@ -0,0 +1,16 @@
+package main
+
+import "fmt"
+
+// The build information is set at build time, e.g.
+//
+//	go build -ldflags "-X main.version=1.0.3 -X main.commit=$(git rev-parse HEAD) -X main.buildDate=$(date -u +%Y-%m-%dT%H:%M:%SZ)"
+var (
+	version   = "dev"
+	commit    = "unknown"
+	buildDate = "unknown"
+)
+
+func buildInfo() string {
+	return fmt.Sprintf("manual-approval %s (commit %s, built %s)", version, commit, buildDate)
+}
@@ -0,0 +1,38 @@
+package main
+
+import (
+	"github.com/google/go-github/v43/github"
+)
+
+// vetoFromComments returns the first veto user who denied the request, or an
+// empty string if none did. A veto denies the gate regardless of how many
+// approvals were given, so it is checked on its own rather than as part of
+// the quorum.
+func vetoFromComments(comments []*github.IssueComment, vetoUsers []string) (string, error) {
+	comment, err := vetoComment(comments, vetoUsers)
+	if err != nil || comment == nil {
+		return "", err
+	}
+	return comment.User.GetLogin(), nil
+}
+
+// vetoComment returns the first denial by a veto user, or nil if there is
+// none.
+func vetoComment(comments []*github.IssueComment, vetoUsers []string) (*github.IssueComment, error) {
+	if len(vetoUsers) == 0 {
+		return nil, nil
+	}
+	for _, comment := range comments {
+		if approversIndex(vetoUsers, comment.User.GetLogin()) < 0 {
+			continue
+		}
+		isDenialComment, err := isDenied(comment.GetBody())
+		if err != nil {
+			return nil, err
+		}
+		if isDenialComment {
+			return comment, nil
+		}
+	}
+	return nil, nil
+}
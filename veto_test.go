@@ -0,0 +1,59 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/google/go-github/v43/github"
+)
+
+func TestVetoFromComments(t *testing.T) {
+	login1 := "login1"
+	security := "security-lead"
+	bodyApproved := "approved"
+	bodyDenied := "deny!"
+
+	testCases := []struct {
+		name      string
+		comments  []*github.IssueComment
+		vetoUsers []string
+		expected  string
+	}{
+		{
+			name: "veto_after_quorum",
+			comments: []*github.IssueComment{
+				{User: &github.User{Login: &login1}, Body: &bodyApproved},
+				{User: &github.User{Login: &security}, Body: &bodyDenied},
+			},
+			vetoUsers: []string{"Security-Lead"},
+			expected:  security,
+		},
+		{
+			name: "veto_user_approves",
+			comments: []*github.IssueComment{
+				{User: &github.User{Login: &security}, Body: &bodyApproved},
+			},
+			vetoUsers: []string{security},
+			expected:  "",
+		},
+		{
+			name: "denial_from_other_user",
+			comments: []*github.IssueComment{
+				{User: &github.User{Login: &login1}, Body: &bodyDenied},
+			},
+			vetoUsers: []string{security},
+			expected:  "",
+		},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			actual, err := vetoFromComments(testCase.comments, testCase.vetoUsers)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if actual != testCase.expected {
+				t.Fatalf("expected %q but got %q", testCase.expected, actual)
+			}
+		})
+	}
+}
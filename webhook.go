@@ -0,0 +1,82 @@
+package main
+
+import (
+	"fmt"
+	"net"
+	"net/http"
+	"strings"
+
+	"github.com/google/go-github/v43/github"
+)
+
+// webhookReceiver wakes the polling loop when GitHub delivers a webhook for
+// activity on the approval issue. The delivery only triggers a poll: the
+// decision is still read from the API, so a forged or replayed delivery can't
+// approve the gate even if it passed signature validation.
+type webhookReceiver struct {
+	secret       string
+	repoFullName string
+	issueNumber  int
+	deliveries   chan struct{}
+}
+
+func (rcv *webhookReceiver) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	payload, err := github.ValidatePayload(r, []byte(rcv.secret))
+	if err != nil {
+		http.Error(w, "invalid signature", http.StatusUnauthorized)
+		return
+	}
+	event, err := github.ParseWebHook(github.WebHookType(r), payload)
+	if err != nil {
+		http.Error(w, "unsupported event", http.StatusBadRequest)
+		return
+	}
+	if rcv.concernsApprovalIssue(event) {
+		// A poll that is already due picks up every delivery made before
+		// it, so further deliveries are dropped.
+		select {
+		case rcv.deliveries <- struct{}{}:
+		default:
+		}
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// concernsApprovalIssue reports whether the event is a comment on, or a
+// label or close of, the approval issue.
+func (rcv *webhookReceiver) concernsApprovalIssue(event interface{}) bool {
+	var repo *github.Repository
+	var issue *github.Issue
+	switch e := event.(type) {
+	case *github.IssueCommentEvent:
+		repo, issue = e.GetRepo(), e.GetIssue()
+	case *github.IssuesEvent:
+		repo, issue = e.GetRepo(), e.GetIssue()
+	default:
+		return false
+	}
+	return strings.EqualFold(repo.GetFullName(), rcv.repoFullName) && issue.GetNumber() == rcv.issueNumber
+}
+
+// listenForWebhooks starts receiving webhooks on address, such as :8080, in
+// the background.
+func (a *approvalEnvironment) listenForWebhooks(address, secret string) error {
+	listener, err := net.Listen("tcp", address)
+	if err != nil {
+		return err
+	}
+	receiver := &webhookReceiver{
+		secret:       secret,
+		repoFullName: a.repoFullName,
+		issueNumber:  a.approvalIssueNumber,
+		deliveries:   make(chan struct{}, 1),
+	}
+	a.webhookDeliveries = receiver.deliveries
+	fmt.Printf("Receiving webhooks on %s\n", listener.Addr())
+	go func() {
+		if err := http.Serve(listener, receiver); err != nil {
+			fmt.Printf("error receiving webhooks: %v\n", err)
+		}
+	}()
+	return nil
+}
@@ -0,0 +1,59 @@
+package main
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestWebhookReceiver(t *testing.T) {
+	sign := func(secret, body string) string {
+		mac := hmac.New(sha256.New, []byte(secret))
+		mac.Write([]byte(body))
+		return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+	}
+	comment := `{"action": "created", "issue": {"number": 5}, "repository": {"full_name": "owner/repo"}}`
+	otherIssue := `{"action": "created", "issue": {"number": 6}, "repository": {"full_name": "owner/repo"}}`
+
+	testCases := []struct {
+		name           string
+		event          string
+		body           string
+		signature      string
+		expectedStatus int
+		delivered      bool
+	}{
+		{name: "comment", event: "issue_comment", body: comment, signature: sign("secret", comment), expectedStatus: http.StatusNoContent, delivered: true},
+		{name: "label", event: "issues", body: comment, signature: sign("secret", comment), expectedStatus: http.StatusNoContent, delivered: true},
+		{name: "other_issue", event: "issue_comment", body: otherIssue, signature: sign("secret", otherIssue), expectedStatus: http.StatusNoContent, delivered: false},
+		{name: "ping", event: "ping", body: `{"zen": "Keep it logically awesome."}`, signature: sign("secret", `{"zen": "Keep it logically awesome."}`), expectedStatus: http.StatusNoContent, delivered: false},
+		{name: "wrong_secret", event: "issue_comment", body: comment, signature: sign("other", comment), expectedStatus: http.StatusUnauthorized, delivered: false},
+		{name: "unsigned", event: "issue_comment", body: comment, expectedStatus: http.StatusUnauthorized, delivered: false},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			receiver := &webhookReceiver{secret: "secret", repoFullName: "owner/repo", issueNumber: 5, deliveries: make(chan struct{}, 1)}
+			req := httptest.NewRequest(http.MethodPost, "/", strings.NewReader(testCase.body))
+			req.Header.Set("Content-Type", "application/json")
+			req.Header.Set("X-GitHub-Event", testCase.event)
+			if testCase.signature != "" {
+				req.Header.Set("X-Hub-Signature-256", testCase.signature)
+			}
+			recorder := httptest.NewRecorder()
+			receiver.ServeHTTP(recorder, req)
+
+			if recorder.Code != testCase.expectedStatus {
+				t.Fatalf("expected status %d but got %d", testCase.expectedStatus, recorder.Code)
+			}
+			delivered := len(receiver.deliveries) == 1
+			if delivered != testCase.delivered {
+				t.Fatalf("expected delivered %t but got %t", testCase.delivered, delivered)
+			}
+		})
+	}
+}